@@ -16,7 +16,7 @@ func ConnectionDB(config *Config) *gorm.DB {
 		log.Fatal("Failed to connect database:", err)
 	}
 
-	err = db.AutoMigrate(&model.Department{}, &model.TrainingPlan{}, &model.User{}, &model.Certificate{}, &model.Record{})
+	err = db.AutoMigrate(&model.Department{}, &model.TrainingPlan{}, &model.User{}, &model.Certificate{}, &model.Record{}, &model.RefreshToken{}, &model.PasswordResetToken{}, &model.RecoveryCode{}, &model.AppRole{}, &model.RolePermission{}, &model.AuditEvent{}, &model.EnrollmentRequest{}, &model.EnrollmentRequestEvent{}, &model.TrainingSession{}, &model.SessionAttendance{}, &model.TrainingPlanSeries{}, &model.CalendarOutbox{}, &model.CalendarFeedToken{}, &model.NotificationPreference{}, &model.NotificationDelivery{}, &model.Notification{}, &model.ScheduledJob{}, &model.WebhookSubscription{}, &model.WebhookSubscriptionEvent{}, &model.WebhookDelivery{}, &model.CertificateVersion{}, &model.CertificateValidity{}, &model.TwoFactorChallenge{})
 	if err != nil {
 		log.Fatal("Migration failed:", err)
 	}
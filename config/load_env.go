@@ -12,6 +12,41 @@ type Config struct {
 	GoogleClientID     string `mapstructure:"GOOGLE_CLIENT_ID"`
 	GoogleClientSecret string `mapstructure:"GOOGLE_CLIENT_SECRET"`
 	GoogleRedirectURL  string `mapstructure:"GOOGLE_REDIRECT_URL"`
+	LoginMaxAttempts    int `mapstructure:"LOGIN_MAX_ATTEMPTS"`
+	LoginLockoutMinutes int `mapstructure:"LOGIN_LOCKOUT_MINUTES"`
+	AppBaseURL   string `mapstructure:"APP_BASE_URL"`
+	APIBaseURL   string `mapstructure:"API_BASE_URL"`
+	SMTPHost     string `mapstructure:"SMTP_HOST"`
+	SMTPPort     string `mapstructure:"SMTP_PORT"`
+	SMTPUsername string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword string `mapstructure:"SMTP_PASSWORD"`
+	SMTPFrom     string `mapstructure:"SMTP_FROM"`
+	TOTPIssuer        string `mapstructure:"TOTP_ISSUER"`
+	TOTPRequiredRoles string `mapstructure:"TOTP_REQUIRED_ROLES"`
+	MinAttendancePercent int `mapstructure:"MIN_ATTENDANCE_PERCENT"`
+	Timezone                 string `mapstructure:"TIMEZONE"`
+	CalendarProvider         string `mapstructure:"CALENDAR_PROVIDER"`
+	GoogleServiceAccountFile string `mapstructure:"GOOGLE_SERVICE_ACCOUNT_FILE"`
+	GoogleCalendarID         string `mapstructure:"GOOGLE_CALENDAR_ID"`
+	CalDAVURL                string `mapstructure:"CALDAV_URL"`
+	CalDAVUsername           string `mapstructure:"CALDAV_USERNAME"`
+	CalDAVPassword           string `mapstructure:"CALDAV_PASSWORD"`
+	CalendarSyncMaxAttempts     int `mapstructure:"CALENDAR_SYNC_MAX_ATTEMPTS"`
+	CalendarSyncIntervalSeconds int `mapstructure:"CALENDAR_SYNC_INTERVAL_SECONDS"`
+	CalendarRSVPIntervalMinutes int `mapstructure:"CALENDAR_RSVP_INTERVAL_MINUTES"`
+	GoogleCalendarSubject       string `mapstructure:"GOOGLE_CALENDAR_SUBJECT"`
+	NotificationMaxAttempts int `mapstructure:"NOTIFICATION_MAX_ATTEMPTS"`
+	ReminderDaysBefore      int `mapstructure:"REMINDER_DAYS_BEFORE"`
+	AttendanceReminderDaysAfter     int `mapstructure:"ATTENDANCE_REMINDER_DAYS_AFTER"`
+	CertificatePendingReminderDays  int `mapstructure:"CERTIFICATE_PENDING_REMINDER_DAYS"`
+	WebhookMaxAttempts              int `mapstructure:"WEBHOOK_MAX_ATTEMPTS"`
+	// CertEvidenceTypes lists the training types, comma separated, for which
+	// an approved certificate marks the record Attended
+	CertEvidenceTypes string `mapstructure:"CERT_EVIDENCE_TYPES"`
+	// CertExpiryReminderDays is how many days before a certificate expires
+	// reminders start and its holder is registered for renewal
+	CertExpiryReminderDays  int  `mapstructure:"CERT_EXPIRY_REMINDER_DAYS"`
+	CertRenewalAutoRegister bool `mapstructure:"CERT_RENEWAL_AUTO_REGISTER"`
 }
 
 func LoadConfig(path string) (Config, error) {
@@ -1,15 +1,16 @@
 package container
 
 import (
+	"context"
 	"time"
 	"training-plan-api/config"
 	"training-plan-api/controller"
 	"training-plan-api/helper"
+	"training-plan-api/model"
 	"training-plan-api/repository"
 	"training-plan-api/service"
 
 	"github.com/go-playground/validator/v10"
-	"google.golang.org/api/calendar/v3"
 	"gorm.io/gorm"
 )
 
@@ -20,59 +21,298 @@ type AppDependencies struct {
 	AuthOAuthController  *controller.AuthOAuthController
 	UserController       *controller.UserController
 	CertificateController *controller.CertificateController
+	PasswordController   *controller.PasswordController
+	TwoFactorController  *controller.TwoFactorController
 	RecordController     *controller.RecordController
+	RoleController       *controller.RoleController
+	AuditController      *controller.AuditController
+	EnrollmentController *controller.EnrollmentController
+	TrainingSessionController *controller.TrainingSessionController
+	TrainingPlanSeriesController *controller.TrainingPlanSeriesController
+	CalendarSyncController *controller.CalendarSyncController
+	CalendarFeedController *controller.CalendarFeedController
+	NotificationController *controller.NotificationController
+	EventController      *controller.EventController
+	ScheduledJobController *controller.ScheduledJobController
+	WebhookController    *controller.WebhookController
 	UserRepository       repository.UserRepository
+	PermissionService    service.PermissionService
+	CalendarSyncService  service.CalendarSyncService
+	NotificationService  service.NotificationService
+	SchedulerService     service.SchedulerService
+	WebhookService       service.WebhookService
 }
 
 func NewAppDependencies(
 	db *gorm.DB,
 	validate *validator.Validate,
-	calendarService *calendar.Service,
+	calendarProvider helper.CalendarProvider,
 	location *time.Location,
 	storage helper.Storage,
+	mailer helper.Mailer,
 	appConfig config.Config,
 ) *AppDependencies {
 
+	// ---------- Audit ----------
+	userRepo := repository.NewUserRepositoryImpl(db)
+	auditRepo := repository.NewAuditRepositoryImpl(db)
+	auditService := service.NewAuditServiceImpl(auditRepo, userRepo)
+	auditController := controller.NewAuditController(auditService)
+
 		// ---------- Department ----------
 	departmentRepo := repository.NewDepartmentRepositoryImpl(db)
-	departmentService := service.NewDepartmentServiceImpl(departmentRepo, validate)
+	departmentService := service.NewDepartmentServiceImpl(departmentRepo, auditService, validate)
 	departmentController := controller.NewDepartmentController(departmentService)
+	// ---------- Role & Permission ----------
+	roleRepo := repository.NewRoleRepositoryImpl(db)
+	permissionService := service.NewPermissionServiceImpl(roleRepo, auditService, validate)
+	roleController := controller.NewRoleController(permissionService)
+
 		// ---------- User ----------
-	userRepo := repository.NewUserRepositoryImpl(db)
-	userService := service.NewUserServiceImpl(userRepo, departmentRepo, validate)
+	refreshTokenRepo := repository.NewRefreshTokenRepositoryImpl(db)
+	authTokenService := service.NewAuthTokenServiceImpl(refreshTokenRepo, userRepo)
+	userService := service.NewUserServiceImpl(userRepo, departmentRepo, roleRepo, authTokenService, auditService, validate)
 	userController := controller.NewUserController(userService, db)
 
-	// ---------- Record ----------
+	// ---------- Live events ----------
+	eventService := service.NewEventServiceImpl(helper.NewMemoryBroker(), userRepo, permissionService)
+	eventController := controller.NewEventController(eventService)
+
+	// ---------- Webhooks ----------
 	recordRepo := repository.NewRecordRepositoryImpl(db)
-	recordService := service.NewRecordServiceImpl(recordRepo, userRepo, validate)
+	trainingPlanRepo := repository.NewTrainingPlanRepositoryImpl(db)
+	webhookService := service.NewWebhookServiceImpl(
+		repository.NewWebhookRepositoryImpl(db),
+		userRepo,
+		trainingPlanRepo,
+		helper.NewWebhookSender(10*time.Second),
+		auditService,
+		helper.NewRetryPolicy(appConfig.WebhookMaxAttempts, 30*time.Second, 12*time.Hour),
+		validate,
+	)
+	webhookController := controller.NewWebhookController(webhookService)
+
+	// ---------- Notification ----------
+	notificationRepo := repository.NewNotificationRepositoryImpl(db)
+	certificateRepo := repository.NewCertificateRepositoryImpl(db)
+	notificationService := service.NewNotificationServiceImpl(
+		notificationRepo,
+		userRepo,
+		trainingPlanRepo,
+		recordRepo,
+		certificateRepo,
+		permissionService,
+		eventService,
+		mailer,
+		helper.NewRetryPolicy(appConfig.NotificationMaxAttempts, time.Minute, 6*time.Hour),
+		validate,
+		location,
+		appConfig.AppBaseURL,
+		service.ReminderSettings{
+			DaysBefore:             appConfig.ReminderDaysBefore,
+			AttendanceDaysAfter:    appConfig.AttendanceReminderDaysAfter,
+			CertificatePendingDays: appConfig.CertificatePendingReminderDays,
+			CertificateExpiryDays:  appConfig.CertExpiryReminderDays,
+		},
+	)
+	notificationController := controller.NewNotificationController(notificationService)
+
+	// ---------- Record ----------
+	trainingSessionRepo := repository.NewTrainingSessionRepositoryImpl(db)
+	recordService := service.NewRecordServiceImpl(recordRepo, userRepo, certificateRepo, trainingSessionRepo, permissionService, auditService, notificationService, eventService, webhookService, validate)
 	recordController := controller.NewRecordController(recordService)
 
 	// ---------- Certificate ----------
-	certificateRepo := repository.NewCertificateRepositoryImpl(db)
-	certificateService := service.NewCertificateServiceImpl(certificateRepo, validate, storage)
+	certificateService := service.NewCertificateServiceImpl(
+		certificateRepo,
+		recordRepo,
+		repository.NewCertificateValidityRepositoryImpl(db),
+		trainingPlanRepo,
+		userRepo,
+		permissionService,
+		auditService,
+		notificationService,
+		eventService,
+		webhookService,
+		validate,
+		storage,
+		location,
+		service.CertificateSettings{
+			EvidenceTypes: appConfig.CertEvidenceTypes,
+			RenewalDays:   appConfig.CertExpiryReminderDays,
+		},
+	)
 	certificateController := controller.NewCertificateController(certificateService)
 
 
 
 	// ---------- TrainingPlan ----------
-	trainingPlanRepo := repository.NewTrainingPlanRepositoryImpl(db)
+	enrollmentRepo := repository.NewEnrollmentRepositoryImpl(db)
 	trainingPlanService := service.NewTrainingPlanServiceImpl(
 		trainingPlanRepo,
+		recordRepo,
+		enrollmentRepo,
+		trainingSessionRepo,
+		permissionService,
+		auditService,
+		notificationService,
+		eventService,
+		webhookService,
 		validate,
-		calendarService,
 		location,
 	)
 	trainingPlanController := controller.NewTrainingPlanController(trainingPlanService)
 
+	// ---------- Calendar sync ----------
+	calendarOutboxRepo := repository.NewCalendarOutboxRepositoryImpl(db)
+	calendarSyncService := service.NewCalendarSyncServiceImpl(
+		calendarOutboxRepo,
+		trainingPlanRepo,
+		recordRepo,
+		trainingSessionRepo,
+		calendarProvider,
+		auditService,
+		helper.NewRetryPolicy(appConfig.CalendarSyncMaxAttempts, 30*time.Second, 6*time.Hour),
+		location,
+		appConfig.AppBaseURL,
+	)
+	calendarSyncController := controller.NewCalendarSyncController(calendarSyncService)
+
+	// ---------- Calendar feeds ----------
+	calendarFeedTokenRepo := repository.NewCalendarFeedTokenRepositoryImpl(db)
+	calendarFeedService := service.NewCalendarFeedServiceImpl(
+		calendarFeedTokenRepo,
+		trainingPlanRepo,
+		recordRepo,
+		permissionService,
+		auditService,
+		location,
+		appConfig.APIBaseURL,
+		appConfig.AppBaseURL,
+	)
+	calendarFeedController := controller.NewCalendarFeedController(calendarFeedService)
+
+	// ---------- TrainingPlan series ----------
+	trainingPlanSeriesRepo := repository.NewTrainingPlanSeriesRepositoryImpl(db)
+	trainingPlanSeriesService := service.NewTrainingPlanSeriesServiceImpl(
+		trainingPlanSeriesRepo,
+		trainingPlanRepo,
+		trainingSessionRepo,
+		trainingPlanService,
+		auditService,
+		validate,
+		location,
+	)
+	trainingPlanSeriesController := controller.NewTrainingPlanSeriesController(trainingPlanSeriesService)
+
+	// ---------- Training session ----------
+	trainingSessionService := service.NewTrainingSessionServiceImpl(
+		trainingSessionRepo,
+		trainingPlanRepo,
+		recordRepo,
+		userRepo,
+		permissionService,
+		auditService,
+		webhookService,
+		validate,
+		helper.NewAttendancePolicy(appConfig.MinAttendancePercent),
+		location,
+	)
+	trainingSessionController := controller.NewTrainingSessionController(trainingSessionService)
+
+	// ---------- Enrollment ----------
+	enrollmentService := service.NewEnrollmentServiceImpl(
+		enrollmentRepo,
+		recordRepo,
+		trainingPlanRepo,
+		userRepo,
+		permissionService,
+		auditService,
+		notificationService,
+		eventService,
+		validate,
+	)
+	enrollmentController := controller.NewEnrollmentController(enrollmentService)
+
+	// ---------- Scheduled jobs ----------
+	schedulerService := service.NewSchedulerServiceImpl(
+		repository.NewScheduledJobRepositoryImpl(db),
+		auditService,
+		validate,
+		location,
+	)
+	schedulerService.Register("training_plan_series", "Generate upcoming plans of recurring series", "0 1 * * *",
+		func(ctx context.Context, now time.Time) error {
+			return trainingPlanSeriesService.GenerateAll(model.SystemActor)
+		})
+	schedulerService.Register("training_reminders", "Remind registrants of upcoming trainings", "0 8 * * *",
+		func(ctx context.Context, now time.Time) error {
+			return notificationService.SendReminders(now)
+		})
+	schedulerService.Register("attendance_reminders", "Ask managers to fill in attendance and scores of ended trainings", "0 9 * * *",
+		func(ctx context.Context, now time.Time) error {
+			return notificationService.SendAttendanceReminders(now)
+		})
+	schedulerService.Register("certificate_pending_reminders", "Remind reviewers of certificates waiting too long", "0 9 * * 1-5",
+		func(ctx context.Context, now time.Time) error {
+			return notificationService.SendPendingCertificateReminders(now)
+		})
+	schedulerService.Register("certificate_expiry_reminders", "Remind holders and managers of expiring certificates", "0 8 * * *",
+		func(ctx context.Context, now time.Time) error {
+			return notificationService.SendCertificateExpiryReminders(now)
+		})
+	if appConfig.CertRenewalAutoRegister {
+		schedulerService.Register("certificate_renewal_registration", "Register holders of expiring certificates on the next renewal plan", "30 8 * * *",
+			func(ctx context.Context, now time.Time) error {
+				return certificateService.RegisterRenewals(now)
+			})
+	}
+	scheduledJobController := controller.NewScheduledJobController(schedulerService)
+
+	// ---------- Two-factor ----------
+	recoveryCodeRepo := repository.NewRecoveryCodeRepositoryImpl(db)
+	lockoutPolicy := helper.NewLockoutPolicy(appConfig.LoginMaxAttempts, appConfig.LoginLockoutMinutes)
+	twoFactorService := service.NewTwoFactorServiceImpl(
+		userRepo,
+		recoveryCodeRepo,
+		repository.NewTwoFactorChallengeRepositoryImpl(db),
+		authTokenService,
+		auditService,
+		appConfig.TOTPIssuer,
+		appConfig.TOTPRequiredRoles,
+		lockoutPolicy,
+		validate,
+	)
+	twoFactorController := controller.NewTwoFactorController(twoFactorService, authTokenService)
+
 	// ---------- Auth ----------
-	authController := controller.NewAuthController(db)
+	authController := controller.NewAuthController(
+		db,
+		userRepo,
+		authTokenService,
+		twoFactorService,
+		lockoutPolicy,
+	)
 	authOAuthService := service.NewAuthOAuthServiceImpl(
 		userRepo,
 		appConfig.GoogleClientID,
 		appConfig.GoogleClientSecret,
 		appConfig.GoogleRedirectURL,
 	)
-	authOAuthController := controller.NewAuthOAuthController(authOAuthService)
+	authOAuthController := controller.NewAuthOAuthController(authOAuthService, authTokenService, twoFactorService)
+
+	// ---------- Password ----------
+	passwordResetRepo := repository.NewPasswordResetRepositoryImpl(db)
+	passwordService := service.NewPasswordServiceImpl(
+		userRepo,
+		passwordResetRepo,
+		authTokenService,
+		auditService,
+		mailer,
+		appConfig.AppBaseURL,
+		validate,
+	)
+	passwordController := controller.NewPasswordController(passwordService, authTokenService)
 
 
 	return &AppDependencies{
@@ -82,7 +322,25 @@ func NewAppDependencies(
 		AuthOAuthController:  authOAuthController,
 		UserController:       userController,
 		CertificateController: certificateController,
+		PasswordController:   passwordController,
+		TwoFactorController:  twoFactorController,
 		RecordController:     recordController,
+		RoleController:       roleController,
+		AuditController:      auditController,
+		EnrollmentController: enrollmentController,
+		TrainingSessionController: trainingSessionController,
+		TrainingPlanSeriesController: trainingPlanSeriesController,
+		CalendarSyncController: calendarSyncController,
+		CalendarFeedController: calendarFeedController,
+		NotificationController: notificationController,
+		EventController:      eventController,
+		ScheduledJobController: scheduledJobController,
+		WebhookController:    webhookController,
 		UserRepository:       userRepo,
+		PermissionService:    permissionService,
+		CalendarSyncService:  calendarSyncService,
+		NotificationService:  notificationService,
+		SchedulerService:     schedulerService,
+		WebhookService:       webhookService,
 	}
 }
\ No newline at end of file
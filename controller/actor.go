@@ -0,0 +1,22 @@
+package controller
+
+import (
+	"training-plan-api/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// currentActor builds the caller identity set by middleware.JWTProtected,
+// together with the client IP and the request ID used in audit events.
+func currentActor(ctx *fiber.Ctx) model.Actor {
+	userID, _ := ctx.Locals("user_id").(uint)
+	role, _ := ctx.Locals("user_role").(string)
+	requestID, _ := ctx.Locals("requestid").(string)
+
+	return model.Actor{
+		UserID:    userID,
+		Role:      model.Role(role),
+		IP:        ctx.IP(),
+		RequestID: requestID,
+	}
+}
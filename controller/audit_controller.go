@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+	"training-plan-api/data/request"
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuditController struct {
+	service service.AuditService
+}
+
+func NewAuditController(service service.AuditService) *AuditController {
+	return &AuditController{service: service}
+}
+
+func (c *AuditController) Search(ctx *fiber.Ctx) error {
+
+	var req request.AuditFilterRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		return helper.BadRequest("Invalid request body")
+	}
+
+	result, err := c.service.Search(req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Audit events fetched successfully",
+		Data:    result,
+	})
+}
+
+func (c *AuditController) Export(ctx *fiber.Ctx) error {
+
+	var req request.AuditFilterRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		return helper.BadRequest("Invalid request body")
+	}
+
+	file, err := c.service.Export(req)
+	if err != nil {
+		return err
+	}
+
+	fileName := fmt.Sprintf("audit_%d.xlsx", time.Now().Unix())
+
+	ctx.Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	ctx.Set("Content-Disposition", "attachment; filename="+fileName)
+
+	return file.Write(ctx.Response().BodyWriter())
+}
@@ -1,19 +1,40 @@
 package controller
 
 import (
+	"fmt"
+	"math"
+	"time"
 	"training-plan-api/helper"
 	"training-plan-api/model"
+	"training-plan-api/repository"
+	"training-plan-api/service"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
 type AuthController struct {
-	db *gorm.DB
+	db               *gorm.DB
+	userRepo         repository.UserRepository
+	tokenService     service.AuthTokenService
+	twoFactorService service.TwoFactorService
+	lockoutPolicy    helper.LockoutPolicy
 }
 
-func NewAuthController(db *gorm.DB) *AuthController {
-	return &AuthController{db: db}
+func NewAuthController(
+	db *gorm.DB,
+	userRepo repository.UserRepository,
+	tokenService service.AuthTokenService,
+	twoFactorService service.TwoFactorService,
+	lockoutPolicy helper.LockoutPolicy,
+) *AuthController {
+	return &AuthController{
+		db:               db,
+		userRepo:         userRepo,
+		tokenService:     tokenService,
+		twoFactorService: twoFactorService,
+		lockoutPolicy:    lockoutPolicy,
+	}
 }
 
 type LoginRequest struct {
@@ -72,7 +93,13 @@ func (ac *AuthController) handleLogin(c *fiber.Ctx, role model.Role) error {
 	}
 
 	var user model.User
-	query := ac.db.Preload("Department").Where("email = ? AND role = ?", req.Email, role)
+	query := ac.db.Preload("Department").Where("email = ?", req.Email)
+	if role == model.RoleStaff {
+		// custom roles created by HR sign in through the staff portal
+		query = query.Where("role NOT IN ?", []model.Role{model.RoleHRAdmin, model.RoleDepartmentManager})
+	} else {
+		query = query.Where("role = ?", role)
+	}
 	if err := query.First(&user).Error; err != nil {
 		return helper.Unauthorized("Invalid credentials")
 	}
@@ -81,97 +108,91 @@ func (ac *AuthController) handleLogin(c *fiber.Ctx, role model.Role) error {
 		return helper.Unauthorized("Account is deactivated")
 	}
 
+	if user.IsLocked() {
+		return ac.lockedError(c, *user.LockedUntil)
+	}
+
 	if !helper.ComparePassword(user.Password, req.Password) {
-		return helper.Unauthorized("Invalid credentials")
+		return ac.registerFailedLogin(c, &user)
+	}
+
+	// with a second factor pending, failed codes keep counting toward the
+	// lockout until the verification succeeds
+	secondFactor := user.TOTPEnabled || ac.twoFactorService.IsRequired(user.Role)
+	if !secondFactor && (user.Error > 0 || user.LockedUntil != nil) {
+		if err := ac.db.Model(&model.User{}).Where("id = ?", user.ID).
+			Updates(map[string]interface{}{"error": 0, "locked_until": nil}).Error; err != nil {
+			return helper.InternalServerError("Failed to reset login attempts")
+		}
+	}
+
+	session, err := beginSession(c, ac.tokenService, ac.twoFactorService, &user)
+	if err != nil {
+		return err
 	}
 
-	accessToken, err := helper.GenerateAccessToken(user.ID, string(user.Role))
+	session["success"] = true
+	session["user"] = ac.buildUserResponse(&user)
+
+	return c.JSON(session)
+}
+
+// registerFailedLogin counts a failed password attempt and locks the account
+// once the policy threshold is reached.
+func (ac *AuthController) registerFailedLogin(c *fiber.Ctx, user *model.User) error {
+	lockedUntil, err := ac.userRepo.RegisterLoginFailure(user.ID, ac.lockoutPolicy)
 	if err != nil {
-		return helper.InternalServerError("Failed to generate access token")
+		return helper.InternalServerError("Failed to record login attempt")
+	}
+	if lockedUntil != nil {
+		return ac.lockedError(c, *lockedUntil)
 	}
 
+	return helper.Unauthorized("Invalid credentials")
+}
+
+func (ac *AuthController) lockedError(c *fiber.Ctx, lockedUntil time.Time) error {
+	retryAfter := int(math.Ceil(time.Until(lockedUntil).Seconds()))
+	c.Set(fiber.HeaderRetryAfter, fmt.Sprint(retryAfter))
+	return helper.Locked(fmt.Sprintf("Account is temporarily locked. Try again in %d seconds", retryAfter))
+}
+
+// Refresh rotates the refresh token and returns a new access token.
+func (ac *AuthController) Refresh(c *fiber.Ctx) error {
+	refreshToken := helper.GetRefreshTokenFromRequest(c)
+
+	tokens, _, err := ac.tokenService.Refresh(refreshToken)
+	if err != nil {
+		helper.ClearAuthCookies(c)
+		return err
+	}
+
+	helper.SetRefreshTokenCookie(c, tokens.RefreshToken)
+
 	return c.JSON(fiber.Map{
-	"success": true,
-	"message": "Login successful",
-	"accessToken": accessToken,
-	"user":    ac.buildUserResponse(&user),
-})
+		"success":      true,
+		"message":      "Tokens refreshed successfully",
+		"accessToken":  tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    tokens.ExpiresIn,
+	})
 }
 
-// func (ac *AuthController) Refresh(c *fiber.Ctx) error {
-// 	refreshToken := utils.GetRefreshTokenFromCookie(c)
-// 	if refreshToken == "" {
-// 		return helper.Unauthorized("Refresh token required")
-// 	}
-
-// 	var tokenRecord model.RefreshToken
-// 	if err := ac.db.Where("token = ?", refreshToken).First(&tokenRecord).Error; err != nil {
-// 		return helper.Unauthorized("Invalid refresh token")
-// 	}
-
-// 	if !tokenRecord.IsValid() {
-// 		utils.ClearAuthCookies(c)
-// 		return helper.Unauthorized("Refresh token is invalid or expired")
-// 	}
-
-// 	var user model.User
-// 	if err := ac.db.First(&user, tokenRecord.UserID).Error; err != nil {
-// 		return helper.Unauthorized("User not found")
-// 	}
-
-// 	if user.Status != model.UserStatusActive {
-// 		ac.db.Model(&tokenRecord).Update("revoked", true)
-// 		utils.ClearAuthCookies(c)
-// 		return helper.Unauthorized("Account is deactivated")
-// 	}
-
-// 	ac.db.Model(&tokenRecord).Update("revoked", true)
-
-// 	newAccessToken, err := utils.GenerateAccessToken(user.ID, string(user.Role))
-// 	if err != nil {
-// 		return helper.InternalServerError("Failed to generate access token")
-// 	}
-
-// 	newRefreshToken, err := utils.GenerateRefreshToken()
-// 	if err != nil {
-// 		return helper.InternalServerError("Failed to generate refresh token")
-// 	}
-
-// 	newTokenRecord := model.RefreshToken{
-// 		UserID:    user.ID,
-// 		Token:     newRefreshToken,
-// 		ExpiresAt: time.Now().Add(utils.RefreshTokenExpiry),
-// 		Revoked:   false,
-// 	}
-// 	if err := ac.db.Create(&newTokenRecord).Error; err != nil {
-// 		return helper.InternalServerError("Failed to store refresh token")
-// 	}
-
-// 	utils.SetAccessTokenCookie(c, newAccessToken)
-// 	utils.SetRefreshTokenCookie(c, newRefreshToken)
-
-// 	return c.JSON(fiber.Map{
-// 		"success": true,
-// 		"message": "Tokens refreshed successfully",
-// 	})
-// }
-
-// func (ac *AuthController) Logout(c *fiber.Ctx) error {
-// 	refreshToken := utils.GetRefreshTokenFromCookie(c)
-
-// 	if refreshToken != "" {
-// 		ac.db.Model(&model.RefreshToken{}).
-// 			Where("token = ?", refreshToken).
-// 			Update("revoked", true)
-// 	}
-
-// 	utils.ClearAuthCookies(c)
-
-// 	return c.JSON(fiber.Map{
-// 		"success": true,
-// 		"message": "Logged out successfully",
-// 	})
-// }
+// Logout revokes the refresh token family of the current session.
+func (ac *AuthController) Logout(c *fiber.Ctx) error {
+	refreshToken := helper.GetRefreshTokenFromRequest(c)
+
+	if err := ac.tokenService.Revoke(refreshToken); err != nil {
+		return helper.InternalServerError("Failed to revoke refresh token")
+	}
+
+	helper.ClearAuthCookies(c)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Logged out successfully",
+	})
+}
 
 func (ac *AuthController) handleRegister(c *fiber.Ctx, role model.Role, successMsg string) error {
 	var req RegisterRequest
@@ -249,6 +270,8 @@ func (ac *AuthController) buildUserResponse(user *model.User) fiber.Map {
 		"role":       user.Role,
 		"status":     user.Status,
 		"position":   user.Position,
+		"mustChangePassword": user.MustChangePassword,
+		"totpEnabled": user.TOTPEnabled,
 	}
 
 	if user.Department != nil {
@@ -13,10 +13,20 @@ const oauthStateCookie = "oauth_state"
 
 type AuthOAuthController struct {
 	authOAuthService service.AuthOAuthService
+	tokenService     service.AuthTokenService
+	twoFactorService service.TwoFactorService
 }
 
-func NewAuthOAuthController(authOAuthService service.AuthOAuthService) *AuthOAuthController {
-	return &AuthOAuthController{authOAuthService: authOAuthService}
+func NewAuthOAuthController(
+	authOAuthService service.AuthOAuthService,
+	tokenService service.AuthTokenService,
+	twoFactorService service.TwoFactorService,
+) *AuthOAuthController {
+	return &AuthOAuthController{
+		authOAuthService: authOAuthService,
+		tokenService:     tokenService,
+		twoFactorService: twoFactorService,
+	}
 }
 
 func (c *AuthOAuthController) GoogleLogin(ctx *fiber.Ctx) error {
@@ -47,21 +57,25 @@ func (c *AuthOAuthController) GoogleExchange(ctx *fiber.Ctx) error {
 		return helper.BadRequest("Missing code")
 	}
 
-	jwtToken, user, err := c.authOAuthService.HandleGoogleCallback(req.Code)
+	user, err := c.authOAuthService.HandleGoogleCallback(req.Code)
 	if err != nil {
 		return err
 	}
 
-	return ctx.JSON(fiber.Map{
-		"accessToken": jwtToken,
-		"isProfileComplete": user.IsProfileComplete,
-		"user": fiber.Map{
-			"id":    user.ID,
-			"email": user.Email,
-			"name":  user.Name,
-			"role":  user.Role,
-		},
-	})
+	session, err := beginSession(ctx, c.tokenService, c.twoFactorService, user)
+	if err != nil {
+		return err
+	}
+
+	session["isProfileComplete"] = user.IsProfileComplete
+	session["user"] = fiber.Map{
+		"id":    user.ID,
+		"email": user.Email,
+		"name":  user.Name,
+		"role":  user.Role,
+	}
+
+	return ctx.JSON(session)
 }
 
 func generateStateToken() (string, error) {
@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CalendarFeedController struct {
+	service service.CalendarFeedService
+}
+
+func NewCalendarFeedController(service service.CalendarFeedService) *CalendarFeedController {
+	return &CalendarFeedController{service: service}
+}
+
+func (c *CalendarFeedController) Create(ctx *fiber.Ctx) error {
+	result, err := c.service.CreateFeed(currentActor(ctx), ctx.Params("scope"))
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Calendar feed created successfully",
+		Data:    result,
+	})
+}
+
+func (c *CalendarFeedController) FindMine(ctx *fiber.Ctx) error {
+	result, err := c.service.FindMine(currentActor(ctx))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Calendar feeds retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (c *CalendarFeedController) Revoke(ctx *fiber.Ctx) error {
+	if err := c.service.RevokeFeed(currentActor(ctx), ctx.Params("scope")); err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Calendar feed revoked successfully",
+	})
+}
+
+// Feed serves a feed to calendar clients. It is public; the token in the
+// URL identifies the subscriber.
+func (c *CalendarFeedController) Feed(ctx *fiber.Ctx) error {
+	token := strings.TrimSuffix(ctx.Params("token"), ".ics")
+
+	body, err := c.service.Feed(token)
+	if err != nil {
+		return err
+	}
+
+	ctx.Set("Content-Type", "text/calendar; charset=utf-8")
+	ctx.Set("Cache-Control", "private, max-age=300")
+	return ctx.SendString(body)
+}
+
+func (c *CalendarFeedController) DownloadTrainingPlan(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("trainingPlanId"))
+	if err != nil {
+		return helper.BadRequest("Invalid training plan ID")
+	}
+
+	body, err := c.service.TrainingPlanICS(currentActor(ctx), id)
+	if err != nil {
+		return err
+	}
+
+	ctx.Set("Content-Type", "text/calendar; charset=utf-8")
+	ctx.Set("Content-Disposition", fmt.Sprintf("attachment; filename=training-plan-%d.ics", id))
+	return ctx.SendString(body)
+}
@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"strconv"
+
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CalendarSyncController struct {
+	service service.CalendarSyncService
+}
+
+func NewCalendarSyncController(service service.CalendarSyncService) *CalendarSyncController {
+	return &CalendarSyncController{service: service}
+}
+
+func (c *CalendarSyncController) FindFailed(ctx *fiber.Ctx) error {
+	page, _ := strconv.Atoi(ctx.Query("page", "1"))
+	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+
+	result, err := c.service.FindFailed(page, limit)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Failed calendar syncs retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (c *CalendarSyncController) Retry(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("outboxId"))
+	if err != nil {
+		return helper.BadRequest("Invalid calendar sync ID")
+	}
+
+	if err := c.service.Retry(currentActor(ctx), uint(id)); err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Calendar sync queued for retry",
+	})
+}
+
+// RefreshResponses reads the plan's invitation responses into its records
+// right away instead of waiting for the next poll.
+func (c *CalendarSyncController) RefreshResponses(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("trainingPlanId"))
+	if err != nil {
+		return helper.BadRequest("Invalid training plan ID")
+	}
+
+	changed, err := c.service.RefreshResponses(ctx.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Calendar responses refreshed",
+		Data:    fiber.Map{"updated": changed},
+	})
+}
+
+// Resync queues the plan's calendar event to be rewritten. ?recreate=true
+// drops the stored event ID first, for events removed by hand.
+func (c *CalendarSyncController) Resync(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("trainingPlanId"))
+	if err != nil {
+		return helper.BadRequest("Invalid training plan ID")
+	}
+
+	if err := c.service.Resync(currentActor(ctx), id, ctx.QueryBool("recreate")); err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Calendar resync queued",
+	})
+}
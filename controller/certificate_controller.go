@@ -2,6 +2,7 @@ package controller
 
 import (
 	"strconv"
+	"time"
 	"training-plan-api/data/request"
 	"training-plan-api/data/response"
 	"training-plan-api/helper"
@@ -35,8 +36,6 @@ func (c *CertificateController) FindByCurrentUser(ctx *fiber.Ctx) error {
 }
 
 func (c *CertificateController) Upload(ctx *fiber.Ctx) error {
-	userID := ctx.Locals("user_id").(uint)
-
 	trainingId, err := strconv.Atoi(ctx.FormValue("trainingId"))
 	if err != nil {
 		return helper.BadRequest("Invalid training ID")
@@ -58,7 +57,15 @@ func (c *CertificateController) Upload(ctx *fiber.Ctx) error {
 		Description: desc,
 	}
 
-	if err := c.service.Upload(userID, req, file); err != nil {
+	if value := ctx.FormValue("expiryDate"); value != "" {
+		expiryDate, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return helper.BadRequest("Invalid expiry date")
+		}
+		req.ExpiryDate = &expiryDate
+	}
+
+	if err := c.service.Upload(currentActor(ctx), req, file); err != nil {
 		return err
 	}
 
@@ -68,15 +75,57 @@ func (c *CertificateController) Upload(ctx *fiber.Ctx) error {
 	})
 }
 
-func (c *CertificateController) Delete(ctx *fiber.Ctx) error {
-	userID := ctx.Locals("user_id").(uint)
+func (c *CertificateController) UploadExternal(ctx *fiber.Ctx) error {
+	file, err := ctx.FormFile("image")
+	if err != nil {
+		return helper.BadRequest("Certificate image is required")
+	}
+
+	issueDate, err := time.Parse("2006-01-02", ctx.FormValue("issueDate"))
+	if err != nil {
+		return helper.BadRequest("Invalid issue date")
+	}
+
+	req := request.CreateExternalCertificateRequest{
+		Issuer:    ctx.FormValue("issuer"),
+		Title:     ctx.FormValue("title"),
+		IssueDate: issueDate,
+		Category:  ctx.FormValue("category"),
+	}
+
+	if value := ctx.FormValue("expiryDate"); value != "" {
+		expiryDate, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return helper.BadRequest("Invalid expiry date")
+		}
+		req.ExpiryDate = &expiryDate
+	}
+
+	if req.Hours, err = strconv.ParseFloat(ctx.FormValue("hours"), 64); err != nil {
+		return helper.BadRequest("Invalid hours")
+	}
+
+	if description := ctx.FormValue("description"); description != "" {
+		req.Description = &description
+	}
 
+	if err := c.service.UploadExternal(currentActor(ctx), req, file); err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Certificate uploaded successfully",
+	})
+}
+
+func (c *CertificateController) Delete(ctx *fiber.Ctx) error {
 	id, err := strconv.Atoi(ctx.Params("id"))
 	if err != nil {
 		return helper.BadRequest("Invalid certificate ID")
 	}
 
-	if err := c.service.Delete(id, userID); err != nil {
+	if err := c.service.Delete(currentActor(ctx), id); err != nil {
 		return err
 	}
 
@@ -86,6 +135,49 @@ func (c *CertificateController) Delete(ctx *fiber.Ctx) error {
 	})
 }
 
+func (c *CertificateController) Resubmit(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return helper.BadRequest("Invalid certificate ID")
+	}
+
+	file, err := ctx.FormFile("image")
+	if err != nil {
+		return helper.BadRequest("Certificate image is required")
+	}
+
+	var req request.ResubmitCertificateRequest
+	if description := ctx.FormValue("description"); description != "" {
+		req.Description = &description
+	}
+
+	if err := c.service.Resubmit(currentActor(ctx), id, req, file); err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Certificate resubmitted successfully",
+	})
+}
+
+func (c *CertificateController) History(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return helper.BadRequest("Invalid certificate ID")
+	}
+
+	result, err := c.service.FindHistory(currentActor(ctx), id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Response{
+		Status: "SUCCESS",
+		Data:   result,
+	})
+}
+
 // ================= ADMIN =================
 
 func (c *CertificateController) FindAllPending(ctx *fiber.Ctx) error {
@@ -109,7 +201,7 @@ func (c *CertificateController) Approve(ctx *fiber.Ctx) error {
 		return helper.BadRequest("Invalid certificate ID")
 	}
 
-	if err := c.service.Approve(id); err != nil {
+	if err := c.service.Approve(currentActor(ctx), id); err != nil {
 		return err
 	}
 
@@ -125,7 +217,12 @@ func (c *CertificateController) Reject(ctx *fiber.Ctx) error {
 		return helper.BadRequest("Invalid certificate ID")
 	}
 
-	if err := c.service.Reject(id); err != nil {
+	var req request.RejectCertificateRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return helper.BadRequest("Invalid request body")
+	}
+
+	if err := c.service.Reject(currentActor(ctx), id, req); err != nil {
 		return err
 	}
 
@@ -134,3 +231,62 @@ func (c *CertificateController) Reject(ctx *fiber.Ctx) error {
 		Message: "Certificate rejected successfully",
 	})
 }
+
+func (c *CertificateController) FindExpiring(ctx *fiber.Ctx) error {
+	days, _ := strconv.Atoi(ctx.Query("days", "0"))
+	departmentId, _ := strconv.Atoi(ctx.Query("departmentId", "0"))
+
+	result, err := c.service.FindExpiring(currentActor(ctx), days, departmentId)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Response{
+		Status: "SUCCESS",
+		Data:   result,
+	})
+}
+
+func (c *CertificateController) FindValidities(ctx *fiber.Ctx) error {
+	result, err := c.service.FindValidities()
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Response{
+		Status: "SUCCESS",
+		Data:   result,
+	})
+}
+
+func (c *CertificateController) SaveValidity(ctx *fiber.Ctx) error {
+	var req request.SaveCertificateValidityRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return helper.BadRequest("Invalid request body")
+	}
+
+	if err := c.service.SaveValidity(currentActor(ctx), req); err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Certificate validity saved successfully",
+	})
+}
+
+func (c *CertificateController) DeleteValidity(ctx *fiber.Ctx) error {
+	category := ctx.Query("category")
+	if category == "" {
+		return helper.BadRequest("Category is required")
+	}
+
+	if err := c.service.DeleteValidity(currentActor(ctx), category); err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Certificate validity deleted successfully",
+	})
+}
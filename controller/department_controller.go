@@ -28,7 +28,7 @@ func (c *DepartmentController) Create(ctx *fiber.Ctx) error {
 		return helper.BadRequest("Invalid department data")
 	}
 
-	if err := c.departmentService.Create(req); err != nil {
+	if err := c.departmentService.Create(currentActor(ctx), req); err != nil {
 		return err //  handled by global error handler
 	}
 
@@ -50,7 +50,7 @@ func (c *DepartmentController) Update(ctx *fiber.Ctx) error {
 		return helper.BadRequest("Invalid department ID")
 	}
 
-	if err := c.departmentService.Update(id, req); err != nil {
+	if err := c.departmentService.Update(currentActor(ctx), id, req); err != nil {
 		return err
 	}
 
@@ -66,7 +66,7 @@ func (c *DepartmentController) Delete(ctx *fiber.Ctx) error {
 		return helper.BadRequest("Invalid department ID")
 	}
 
-	if err := c.departmentService.Delete(id); err != nil {
+	if err := c.departmentService.Delete(currentActor(ctx), id); err != nil {
 		return err
 	}
 
@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"strconv"
+
+	"training-plan-api/data/request"
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+	"training-plan-api/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type EnrollmentController struct {
+	service service.EnrollmentService
+}
+
+func NewEnrollmentController(service service.EnrollmentService) *EnrollmentController {
+	return &EnrollmentController{service: service}
+}
+
+func (c *EnrollmentController) Request(ctx *fiber.Ctx) error {
+	trainingPlanId, err := strconv.Atoi(ctx.Params("trainingPlanId"))
+	if err != nil {
+		return helper.BadRequest("Invalid training plan ID")
+	}
+
+	var req request.CreateEnrollmentRequest
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&req); err != nil {
+			return helper.BadRequest("Invalid request body")
+		}
+	}
+
+	result, err := c.service.Request(currentActor(ctx), uint(trainingPlanId), req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Enrollment request submitted successfully",
+		Data:    result,
+	})
+}
+
+func (c *EnrollmentController) FindMine(ctx *fiber.Ctx) error {
+	page, _ := strconv.Atoi(ctx.Query("page", "1"))
+	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+
+	result, err := c.service.FindMine(currentActor(ctx), page, limit)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Enrollment requests retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (c *EnrollmentController) FindForReview(ctx *fiber.Ctx) error {
+	page, _ := strconv.Atoi(ctx.Query("page", "1"))
+	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+
+	result, err := c.service.FindForReview(currentActor(ctx), ctx.Query("status"), page, limit)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Enrollment requests retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (c *EnrollmentController) FindById(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return helper.BadRequest("Invalid enrollment request ID")
+	}
+
+	result, err := c.service.FindById(currentActor(ctx), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Enrollment request retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (c *EnrollmentController) Cancel(ctx *fiber.Ctx) error {
+	return c.decide(ctx, c.service.Cancel, "Enrollment request cancelled successfully")
+}
+
+func (c *EnrollmentController) Approve(ctx *fiber.Ctx) error {
+	return c.decide(ctx, c.service.Approve, "Enrollment request approved successfully")
+}
+
+func (c *EnrollmentController) Reject(ctx *fiber.Ctx) error {
+	return c.decide(ctx, c.service.Reject, "Enrollment request rejected successfully")
+}
+
+func (c *EnrollmentController) Escalate(ctx *fiber.Ctx) error {
+	return c.decide(ctx, c.service.Escalate, "Enrollment request escalated successfully")
+}
+
+func (c *EnrollmentController) decide(
+	ctx *fiber.Ctx,
+	action func(model.Actor, uint, request.EnrollmentDecisionRequest) error,
+	message string,
+) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return helper.BadRequest("Invalid enrollment request ID")
+	}
+
+	var req request.EnrollmentDecisionRequest
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&req); err != nil {
+			return helper.BadRequest("Invalid request body")
+		}
+	}
+
+	actor := currentActor(ctx)
+	if err := action(actor, uint(id), req); err != nil {
+		return err
+	}
+
+	result, err := c.service.FindById(actor, uint(id))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: message,
+		Data:    result,
+	})
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"bufio"
+	"fmt"
+	"time"
+	"training-plan-api/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// eventHeartbeat keeps proxies from closing an idle stream and notices
+// clients that went away.
+const eventHeartbeat = 25 * time.Second
+
+type EventController struct {
+	service service.EventService
+}
+
+func NewEventController(service service.EventService) *EventController {
+	return &EventController{service: service}
+}
+
+// Stream sends the caller's live updates as Server-Sent Events until the
+// client disconnects. Events missed while disconnected are not replayed;
+// clients reload their lists after reconnecting.
+func (c *EventController) Stream(ctx *fiber.Ctx) error {
+	events, unsubscribe, err := c.service.Subscribe(currentActor(ctx))
+	if err != nil {
+		return err
+	}
+
+	ctx.Set("Content-Type", "text/event-stream")
+	ctx.Set("Cache-Control", "no-cache")
+	ctx.Set("Connection", "keep-alive")
+	ctx.Set("X-Accel-Buffering", "no")
+
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		heartbeat := time.NewTicker(eventHeartbeat)
+		defer heartbeat.Stop()
+
+		fmt.Fprint(w, "retry: 5000\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": ping\n\n")
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"strconv"
+	"training-plan-api/data/request"
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type NotificationController struct {
+	service service.NotificationService
+}
+
+func NewNotificationController(service service.NotificationService) *NotificationController {
+	return &NotificationController{service: service}
+}
+
+func (c *NotificationController) GetPreferences(ctx *fiber.Ctx) error {
+	result, err := c.service.GetPreferences(currentActor(ctx))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Notification preferences retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (c *NotificationController) UpdatePreferences(ctx *fiber.Ctx) error {
+	var req request.UpdateNotificationPreferencesRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return helper.BadRequest("Invalid notification preferences")
+	}
+
+	result, err := c.service.UpdatePreferences(currentActor(ctx), req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Notification preferences updated successfully",
+		Data:    result,
+	})
+}
+
+func (c *NotificationController) FindInbox(ctx *fiber.Ctx) error {
+	page, _ := strconv.Atoi(ctx.Query("page", "1"))
+	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+	unreadOnly := ctx.Query("unread") == "true"
+
+	result, err := c.service.FindInbox(currentActor(ctx), unreadOnly, page, limit)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Notifications retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (c *NotificationController) CountUnread(ctx *fiber.Ctx) error {
+	result, err := c.service.CountUnread(currentActor(ctx))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Unread notifications counted successfully",
+		Data:    result,
+	})
+}
+
+func (c *NotificationController) MarkRead(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("notificationId"))
+	if err != nil || id <= 0 {
+		return helper.BadRequest("Invalid notification ID")
+	}
+
+	result, err := c.service.MarkRead(currentActor(ctx), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Notification marked as read",
+		Data:    result,
+	})
+}
+
+func (c *NotificationController) MarkAllRead(ctx *fiber.Ctx) error {
+	result, err := c.service.MarkAllRead(currentActor(ctx))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "All notifications marked as read",
+		Data:    result,
+	})
+}
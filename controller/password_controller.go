@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"strconv"
+
+	"training-plan-api/data/request"
+	"training-plan-api/helper"
+	"training-plan-api/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PasswordController struct {
+	passwordService service.PasswordService
+	tokenService    service.AuthTokenService
+}
+
+func NewPasswordController(
+	passwordService service.PasswordService,
+	tokenService service.AuthTokenService,
+) *PasswordController {
+	return &PasswordController{
+		passwordService: passwordService,
+		tokenService:    tokenService,
+	}
+}
+
+func (pc *PasswordController) Forgot(c *fiber.Ctx) error {
+	var req request.ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.BadRequest("Invalid request body")
+	}
+
+	if err := pc.passwordService.ForgotPassword(req); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "If the email is registered, a reset link has been sent",
+	})
+}
+
+func (pc *PasswordController) Reset(c *fiber.Ctx) error {
+	var req request.ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.BadRequest("Invalid request body")
+	}
+
+	if err := pc.passwordService.ResetPassword(req); err != nil {
+		return err
+	}
+
+	helper.ClearAuthCookies(c)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Password reset successfully",
+	})
+}
+
+// Change updates the current user's password and returns a fresh session,
+// since all previous refresh tokens are revoked.
+func (pc *PasswordController) Change(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var req request.ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.BadRequest("Invalid request body")
+	}
+
+	user, err := pc.passwordService.ChangePassword(userID, req)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := issueSession(c, pc.tokenService, user)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success":      true,
+		"message":      "Password changed successfully",
+		"accessToken":  tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    tokens.ExpiresIn,
+	})
+}
+
+func (pc *PasswordController) AdminReset(c *fiber.Ctx) error {
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return helper.BadRequest("Invalid user ID")
+	}
+
+	var req request.AdminResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.BadRequest("Invalid request body")
+	}
+
+	if err := pc.passwordService.AdminResetPassword(currentActor(c), uint(userID), req); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Password reset successfully. The user must change it on next login",
+	})
+}
@@ -31,13 +31,15 @@ func (c *RecordController) RegisterStaff(ctx *fiber.Ctx) error {
 		return helper.BadRequest("Invalid request body")
 	}
 
-	if err := c.service.RegisterStaff(uint(trainingPlanId), req); err != nil {
+	result, err := c.service.RegisterStaff(currentActor(ctx), uint(trainingPlanId), req)
+	if err != nil {
 		return err
 	}
 
 	return ctx.Status(fiber.StatusCreated).JSON(response.Response{
 		Status:  "SUCCESS",
 		Message: "Staff registered to training plan successfully",
+		Data:    result,
 	})
 }
 func (c *RecordController) FindById(ctx *fiber.Ctx) error {
@@ -46,7 +48,7 @@ func (c *RecordController) FindById(ctx *fiber.Ctx) error {
 		return helper.BadRequest("Invalid record ID")
 	}
 
-	result, err := c.service.FindById(id)
+	result, err := c.service.FindById(currentActor(ctx), id)
 	if err != nil {
 		return err
 	}
@@ -69,7 +71,7 @@ func (c *RecordController) Update(ctx *fiber.Ctx) error {
 		return helper.BadRequest("Invalid request body")
 	}
 
-	if err := c.service.Update(id, req); err != nil {
+	if err := c.service.Update(currentActor(ctx), id, req); err != nil {
 		return err
 	}
 
@@ -85,7 +87,7 @@ func (c *RecordController) Delete(ctx *fiber.Ctx) error {
 		return helper.BadRequest("Invalid record ID")
 	}
 
-	if err := c.service.Delete(id); err != nil {
+	if err := c.service.Delete(currentActor(ctx), id); err != nil {
 		return err
 	}
 
@@ -114,6 +116,39 @@ func (c *RecordController) FindRecordByCurrentDepartment(ctx *fiber.Ctx) error {
 
 }
 
+func (c *RecordController) FindTrainingHistory(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	result, err := c.service.FindTrainingHistory(userID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Training history retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (c *RecordController) FindTrainingHistoryByUser(ctx *fiber.Ctx) error {
+	userID, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return helper.BadRequest("Invalid user ID")
+	}
+
+	result, err := c.service.FindTrainingHistory(uint(userID))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Training history retrieved successfully",
+		Data:    result,
+	})
+}
+
 func (c *RecordController) FindByCurrentUser(ctx *fiber.Ctx) error {
 	userID := ctx.Locals("user_id").(uint)
 
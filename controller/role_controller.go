@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"net/url"
+
+	"training-plan-api/data/request"
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type RoleController struct {
+	permissionService service.PermissionService
+}
+
+func NewRoleController(permissionService service.PermissionService) *RoleController {
+	return &RoleController{
+		permissionService: permissionService,
+	}
+}
+
+func (c *RoleController) ListPermissions(ctx *fiber.Ctx) error {
+	return ctx.Status(fiber.StatusOK).JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Permissions retrieved successfully",
+		Data:    c.permissionService.ListPermissions(),
+	})
+}
+
+func (c *RoleController) FindAll(ctx *fiber.Ctx) error {
+	roles, err := c.permissionService.FindRoles()
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Roles retrieved successfully",
+		Data:    roles,
+	})
+}
+
+func (c *RoleController) Create(ctx *fiber.Ctx) error {
+	var req request.CreateRoleRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		return helper.BadRequest("Invalid role data")
+	}
+
+	role, err := c.permissionService.CreateRole(currentActor(ctx), req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Role created successfully",
+		Data:    role,
+	})
+}
+
+func (c *RoleController) Update(ctx *fiber.Ctx) error {
+	var req request.UpdateRoleRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		return helper.BadRequest("Invalid role data")
+	}
+
+	name, err := roleNameParam(ctx)
+	if err != nil {
+		return err
+	}
+
+	role, err := c.permissionService.UpdateRole(currentActor(ctx), name, req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Role updated successfully",
+		Data:    role,
+	})
+}
+
+func (c *RoleController) Delete(ctx *fiber.Ctx) error {
+	name, err := roleNameParam(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := c.permissionService.DeleteRole(currentActor(ctx), name); err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Role deleted successfully",
+	})
+}
+
+// roleNameParam decodes the :name path segment; built-in role names
+// contain characters such as parentheses that clients percent-encode.
+func roleNameParam(ctx *fiber.Ctx) (string, error) {
+	name, err := url.PathUnescape(ctx.Params("name"))
+	if err != nil || name == "" {
+		return "", helper.BadRequest("Invalid role name")
+	}
+	return name, nil
+}
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"training-plan-api/data/request"
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ScheduledJobController struct {
+	service service.SchedulerService
+}
+
+func NewScheduledJobController(service service.SchedulerService) *ScheduledJobController {
+	return &ScheduledJobController{service: service}
+}
+
+func (c *ScheduledJobController) FindAll(ctx *fiber.Ctx) error {
+	result, err := c.service.FindJobs()
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Jobs retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (c *ScheduledJobController) Update(ctx *fiber.Ctx) error {
+	var req request.UpdateScheduledJobRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return helper.BadRequest("Invalid job settings")
+	}
+
+	result, err := c.service.UpdateJob(currentActor(ctx), ctx.Params("name"), req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Job updated successfully",
+		Data:    result,
+	})
+}
+
+// Run queues the job to run on the next scheduler tick.
+func (c *ScheduledJobController) Run(ctx *fiber.Ctx) error {
+	if err := c.service.TriggerJob(currentActor(ctx), ctx.Params("name")); err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusAccepted).JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Job queued to run",
+	})
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+	"training-plan-api/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// beginSession finishes the password (or Google) step of a login. Users
+// with two-factor authentication, or whose role requires it, receive a
+// challenge token instead of a session.
+func beginSession(
+	c *fiber.Ctx,
+	tokenService service.AuthTokenService,
+	twoFactorService service.TwoFactorService,
+	user *model.User,
+) (fiber.Map, error) {
+	if user.TOTPEnabled || twoFactorService.IsRequired(user.Role) {
+		purpose := helper.ChallengeVerifyTwoFactor
+		message := "Two-factor authentication required"
+		if !user.TOTPEnabled {
+			purpose = helper.ChallengeSetupTwoFactor
+			message = "Two-factor authentication setup required"
+		}
+
+		challengeToken, err := twoFactorService.IssueChallenge(user, purpose)
+		if err != nil {
+			return nil, err
+		}
+
+		return fiber.Map{
+			"message":                message,
+			"twoFactorRequired":      user.TOTPEnabled,
+			"twoFactorSetupRequired": !user.TOTPEnabled,
+			"challengeToken":         challengeToken,
+			"challengeExpiresIn":     int64(helper.ChallengeTokenExpiry.Seconds()),
+		}, nil
+	}
+
+	tokens, err := issueSession(c, tokenService, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return fiber.Map{
+		"message":      "Login successful",
+		"accessToken":  tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    tokens.ExpiresIn,
+	}, nil
+}
+
+// issueSession creates an access/refresh token pair for user and sets the
+// refresh token cookie.
+func issueSession(c *fiber.Ctx, tokenService service.AuthTokenService, user *model.User) (response.TokenResponse, error) {
+	tokens, err := tokenService.IssueTokens(user)
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+
+	helper.SetRefreshTokenCookie(c, tokens.RefreshToken)
+	return tokens, nil
+}
@@ -27,7 +27,7 @@ func (c *TrainingPlanController) Create(ctx *fiber.Ctx) error {
 		return helper.BadRequest("Invalid training plan data")
 	}
 
-	if err := c.trainingPlanService.Create(req); err != nil {
+	if err := c.trainingPlanService.Create(currentActor(ctx), req); err != nil {
 		return err // handled by global error handler
 	}
 
@@ -50,7 +50,7 @@ func (c *TrainingPlanController) Update(ctx *fiber.Ctx) error {
 		return helper.BadRequest("Invalid training plan ID")
 	}
 
-	if err := c.trainingPlanService.Update(id, req); err != nil {
+	if err := c.trainingPlanService.Update(currentActor(ctx), id, req); err != nil {
 		return err
 	}
 
@@ -67,7 +67,7 @@ func (c *TrainingPlanController) Delete(ctx *fiber.Ctx) error {
 		return helper.BadRequest("Invalid training plan ID")
 	}
 
-	if err := c.trainingPlanService.Delete(id); err != nil {
+	if err := c.trainingPlanService.Delete(currentActor(ctx), id); err != nil {
 		return err
 	}
 
@@ -77,6 +77,88 @@ func (c *TrainingPlanController) Delete(ctx *fiber.Ctx) error {
 	})
 }
 
+// PUBLISH
+func (c *TrainingPlanController) Publish(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("trainingPlanId"))
+	if err != nil {
+		return helper.BadRequest("Invalid training plan ID")
+	}
+
+	if err := c.trainingPlanService.Publish(currentActor(ctx), id); err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Training plan published successfully",
+	})
+}
+
+// CANCEL
+func (c *TrainingPlanController) Cancel(ctx *fiber.Ctx) error {
+	var req request.CancelTrainingPlanRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		return helper.BadRequest("Invalid request body")
+	}
+
+	id, err := strconv.Atoi(ctx.Params("trainingPlanId"))
+	if err != nil {
+		return helper.BadRequest("Invalid training plan ID")
+	}
+
+	if err := c.trainingPlanService.Cancel(currentActor(ctx), id, req); err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Training plan cancelled successfully",
+	})
+}
+
+// COMPLETE
+func (c *TrainingPlanController) Complete(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("trainingPlanId"))
+	if err != nil {
+		return helper.BadRequest("Invalid training plan ID")
+	}
+
+	if err := c.trainingPlanService.Complete(currentActor(ctx), id); err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Training plan completed successfully",
+	})
+}
+
+// CLONE
+func (c *TrainingPlanController) Clone(ctx *fiber.Ctx) error {
+	var req request.CloneTrainingPlanRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		return helper.BadRequest("Invalid request body")
+	}
+
+	id, err := strconv.Atoi(ctx.Params("trainingPlanId"))
+	if err != nil {
+		return helper.BadRequest("Invalid training plan ID")
+	}
+
+	trainingPlan, err := c.trainingPlanService.Clone(currentActor(ctx), id, req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Training plan cloned successfully",
+		Data:    trainingPlan,
+	})
+}
+
 // FIND BY ID
 func (c *TrainingPlanController) FindById(ctx *fiber.Ctx) error {
 	id, err := strconv.Atoi(ctx.Params("trainingPlanId"))
@@ -84,7 +166,7 @@ func (c *TrainingPlanController) FindById(ctx *fiber.Ctx) error {
 		return helper.BadRequest("Invalid training plan ID")
 	}
 
-	trainingPlan, err := c.trainingPlanService.FindById(id)
+	trainingPlan, err := c.trainingPlanService.FindById(currentActor(ctx), id)
 	if err != nil {
 		return err
 	}
@@ -101,7 +183,24 @@ func (c *TrainingPlanController) FindPaginated(ctx *fiber.Ctx) error {
 	page, _ := strconv.Atoi(ctx.Query("page", "1"))
 	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
 
-	result, err := c.trainingPlanService.FindPaginated(page, limit)
+	result, err := c.trainingPlanService.FindPaginated(currentActor(ctx), ctx.Query("status"), page, limit)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Training plans retrieved successfully",
+		Data:    result,
+	})
+}
+
+// FIND CATALOG (staff)
+func (c *TrainingPlanController) FindCatalog(ctx *fiber.Ctx) error {
+	page, _ := strconv.Atoi(ctx.Query("page", "1"))
+	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+
+	result, err := c.trainingPlanService.FindCatalog(page, limit)
 	if err != nil {
 		return err
 	}
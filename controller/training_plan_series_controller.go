@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"strconv"
+
+	"training-plan-api/data/request"
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type TrainingPlanSeriesController struct {
+	service service.TrainingPlanSeriesService
+}
+
+func NewTrainingPlanSeriesController(service service.TrainingPlanSeriesService) *TrainingPlanSeriesController {
+	return &TrainingPlanSeriesController{service: service}
+}
+
+func (c *TrainingPlanSeriesController) Create(ctx *fiber.Ctx) error {
+	var req request.CreateTrainingPlanSeriesRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return helper.BadRequest("Invalid training plan series data")
+	}
+
+	result, err := c.service.Create(currentActor(ctx), req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Training plan series created successfully",
+		Data:    result,
+	})
+}
+
+func (c *TrainingPlanSeriesController) FindPaginated(ctx *fiber.Ctx) error {
+	page, _ := strconv.Atoi(ctx.Query("page", "1"))
+	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+
+	result, err := c.service.FindPaginated(page, limit)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Training plan series retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (c *TrainingPlanSeriesController) FindById(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("seriesId"))
+	if err != nil {
+		return helper.BadRequest("Invalid training plan series ID")
+	}
+
+	result, err := c.service.FindById(uint(id))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Training plan series retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (c *TrainingPlanSeriesController) Generate(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("seriesId"))
+	if err != nil {
+		return helper.BadRequest("Invalid training plan series ID")
+	}
+
+	result, err := c.service.Generate(currentActor(ctx), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Training plans generated successfully",
+		Data:    result,
+	})
+}
+
+func (c *TrainingPlanSeriesController) Stop(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("seriesId"))
+	if err != nil {
+		return helper.BadRequest("Invalid training plan series ID")
+	}
+
+	if err := c.service.Stop(currentActor(ctx), uint(id)); err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Training plan series stopped successfully",
+	})
+}
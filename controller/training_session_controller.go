@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"strconv"
+
+	"training-plan-api/data/request"
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type TrainingSessionController struct {
+	service service.TrainingSessionService
+}
+
+func NewTrainingSessionController(service service.TrainingSessionService) *TrainingSessionController {
+	return &TrainingSessionController{service: service}
+}
+
+func (c *TrainingSessionController) Create(ctx *fiber.Ctx) error {
+	trainingPlanId, err := strconv.Atoi(ctx.Params("trainingPlanId"))
+	if err != nil {
+		return helper.BadRequest("Invalid training plan ID")
+	}
+
+	var req request.CreateTrainingSessionRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return helper.BadRequest("Invalid training session data")
+	}
+
+	result, err := c.service.Create(currentActor(ctx), trainingPlanId, req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Training session created successfully",
+		Data:    result,
+	})
+}
+
+func (c *TrainingSessionController) Update(ctx *fiber.Ctx) error {
+	sessionId, err := strconv.Atoi(ctx.Params("sessionId"))
+	if err != nil {
+		return helper.BadRequest("Invalid training session ID")
+	}
+
+	var req request.UpdateTrainingSessionRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return helper.BadRequest("Invalid training session data")
+	}
+
+	result, err := c.service.Update(currentActor(ctx), uint(sessionId), req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Training session updated successfully",
+		Data:    result,
+	})
+}
+
+func (c *TrainingSessionController) Delete(ctx *fiber.Ctx) error {
+	sessionId, err := strconv.Atoi(ctx.Params("sessionId"))
+	if err != nil {
+		return helper.BadRequest("Invalid training session ID")
+	}
+
+	if err := c.service.Delete(currentActor(ctx), uint(sessionId)); err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Training session deleted successfully",
+	})
+}
+
+func (c *TrainingSessionController) FindByTrainingPlan(ctx *fiber.Ctx) error {
+	trainingPlanId, err := strconv.Atoi(ctx.Params("trainingPlanId"))
+	if err != nil {
+		return helper.BadRequest("Invalid training plan ID")
+	}
+
+	result, err := c.service.FindByTrainingPlan(currentActor(ctx), trainingPlanId)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Training sessions retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (c *TrainingSessionController) FindAttendance(ctx *fiber.Ctx) error {
+	sessionId, err := strconv.Atoi(ctx.Params("sessionId"))
+	if err != nil {
+		return helper.BadRequest("Invalid training session ID")
+	}
+
+	result, err := c.service.FindAttendance(currentActor(ctx), uint(sessionId))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Attendance retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (c *TrainingSessionController) MarkAttendance(ctx *fiber.Ctx) error {
+	sessionId, err := strconv.Atoi(ctx.Params("sessionId"))
+	if err != nil {
+		return helper.BadRequest("Invalid training session ID")
+	}
+
+	var req request.MarkAttendanceRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return helper.BadRequest("Invalid attendance data")
+	}
+
+	if err := c.service.MarkAttendance(currentActor(ctx), uint(sessionId), req); err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Attendance marked successfully",
+	})
+}
@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"strconv"
+
+	"training-plan-api/data/request"
+	"training-plan-api/helper"
+	"training-plan-api/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type TwoFactorController struct {
+	twoFactorService service.TwoFactorService
+	tokenService     service.AuthTokenService
+}
+
+func NewTwoFactorController(
+	twoFactorService service.TwoFactorService,
+	tokenService service.AuthTokenService,
+) *TwoFactorController {
+	return &TwoFactorController{
+		twoFactorService: twoFactorService,
+		tokenService:     tokenService,
+	}
+}
+
+func (tc *TwoFactorController) Status(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	status, err := tc.twoFactorService.Status(userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    status,
+	})
+}
+
+func (tc *TwoFactorController) Setup(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	setup, err := tc.twoFactorService.Setup(userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Scan the provisioning URI with an authenticator app, then activate with a code",
+		"data":    setup,
+	})
+}
+
+// Activate confirms enrollment. When the caller is finishing a login that
+// required enrollment, a session is issued as well.
+func (tc *TwoFactorController) Activate(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var req request.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.BadRequest("Invalid request body")
+	}
+
+	user, recoveryCodes, err := tc.twoFactorService.Activate(userID, req)
+	if err != nil {
+		return err
+	}
+
+	result := fiber.Map{
+		"success":       true,
+		"message":       "Two-factor authentication enabled. Store the recovery codes somewhere safe",
+		"recoveryCodes": recoveryCodes,
+	}
+
+	if viaChallenge, _ := c.Locals("two_factor_setup").(bool); viaChallenge {
+		tokens, err := issueSession(c, tc.tokenService, user)
+		if err != nil {
+			return err
+		}
+		result["accessToken"] = tokens.AccessToken
+		result["refreshToken"] = tokens.RefreshToken
+		result["expiresIn"] = tokens.ExpiresIn
+	}
+
+	return c.JSON(result)
+}
+
+// Verify completes a two-step login with a TOTP or recovery code.
+func (tc *TwoFactorController) Verify(c *fiber.Ctx) error {
+	var req request.TwoFactorVerifyRequest
+	if err := c.BodyParser(&req); err != nil || req.ChallengeToken == "" || req.Code == "" {
+		return helper.BadRequest("Challenge token and code are required")
+	}
+
+	user, err := tc.twoFactorService.Verify(req.ChallengeToken, req.Code)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := issueSession(c, tc.tokenService, user)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success":      true,
+		"message":      "Login successful",
+		"accessToken":  tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    tokens.ExpiresIn,
+	})
+}
+
+func (tc *TwoFactorController) Disable(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var req request.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.BadRequest("Invalid request body")
+	}
+
+	if err := tc.twoFactorService.Disable(userID, req); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+func (tc *TwoFactorController) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var req request.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.BadRequest("Invalid request body")
+	}
+
+	recoveryCodes, err := tc.twoFactorService.RegenerateRecoveryCodes(userID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success":       true,
+		"message":       "Recovery codes regenerated",
+		"recoveryCodes": recoveryCodes,
+	})
+}
+
+func (tc *TwoFactorController) AdminReset(c *fiber.Ctx) error {
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return helper.BadRequest("Invalid user ID")
+	}
+
+	if err := tc.twoFactorService.AdminReset(currentActor(c), uint(userID)); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Two-factor authentication reset successfully",
+	})
+}
@@ -31,9 +31,7 @@ func (uc *UserController) AdminCreate(c *fiber.Ctx) error {
 		return helper.BadRequest("Invalid request body")
 	}
 
-	creatorID := c.Locals("user_id").(uint)
-
-	if err := uc.userService.AdminCreate(req, creatorID); err != nil {
+	if err := uc.userService.AdminCreate(currentActor(c), req); err != nil {
 		return err
 	}
 
@@ -54,7 +52,7 @@ func (uc *UserController) AdminUpdate(c *fiber.Ctx) error {
 		return helper.BadRequest("Invalid request body")
 	}
 
-	if err := uc.userService.AdminUpdate(uint(userID), req); err != nil {
+	if err := uc.userService.AdminUpdate(currentActor(c), uint(userID), req); err != nil {
 		return err
 	}
 
@@ -70,7 +68,7 @@ func (uc *UserController) AdminDelete(c *fiber.Ctx) error {
 		return helper.BadRequest("Invalid user ID")
 	}
 
-	if err := uc.userService.AdminDelete(uint(userID)); err != nil {
+	if err := uc.userService.AdminDelete(currentActor(c), uint(userID)); err != nil {
 		return err
 	}
 
@@ -80,6 +78,22 @@ func (uc *UserController) AdminDelete(c *fiber.Ctx) error {
 	})
 }
 
+func (uc *UserController) AdminUnlock(c *fiber.Ctx) error {
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return helper.BadRequest("Invalid user ID")
+	}
+
+	if err := uc.userService.AdminUnlock(currentActor(c), uint(userID)); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "User unlocked successfully",
+	})
+}
+
 func (uc *UserController) AdminFindAll(c *fiber.Ctx) error {
 	params := request.UserTableQueryParams{
 		Search:       c.Query("search"),
@@ -144,7 +158,7 @@ func (uc *UserController) ManagerCreate(c *fiber.Ctx) error {
 		return helper.InternalServerError("Failed to get manager department")
 	}
 
-	if err := uc.userService.ManagerCreate(req, managerID, managerDepartmentID); err != nil {
+	if err := uc.userService.ManagerCreate(currentActor(c), req, managerDepartmentID); err != nil {
 		return err
 	}
 
@@ -188,14 +202,12 @@ func (uc *UserController) getManagerDepartmentID(managerID uint) (int, error) {
 // ============== STAFF ENDPOINTS ==============
 
 func (uc *UserController) CompleteProfile(c *fiber.Ctx) error {
-	userID := c.Locals("user_id").(uint)
-
 	var req request.CompleteProfileRequest
 	if err := c.BodyParser(&req); err != nil {
 		return helper.BadRequest("Invalid request body")
 	}
 
-	if err := uc.userService.CompleteProfile(userID, req); err != nil {
+	if err := uc.userService.CompleteProfile(currentActor(c), req); err != nil {
 		return err
 	}
 
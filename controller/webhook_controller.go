@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"strconv"
+
+	"training-plan-api/data/request"
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WebhookController struct {
+	service service.WebhookService
+}
+
+func NewWebhookController(service service.WebhookService) *WebhookController {
+	return &WebhookController{service: service}
+}
+
+func (c *WebhookController) Create(ctx *fiber.Ctx) error {
+	var req request.WebhookSubscriptionRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return helper.BadRequest("Invalid webhook subscription")
+	}
+
+	result, err := c.service.Create(currentActor(ctx), req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Webhook subscription created successfully",
+		Data:    result,
+	})
+}
+
+func (c *WebhookController) FindAll(ctx *fiber.Ctx) error {
+	result, err := c.service.FindAll()
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Webhook subscriptions retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (c *WebhookController) FindById(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("webhookId"))
+	if err != nil || id <= 0 {
+		return helper.BadRequest("Invalid webhook subscription ID")
+	}
+
+	result, err := c.service.FindById(uint(id))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Webhook subscription retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (c *WebhookController) Update(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("webhookId"))
+	if err != nil || id <= 0 {
+		return helper.BadRequest("Invalid webhook subscription ID")
+	}
+
+	var req request.WebhookSubscriptionRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return helper.BadRequest("Invalid webhook subscription")
+	}
+
+	result, err := c.service.Update(currentActor(ctx), uint(id), req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Webhook subscription updated successfully",
+		Data:    result,
+	})
+}
+
+func (c *WebhookController) Delete(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("webhookId"))
+	if err != nil || id <= 0 {
+		return helper.BadRequest("Invalid webhook subscription ID")
+	}
+
+	if err := c.service.Delete(currentActor(ctx), uint(id)); err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Webhook subscription deleted successfully",
+	})
+}
+
+func (c *WebhookController) FindDeliveries(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("webhookId"))
+	if err != nil || id <= 0 {
+		return helper.BadRequest("Invalid webhook subscription ID")
+	}
+
+	page, _ := strconv.Atoi(ctx.Query("page", "1"))
+	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+
+	result, err := c.service.FindDeliveries(uint(id), ctx.Query("status"), page, limit)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Webhook deliveries retrieved successfully",
+		Data:    result,
+	})
+}
+
+func (c *WebhookController) Replay(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("webhookId"))
+	if err != nil || id <= 0 {
+		return helper.BadRequest("Invalid webhook subscription ID")
+	}
+	deliveryId, err := strconv.Atoi(ctx.Params("deliveryId"))
+	if err != nil || deliveryId <= 0 {
+		return helper.BadRequest("Invalid webhook delivery ID")
+	}
+
+	result, err := c.service.Replay(currentActor(ctx), uint(id), uint(deliveryId))
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusAccepted).JSON(response.Response{
+		Status:  "SUCCESS",
+		Message: "Webhook delivery queued for replay",
+		Data:    result,
+	})
+}
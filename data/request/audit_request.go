@@ -0,0 +1,17 @@
+package request
+
+import "time"
+
+type AuditFilterRequest struct {
+	ActorID    *uint    `json:"actorId"`
+	Actions    []string `json:"actions"`
+	EntityType string   `json:"entityType"`
+	EntityID   string   `json:"entityId"`
+	RequestID  string   `json:"requestId"`
+
+	StartDate *time.Time `json:"startDate"`
+	EndDate   *time.Time `json:"endDate"`
+
+	Page  int `json:"page"`
+	Limit int `json:"limit"`
+}
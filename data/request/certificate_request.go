@@ -1,9 +1,25 @@
 package request
 
+import "time"
+
 type CreateCertificateRequest struct {
 	TrainingID    uint    `json:"trainingId" validate:"required"`
 	Image        string  `json:"image" validate:"omitempty"`
 	Description  *string `json:"description" validate:"omitempty,max=1000"`
+	// ExpiryDate overrides the validity of the plan's category
+	ExpiryDate *time.Time `json:"expiryDate" validate:"omitempty"`
+}
+
+// CreateExternalCertificateRequest logs a certificate of a course taken
+// outside the company, such as a vendor certification.
+type CreateExternalCertificateRequest struct {
+	Issuer      string     `json:"issuer" validate:"required,max=255"`
+	Title       string     `json:"title" validate:"required,max=255"`
+	IssueDate   time.Time  `json:"issueDate" validate:"required"`
+	ExpiryDate  *time.Time `json:"expiryDate" validate:"omitempty"`
+	Hours       float64    `json:"hours" validate:"required,gt=0,lte=9999"`
+	Category    string     `json:"category" validate:"required,max=255"`
+	Description *string    `json:"description" validate:"omitempty,max=1000"`
 }
 
 type UpdateCertificateRequest struct {
@@ -13,4 +29,19 @@ type UpdateCertificateRequest struct {
 
 type UpdateCertificateStatusRequest struct {
 	Status string `json:"status" validate:"required,oneof=Approved Rejected"`
-}
\ No newline at end of file
+}
+
+type RejectCertificateRequest struct {
+	Reason string `json:"reason" validate:"required,max=1000"`
+}
+
+type ResubmitCertificateRequest struct {
+	Description *string `json:"description" validate:"omitempty,max=1000"`
+}
+
+// SaveCertificateValidityRequest sets how many months certificates of a
+// category stay valid.
+type SaveCertificateValidityRequest struct {
+	Category string `json:"category" validate:"required,max=255"`
+	Months   int    `json:"months" validate:"required,gt=0,lte=600"`
+}
@@ -0,0 +1,9 @@
+package request
+
+type CreateEnrollmentRequest struct {
+	Reason string `json:"reason" validate:"max=1000"`
+}
+
+type EnrollmentDecisionRequest struct {
+	Reason string `json:"reason" validate:"max=1000"`
+}
@@ -0,0 +1,12 @@
+package request
+
+type NotificationPreferenceEntry struct {
+	Event   string `json:"event" validate:"required"`
+	Channel string `json:"channel" validate:"required"`
+	Enabled *bool  `json:"enabled" validate:"required"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	Language    *string                       `json:"language" validate:"omitempty,oneof=th en"`
+	Preferences []NotificationPreferenceEntry `json:"preferences" validate:"omitempty,dive"`
+}
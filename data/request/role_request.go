@@ -0,0 +1,12 @@
+package request
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" validate:"required,min=2,max=52"`
+	Description string   `json:"description" validate:"max=255"`
+	Permissions []string `json:"permissions" validate:"dive,required"`
+}
+
+type UpdateRoleRequest struct {
+	Description *string  `json:"description" validate:"omitempty,max=255"`
+	Permissions []string `json:"permissions" validate:"required,dive,required"`
+}
@@ -0,0 +1,7 @@
+package request
+
+type UpdateScheduledJobRequest struct {
+	// Schedule is a five-field cron expression; empty keeps the current one
+	Schedule string `json:"schedule" validate:"omitempty,max=64"`
+	Enabled  *bool  `json:"enabled"`
+}
@@ -43,4 +43,13 @@ type UpdateTrainingPlanRequest struct {
 	CostPerPerson  *int    `json:"costPerPerson" validate:"omitempty,gte=0"`
 }
 
+type CancelTrainingPlanRequest struct {
+	Reason string `json:"reason" validate:"required,max=1000"`
+}
 
+// CloneTrainingPlanRequest copies a plan to a new date. Name defaults to
+// the source plan's name.
+type CloneTrainingPlanRequest struct {
+	Date time.Time `json:"date" validate:"required"`
+	Name *string   `json:"name" validate:"omitempty,min=3,max=52"`
+}
@@ -0,0 +1,12 @@
+package request
+
+// CreateTrainingPlanSeriesRequest turns an existing plan into the template
+// of a recurring series. RRule uses FREQ (WEEKLY, MONTHLY, YEARLY),
+// INTERVAL, COUNT and UNTIL, e.g. "FREQ=MONTHLY;INTERVAL=3".
+type CreateTrainingPlanSeriesRequest struct {
+	TemplatePlanID int    `json:"templatePlanId" validate:"required,gt=0"`
+	Name           string `json:"name" validate:"omitempty,max=52"`
+	RRule          string `json:"rrule" validate:"required,max=255"`
+	HorizonMonths  int    `json:"horizonMonths" validate:"omitempty,min=1,max=36"`
+	AutoPublish    bool   `json:"autoPublish"`
+}
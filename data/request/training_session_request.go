@@ -0,0 +1,31 @@
+package request
+
+import (
+	"time"
+	"training-plan-api/model"
+)
+
+type CreateTrainingSessionRequest struct {
+	Date      time.Time `json:"date" validate:"required"`
+	StartTime string    `json:"startTime" validate:"required,datetime=15:04"`
+	EndTime   string    `json:"endTime" validate:"required,datetime=15:04"`
+	Location  *string   `json:"location" validate:"omitempty"`
+	Trainer   *string   `json:"trainer" validate:"omitempty,max=255"`
+}
+
+type UpdateTrainingSessionRequest struct {
+	Date      *time.Time `json:"date" validate:"omitempty"`
+	StartTime *string    `json:"startTime" validate:"omitempty,datetime=15:04"`
+	EndTime   *string    `json:"endTime" validate:"omitempty,datetime=15:04"`
+	Location  *string    `json:"location" validate:"omitempty"`
+	Trainer   *string    `json:"trainer" validate:"omitempty,max=255"`
+}
+
+type AttendanceEntry struct {
+	RecordID uint                   `json:"recordId" validate:"required,gt=0"`
+	Status   model.AttendanceStatus `json:"status" validate:"required,oneof=Present Absent"`
+}
+
+type MarkAttendanceRequest struct {
+	Entries []AttendanceEntry `json:"entries" validate:"required,min=1,dive"`
+}
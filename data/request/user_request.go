@@ -35,6 +35,26 @@ type ManagerCreateUserRequest struct {
 	Password   string           `json:"password" validate:"required,min=6"`
 }
 
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token           string `json:"token" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required,min=6"`
+	ConfirmPassword string `json:"confirmPassword" validate:"required,eqfield=NewPassword"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword" validate:"required,min=6"`
+	ConfirmPassword string `json:"confirmPassword" validate:"required,eqfield=NewPassword"`
+}
+
+type AdminResetPasswordRequest struct {
+	NewPassword string `json:"newPassword" validate:"required,min=6"`
+}
+
 type UserTableQueryParams struct {
 	Search       string `query:"search"`
 	DepartmentID int    `query:"departmentId"`
@@ -51,3 +71,12 @@ type CompleteProfileRequest struct {
 	Phone        string `json:"phone" validate:"max=20"`
 	Position     string `json:"position" validate:"max=100"`
 }
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
@@ -0,0 +1,11 @@
+package request
+
+type WebhookSubscriptionRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+	URL  string `json:"url" validate:"required,url,max=500"`
+	// Secret signs the requests. A random one is generated on create when
+	// empty; on update an empty secret keeps the current one.
+	Secret string   `json:"secret" validate:"omitempty,min=16,max=128"`
+	Events []string `json:"events" validate:"required,min=1,dive,required"`
+	Active *bool    `json:"active"`
+}
@@ -0,0 +1,20 @@
+package response
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditEventResponse struct {
+	ID         uint            `json:"id"`
+	ActorID    *uint           `json:"actorId,omitempty"`
+	ActorName  string          `json:"actorName,omitempty"`
+	ActorRole  string          `json:"actorRole"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entityType"`
+	EntityID   string          `json:"entityId"`
+	Changes    json.RawMessage `json:"changes"`
+	IP         string          `json:"ip"`
+	RequestID  string          `json:"requestId"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
@@ -0,0 +1,12 @@
+package response
+
+import "time"
+
+// CalendarFeedResponse describes a calendar feed subscription. URL is only
+// set when the feed is created.
+type CalendarFeedResponse struct {
+	Scope      string     `json:"scope"`
+	URL        string     `json:"url,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+}
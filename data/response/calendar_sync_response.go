@@ -0,0 +1,15 @@
+package response
+
+import "time"
+
+// CalendarSyncFailureResponse is a calendar change that ran out of retries.
+type CalendarSyncFailureResponse struct {
+	ID             uint       `json:"id"`
+	TrainingPlanID int        `json:"trainingPlanId"`
+	Operation      string     `json:"operation"`
+	EventID        *string    `json:"eventId,omitempty"`
+	Attempts       int        `json:"attempts"`
+	LastError      string     `json:"lastError"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	FailedAt       *time.Time `json:"failedAt"`
+}
@@ -12,13 +12,28 @@ type CertificateResponse struct {
 	Division string   `json:"division"`
 	Category   string  `json:"category"`
 
-	TrainingID   uint   `json:"trainingId"`
+	Kind         string  `json:"kind"`
+	TrainingID   *uint   `json:"trainingId,omitempty"`
 	TrainingName string  `json:"trainingName"`
+
+	// Course details of external certificates
+	Issuer     *string    `json:"issuer,omitempty"`
+	IssueDate  *time.Time `json:"issueDate,omitempty"`
+	ExpiryDate *time.Time `json:"expiryDate,omitempty"`
+	Hours      *float64   `json:"hours,omitempty"`
+
 	Image        string  `json:"image"`
 	Description  *string `json:"description,omitempty"`
 
 	Status string `json:"status"`
 
+	Version         int        `json:"version"`
+	RejectionReason *string    `json:"rejectionReason,omitempty"`
+	ReviewedByID    *uint      `json:"reviewedById,omitempty"`
+	ReviewedByName  string     `json:"reviewedByName,omitempty"`
+	ReviewedAt      *time.Time `json:"reviewedAt,omitempty"`
+	RecordID        *uint      `json:"recordId,omitempty"`
+
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
@@ -32,3 +47,43 @@ type UserCertificateResponse struct {
 
 	Description *string `json:"description,omitempty"`
 }
+
+
+type CertificateVersionResponse struct {
+	Version         int        `json:"version"`
+	Image           string     `json:"image"`
+	Description     *string    `json:"description,omitempty"`
+	Status          string     `json:"status"`
+	RejectionReason *string    `json:"rejectionReason,omitempty"`
+	ReviewedByID    *uint      `json:"reviewedById,omitempty"`
+	ReviewedByName  string     `json:"reviewedByName,omitempty"`
+	ReviewedAt      *time.Time `json:"reviewedAt,omitempty"`
+	SubmittedAt     time.Time  `json:"submittedAt"`
+}
+
+// CertificateHistoryResponse is the review trail of a certificate, oldest
+// version first.
+type CertificateHistoryResponse struct {
+	Certificate CertificateResponse          `json:"certificate"`
+	Versions    []CertificateVersionResponse `json:"versions"`
+}
+
+type CertificateValidityResponse struct {
+	Category  string    `json:"category"`
+	Months    int       `json:"months"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ExpiringCertificateResponse is a certificate of the expiring report.
+// DaysLeft is negative once the certificate has expired.
+type ExpiringCertificateResponse struct {
+	CertificateResponse
+	DaysLeft int `json:"daysLeft"`
+}
+
+// ExpiringDepartmentResponse groups the expiring report by department.
+type ExpiringDepartmentResponse struct {
+	DepartmentID int                           `json:"departmentId"`
+	Department   string                        `json:"department"`
+	Certificates []ExpiringCertificateResponse `json:"certificates"`
+}
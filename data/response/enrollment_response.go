@@ -0,0 +1,34 @@
+package response
+
+import "time"
+
+type EnrollmentEventResponse struct {
+	ActorID    uint      `json:"actorId"`
+	ActorRole  string    `json:"actorRole"`
+	Action     string    `json:"action"`
+	FromStatus string    `json:"fromStatus,omitempty"`
+	ToStatus   string    `json:"toStatus"`
+	Reason     string    `json:"reason,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type EnrollmentRequestResponse struct {
+	ID               uint       `json:"id"`
+	UserID           uint       `json:"userId"`
+	EmployeeID       string     `json:"employeeId"`
+	EmployeeName     string     `json:"employeeName"`
+	Department       string     `json:"department"`
+	TrainingPlanID   uint       `json:"trainingPlanId"`
+	TrainingPlanName string     `json:"trainingPlanName"`
+	TrainingDate     time.Time  `json:"trainingDate"`
+	Status           string     `json:"status"`
+	Reason           string     `json:"reason,omitempty"`
+	DecidedByID      *uint      `json:"decidedById,omitempty"`
+	DecisionReason   string     `json:"decisionReason,omitempty"`
+	DecidedAt        *time.Time `json:"decidedAt,omitempty"`
+	RecordID         *uint      `json:"recordId,omitempty"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+
+	History []EnrollmentEventResponse `json:"history,omitempty"`
+}
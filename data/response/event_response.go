@@ -0,0 +1,17 @@
+package response
+
+type CertificateEventData struct {
+	CertificateID uint   `json:"certificateId"`
+	UserID        uint   `json:"userId"`
+	Kind          string `json:"kind"`
+	TrainingID    *uint  `json:"trainingId,omitempty"`
+	Status        string `json:"status"`
+}
+
+type RegistrationEventData struct {
+	RecordID       uint `json:"recordId"`
+	UserID         uint `json:"userId"`
+	TrainingPlanID uint `json:"trainingPlanId"`
+	// Status is empty when the record was deleted
+	Status string `json:"status"`
+}
@@ -0,0 +1,29 @@
+package response
+
+import "time"
+
+type NotificationPreferenceItem struct {
+	Event   string `json:"event"`
+	Channel string `json:"channel"`
+	Enabled bool   `json:"enabled"`
+}
+
+type NotificationPreferencesResponse struct {
+	Language    string                       `json:"language"`
+	Preferences []NotificationPreferenceItem `json:"preferences"`
+}
+
+type NotificationResponse struct {
+	ID        uint       `json:"id"`
+	Event     string     `json:"event"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	Link      string     `json:"link,omitempty"`
+	Read      bool       `json:"read"`
+	ReadAt    *time.Time `json:"readAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+type UnreadCountResponse struct {
+	Unread int64 `json:"unread"`
+}
@@ -14,6 +14,8 @@ type AdminRecordResponse struct {
 	Department       string    `json:"department"`
 	Division         string    `json:"division"`
 	Status           string    `json:"status"`
+	CreditedHours    float64   `json:"creditedHours"`
+	RSVPStatus       *string   `json:"rsvpStatus,omitempty"`
 	Evaluation       *string   `json:"evaluation,omitempty"`
 	PreTestScore     *int      `json:"preTestScore,omitempty"`
 	PostTestScore    *int      `json:"postTestScore,omitempty"`
@@ -51,6 +53,8 @@ type StaffRecordResponse struct {
 	TrainingPlanID   uint      `json:"trainingPlanId"`
 	TrainingPlanName string    `json:"trainingPlanName"`
 	Status           string    `json:"status"`
+	CreditedHours    float64   `json:"creditedHours"`
+	RSVPStatus       *string   `json:"rsvpStatus,omitempty"`
 	Location         *string    `json:"location"`
 	TrainingDate     time.Time `json:"trainingDate"`
 	NumberOfHours    int       `json:"numberOfHours"`
@@ -106,6 +110,8 @@ type RecordResponseFinal struct {
 	TrainingPlanID  uint      `json:"trainingPlanId"`
 	TrainingPlan    TrainingPlanResponse `json:"trainingPlan"`
 	Status           string    `json:"status"`
+	CreditedHours    float64   `json:"creditedHours"`
+	RSVPStatus       *string   `json:"rsvpStatus,omitempty"`
 	Evaluation       *string   `json:"evaluation,omitempty"`
 	PreTestScore     *int      `json:"preTestScore,omitempty"`
 	PostTestScore    *int      `json:"postTestScore,omitempty"`
@@ -113,5 +119,30 @@ type RecordResponseFinal struct {
 	UpdatedAt        time.Time `json:"updatedAt"`
 }
 
+// RegistrationResponse lists the user IDs of a registration request by
+// outcome. Skipped users were already on the training plan.
+type RegistrationResponse struct {
+	Registered []uint `json:"registered"`
+	Waitlisted []uint `json:"waitlisted"`
+	Skipped    []uint `json:"skipped"`
+}
 
+// TrainingHistoryResponse totals the training hours of an employee.
+// InternalHours come from attended training plans, ExternalHours from
+// approved external certificates.
+type TrainingHistoryResponse struct {
+	InternalHours float64                    `json:"internalHours"`
+	ExternalHours float64                    `json:"externalHours"`
+	TotalHours    float64                    `json:"totalHours"`
+	External      []ExternalTrainingResponse `json:"external"`
+}
 
+type ExternalTrainingResponse struct {
+	CertificateID uint       `json:"certificateId"`
+	Title         string     `json:"title"`
+	Issuer        *string    `json:"issuer,omitempty"`
+	Category      string     `json:"category"`
+	IssueDate     *time.Time `json:"issueDate,omitempty"`
+	ExpiryDate    *time.Time `json:"expiryDate,omitempty"`
+	Hours         float64    `json:"hours"`
+}
@@ -0,0 +1,18 @@
+package response
+
+import "time"
+
+type ScheduledJobResponse struct {
+	Name           string     `json:"name"`
+	Description    string     `json:"description"`
+	Schedule       string     `json:"schedule"`
+	Enabled        bool       `json:"enabled"`
+	Running        bool       `json:"running"`
+	NextRunAt      time.Time  `json:"nextRunAt"`
+	LastStartedAt  *time.Time `json:"lastStartedAt,omitempty"`
+	LastFinishedAt *time.Time `json:"lastFinishedAt,omitempty"`
+	LastSuccessAt  *time.Time `json:"lastSuccessAt,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+	RunCount       int        `json:"runCount"`
+	FailureCount   int        `json:"failureCount"`
+}
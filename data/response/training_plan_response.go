@@ -2,6 +2,16 @@ package response
 
 import "time"
 
+// SeatSummary reports how a training plan's capacity is used. Remaining is
+// nil when the plan has no capacity limit (numberOfPerson = 0).
+type SeatSummary struct {
+	Registered int64  `json:"registered"`
+	Attended   int64  `json:"attended"`
+	Waitlisted int64  `json:"waitlisted"`
+	Cancelled  int64  `json:"cancelled"`
+	Remaining  *int64 `json:"remaining"`
+}
+
 type TrainingPlanResponse struct {
 	ID   int    `json:"id"`
 	Name string `json:"name"`
@@ -22,6 +32,13 @@ type TrainingPlanResponse struct {
 	NumberOfPerson int     `json:"numberOfPerson"`
 	CostPerPerson  *int    `json:"costPerPerson,omitempty"`
 
+	Status             string  `json:"status"`
+	CancellationReason *string `json:"cancellationReason,omitempty"`
+	SeriesID           *uint   `json:"seriesId,omitempty"`
+	ClonedFromID       *int    `json:"clonedFromId,omitempty"`
+
+	Seats SeatSummary `json:"seats"`
+
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
@@ -0,0 +1,20 @@
+package response
+
+import "time"
+
+type TrainingPlanSeriesResponse struct {
+	ID               uint       `json:"id"`
+	Name             string     `json:"name"`
+	TemplatePlanID   int        `json:"templatePlanId"`
+	RRule            string     `json:"rrule"`
+	StartDate        time.Time  `json:"startDate"`
+	HorizonMonths    int        `json:"horizonMonths"`
+	AutoPublish      bool       `json:"autoPublish"`
+	Active           bool       `json:"active"`
+	GeneratedThrough *time.Time `json:"generatedThrough"`
+
+	Instances []TrainingPlanResponse `json:"instances,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,27 @@
+package response
+
+import "time"
+
+type TrainingSessionResponse struct {
+	ID             uint      `json:"id"`
+	TrainingPlanID uint      `json:"trainingPlanId"`
+	Date           time.Time `json:"date"`
+	StartTime      string    `json:"startTime"`
+	EndTime        string    `json:"endTime"`
+	Hours          float64   `json:"hours"`
+	Location       *string   `json:"location,omitempty"`
+	Trainer        *string   `json:"trainer,omitempty"`
+}
+
+// SessionAttendanceResponse is one row of a session's attendance sheet.
+// Status is nil while the record has not been marked for the session.
+type SessionAttendanceResponse struct {
+	RecordID      uint    `json:"recordId"`
+	UserID        uint    `json:"userId"`
+	EmployeeID    string  `json:"employeeId"`
+	EmployeeName  string  `json:"employeeName"`
+	Department    string  `json:"department"`
+	RecordStatus  string  `json:"recordStatus"`
+	CreditedHours float64 `json:"creditedHours"`
+	Status        *string `json:"status"`
+}
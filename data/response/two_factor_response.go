@@ -0,0 +1,12 @@
+package response
+
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+type TwoFactorStatusResponse struct {
+	Enabled                bool  `json:"enabled"`
+	Required               bool  `json:"required"`
+	RemainingRecoveryCodes int64 `json:"remainingRecoveryCodes"`
+}
@@ -1,6 +1,9 @@
 package response
 
-import "training-plan-api/model"
+import (
+	"time"
+	"training-plan-api/model"
+)
 
 type UserResponse struct {
 	ID           uint                `json:"id"`
@@ -17,6 +20,8 @@ type UserResponse struct {
 	Avatar       string              `json:"avatar,omitempty"`
 	Provider     string              `json:"provider,omitempty"`
 	CreatedBy    model.CreatedByType `json:"createdBy"`
+	FailedLoginAttempts int16        `json:"failedLoginAttempts"`
+	LockedUntil  *time.Time          `json:"lockedUntil,omitempty"`
 	CreatedAt    int64               `json:"createdAt"`
 	UpdatedAt    int64               `json:"updatedAt"`
 }
@@ -47,6 +52,7 @@ type UserTableResponse struct {
 	JobRole        string `json:"jobRole"`
 	Status         string `json:"status"`
 	IsManager      bool   `json:"isManager"`
+	IsLocked       bool   `json:"isLocked"`
 }
 
 func ToUserTableResponse(user model.User) UserTableResponse {
@@ -75,6 +81,7 @@ func ToUserTableResponse(user model.User) UserTableResponse {
 		JobRole:        user.Position,
 		Status:         status,
 		IsManager:      user.Role == model.RoleDepartmentManager,
+		IsLocked:       user.IsLocked(),
 	}
 }
 
@@ -100,6 +107,8 @@ func ToUserResponse(user model.User) UserResponse {
 		Avatar:       user.Avatar,
 		Provider:     user.Provider,
 		CreatedBy:    user.CreatedBy,
+		FailedLoginAttempts: user.Error,
+		LockedUntil:  user.LockedUntil,
 		CreatedAt:    user.CreatedAt,
 		UpdatedAt:    user.UpdatedAt,
 	}
@@ -0,0 +1,78 @@
+package response
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type WebhookSubscriptionResponse struct {
+	ID     uint     `json:"id"`
+	Name   string   `json:"name"`
+	URL    string   `json:"url"`
+	Active bool     `json:"active"`
+	Events []string `json:"events"`
+	// Secret is only returned when it was generated or changed
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             uint            `json:"id"`
+	SubscriptionID uint            `json:"subscriptionId"`
+	Event          string          `json:"event"`
+	EventID        string          `json:"eventId"`
+	ReplayOfID     *uint           `json:"replayOfId,omitempty"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"nextAttemptAt"`
+	ResponseStatus *int            `json:"responseStatus,omitempty"`
+	ResponseBody   string          `json:"responseBody,omitempty"`
+	LastError      string          `json:"lastError,omitempty"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+// WebhookPayload is the body of every webhook request.
+type WebhookPayload struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+type WebhookRecordData struct {
+	RecordID         uint    `json:"recordId"`
+	UserID           uint    `json:"userId"`
+	EmployeeID       string  `json:"employeeId"`
+	Email            string  `json:"email"`
+	TrainingPlanID   uint    `json:"trainingPlanId"`
+	TrainingPlanName string  `json:"trainingPlanName"`
+	Status           string  `json:"status"`
+	CreditedHours    float64 `json:"creditedHours"`
+}
+
+type WebhookCertificateData struct {
+	CertificateID    uint   `json:"certificateId"`
+	UserID           uint   `json:"userId"`
+	EmployeeID       string `json:"employeeId"`
+	Kind             string `json:"kind"`
+	TrainingPlanID   *uint  `json:"trainingPlanId,omitempty"`
+	TrainingPlanName string `json:"trainingPlanName,omitempty"`
+	// Course details of external certificates
+	Title  *string  `json:"title,omitempty"`
+	Issuer *string  `json:"issuer,omitempty"`
+	Hours  *float64 `json:"hours,omitempty"`
+	Status string   `json:"status"`
+}
+
+type WebhookTrainingPlanData struct {
+	TrainingPlanID     int       `json:"trainingPlanId"`
+	Name               string    `json:"name"`
+	Date               time.Time `json:"date"`
+	NumberOfDays       int       `json:"numberOfDays"`
+	Location           *string   `json:"location,omitempty"`
+	Status             string    `json:"status"`
+	CancellationReason *string   `json:"cancellationReason,omitempty"`
+}
@@ -43,6 +43,8 @@ func FormatValidationError(err error) map[string]string {
 			out[field] = "must be at least " + e.Param() + " characters"
 		case "gte":
 			out[field] = "must be greater than or equal to " + e.Param()
+		case "eqfield":
+			out[field] = "must match " + strings.ToLower(e.Param())
 		default:
 			out[field] = "is invalid"
 		}
@@ -80,6 +82,13 @@ func Unauthorized(msg string) error {
 	}
 }
 
+func Locked(msg string) error {
+	return &AppError{
+		StatusCode: http.StatusLocked,
+		Message:    msg,
+	}
+}
+
 func Forbidden(msg string) error {
 	return &AppError{
 		StatusCode: http.StatusForbidden,
@@ -0,0 +1,32 @@
+package helper
+
+const DefaultMinAttendancePercent = 80
+
+// AttendancePolicy decides the outcome of a multi-session training from the
+// hours attended. A record counts as attended once MinPercent of the total
+// session hours were attended, and as absent once that is out of reach.
+type AttendancePolicy struct {
+	MinPercent int
+}
+
+func NewAttendancePolicy(minPercent int) AttendancePolicy {
+	if minPercent <= 0 || minPercent > 100 {
+		minPercent = DefaultMinAttendancePercent
+	}
+
+	return AttendancePolicy{MinPercent: minPercent}
+}
+
+// Meets reports whether attendedHours out of totalHours is enough.
+func (p AttendancePolicy) Meets(attendedHours, totalHours float64) bool {
+	if totalHours <= 0 {
+		return false
+	}
+	return attendedHours*100 >= float64(p.MinPercent)*totalHours
+}
+
+// Unreachable reports whether the minimum can no longer be met even if
+// every session still unmarked (pendingHours) is attended.
+func (p AttendancePolicy) Unreachable(attendedHours, pendingHours, totalHours float64) bool {
+	return totalHours > 0 && !p.Meets(attendedHours+pendingHours, totalHours)
+}
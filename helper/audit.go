@@ -0,0 +1,73 @@
+package helper
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// auditIgnoredFields are bookkeeping columns that change on every write and
+// would only add noise to a diff.
+// Keys are compared case-insensitively since not every model has json tags.
+var auditIgnoredFields = map[string]bool{
+	"createdat": true,
+	"updatedat": true,
+}
+
+// AuditChange is one field's value before and after a mutation.
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditSnapshot captures v as a field map using its JSON representation, so
+// fields tagged json:"-" (password hashes, secrets) never reach the audit
+// log. Take the snapshot before mutating an entity in place.
+func AuditSnapshot(v interface{}) map[string]interface{} {
+	if v == nil {
+		return nil
+	}
+	if snapshot, ok := v.(map[string]interface{}); ok {
+		return snapshot
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+
+	var snapshot map[string]interface{}
+	if err := json.Unmarshal(raw, &snapshot); err != nil {
+		return nil
+	}
+	return snapshot
+}
+
+// AuditDiff returns the changed fields between two snapshots as a JSON
+// object. A nil before or after records a create or delete respectively.
+func AuditDiff(before, after map[string]interface{}) string {
+	changes := make(map[string]AuditChange)
+
+	for key, value := range after {
+		if auditIgnoredFields[strings.ToLower(key)] {
+			continue
+		}
+		if old, ok := before[key]; !ok || !reflect.DeepEqual(old, value) {
+			changes[key] = AuditChange{Before: before[key], After: value}
+		}
+	}
+	for key, old := range before {
+		if auditIgnoredFields[strings.ToLower(key)] {
+			continue
+		}
+		if _, ok := after[key]; !ok {
+			changes[key] = AuditChange{Before: old, After: nil}
+		}
+	}
+
+	raw, err := json.Marshal(changes)
+	if err != nil {
+		return "{}"
+	}
+	return string(raw)
+}
@@ -0,0 +1,260 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CalDAVCalendar stores events as iCalendar resources in a CalDAV
+// collection (Nextcloud, Radicale, SOGo, ...). The event ID is the
+// resource UID.
+type CalDAVCalendar struct {
+	collectionURL string
+	username      string
+	password      string
+	client        *http.Client
+}
+
+func NewCalDAVCalendar(collectionURL, username, password string) (*CalDAVCalendar, error) {
+	collectionURL = strings.TrimSpace(collectionURL)
+	if collectionURL == "" {
+		return nil, errors.New("CALDAV_URL is not set")
+	}
+
+	return &CalDAVCalendar{
+		collectionURL: strings.TrimSuffix(collectionURL, "/") + "/",
+		username:      username,
+		password:      password,
+		client:        &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+func (c *CalDAVCalendar) Name() string { return CalendarProviderCalDAV }
+
+func (c *CalDAVCalendar) CreateEvent(ctx context.Context, event CalendarEvent) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	uid := hex.EncodeToString(buf)
+
+	// If-None-Match keeps a UID collision from overwriting another event
+	if err := c.put(ctx, uid, event, nil, "*"); err != nil {
+		return "", err
+	}
+	return uid, nil
+}
+
+func (c *CalDAVCalendar) UpdateEvent(ctx context.Context, eventID string, event CalendarEvent) error {
+	// keep the responses attendees already gave
+	responses, err := c.Responses(ctx, eventID)
+	if err != nil {
+		return err
+	}
+	return c.put(ctx, eventID, event, responses, "")
+}
+
+// Responses reads the PARTSTAT of every ATTENDEE of the stored event. An
+// event that no longer exists has no responses.
+func (c *CalDAVCalendar) Responses(ctx context.Context, eventID string) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.resourceURL(eventID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return map[string]string{}, nil
+	}
+	if err := checkCalDAVResponse(resp); err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	return parseCalDAVResponses(string(body)), nil
+}
+
+func (c *CalDAVCalendar) DeleteEvent(ctx context.Context, eventID string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.resourceURL(eventID), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return checkCalDAVResponse(resp)
+}
+
+func (c *CalDAVCalendar) put(ctx context.Context, uid string, event CalendarEvent, responses map[string]string, ifNoneMatch string) error {
+	body := buildCalDAVEvent(uid, event, responses)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.resourceURL(uid), bytes.NewBufferString(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/calendar; charset=utf-8")
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkCalDAVResponse(resp)
+}
+
+func (c *CalDAVCalendar) do(req *http.Request) (*http.Response, error) {
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	return c.client.Do(req)
+}
+
+func (c *CalDAVCalendar) resourceURL(uid string) string {
+	return c.collectionURL + uid + ".ics"
+}
+
+func checkCalDAVResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("caldav: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+}
+
+// calDAVPartStat maps attendee responses to iCalendar PARTSTAT values.
+var calDAVPartStat = map[string]string{
+	CalendarResponseNeedsAction: "NEEDS-ACTION",
+	CalendarResponseAccepted:    "ACCEPTED",
+	CalendarResponseDeclined:    "DECLINED",
+	CalendarResponseTentative:   "TENTATIVE",
+}
+
+func buildCalDAVEvent(uid string, event CalendarEvent, responses map[string]string) string {
+	const stamp = "20060102T150405Z"
+
+	var b strings.Builder
+	b.WriteString("BEGIN:VCALENDAR\r\n")
+	b.WriteString("VERSION:2.0\r\n")
+	b.WriteString("PRODID:-//training-plan-api//EN\r\n")
+	b.WriteString("BEGIN:VEVENT\r\n")
+	b.WriteString("UID:" + uid + "\r\n")
+	b.WriteString("DTSTAMP:" + time.Now().UTC().Format(stamp) + "\r\n")
+	b.WriteString("DTSTART:" + event.Start.UTC().Format(stamp) + "\r\n")
+	b.WriteString("DTEND:" + event.End.UTC().Format(stamp) + "\r\n")
+	b.WriteString("SUMMARY:" + escapeICSText(event.Title) + "\r\n")
+	if event.Description != "" {
+		b.WriteString("DESCRIPTION:" + escapeICSText(event.Description) + "\r\n")
+	}
+	if event.Location != "" {
+		b.WriteString("LOCATION:" + escapeICSText(event.Location) + "\r\n")
+	}
+	if event.URL != "" {
+		b.WriteString("URL:" + event.URL + "\r\n")
+	}
+	for _, attendee := range event.Attendees {
+		partStat := calDAVPartStat[CalendarResponseNeedsAction]
+		if response, ok := responses[strings.ToLower(attendee.Email)]; ok {
+			partStat = calDAVPartStat[response]
+		}
+
+		b.WriteString("ATTENDEE")
+		if attendee.Name != "" {
+			b.WriteString(`;CN="` + strings.ReplaceAll(attendee.Name, `"`, "'") + `"`)
+		}
+		b.WriteString(";ROLE=REQ-PARTICIPANT;PARTSTAT=" + partStat + ";RSVP=TRUE:mailto:" + attendee.Email + "\r\n")
+	}
+	b.WriteString("END:VEVENT\r\n")
+	b.WriteString("END:VCALENDAR\r\n")
+	return b.String()
+}
+
+// escapeICSText escapes a TEXT value as required by RFC 5545 section 3.3.11.
+func escapeICSText(value string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(value)
+}
+
+// parseCalDAVResponses extracts attendee responses from an iCalendar
+// resource, keyed by lower-case email.
+func parseCalDAVResponses(ics string) map[string]string {
+	// unfold continuation lines (RFC 5545 section 3.1)
+	ics = strings.NewReplacer("\r\n ", "", "\r\n\t", "", "\n ", "", "\n\t", "").Replace(ics)
+
+	responses := make(map[string]string)
+	for _, line := range strings.Split(ics, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if !strings.HasPrefix(strings.ToUpper(line), "ATTENDEE") {
+			continue
+		}
+
+		// the value starts at the first colon outside a quoted parameter
+		quoted := false
+		split := -1
+		for i, r := range line {
+			if r == '"' {
+				quoted = !quoted
+			} else if r == ':' && !quoted {
+				split = i
+				break
+			}
+		}
+		if split < 0 {
+			continue
+		}
+
+		email := strings.ToLower(strings.TrimSpace(line[split+1:]))
+		email = strings.TrimPrefix(email, "mailto:")
+		if email == "" {
+			continue
+		}
+
+		response := CalendarResponseNeedsAction
+		for _, param := range strings.Split(line[:split], ";") {
+			name, value, ok := strings.Cut(param, "=")
+			if !ok || !strings.EqualFold(name, "PARTSTAT") {
+				continue
+			}
+			for r, partStat := range calDAVPartStat {
+				if strings.EqualFold(value, partStat) {
+					response = r
+				}
+			}
+		}
+		responses[email] = response
+	}
+	return responses
+}
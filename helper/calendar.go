@@ -0,0 +1,122 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	CalendarProviderGoogle = "google"
+	CalendarProviderCalDAV = "caldav"
+	CalendarProviderMemory = "memory"
+	CalendarProviderNone   = "none"
+)
+
+// Attendee responses as reported by Responses.
+const (
+	CalendarResponseNeedsAction = "NeedsAction"
+	CalendarResponseAccepted    = "Accepted"
+	CalendarResponseDeclined    = "Declined"
+	CalendarResponseTentative   = "Tentative"
+)
+
+// CalendarEvent is a training plan as it appears on a shared calendar.
+type CalendarEvent struct {
+	Title       string
+	Description string
+	Location    string
+	URL         string
+	Start       time.Time
+	End         time.Time
+	Attendees   []CalendarAttendee
+}
+
+// CalendarAttendee is a person invited to an event. Providers keep the
+// response an attendee already gave when the event is updated.
+type CalendarAttendee struct {
+	Email string
+	Name  string
+}
+
+// CalendarProvider publishes training plans to a calendar. Event IDs are
+// opaque to callers and stored on the plan; a provider that does not keep
+// events returns an empty ID.
+type CalendarProvider interface {
+	Name() string
+	CreateEvent(ctx context.Context, event CalendarEvent) (string, error)
+	UpdateEvent(ctx context.Context, eventID string, event CalendarEvent) error
+	// DeleteEvent succeeds for an event that is already gone, so a delete
+	// that is retried, or removed by hand, does not hold up the sync.
+	DeleteEvent(ctx context.Context, eventID string) error
+	// Responses returns the attendees' responses keyed by lower-case
+	// email, or nil if the provider cannot read them back.
+	Responses(ctx context.Context, eventID string) (map[string]string, error)
+}
+
+// CalendarConfig selects and configures the calendar provider. An empty
+// Provider means Google when a service account file is configured and no
+// calendar otherwise.
+type CalendarConfig struct {
+	Provider string
+	Timezone string
+
+	GoogleServiceAccountFile string
+	GoogleCalendarID         string
+	// GoogleCalendarSubject is the Workspace user the service account acts
+	// as. Service accounts can only invite attendees with domain-wide
+	// delegation.
+	GoogleCalendarSubject string
+
+	CalDAVURL      string
+	CalDAVUsername string
+	CalDAVPassword string
+}
+
+func NewCalendarProvider(ctx context.Context, config CalendarConfig) (CalendarProvider, error) {
+	provider := strings.ToLower(strings.TrimSpace(config.Provider))
+	if provider == "" {
+		provider = CalendarProviderNone
+		if config.GoogleServiceAccountFile != "" {
+			provider = CalendarProviderGoogle
+		}
+	}
+
+	log.Println("Calendar provider:", provider)
+
+	switch provider {
+	case CalendarProviderGoogle:
+		return NewGoogleCalendar(ctx, config.GoogleServiceAccountFile, config.GoogleCalendarID, config.GoogleCalendarSubject, config.Timezone)
+	case CalendarProviderCalDAV:
+		return NewCalDAVCalendar(config.CalDAVURL, config.CalDAVUsername, config.CalDAVPassword)
+	case CalendarProviderMemory:
+		return NewMemoryCalendar(), nil
+	case CalendarProviderNone:
+		return NoopCalendar{}, nil
+	}
+
+	return nil, fmt.Errorf("unknown CALENDAR_PROVIDER %q", config.Provider)
+}
+
+// NoopCalendar drops every event. It is used when no calendar is configured.
+type NoopCalendar struct{}
+
+func (NoopCalendar) Name() string { return CalendarProviderNone }
+
+func (NoopCalendar) CreateEvent(ctx context.Context, event CalendarEvent) (string, error) {
+	return "", nil
+}
+
+func (NoopCalendar) UpdateEvent(ctx context.Context, eventID string, event CalendarEvent) error {
+	return nil
+}
+
+func (NoopCalendar) DeleteEvent(ctx context.Context, eventID string) error {
+	return nil
+}
+
+func (NoopCalendar) Responses(ctx context.Context, eventID string) (map[string]string, error) {
+	return nil, nil
+}
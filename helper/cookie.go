@@ -0,0 +1,48 @@
+package helper
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const RefreshTokenCookie = "refresh_token"
+
+func SetRefreshTokenCookie(c *fiber.Ctx, refreshToken string) {
+	c.Cookie(&fiber.Cookie{
+		Name:     RefreshTokenCookie,
+		Value:    refreshToken,
+		Path:     "/api/v1/auth",
+		Expires:  time.Now().Add(RefreshTokenExpiry),
+		HTTPOnly: true,
+		Secure:   c.Protocol() == "https",
+		SameSite: "Lax",
+	})
+}
+
+// GetRefreshTokenFromRequest reads the refresh token from the cookie, falling
+// back to a "refreshToken" field in the JSON body for non-browser clients.
+func GetRefreshTokenFromRequest(c *fiber.Ctx) string {
+	if token := c.Cookies(RefreshTokenCookie); token != "" {
+		return token
+	}
+
+	var body struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	if err := c.BodyParser(&body); err == nil {
+		return body.RefreshToken
+	}
+	return ""
+}
+
+func ClearAuthCookies(c *fiber.Ctx) {
+	c.Cookie(&fiber.Cookie{
+		Name:     RefreshTokenCookie,
+		Value:    "",
+		Path:     "/api/v1/auth",
+		Expires:  time.Unix(0, 0),
+		HTTPOnly: true,
+		SameSite: "Lax",
+	})
+}
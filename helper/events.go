@@ -0,0 +1,88 @@
+package helper
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+// Event is a message pushed to connected clients. The audience fields say
+// who may receive it and are never sent to the client.
+type Event struct {
+	ID   uint64          `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+
+	// UserIDs always receive the event.
+	UserIDs []uint `json:"userIds,omitempty"`
+	// Permissions lets holders of any of them receive the event.
+	Permissions []string `json:"permissions,omitempty"`
+	// DepartmentPermissions lets holders of any of them receive the event
+	// when they belong to DepartmentID.
+	DepartmentPermissions []string `json:"departmentPermissions,omitempty"`
+	DepartmentID          int      `json:"departmentId,omitempty"`
+}
+
+// EventBroker fans events out to subscribers. MemoryBroker serves a single
+// instance; running several needs a broker every instance subscribes to.
+type EventBroker interface {
+	Publish(event Event)
+	// Subscribe returns a channel receiving every published event and a
+	// function that ends the subscription and closes the channel.
+	Subscribe() (<-chan Event, func())
+}
+
+// eventBuffer is how many events a subscriber may fall behind before
+// events are dropped for it.
+const eventBuffer = 64
+
+// MemoryBroker is an in-process EventBroker.
+type MemoryBroker struct {
+	mu          sync.RWMutex
+	subscribers map[uint64]chan Event
+	nextSub     uint64
+	nextEvent   atomic.Uint64
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{subscribers: make(map[uint64]chan Event)}
+}
+
+// Publish implements EventBroker. Subscribers that are too far behind miss
+// the event rather than slowing the publisher down.
+func (b *MemoryBroker) Publish(event Event) {
+	event.ID = b.nextEvent.Add(1)
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for id, ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			log.Printf("events: subscriber %d is behind, dropped %s", id, event.Type)
+		}
+	}
+}
+
+// Subscribe implements EventBroker.
+func (b *MemoryBroker) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, eventBuffer)
+
+	b.mu.Lock()
+	b.nextSub++
+	id := b.nextSub
+	b.subscribers[id] = ch
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, id)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
@@ -2,7 +2,10 @@ package helper
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -10,19 +13,26 @@ import (
 	"github.com/spf13/viper"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
 )
 
-// GOOGLE CALENDAR CLIENT
-func NewGoogleCalendarService(ctx context.Context) *calendar.Service {
-	credPath := viper.GetString("GOOGLE_SERVICE_ACCOUNT_FILE")
+// GoogleCalendar publishes events to a Google calendar through a service
+// account.
+type GoogleCalendar struct {
+	srv        *calendar.Service
+	calendarID string
+	timezone   string
+}
 
+// GOOGLE CALENDAR CLIENT
+func NewGoogleCalendar(ctx context.Context, credPath, calendarID, subject, timezone string) (*GoogleCalendar, error) {
 	if credPath == "" {
-		log.Fatal("GOOGLE_SERVICE_ACCOUNT_FILE is not set")
+		return nil, fmt.Errorf("GOOGLE_SERVICE_ACCOUNT_FILE is not set")
 	}
 
 	credData, err := os.ReadFile(credPath)
 	if err != nil {
-		log.Fatal("Unable to read service account file:", err)
+		return nil, fmt.Errorf("unable to read service account file: %w", err)
 	}
 
 	config, err := google.JWTConfigFromJSON(
@@ -30,17 +40,32 @@ func NewGoogleCalendarService(ctx context.Context) *calendar.Service {
 		calendar.CalendarEventsScope,
 	)
 	if err != nil {
-		log.Fatal("Unable to parse service account JSON:", err)
+		return nil, fmt.Errorf("unable to parse service account JSON: %w", err)
 	}
+	config.Subject = strings.TrimSpace(subject)
 
 	client := config.Client(ctx)
 
 	srv, err := calendar.New(client)
 	if err != nil {
-		log.Fatal("Unable to create calendar service:", err)
+		return nil, fmt.Errorf("unable to create calendar service: %w", err)
 	}
 
-	return srv
+	calendarID = strings.TrimSpace(calendarID)
+	if strings.HasSuffix(calendarID, "$") {
+		calendarID = strings.TrimSuffix(calendarID, "$")
+		log.Println("Warning: GOOGLE_CALENDAR_ID had trailing '$', trimmed automatically")
+	}
+	if calendarID == "" {
+		calendarID = "primary"
+	}
+
+	timezone = strings.TrimSpace(timezone)
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	return &GoogleCalendar{srv: srv, calendarID: calendarID, timezone: timezone}, nil
 }
 
 // TIMEZONE
@@ -59,56 +84,13 @@ func LoadLocation() *time.Location {
 	return loc
 }
 
-func getCalendarID() string {
-	calendarID := strings.TrimSpace(viper.GetString("GOOGLE_CALENDAR_ID"))
-
-	if strings.HasSuffix(calendarID, "$") {
-		calendarID = strings.TrimSuffix(calendarID, "$")
-		log.Println("Warning: GOOGLE_CALENDAR_ID had trailing '$', trimmed automatically")
-	}
-
-	if calendarID == "" {
-		return "primary"
-	}
-
-	return calendarID
-}
+func (g *GoogleCalendar) Name() string { return CalendarProviderGoogle }
 
 // CREATE CALENDAR EVENT
-func CreateTrainingPlanCalendarEvent(
-	ctx context.Context,
-	srv *calendar.Service,
-	title string,
-	description string,
-	start time.Time,
-	durationHours int,
-) (string, error) {
-
-	calendarID := getCalendarID()
-	timezone := strings.TrimSpace(viper.GetString("TIMEZONE"))
-	if timezone == "" {
-		timezone = "UTC"
-	}
-
-	end := start.Add(time.Duration(durationHours) * time.Hour)
-
-	event := &calendar.Event{
-		Summary:     title,
-		Description: description,
-		Start: &calendar.EventDateTime{
-			DateTime: start.Format(time.RFC3339),
-			TimeZone: timezone,
-		},
-		End: &calendar.EventDateTime{
-			DateTime: end.Format(time.RFC3339),
-			TimeZone: timezone,
-		},
-	}
-	log.Println("Calendar ID:", calendarID)
-	log.Println("Event:", event)
-
-	createdEvent, err := srv.Events.
-		Insert(calendarID, event).
+func (g *GoogleCalendar) CreateEvent(ctx context.Context, event CalendarEvent) (string, error) {
+	createdEvent, err := g.srv.Events.
+		Insert(g.calendarID, g.toGoogleEvent(event)).
+		SendUpdates("all").
 		Context(ctx).
 		Do()
 
@@ -121,39 +103,25 @@ func CreateTrainingPlanCalendarEvent(
 }
 
 // UPDATE CALENDAR EVENT
-func UpdateTrainingPlanCalendarEvent(
-	ctx context.Context,
-	srv *calendar.Service,
-	eventID string,
-	title string,
-	description string,
-	start time.Time,
-	durationHours int,
-) error {
-
-	calendarID := getCalendarID()
-	timezone := strings.TrimSpace(viper.GetString("TIMEZONE"))
-	if timezone == "" {
-		timezone = "UTC"
+func (g *GoogleCalendar) UpdateEvent(ctx context.Context, eventID string, event CalendarEvent) error {
+	existing, err := g.srv.Events.Get(g.calendarID, eventID).Context(ctx).Do()
+	if err != nil {
+		return err
 	}
 
-	end := start.Add(time.Duration(durationHours) * time.Hour)
-
-	event := &calendar.Event{
-		Summary:     title,
-		Description: description,
-		Start: &calendar.EventDateTime{
-			DateTime: start.Format(time.RFC3339),
-			TimeZone: timezone,
-		},
-		End: &calendar.EventDateTime{
-			DateTime: end.Format(time.RFC3339),
-			TimeZone: timezone,
-		},
+	// keep the responses attendees already gave
+	updated := g.toGoogleEvent(event)
+	for _, attendee := range updated.Attendees {
+		for _, old := range existing.Attendees {
+			if strings.EqualFold(old.Email, attendee.Email) {
+				attendee.ResponseStatus = old.ResponseStatus
+			}
+		}
 	}
 
-	_, err := srv.Events.
-		Update(calendarID, eventID, event).
+	_, err = g.srv.Events.
+		Update(g.calendarID, eventID, updated).
+		SendUpdates("all").
 		Context(ctx).
 		Do()
 
@@ -161,16 +129,70 @@ func UpdateTrainingPlanCalendarEvent(
 }
 
 // DELETE CALENDAR EVENT
-func DeleteTrainingPlanCalendarEvent(
-	ctx context.Context,
-	srv *calendar.Service,
-	eventID string,
-) error {
-
-	calendarID := getCalendarID()
-
-	return srv.Events.
-		Delete(calendarID, eventID).
+func (g *GoogleCalendar) DeleteEvent(ctx context.Context, eventID string) error {
+	err := g.srv.Events.
+		Delete(g.calendarID, eventID).
 		Context(ctx).
 		Do()
+
+	// already deleted, in Google or by an earlier attempt
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && (apiErr.Code == http.StatusNotFound || apiErr.Code == http.StatusGone) {
+		return nil
+	}
+	return err
+}
+
+// RESPONSES
+func (g *GoogleCalendar) Responses(ctx context.Context, eventID string) (map[string]string, error) {
+	event, err := g.srv.Events.Get(g.calendarID, eventID).Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make(map[string]string, len(event.Attendees))
+	for _, attendee := range event.Attendees {
+		switch attendee.ResponseStatus {
+		case "accepted":
+			responses[strings.ToLower(attendee.Email)] = CalendarResponseAccepted
+		case "declined":
+			responses[strings.ToLower(attendee.Email)] = CalendarResponseDeclined
+		case "tentative":
+			responses[strings.ToLower(attendee.Email)] = CalendarResponseTentative
+		default:
+			responses[strings.ToLower(attendee.Email)] = CalendarResponseNeedsAction
+		}
+	}
+	return responses, nil
+}
+
+func (g *GoogleCalendar) toGoogleEvent(event CalendarEvent) *calendar.Event {
+	attendees := make([]*calendar.EventAttendee, 0, len(event.Attendees))
+	for _, attendee := range event.Attendees {
+		attendees = append(attendees, &calendar.EventAttendee{
+			Email:       attendee.Email,
+			DisplayName: attendee.Name,
+		})
+	}
+
+	var source *calendar.EventSource
+	if event.URL != "" {
+		source = &calendar.EventSource{Title: event.Title, Url: event.URL}
+	}
+
+	return &calendar.Event{
+		Summary:     event.Title,
+		Description: event.Description,
+		Location:    event.Location,
+		Source:      source,
+		Attendees:   attendees,
+		Start: &calendar.EventDateTime{
+			DateTime: event.Start.Format(time.RFC3339),
+			TimeZone: g.timezone,
+		},
+		End: &calendar.EventDateTime{
+			DateTime: event.End.Format(time.RFC3339),
+			TimeZone: g.timezone,
+		},
+	}
 }
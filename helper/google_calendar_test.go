@@ -0,0 +1,83 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+)
+
+func TestGoogleCalendarDeleteEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{"deleted", http.StatusNoContent, false},
+		{"already deleted", http.StatusGone, false},
+		{"unknown event", http.StatusNotFound, false},
+		{"not allowed", http.StatusForbidden, true},
+		{"server error", http.StatusInternalServerError, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.Method + " " + r.URL.Path
+				if tt.status == http.StatusNoContent {
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				fmt.Fprintf(w, `{"error":{"code":%d,"message":%q}}`, tt.status, http.StatusText(tt.status))
+			}))
+			defer server.Close()
+
+			srv, err := calendar.NewService(context.Background(),
+				option.WithHTTPClient(server.Client()),
+				option.WithEndpoint(server.URL+"/"),
+			)
+			if err != nil {
+				t.Fatalf("calendar service: %v", err)
+			}
+			g := &GoogleCalendar{srv: srv, calendarID: "training@example.com"}
+
+			err = g.DeleteEvent(context.Background(), "evt-1")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if !strings.HasPrefix(path, "DELETE ") || !strings.HasSuffix(path, "/events/evt-1") {
+				t.Errorf("request = %s, want DELETE of evt-1", path)
+			}
+		})
+	}
+}
+
+func TestMemoryCalendarDeleteEventIsIdempotent(t *testing.T) {
+	c := NewMemoryCalendar()
+	ctx := context.Background()
+
+	id, err := c.CreateEvent(ctx, CalendarEvent{Title: "Fire safety"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		if err := c.DeleteEvent(ctx, id); err != nil {
+			t.Errorf("delete attempt %d: %v", attempt, err)
+		}
+	}
+	if err := c.DeleteEvent(ctx, "mem-unknown"); err != nil {
+		t.Errorf("delete unknown event: %v", err)
+	}
+	if err := c.UpdateEvent(ctx, id, CalendarEvent{Title: "Fire safety"}); err == nil {
+		t.Error("deleted event can still be updated")
+	}
+}
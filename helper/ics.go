@@ -0,0 +1,100 @@
+package helper
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ICSStatusConfirmed = "CONFIRMED"
+	ICSStatusTentative = "TENTATIVE"
+	ICSStatusCancelled = "CANCELLED"
+)
+
+// ICSEvent is one VEVENT of a published calendar. UID must stay the same
+// for the life of the event and Sequence must grow with every change, so
+// that subscribed clients update the event in place instead of adding a
+// copy.
+type ICSEvent struct {
+	UID          string
+	Sequence     int
+	Status       string
+	Title        string
+	Description  string
+	Location     string
+	URL          string
+	Start        time.Time
+	End          time.Time
+	LastModified time.Time
+}
+
+// BuildICSCalendar renders events as an iCalendar (RFC 5545) document.
+func BuildICSCalendar(name string, events []ICSEvent) string {
+	const stamp = "20060102T150405Z"
+
+	var b strings.Builder
+	line := func(content string) {
+		b.WriteString(foldICSLine(content))
+		b.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//training-plan-api//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	if name != "" {
+		line("X-WR-CALNAME:" + escapeICSText(name))
+	}
+
+	for _, event := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + event.UID)
+		line("SEQUENCE:" + strconv.Itoa(event.Sequence))
+		line("DTSTAMP:" + event.LastModified.UTC().Format(stamp))
+		line("LAST-MODIFIED:" + event.LastModified.UTC().Format(stamp))
+		line("DTSTART:" + event.Start.UTC().Format(stamp))
+		line("DTEND:" + event.End.UTC().Format(stamp))
+		line("SUMMARY:" + escapeICSText(event.Title))
+		if event.Status != "" {
+			line("STATUS:" + event.Status)
+		}
+		if event.Description != "" {
+			line("DESCRIPTION:" + escapeICSText(event.Description))
+		}
+		if event.Location != "" {
+			line("LOCATION:" + escapeICSText(event.Location))
+		}
+		if event.URL != "" {
+			line("URL:" + event.URL)
+		}
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+	return b.String()
+}
+
+// foldICSLine splits a content line into lines of at most 75 octets, as
+// required by RFC 5545 section 3.1, without breaking UTF-8 sequences.
+func foldICSLine(content string) string {
+	const limit = 75
+	if len(content) <= limit {
+		return content
+	}
+
+	var b strings.Builder
+	width := 0
+	for _, r := range content {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			// the leading space counts towards the next line
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
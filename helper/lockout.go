@@ -0,0 +1,52 @@
+package helper
+
+import "time"
+
+const (
+	DefaultLoginMaxAttempts    = 5
+	DefaultLoginLockoutMinutes = 1
+	MaxLoginLockoutDuration    = 24 * time.Hour
+)
+
+// LockoutPolicy decides how long an account is locked after consecutive
+// failed logins. Every MaxAttempts failures lock the account, and each
+// further lock doubles the previous duration up to MaxDuration.
+type LockoutPolicy struct {
+	MaxAttempts  int
+	BaseDuration time.Duration
+	MaxDuration  time.Duration
+}
+
+func NewLockoutPolicy(maxAttempts int, lockoutMinutes int) LockoutPolicy {
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultLoginMaxAttempts
+	}
+	if lockoutMinutes <= 0 {
+		lockoutMinutes = DefaultLoginLockoutMinutes
+	}
+
+	return LockoutPolicy{
+		MaxAttempts:  maxAttempts,
+		BaseDuration: time.Duration(lockoutMinutes) * time.Minute,
+		MaxDuration:  MaxLoginLockoutDuration,
+	}
+}
+
+// LockDuration returns the lock to apply after failedAttempts consecutive
+// failures, or zero when the account should stay unlocked.
+func (p LockoutPolicy) LockDuration(failedAttempts int) time.Duration {
+	if failedAttempts < p.MaxAttempts || failedAttempts%p.MaxAttempts != 0 {
+		return 0
+	}
+
+	lockCount := failedAttempts / p.MaxAttempts
+	duration := p.BaseDuration
+	for i := 1; i < lockCount; i++ {
+		duration *= 2
+		if duration >= p.MaxDuration {
+			return p.MaxDuration
+		}
+	}
+
+	return duration
+}
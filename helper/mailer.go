@@ -0,0 +1,111 @@
+package helper
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+type MailMessage struct {
+	To       []string
+	Subject  string
+	TextBody string
+	HTMLBody string
+}
+
+// Mailer sends outgoing email. SMTPMailer is used when SMTP_HOST is set;
+// otherwise LogMailer only logs that a message would have been sent.
+type Mailer interface {
+	Send(msg MailMessage) error
+}
+
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func NewMailer(host, port, username, password, from string) Mailer {
+	if strings.TrimSpace(host) == "" {
+		log.Println("SMTP_HOST is not set, emails will only be logged")
+		return &LogMailer{}
+	}
+	if port == "" {
+		port = "25"
+	}
+	if from == "" {
+		from = "no-reply@localhost"
+	}
+
+	return &SMTPMailer{
+		Host:     host,
+		Port:     port,
+		Username: username,
+		Password: password,
+		From:     from,
+	}
+}
+
+func (m *SMTPMailer) Send(msg MailMessage) error {
+	if len(msg.To) == 0 {
+		return nil
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	return smtp.SendMail(m.Host+":"+m.Port, auth, m.From, msg.To, m.buildMessage(msg))
+}
+
+func (m *SMTPMailer) buildMessage(msg MailMessage) []byte {
+	var b strings.Builder
+
+	b.WriteString("From: " + m.From + "\r\n")
+	b.WriteString("To: " + strings.Join(msg.To, ", ") + "\r\n")
+	b.WriteString("Subject: " + mimeEncodeHeader(msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+
+	if msg.HTMLBody == "" {
+		b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+		b.WriteString(msg.TextBody)
+		return []byte(b.String())
+	}
+
+	boundary := fmt.Sprintf("boundary-%d", time.Now().UnixNano())
+	b.WriteString("Content-Type: multipart/alternative; boundary=" + boundary + "\r\n\r\n")
+	b.WriteString("--" + boundary + "\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(msg.TextBody + "\r\n")
+	b.WriteString("--" + boundary + "\r\n")
+	b.WriteString("Content-Type: text/html; charset=UTF-8\r\n\r\n")
+	b.WriteString(msg.HTMLBody + "\r\n")
+	b.WriteString("--" + boundary + "--\r\n")
+
+	return []byte(b.String())
+}
+
+// mimeEncodeHeader keeps non-ASCII subjects (e.g. Thai) readable.
+func mimeEncodeHeader(value string) string {
+	for _, r := range value {
+		if r > 127 {
+			return "=?UTF-8?B?" + base64.StdEncoding.EncodeToString([]byte(value)) + "?="
+		}
+	}
+	return value
+}
+
+type LogMailer struct{}
+
+func (m *LogMailer) Send(msg MailMessage) error {
+	// do not log the body, it may contain tokens
+	log.Println("mail (not sent, SMTP disabled) to:", strings.Join(msg.To, ", "), "subject:", msg.Subject)
+	return nil
+}
@@ -0,0 +1,106 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// MemoryCalendar keeps events in process memory, for local development and
+// tests that need to inspect what would have been published.
+type MemoryCalendar struct {
+	mu        sync.RWMutex
+	nextID    int
+	events    map[string]CalendarEvent
+	responses map[string]map[string]string
+}
+
+func NewMemoryCalendar() *MemoryCalendar {
+	return &MemoryCalendar{
+		events:    make(map[string]CalendarEvent),
+		responses: make(map[string]map[string]string),
+	}
+}
+
+func (c *MemoryCalendar) Name() string { return CalendarProviderMemory }
+
+func (c *MemoryCalendar) CreateEvent(ctx context.Context, event CalendarEvent) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nextID++
+	id := fmt.Sprintf("mem-%d", c.nextID)
+	c.events[id] = event
+	return id, nil
+}
+
+func (c *MemoryCalendar) UpdateEvent(ctx context.Context, eventID string, event CalendarEvent) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.events[eventID]; !ok {
+		return fmt.Errorf("calendar event %s not found", eventID)
+	}
+	c.events[eventID] = event
+	return nil
+}
+
+// DeleteEvent succeeds for unknown events, like the Google provider does
+// for events that are already gone.
+func (c *MemoryCalendar) DeleteEvent(ctx context.Context, eventID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.events, eventID)
+	delete(c.responses, eventID)
+	return nil
+}
+
+func (c *MemoryCalendar) Responses(ctx context.Context, eventID string) (map[string]string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	event, ok := c.events[eventID]
+	if !ok {
+		return nil, fmt.Errorf("calendar event %s not found", eventID)
+	}
+
+	responses := make(map[string]string, len(event.Attendees))
+	for _, attendee := range event.Attendees {
+		email := strings.ToLower(attendee.Email)
+		responses[email] = CalendarResponseNeedsAction
+		if response, ok := c.responses[eventID][email]; ok {
+			responses[email] = response
+		}
+	}
+	return responses, nil
+}
+
+// Respond records an attendee's response, standing in for the attendee
+// answering the invitation.
+func (c *MemoryCalendar) Respond(eventID, email, response string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.events[eventID]; !ok {
+		return fmt.Errorf("calendar event %s not found", eventID)
+	}
+	if c.responses[eventID] == nil {
+		c.responses[eventID] = make(map[string]string)
+	}
+	c.responses[eventID][strings.ToLower(email)] = response
+	return nil
+}
+
+// Events returns a copy of the stored events keyed by ID.
+func (c *MemoryCalendar) Events() map[string]CalendarEvent {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	events := make(map[string]CalendarEvent, len(c.events))
+	for id, event := range c.events {
+		events[id] = event
+	}
+	return events
+}
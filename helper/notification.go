@@ -0,0 +1,345 @@
+package helper
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	LanguageThai    = "th"
+	LanguageEnglish = "en"
+)
+
+// NotificationData fills the notification templates. Fields that do not
+// apply to an event are left empty.
+type NotificationData struct {
+	RecipientName string
+	TrainingName  string
+	TrainingDate  time.Time
+	Location      string
+	// Status is the registration outcome (Register, Waitlisted or Promoted)
+	// or, for record_updated, the record's new status.
+	Status string
+	Reason string
+	Link   string
+	// Count is how many records or certificates a reminder is about
+	Count int
+	// Days is how long the certificates in a reminder have been waiting or,
+	// for certificate_expiring, how many days are left
+	Days int
+	// EmployeeName is the holder of the certificate a manager is told about
+	EmployeeName string
+	ExpiryDate   time.Time
+}
+
+type notificationTemplate struct {
+	Subject string
+	Body    string
+}
+
+// notificationTemplates holds the subject and body of every event per
+// language. Events are keyed by their model.NotificationEvent value.
+var notificationTemplates = map[string]map[string]notificationTemplate{
+	"registration": {
+		LanguageEnglish: {
+			Subject: `{{if eq .Status "Waitlisted"}}Waitlisted{{else}}Registered{{end}}: {{.TrainingName}}`,
+			Body: `Dear {{.RecipientName}},
+
+{{if eq .Status "Waitlisted"}}The training "{{.TrainingName}}" is full. You have been placed on the waitlist and will be notified when a seat becomes available.
+{{else if eq .Status "Promoted"}}A seat has become available and you are now registered for "{{.TrainingName}}".
+{{else}}You have been registered for "{{.TrainingName}}".
+{{end}}
+Date: {{date .TrainingDate}}
+{{if .Location}}Location: {{.Location}}
+{{end}}
+{{.Link}}`,
+		},
+		LanguageThai: {
+			Subject: `{{if eq .Status "Waitlisted"}}อยู่ในรายชื่อสำรอง{{else}}ลงทะเบียนแล้ว{{end}}: {{.TrainingName}}`,
+			Body: `เรียน คุณ{{.RecipientName}}
+
+{{if eq .Status "Waitlisted"}}หลักสูตร "{{.TrainingName}}" มีผู้ลงทะเบียนเต็มแล้ว ท่านอยู่ในรายชื่อสำรอง และจะได้รับแจ้งเมื่อมีที่ว่าง
+{{else if eq .Status "Promoted"}}มีที่ว่างแล้ว ท่านได้รับการลงทะเบียนในหลักสูตร "{{.TrainingName}}"
+{{else}}ท่านได้รับการลงทะเบียนในหลักสูตร "{{.TrainingName}}" แล้ว
+{{end}}
+วันที่: {{date .TrainingDate}}
+{{if .Location}}สถานที่: {{.Location}}
+{{end}}
+{{.Link}}`,
+		},
+	},
+	"record_updated": {
+		LanguageEnglish: {
+			Subject: `Training record updated: {{.TrainingName}}`,
+			Body: `Dear {{.RecipientName}},
+
+Your record for "{{.TrainingName}}" on {{date .TrainingDate}} is now "{{.Status}}".
+
+{{.Link}}`,
+		},
+		LanguageThai: {
+			Subject: `ปรับปรุงประวัติการอบรม: {{.TrainingName}}`,
+			Body: `เรียน คุณ{{.RecipientName}}
+
+สถานะการอบรมหลักสูตร "{{.TrainingName}}" วันที่ {{date .TrainingDate}} ของท่านเปลี่ยนเป็น "{{.Status}}"
+
+{{.Link}}`,
+		},
+	},
+	"plan_changed": {
+		LanguageEnglish: {
+			Subject: `Training updated: {{.TrainingName}}`,
+			Body: `Dear {{.RecipientName}},
+
+The details of "{{.TrainingName}}" have changed. Please check the current schedule.
+
+Date: {{date .TrainingDate}}
+{{if .Location}}Location: {{.Location}}
+{{end}}
+{{.Link}}`,
+		},
+		LanguageThai: {
+			Subject: `มีการเปลี่ยนแปลงหลักสูตร: {{.TrainingName}}`,
+			Body: `เรียน คุณ{{.RecipientName}}
+
+รายละเอียดของหลักสูตร "{{.TrainingName}}" มีการเปลี่ยนแปลง กรุณาตรวจสอบกำหนดการล่าสุด
+
+วันที่: {{date .TrainingDate}}
+{{if .Location}}สถานที่: {{.Location}}
+{{end}}
+{{.Link}}`,
+		},
+	},
+	"plan_cancelled": {
+		LanguageEnglish: {
+			Subject: `Training cancelled: {{.TrainingName}}`,
+			Body: `Dear {{.RecipientName}},
+
+The training "{{.TrainingName}}" on {{date .TrainingDate}} has been cancelled.
+{{if .Reason}}
+Reason: {{.Reason}}
+{{end}}
+{{.Link}}`,
+		},
+		LanguageThai: {
+			Subject: `ยกเลิกหลักสูตร: {{.TrainingName}}`,
+			Body: `เรียน คุณ{{.RecipientName}}
+
+หลักสูตร "{{.TrainingName}}" วันที่ {{date .TrainingDate}} ถูกยกเลิก
+{{if .Reason}}
+เหตุผล: {{.Reason}}
+{{end}}
+{{.Link}}`,
+		},
+	},
+	"certificate_approved": {
+		LanguageEnglish: {
+			Subject: `Certificate approved: {{.TrainingName}}`,
+			Body: `Dear {{.RecipientName}},
+
+Your certificate for "{{.TrainingName}}" has been approved.
+
+{{.Link}}`,
+		},
+		LanguageThai: {
+			Subject: `อนุมัติใบประกาศนียบัตร: {{.TrainingName}}`,
+			Body: `เรียน คุณ{{.RecipientName}}
+
+ใบประกาศนียบัตรหลักสูตร "{{.TrainingName}}" ของท่านได้รับการอนุมัติแล้ว
+
+{{.Link}}`,
+		},
+	},
+	"certificate_rejected": {
+		LanguageEnglish: {
+			Subject: `Certificate rejected: {{.TrainingName}}`,
+			Body: `Dear {{.RecipientName}},
+
+Your certificate for "{{.TrainingName}}" has been rejected.
+{{if .Reason}}
+Reason: {{.Reason}}
+{{end}}
+You can resubmit a corrected certificate from your certificate history.
+
+{{.Link}}`,
+		},
+		LanguageThai: {
+			Subject: `ไม่อนุมัติใบประกาศนียบัตร: {{.TrainingName}}`,
+			Body: `เรียน คุณ{{.RecipientName}}
+
+ใบประกาศนียบัตรหลักสูตร "{{.TrainingName}}" ของท่านไม่ได้รับการอนุมัติ
+{{if .Reason}}
+เหตุผล: {{.Reason}}
+{{end}}
+ท่านสามารถส่งใบประกาศนียบัตรฉบับแก้ไขใหม่ได้จากประวัติใบประกาศนียบัตร
+
+{{.Link}}`,
+		},
+	},
+	"training_reminder": {
+		LanguageEnglish: {
+			Subject: `Reminder: {{.TrainingName}} on {{date .TrainingDate}}`,
+			Body: `Dear {{.RecipientName}},
+
+This is a reminder that you are registered for "{{.TrainingName}}".
+
+Date: {{date .TrainingDate}}
+{{if .Location}}Location: {{.Location}}
+{{end}}
+{{.Link}}`,
+		},
+		LanguageThai: {
+			Subject: `แจ้งเตือน: {{.TrainingName}} วันที่ {{date .TrainingDate}}`,
+			Body: `เรียน คุณ{{.RecipientName}}
+
+ขอแจ้งเตือนว่าท่านได้ลงทะเบียนหลักสูตร "{{.TrainingName}}"
+
+วันที่: {{date .TrainingDate}}
+{{if .Location}}สถานที่: {{.Location}}
+{{end}}
+{{.Link}}`,
+		},
+	},
+	"attendance_reminder": {
+		LanguageEnglish: {
+			Subject: `Attendance needed: {{.TrainingName}}`,
+			Body: `Dear {{.RecipientName}},
+
+The training "{{.TrainingName}}" on {{date .TrainingDate}} has ended. {{.Count}} participant record(s) in your department still need attendance or test scores.
+
+{{.Link}}`,
+		},
+		LanguageThai: {
+			Subject: `กรุณาบันทึกผลการอบรม: {{.TrainingName}}`,
+			Body: `เรียน คุณ{{.RecipientName}}
+
+หลักสูตร "{{.TrainingName}}" วันที่ {{date .TrainingDate}} สิ้นสุดแล้ว ยังมีผู้เข้าอบรมในหน่วยงานของท่าน {{.Count}} รายการที่ยังไม่ได้บันทึกการเข้าร่วมหรือคะแนนสอบ
+
+{{.Link}}`,
+		},
+	},
+	"certificates_pending": {
+		LanguageEnglish: {
+			Subject: `{{.Count}} certificate(s) awaiting review`,
+			Body: `Dear {{.RecipientName}},
+
+{{.Count}} certificate(s) have been waiting for review for more than {{.Days}} day(s).
+
+{{.Link}}`,
+		},
+		LanguageThai: {
+			Subject: `มีใบประกาศนียบัตรรอตรวจสอบ {{.Count}} รายการ`,
+			Body: `เรียน คุณ{{.RecipientName}}
+
+มีใบประกาศนียบัตร {{.Count}} รายการที่รอการตรวจสอบเกิน {{.Days}} วัน
+
+{{.Link}}`,
+		},
+	},
+	"certificate_expiring": {
+		LanguageEnglish: {
+			Subject: `Certificate expiring: {{.TrainingName}}`,
+			Body: `Dear {{.RecipientName}},
+
+Your certificate for "{{.TrainingName}}" expires on {{date .ExpiryDate}}, in {{.Days}} day(s).
+Please arrange a renewal training in time.
+
+{{.Link}}`,
+		},
+		LanguageThai: {
+			Subject: `ใบประกาศนียบัตรใกล้หมดอายุ: {{.TrainingName}}`,
+			Body: `เรียน คุณ{{.RecipientName}}
+
+ใบประกาศนียบัตรหลักสูตร "{{.TrainingName}}" ของท่านจะหมดอายุในวันที่ {{date .ExpiryDate}} (อีก {{.Days}} วัน)
+กรุณาวางแผนเข้ารับการอบรมเพื่อต่ออายุ
+
+{{.Link}}`,
+		},
+	},
+	"certificate_expiring_staff": {
+		LanguageEnglish: {
+			Subject: `Certificate of {{.EmployeeName}} expiring: {{.TrainingName}}`,
+			Body: `Dear {{.RecipientName}},
+
+The certificate of {{.EmployeeName}} for "{{.TrainingName}}" expires on {{date .ExpiryDate}}, in {{.Days}} day(s).
+
+{{.Link}}`,
+		},
+		LanguageThai: {
+			Subject: `ใบประกาศนียบัตรของ {{.EmployeeName}} ใกล้หมดอายุ: {{.TrainingName}}`,
+			Body: `เรียน คุณ{{.RecipientName}}
+
+ใบประกาศนียบัตรหลักสูตร "{{.TrainingName}}" ของคุณ{{.EmployeeName}} จะหมดอายุในวันที่ {{date .ExpiryDate}} (อีก {{.Days}} วัน)
+
+{{.Link}}`,
+		},
+	},
+}
+
+// NormalizeLanguage returns language if templates exist for it and Thai
+// otherwise.
+func NormalizeLanguage(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language == LanguageEnglish {
+		return LanguageEnglish
+	}
+	return LanguageThai
+}
+
+// RenderNotification renders the subject and body of event in language.
+func RenderNotification(event, language string, data NotificationData) (string, string, error) {
+	templates, ok := notificationTemplates[event]
+	if !ok {
+		return "", "", fmt.Errorf("no notification template for %q", event)
+	}
+
+	language = NormalizeLanguage(language)
+	tmpl := templates[language]
+
+	funcs := template.FuncMap{
+		"date": func(t time.Time) string { return FormatDate(t, language) },
+	}
+
+	render := func(name, text string) (string, error) {
+		t, err := template.New(name).Funcs(funcs).Parse(text)
+		if err != nil {
+			return "", err
+		}
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+
+	subject, err := render(event+".subject", tmpl.Subject)
+	if err != nil {
+		return "", "", err
+	}
+	body, err := render(event+".body", tmpl.Body)
+	if err != nil {
+		return "", "", err
+	}
+
+	return strings.TrimSpace(subject), strings.TrimSpace(body) + "\n", nil
+}
+
+var thaiMonths = [...]string{
+	"มกราคม", "กุมภาพันธ์", "มีนาคม", "เมษายน", "พฤษภาคม", "มิถุนายน",
+	"กรกฎาคม", "สิงหาคม", "กันยายน", "ตุลาคม", "พฤศจิกายน", "ธันวาคม",
+}
+
+// FormatDate formats a date for people: "2 January 2006" in English and
+// the Thai month with the Buddhist-era year in Thai.
+func FormatDate(t time.Time, language string) string {
+	if t.IsZero() {
+		return "-"
+	}
+	if NormalizeLanguage(language) == LanguageThai {
+		return fmt.Sprintf("%d %s %d", t.Day(), thaiMonths[t.Month()-1], t.Year()+543)
+	}
+	return t.Format("2 January 2006")
+}
@@ -0,0 +1,43 @@
+package helper
+
+import "time"
+
+// RetryPolicy spaces out retries of background deliveries. The delay
+// doubles after every failed attempt, starting at BaseDelay and capped at
+// MaxDelay; after MaxAttempts the delivery is given up.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+func NewRetryPolicy(maxAttempts int, baseDelay, maxDelay time.Duration) RetryPolicy {
+	if maxAttempts <= 0 {
+		maxAttempts = 8
+	}
+	if baseDelay <= 0 {
+		baseDelay = 30 * time.Second
+	}
+	if maxDelay < baseDelay {
+		maxDelay = baseDelay
+	}
+
+	return RetryPolicy{MaxAttempts: maxAttempts, BaseDelay: baseDelay, MaxDelay: maxDelay}
+}
+
+// Exhausted reports whether no attempt is left after attempts failures.
+func (p RetryPolicy) Exhausted(attempts int) bool {
+	return attempts >= p.MaxAttempts
+}
+
+// Delay returns the wait before the next attempt after attempts failures.
+func (p RetryPolicy) Delay(attempts int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	return delay
+}
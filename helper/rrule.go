@@ -0,0 +1,144 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	RRuleWeekly  = "WEEKLY"
+	RRuleMonthly = "MONTHLY"
+	RRuleYearly  = "YEARLY"
+
+	// maxRRuleOccurrences bounds expansion of rules without COUNT or UNTIL.
+	maxRRuleOccurrences = 1000
+)
+
+// RRule is the subset of an RFC 5545 recurrence rule used for recurring
+// training plans: FREQ (WEEKLY, MONTHLY or YEARLY), INTERVAL, COUNT and
+// UNTIL, e.g. "FREQ=MONTHLY;INTERVAL=3" for a quarterly course.
+type RRule struct {
+	Freq     string
+	Interval int
+	Count    int
+	Until    *time.Time
+}
+
+// ParseRRule parses a rule such as "FREQ=YEARLY;COUNT=5". An optional
+// "RRULE:" prefix is accepted. UNTIL takes a YYYYMMDD date.
+func ParseRRule(value string) (RRule, error) {
+	rule := RRule{Interval: 1}
+
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return rule, errors.New("rrule is empty")
+	}
+
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return rule, fmt.Errorf("invalid rrule part %q", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(val)
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return rule, fmt.Errorf("invalid INTERVAL %q", val)
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return rule, fmt.Errorf("invalid COUNT %q", val)
+			}
+			rule.Count = n
+		case "UNTIL":
+			// a date-time UNTIL (20251231T235959Z) is cut to its date
+			date := val
+			if len(date) > 8 {
+				date = date[:8]
+			}
+			until, err := time.Parse("20060102", date)
+			if err != nil {
+				return rule, fmt.Errorf("invalid UNTIL %q", val)
+			}
+			rule.Until = &until
+		default:
+			return rule, fmt.Errorf("unsupported rrule part %q", key)
+		}
+	}
+
+	switch rule.Freq {
+	case RRuleWeekly, RRuleMonthly, RRuleYearly:
+	case "":
+		return rule, errors.New("rrule needs a FREQ")
+	default:
+		return rule, fmt.Errorf("unsupported FREQ %q", rule.Freq)
+	}
+
+	if rule.Count > 0 && rule.Until != nil {
+		return rule, errors.New("COUNT and UNTIL cannot be combined")
+	}
+
+	return rule, nil
+}
+
+func (r RRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Occurrences returns the dates of the rule starting at start (the first
+// occurrence) up to and including through. Like RFC 5545, monthly and
+// yearly dates that do not exist (e.g. the 31st of a 30-day month) are
+// skipped rather than moved.
+func (r RRule) Occurrences(start, through time.Time) []time.Time {
+	if r.Until != nil && r.Until.Before(through) {
+		through = *r.Until
+	}
+
+	var dates []time.Time
+	for n := 0; n < maxRRuleOccurrences; n++ {
+		if r.Count > 0 && len(dates) >= r.Count {
+			break
+		}
+
+		var next time.Time
+		switch r.Freq {
+		case RRuleWeekly:
+			next = start.AddDate(0, 0, 7*r.Interval*n)
+		case RRuleMonthly:
+			next = start.AddDate(0, r.Interval*n, 0)
+		case RRuleYearly:
+			next = start.AddDate(r.Interval*n, 0, 0)
+		default:
+			return nil
+		}
+
+		if next.After(through) {
+			break
+		}
+		if next.Day() != start.Day() && r.Freq != RRuleWeekly {
+			continue
+		}
+
+		dates = append(dates, next)
+	}
+
+	return dates
+}
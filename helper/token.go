@@ -1,6 +1,10 @@
 package helper
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"os"
 	"time"
 
@@ -8,7 +12,17 @@ import (
 )
 
 const (
-	JWTTokenExpiry  = 7 * 24 * time.Hour
+	AccessTokenExpiry        = 15 * time.Minute
+	RefreshTokenExpiry       = 7 * 24 * time.Hour
+	PasswordResetTokenExpiry = 30 * time.Minute
+	ChallengeTokenExpiry     = 5 * time.Minute
+)
+
+// Challenge token purposes issued between the password step and the
+// second factor of a login.
+const (
+	ChallengeVerifyTwoFactor = "2fa_verify"
+	ChallengeSetupTwoFactor  = "2fa_setup"
 )
 
 
@@ -16,7 +30,7 @@ func GenerateAccessToken(userID uint, role string) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": userID,
 		"role":    role,
-		"exp":     time.Now().Add(JWTTokenExpiry).Unix(),
+		"exp":     time.Now().Add(AccessTokenExpiry).Unix(),
 		"iat":     time.Now().Unix(),
 		"type":    "access",
 	}
@@ -27,6 +41,41 @@ func GenerateAccessToken(userID uint, role string) (string, error) {
 
 
 func VerifyAccessToken(tokenString string) (*jwt.MapClaims, error) {
+	return verifyTypedToken(tokenString, "access")
+}
+
+// GenerateChallengeToken issues a short-lived token proving the password
+// step of a login succeeded. It cannot be used as an access token. id
+// identifies the challenge so its use can be tracked.
+func GenerateChallengeToken(userID uint, role string, purpose string, id string) (string, error) {
+	claims := jwt.MapClaims{
+		"jti":     id,
+		"user_id": userID,
+		"role":    role,
+		"purpose": purpose,
+		"exp":     time.Now().Add(ChallengeTokenExpiry).Unix(),
+		"iat":     time.Now().Unix(),
+		"type":    "challenge",
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+}
+
+func VerifyChallengeToken(tokenString string, purpose string) (*jwt.MapClaims, error) {
+	claims, err := verifyTypedToken(tokenString, "challenge")
+	if err != nil {
+		return nil, err
+	}
+
+	if tokenPurpose, ok := (*claims)["purpose"].(string); !ok || tokenPurpose != purpose {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+
+	return claims, nil
+}
+
+func verifyTypedToken(tokenString string, expectedType string) (*jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, jwt.ErrSignatureInvalid
@@ -38,7 +87,7 @@ func VerifyAccessToken(tokenString string) (*jwt.MapClaims, error) {
 	}
 
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		if tokenType, ok := claims["type"].(string); !ok || tokenType != "access" {
+		if tokenType, ok := claims["type"].(string); !ok || tokenType != expectedType {
 			return nil, jwt.ErrTokenInvalidClaims
 		}
 		return &claims, nil
@@ -54,6 +103,14 @@ func ExtractUserID(claims *jwt.MapClaims) uint {
 	return 0
 }
 
+// ExtractChallengeID returns the id of a challenge token.
+func ExtractChallengeID(claims *jwt.MapClaims) string {
+	if id, ok := (*claims)["jti"].(string); ok {
+		return id
+	}
+	return ""
+}
+
 func ExtractUserRole(claims *jwt.MapClaims) string {
 	if role, ok := (*claims)["role"].(string); ok {
 		return role
@@ -61,6 +118,26 @@ func ExtractUserRole(claims *jwt.MapClaims) string {
 	return ""
 }
 
+// GenerateRefreshToken returns an opaque random token. Only its hash is stored.
+func GenerateRefreshToken() (string, error) {
+	return GenerateRandomToken(32)
+}
+
+// GenerateRandomToken returns n random bytes encoded as URL-safe base64.
+func GenerateRandomToken(n int) (string, error) {
+	bytes := make([]byte, n)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// HashToken returns the hex SHA-256 of an opaque token for lookup in the DB.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 
 
 
@@ -0,0 +1,108 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Webhook request headers. The signature covers the timestamp and the body
+// as "<timestamp>.<body>" so a captured request cannot be replayed later
+// with a new timestamp.
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// webhookResponseLimit bounds how much of a receiver's response is kept in
+// the delivery log.
+const webhookResponseLimit = 2048
+
+// SignWebhook returns the signature header value of body sent at timestamp.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook reports whether signature matches body sent at timestamp.
+// Receivers written in Go can use it as is.
+func VerifyWebhook(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignWebhook(secret, timestamp, body)), []byte(signature))
+}
+
+// WebhookRequest is one signed POST to a subscriber.
+type WebhookRequest struct {
+	URL        string
+	Secret     string
+	Event      string
+	DeliveryID uint
+	Body       []byte
+}
+
+// WebhookResult is what the receiver answered. StatusCode is 0 when no
+// response was received.
+type WebhookResult struct {
+	StatusCode int
+	Body       string
+}
+
+// WebhookSender posts signed webhook requests.
+type WebhookSender struct {
+	client *http.Client
+}
+
+func NewWebhookSender(timeout time.Duration) *WebhookSender {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &WebhookSender{client: &http.Client{
+		Timeout: timeout,
+		// a redirect would resend the payload somewhere not configured
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+// Send posts req. Any status outside 2xx is returned as an error together
+// with the result.
+func (s *WebhookSender) Send(ctx context.Context, req WebhookRequest) (WebhookResult, error) {
+	timestamp := time.Now().Unix()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return WebhookResult{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "training-plan-api-webhooks")
+	httpReq.Header.Set(WebhookEventHeader, req.Event)
+	httpReq.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(uint64(req.DeliveryID), 10))
+	httpReq.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	httpReq.Header.Set(WebhookSignatureHeader, SignWebhook(req.Secret, timestamp, req.Body))
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return WebhookResult{}, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	result := WebhookResult{StatusCode: resp.StatusCode, Body: string(body)}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return result, fmt.Errorf("receiver answered %s", resp.Status)
+	}
+	return result, nil
+}
@@ -0,0 +1,123 @@
+package helper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWebhookSenderSignsRequest(t *testing.T) {
+	const secret = "subscription-secret"
+	body := []byte(`{"id":"evt","event":"record.attended"}`)
+
+	var received *http.Request
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	result, err := NewWebhookSender(time.Second).Send(context.Background(), WebhookRequest{
+		URL:        server.URL,
+		Secret:     secret,
+		Event:      "record.attended",
+		DeliveryID: 42,
+		Body:       body,
+	})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if result.StatusCode != http.StatusNoContent {
+		t.Errorf("status = %d, want %d", result.StatusCode, http.StatusNoContent)
+	}
+
+	if got := received.Header.Get(WebhookEventHeader); got != "record.attended" {
+		t.Errorf("event header = %q", got)
+	}
+	if got := received.Header.Get(WebhookDeliveryHeader); got != "42" {
+		t.Errorf("delivery header = %q", got)
+	}
+	if string(receivedBody) != string(body) {
+		t.Errorf("body = %s, want %s", receivedBody, body)
+	}
+
+	timestamp, err := strconv.ParseInt(received.Header.Get(WebhookTimestampHeader), 10, 64)
+	if err != nil {
+		t.Fatalf("timestamp header: %v", err)
+	}
+	signature := received.Header.Get(WebhookSignatureHeader)
+
+	if !VerifyWebhook(secret, timestamp, receivedBody, signature) {
+		t.Error("signature does not verify")
+	}
+	if VerifyWebhook("other-secret", timestamp, receivedBody, signature) {
+		t.Error("signature verifies with the wrong secret")
+	}
+	if VerifyWebhook(secret, timestamp, []byte(`{"id":"evt","event":"tampered"}`), signature) {
+		t.Error("signature verifies a tampered body")
+	}
+	// a captured request cannot be replayed under a fresh timestamp
+	if VerifyWebhook(secret, timestamp+600, receivedBody, signature) {
+		t.Error("signature verifies with another timestamp")
+	}
+}
+
+func TestWebhookSenderRejectsNon2xx(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{"server error", http.StatusServiceUnavailable},
+		{"client error", http.StatusGone},
+		{"redirect is not followed", http.StatusFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.status == http.StatusFound {
+					w.Header().Set("Location", "http://example.invalid/elsewhere")
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte("receiver says no"))
+			}))
+			defer server.Close()
+
+			result, err := NewWebhookSender(time.Second).Send(context.Background(), WebhookRequest{
+				URL:    server.URL,
+				Secret: "secret",
+				Event:  "record.attended",
+				Body:   []byte(`{}`),
+			})
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if result.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", result.StatusCode, tt.status)
+			}
+			if result.Body != "receiver says no" {
+				t.Errorf("body = %q", result.Body)
+			}
+		})
+	}
+}
+
+func TestWebhookSenderUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	result, err := NewWebhookSender(time.Second).Send(context.Background(), WebhookRequest{URL: url, Body: []byte(`{}`)})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if result.StatusCode != 0 {
+		t.Errorf("status = %d, want 0", result.StatusCode)
+	}
+}
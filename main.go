@@ -17,6 +17,7 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/helmet"
 	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 )
 
 func main() {
@@ -33,6 +34,8 @@ func main() {
 	//  DB and migration
 	db := config.ConnectionDB(&appConfig)
 	seed.SeedAdmin(db)/// for development purpose only
+	seed.SeedPermissions(db)
+	seed.SeedCertificateValidities(db)
 
 
 	app.Use(cors.New(cors.Config{
@@ -44,8 +47,10 @@ func main() {
     CrossOriginResourcePolicy: "cross-origin",
 }))
 
+	app.Use(requestid.New())
+
 	app.Use(logger.New(logger.Config{
-		Format: "[${ip}]:${port} ${status} - ${method} ${path}\n",
+		Format: "[${ip}]:${port} ${status} - ${locals:requestid} ${method} ${path}\n",
 	}))
 
 	app.Use(limiter.New(limiter.Config{
@@ -56,21 +61,66 @@ func main() {
 	//  Validator
 	validate := validator.New()
 
-	calendarService := helper.NewGoogleCalendarService(context.Background())
+	calendarProvider, err := helper.NewCalendarProvider(context.Background(), helper.CalendarConfig{
+		Provider:                 appConfig.CalendarProvider,
+		Timezone:                 appConfig.Timezone,
+		GoogleServiceAccountFile: appConfig.GoogleServiceAccountFile,
+		GoogleCalendarID:         appConfig.GoogleCalendarID,
+		GoogleCalendarSubject:    appConfig.GoogleCalendarSubject,
+		CalDAVURL:                appConfig.CalDAVURL,
+		CalDAVUsername:           appConfig.CalDAVUsername,
+		CalDAVPassword:           appConfig.CalDAVPassword,
+	})
+	if err != nil {
+		log.Fatal(" Cannot set up calendar provider:", err)
+	}
 	location := helper.LoadLocation()
 
 	// Initialize storage
 	storage := helper.NewLocalStorage(appConfig.UploadPath)
 
+	mailer := helper.NewMailer(
+		appConfig.SMTPHost,
+		appConfig.SMTPPort,
+		appConfig.SMTPUsername,
+		appConfig.SMTPPassword,
+		appConfig.SMTPFrom,
+	)
+
 	deps := container.NewAppDependencies(
 		db,
 		validate,
-		calendarService,
+		calendarProvider,
 		location,
 		storage,
+		mailer,
 		appConfig,
 	)
 
+	// Scheduled jobs (reminders, series generation); each due job runs on
+	// one instance only
+	go deps.SchedulerService.Run(context.Background(), 30*time.Second)
+
+	// Calendar changes are pushed from the outbox in the background
+	syncInterval := time.Duration(appConfig.CalendarSyncIntervalSeconds) * time.Second
+	if syncInterval <= 0 {
+		syncInterval = time.Minute
+	}
+	go deps.CalendarSyncService.Run(context.Background(), syncInterval)
+
+	// Notifications are sent in the background
+	go deps.NotificationService.Run(context.Background(), 30*time.Second)
+
+	// Webhook deliveries are sent and retried in the background
+	go deps.WebhookService.Run(context.Background(), 15*time.Second)
+
+	// Invitation responses are read back into the records
+	rsvpInterval := time.Duration(appConfig.CalendarRSVPIntervalMinutes) * time.Minute
+	if rsvpInterval <= 0 {
+		rsvpInterval = 30 * time.Minute
+	}
+	go deps.CalendarSyncService.RunResponses(context.Background(), rsvpInterval)
+
 	//  Routes
 	router.RegisterRoutes(app, deps)
 
@@ -12,6 +12,7 @@ func ToRecordResponse(record model.Record) response.RecordResponseFinal  {
 		TrainingPlanID: record.TrainingPlanID,
 		TrainingPlan:   ToTrainingPlanResponse(*record.TrainingPlan),
 		Status:         string(record.Status),
+		CreditedHours:  record.CreditedHours,
 		Evaluation:     record.Evaluation,
 		PreTestScore:  record.PreTestScore,
 		PostTestScore: record.PostTestScore,
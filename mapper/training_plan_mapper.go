@@ -22,6 +22,8 @@ func ToTrainingPlanModel(req request.CreateTrainingPlanRequest) model.TrainingPl
 		TotalCost:      req.TotalCost,
 		BudgetCode:     req.BudgetCode,
 		CostPerPerson:  req.CostPerPerson,
+
+		Status: model.TrainingPlanDraft,
 	}
 
 	if req.NumberOfPerson != nil {
@@ -51,6 +53,11 @@ func ToTrainingPlanResponse(trainingPlan model.TrainingPlan) response.TrainingPl
 		NumberOfPerson: trainingPlan.NumberOfPerson,
 		CostPerPerson:  trainingPlan.CostPerPerson,
 
+		Status:             string(trainingPlan.Status),
+		CancellationReason: trainingPlan.CancellationReason,
+		SeriesID:           trainingPlan.SeriesID,
+		ClonedFromID:       trainingPlan.ClonedFromID,
+
 		CreatedAt: trainingPlan.CreatedAt,
 		UpdatedAt: trainingPlan.UpdatedAt,
 	}
@@ -4,7 +4,6 @@ import (
 	"os"
 	"strings"
 
-	"training-plan-api/model"
 	"training-plan-api/helper"
 	"github.com/gofiber/fiber/v2"
 )
@@ -44,50 +43,17 @@ func JWTProtected(c *fiber.Ctx) error {
 	return c.Next()
 }
 
-func AdminOnly(c *fiber.Ctx) error {
-	role := c.Locals("user_role").(string)
-	if role != string(model.RoleHRAdmin) {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"success": false,
-			"message": "Access denied. Admin only.",
-		})
-	}
-	return c.Next()
-}
-
-func ManagerOnly(c *fiber.Ctx) error {
-	role := c.Locals("user_role").(string)
-	if role != string(model.RoleDepartmentManager) {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"success": false,
-			"message": "Access denied. Manager only.",
-		})
-	}
-	return c.Next()
-}
-
-func StaffOnly(c *fiber.Ctx) error {
-	role := c.Locals("user_role").(string)
-	if role != string(model.RoleStaff) {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"success": false,
-			"message": "Access denied. Staff only.",
-		})
-	}
-	return c.Next()
+func GetJWTSecret() string {
+	return os.Getenv("JWT_SECRET")
 }
-
-func AdminOrManager(c *fiber.Ctx) error {
-	role := c.Locals("user_role").(string)
-	if role != string(model.RoleHRAdmin) && role != string(model.RoleDepartmentManager) {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"success": false,
-			"message": "Access denied. Admin or Manager only.",
-		})
+// JWTFromQuery lets clients that cannot set headers, such as the browser
+// EventSource, pass the access token as ?access_token=. It must run before
+// JWTProtected.
+func JWTFromQuery(c *fiber.Ctx) error {
+	if c.Get("Authorization") == "" {
+		if token := c.Query("access_token"); token != "" {
+			c.Request().Header.Set("Authorization", "Bearer "+token)
+		}
 	}
 	return c.Next()
 }
-
-func GetJWTSecret() string {
-	return os.Getenv("JWT_SECRET")
-}
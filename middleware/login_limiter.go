@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"training-plan-api/helper"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+)
+
+// LoginRateLimiter throttles login attempts per client IP and email, on top
+// of the global limiter and the per-account lockout.
+func LoginRateLimiter() fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        5,
+		Expiration: time.Minute,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			var body struct {
+				Email string `json:"email"`
+			}
+			_ = c.BodyParser(&body)
+			return "login:" + c.IP() + ":" + strings.ToLower(strings.TrimSpace(body.Email))
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"success": false,
+				"message": "Too many login attempts. Please try again later.",
+			})
+		},
+	})
+}
+
+// TwoFactorRateLimiter throttles second-factor attempts per challenge
+// subject, so codes of one account cannot be guessed faster by spreading
+// them over several IPs. Requests without a valid challenge fall back to
+// the client IP.
+func TwoFactorRateLimiter() fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        5,
+		Expiration: time.Minute,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			var body struct {
+				ChallengeToken string `json:"challengeToken"`
+			}
+			_ = c.BodyParser(&body)
+			claims, err := helper.VerifyChallengeToken(body.ChallengeToken, helper.ChallengeVerifyTwoFactor)
+			if err != nil {
+				return "2fa:ip:" + c.IP()
+			}
+			return fmt.Sprintf("2fa:user:%d", helper.ExtractUserID(claims))
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"success": false,
+				"message": "Too many verification attempts. Please try again later.",
+			})
+		},
+	})
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"training-plan-api/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequirePasswordChanged blocks accounts flagged with MustChangePassword
+// until they set their own password via PUT /auth/password.
+func RequirePasswordChanged(userRepo repository.UserRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := c.Locals("user_id").(uint)
+
+		user, err := userRepo.FindById(userID)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"message": "User not found",
+			})
+		}
+
+		if user.MustChangePassword {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"message": "password change required",
+			})
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"training-plan-api/model"
+	"training-plan-api/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequirePermission allows the request only when the caller's role holds
+// every listed permission. It must run after JWTProtected.
+func RequirePermission(ps service.PermissionService, permissions ...model.Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("user_role").(string)
+
+		for _, permission := range permissions {
+			if !ps.HasPermission(role, permission) {
+				return permissionDenied(c)
+			}
+		}
+
+		return c.Next()
+	}
+}
+
+// RequireAnyPermission allows the request when the caller's role holds at
+// least one of the listed permissions.
+func RequireAnyPermission(ps service.PermissionService, permissions ...model.Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("user_role").(string)
+
+		if !ps.HasAnyPermission(role, permissions...) {
+			return permissionDenied(c)
+		}
+
+		return c.Next()
+	}
+}
+
+func permissionDenied(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"success": false,
+		"message": "Access denied. Missing permission.",
+	})
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"strings"
+
+	"training-plan-api/helper"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// JWTOrTwoFactorSetup accepts a normal access token, or the challenge token
+// issued at login when the user's role requires two-factor enrollment.
+func JWTOrTwoFactorSetup(c *fiber.Ctx) error {
+	parts := strings.Split(c.Get("Authorization"), " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"message": "Authentication required",
+		})
+	}
+
+	if claims, err := helper.VerifyAccessToken(parts[1]); err == nil {
+		c.Locals("user_id", helper.ExtractUserID(claims))
+		c.Locals("user_role", helper.ExtractUserRole(claims))
+		return c.Next()
+	}
+
+	claims, err := helper.VerifyChallengeToken(parts[1], helper.ChallengeSetupTwoFactor)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid or expired token",
+		})
+	}
+
+	c.Locals("user_id", helper.ExtractUserID(claims))
+	c.Locals("user_role", helper.ExtractUserRole(claims))
+	c.Locals("two_factor_setup", true)
+
+	return c.Next()
+}
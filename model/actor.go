@@ -0,0 +1,14 @@
+package model
+
+// Actor is the authenticated caller of a service method. Services use it to
+// decide whether the caller may touch a resource owned by another user, and
+// to attribute audit events.
+type Actor struct {
+	UserID    uint
+	Role      Role
+	IP        string
+	RequestID string
+}
+
+// SystemActor attributes changes made by background jobs.
+var SystemActor = Actor{Role: "system"}
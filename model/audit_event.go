@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+type AuditAction string
+
+const (
+	AuditCreate         AuditAction = "create"
+	AuditUpdate         AuditAction = "update"
+	AuditDelete         AuditAction = "delete"
+	AuditApprove        AuditAction = "approve"
+	AuditReject         AuditAction = "reject"
+	AuditUnlock         AuditAction = "unlock"
+	AuditResetPassword  AuditAction = "reset_password"
+	AuditResetTwoFactor AuditAction = "reset_2fa"
+	AuditPublish        AuditAction = "publish"
+	AuditCancel         AuditAction = "cancel"
+	AuditComplete       AuditAction = "complete"
+	AuditRun            AuditAction = "run"
+)
+
+const (
+	AuditEntityDepartment   = "department"
+	AuditEntityTrainingPlan = "training_plan"
+	AuditEntityUser         = "user"
+	AuditEntityRecord       = "record"
+	AuditEntityCertificate  = "certificate"
+	AuditEntityRole         = "role"
+	AuditEntityEnrollment   = "enrollment_request"
+	AuditEntitySession      = "training_session"
+	AuditEntitySeries       = "training_plan_series"
+	AuditEntityCalendarFeed = "calendar_feed"
+	AuditEntityScheduledJob = "scheduled_job"
+	AuditEntityWebhook      = "webhook_subscription"
+	AuditEntityCertValidity = "certificate_validity"
+)
+
+// AuditEvent is an append-only trail of mutating actions. Changes holds a
+// JSON object mapping each changed field to its before and after value.
+type AuditEvent struct {
+	ID         uint        `gorm:"primaryKey;autoIncrement" json:"id"`
+	ActorID    *uint       `gorm:"index" json:"actorId,omitempty"`
+	ActorRole  string      `gorm:"type:varchar(52)" json:"actorRole"`
+	Action     AuditAction `gorm:"type:varchar(32);not null;index" json:"action"`
+	EntityType string      `gorm:"type:varchar(52);not null;index:idx_audit_entity" json:"entityType"`
+	EntityID   string      `gorm:"type:varchar(64);not null;index:idx_audit_entity" json:"entityId"`
+	Changes    string      `gorm:"type:json" json:"changes"`
+	IP         string      `gorm:"type:varchar(45)" json:"ip"`
+	RequestID  string      `gorm:"type:varchar(64);index" json:"requestId"`
+	CreatedAt  time.Time   `gorm:"autoCreateTime;index" json:"createdAt"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+type CalendarFeedScope string
+
+const (
+	// CalendarFeedPersonal lists the trainings the user is registered on.
+	CalendarFeedPersonal CalendarFeedScope = "personal"
+	// CalendarFeedDepartment lists the trainings the user's department
+	// is registered on.
+	CalendarFeedDepartment CalendarFeedScope = "department"
+	// CalendarFeedOrganisation lists the whole published catalog.
+	CalendarFeedOrganisation CalendarFeedScope = "organisation"
+)
+
+func (s CalendarFeedScope) IsValid() bool {
+	switch s {
+	case CalendarFeedPersonal, CalendarFeedDepartment, CalendarFeedOrganisation:
+		return true
+	}
+	return false
+}
+
+// CalendarFeedToken addresses a user's ICS feed. Calendar clients cannot
+// send a JWT, so the token in the feed URL is the credential; only its hash
+// is stored. A user has at most one token per scope, and the user's
+// permissions are checked again on every fetch.
+type CalendarFeedToken struct {
+	ID         uint              `gorm:"primaryKey;autoIncrement"`
+	UserID     uint              `gorm:"not null;uniqueIndex:idx_feed_user_scope"`
+	User       *User             `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Scope      CalendarFeedScope `gorm:"type:varchar(16);not null;uniqueIndex:idx_feed_user_scope"`
+	TokenHash  string            `gorm:"type:char(64);uniqueIndex;not null"`
+	LastUsedAt *time.Time
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+type CalendarOperation string
+type CalendarOutboxStatus string
+
+const (
+	// CalendarOpUpsert creates the plan's event, or updates it when the plan
+	// already has one. The worker reads the plan when it runs, so several
+	// queued upserts all publish the latest state.
+	CalendarOpUpsert CalendarOperation = "upsert"
+	// CalendarOpDelete removes EventID; the plan may already be gone.
+	CalendarOpDelete CalendarOperation = "delete"
+)
+
+const (
+	CalendarOutboxPending CalendarOutboxStatus = "Pending"
+	CalendarOutboxDone    CalendarOutboxStatus = "Done"
+	CalendarOutboxFailed  CalendarOutboxStatus = "Failed"
+)
+
+// CalendarOutbox is a calendar change waiting to be sent to the provider.
+// Rows are written in the same transaction as the plan change and processed
+// by the calendar sync worker; Failed rows ran out of attempts.
+type CalendarOutbox struct {
+	ID             uint                 `gorm:"primaryKey;autoIncrement"`
+	TrainingPlanID int                  `gorm:"not null;index"`
+	Operation      CalendarOperation    `gorm:"type:varchar(16);not null"`
+	EventID        *string              `gorm:"type:varchar(128)"`
+	Status         CalendarOutboxStatus `gorm:"type:varchar(16);not null;default:'Pending';index:idx_calendar_outbox_due"`
+	Attempts       int                  `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time            `gorm:"not null;index:idx_calendar_outbox_due"`
+	LastError      string               `gorm:"type:text"`
+	ProcessedAt    *time.Time
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
@@ -25,6 +25,15 @@ import "time"
 
 type CertificateStatus string
 
+// CertificateKind tells certificates of internal training plans from those
+// of courses taken outside the company.
+type CertificateKind string
+
+const (
+	CertKindInternal CertificateKind = "Internal"
+	CertKindExternal CertificateKind = "External"
+)
+
 const (
 	CertPending  CertificateStatus = "Pending"
 	CertApproved CertificateStatus = "Approved"
@@ -37,15 +46,90 @@ type Certificate struct {
 	UserID uint `gorm:"not null;index"`
 	User   *User `gorm:"foreignKey:UserID"`
 
+	Kind CertificateKind `gorm:"type:enum('Internal','External');not null;default:'Internal'"`
+
 	// TrainingName string `gorm:"type:varchar(255);not null"`
-	TrainingID    uint `gorm:"not null;index"`
+	// TrainingID is set for internal certificates only
+	TrainingID    *uint `gorm:"index"`
 	Training     *TrainingPlan `gorm:"foreignKey:TrainingID"`
 
+	// Course details of external certificates
+	Issuer     *string               `gorm:"type:varchar(255)"`
+	Title      *string               `gorm:"type:varchar(255)"`
+	IssueDate  *time.Time            `gorm:"type:date"`
+	ExpiryDate *time.Time            `gorm:"type:date"`
+	Hours      *float64              `gorm:"type:decimal(6,2)"`
+	Category   *TrainingPlanCategory `gorm:"type:varchar(255)"`
+
 	Image        string `gorm:"type:text;not null"`
 	Description  *string `gorm:"type:text"`
 
 	Status CertificateStatus `gorm:"type:enum('Pending','Approved','Rejected');default:'Pending'"`
 
+	// Version counts the files submitted; it grows with every resubmission
+	Version int `gorm:"not null;default:1"`
+
+	// Review of the current version
+	RejectionReason *string    `gorm:"type:text"`
+	ReviewedByID    *uint
+	ReviewedBy      *User      `gorm:"foreignKey:ReviewedByID"`
+	ReviewedAt      *time.Time
+
+	// RecordID is the training record an approved certificate was accepted
+	// as attendance evidence for
+	RecordID *uint
+	Record   *Record `gorm:"foreignKey:RecordID"`
+
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// TrainingName returns the name of the plan of an internal certificate or
+// the course title of an external one.
+func (c *Certificate) TrainingName() string {
+	if c.Kind == CertKindExternal {
+		if c.Title != nil {
+			return *c.Title
+		}
+		return ""
+	}
+	if c.Training != nil {
+		return c.Training.Name
+	}
+	return ""
+}
+
+// CertificateVersion is one file submitted for a certificate together with
+// the review it got, so earlier submissions and rejections stay visible.
+type CertificateVersion struct {
+	ID            uint `gorm:"primaryKey;autoIncrement"`
+	CertificateID uint `gorm:"not null;uniqueIndex:idx_certificate_version"`
+	Version       int  `gorm:"not null;uniqueIndex:idx_certificate_version"`
+
+	Image       string  `gorm:"type:text;not null"`
+	Description *string `gorm:"type:text"`
+
+	Status          CertificateStatus `gorm:"type:varchar(16);not null"`
+	RejectionReason *string           `gorm:"type:text"`
+	ReviewedByID    *uint
+	ReviewedBy      *User `gorm:"foreignKey:ReviewedByID"`
+	ReviewedAt      *time.Time
+
+	// CreatedAt is when the version was submitted
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// NewCertificateVersion returns the version row of certificate's current
+// file and review.
+func NewCertificateVersion(certificate *Certificate) CertificateVersion {
+	return CertificateVersion{
+		CertificateID:   certificate.ID,
+		Version:         certificate.Version,
+		Image:           certificate.Image,
+		Description:     certificate.Description,
+		Status:          certificate.Status,
+		RejectionReason: certificate.RejectionReason,
+		ReviewedByID:    certificate.ReviewedByID,
+		ReviewedAt:      certificate.ReviewedAt,
+	}
 }
\ No newline at end of file
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// CertificateValidity is how long certificates of a category stay valid.
+// It gives approved certificates without an explicit expiry date one.
+// Categories without a row never expire.
+type CertificateValidity struct {
+	Category  TrainingPlanCategory `gorm:"primaryKey;type:varchar(255)"`
+	Months    int                  `gorm:"not null"`
+	UpdatedAt time.Time            `gorm:"autoUpdateTime"`
+}
+
+// DefaultCertificateValidities are seeded on startup. HR can change them
+// at runtime.
+var DefaultCertificateValidities = []CertificateValidity{
+	{Category: CategorySafety, Months: 24},
+	{Category: CategoryMachine, Months: 24},
+}
@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+type EnrollmentStatus string
+
+const (
+	EnrollmentPending   EnrollmentStatus = "Pending"
+	EnrollmentEscalated EnrollmentStatus = "Escalated"
+	EnrollmentApproved  EnrollmentStatus = "Approved"
+	EnrollmentRejected  EnrollmentStatus = "Rejected"
+	EnrollmentCancelled EnrollmentStatus = "Cancelled"
+)
+
+// IsOpen reports whether the request still awaits a decision.
+func (s EnrollmentStatus) IsOpen() bool {
+	return s == EnrollmentPending || s == EnrollmentEscalated
+}
+
+type EnrollmentAction string
+
+const (
+	EnrollmentActionRequest  EnrollmentAction = "request"
+	EnrollmentActionApprove  EnrollmentAction = "approve"
+	EnrollmentActionReject   EnrollmentAction = "reject"
+	EnrollmentActionEscalate EnrollmentAction = "escalate"
+	EnrollmentActionOverride EnrollmentAction = "override"
+	EnrollmentActionCancel   EnrollmentAction = "cancel"
+)
+
+// EnrollmentRequest is a staff member's request to join a training plan.
+// Department managers decide on it; HR can escalate it to themselves or
+// override any decision. Every step is kept in Events.
+type EnrollmentRequest struct {
+	ID             uint             `gorm:"primaryKey;autoIncrement"`
+	UserID         uint             `gorm:"not null;index"`
+	User           *User            `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	TrainingPlanID uint             `gorm:"not null;index"`
+	TrainingPlan   *TrainingPlan    `gorm:"foreignKey:TrainingPlanID;constraint:OnDelete:CASCADE"`
+	Status         EnrollmentStatus `gorm:"type:varchar(20);not null;default:'Pending';index"`
+	Reason         string           `gorm:"type:text"`
+	DecidedByID    *uint
+	DecisionReason string     `gorm:"type:text"`
+	DecidedAt      *time.Time `gorm:"type:timestamp null"`
+	RecordID       *uint
+
+	Events []EnrollmentRequestEvent `gorm:"foreignKey:EnrollmentRequestID;constraint:OnDelete:CASCADE"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+type EnrollmentRequestEvent struct {
+	ID                  uint             `gorm:"primaryKey;autoIncrement"`
+	EnrollmentRequestID uint             `gorm:"not null;index"`
+	ActorID             uint             `gorm:"not null"`
+	ActorRole           string           `gorm:"type:varchar(52)"`
+	Action              EnrollmentAction `gorm:"type:varchar(20);not null"`
+	FromStatus          EnrollmentStatus `gorm:"type:varchar(20)"`
+	ToStatus            EnrollmentStatus `gorm:"type:varchar(20);not null"`
+	Reason              string           `gorm:"type:text"`
+	CreatedAt           time.Time        `gorm:"autoCreateTime"`
+}
@@ -0,0 +1,21 @@
+package model
+
+// EventType names a live update pushed over the event stream.
+type EventType string
+
+const (
+	EventCertificateUploaded EventType = "certificate.uploaded"
+	EventCertificateApproved EventType = "certificate.approved"
+	EventCertificateRejected EventType = "certificate.rejected"
+	EventRegistrationChanged EventType = "registration.changed"
+	EventNotificationCreated EventType = "notification.created"
+)
+
+// EventAudience selects who receives a live update: the user it is about,
+// everyone holding Permission, and holders of DepartmentPermission in that
+// user's department.
+type EventAudience struct {
+	UserID               uint
+	Permission           Permission
+	DepartmentPermission Permission
+}
@@ -0,0 +1,121 @@
+package model
+
+import "time"
+
+type NotificationEvent string
+type NotificationChannel string
+type NotificationDeliveryStatus string
+
+const (
+	NotifyRegistration        NotificationEvent = "registration"
+	NotifyRecordUpdated       NotificationEvent = "record_updated"
+	NotifyPlanChanged         NotificationEvent = "plan_changed"
+	NotifyPlanCancelled       NotificationEvent = "plan_cancelled"
+	NotifyCertificateApproved NotificationEvent = "certificate_approved"
+	NotifyCertificateRejected NotificationEvent = "certificate_rejected"
+	NotifyTrainingReminder    NotificationEvent = "training_reminder"
+	NotifyAttendanceReminder  NotificationEvent = "attendance_reminder"
+	NotifyCertificatesPending NotificationEvent = "certificates_pending"
+	// NotifyCertificateExpiring goes to the holder of an expiring
+	// certificate, NotifyCertificateExpiringStaff to their managers.
+	NotifyCertificateExpiring      NotificationEvent = "certificate_expiring"
+	NotifyCertificateExpiringStaff NotificationEvent = "certificate_expiring_staff"
+)
+
+// NotificationEvents lists every event users can opt out of.
+var NotificationEvents = []NotificationEvent{
+	NotifyRegistration,
+	NotifyRecordUpdated,
+	NotifyPlanChanged,
+	NotifyPlanCancelled,
+	NotifyCertificateApproved,
+	NotifyCertificateRejected,
+	NotifyTrainingReminder,
+	NotifyAttendanceReminder,
+	NotifyCertificatesPending,
+	NotifyCertificateExpiring,
+	NotifyCertificateExpiringStaff,
+}
+
+func (e NotificationEvent) IsValid() bool {
+	for _, event := range NotificationEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	NotificationChannelEmail NotificationChannel = "email"
+	NotificationChannelInApp NotificationChannel = "in_app"
+)
+
+// NotificationChannels lists the channels notifications are delivered on.
+var NotificationChannels = []NotificationChannel{NotificationChannelEmail, NotificationChannelInApp}
+
+func (c NotificationChannel) IsValid() bool {
+	for _, channel := range NotificationChannels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	NotificationPending NotificationDeliveryStatus = "Pending"
+	NotificationSent    NotificationDeliveryStatus = "Sent"
+	NotificationFailed  NotificationDeliveryStatus = "Failed"
+)
+
+// NotificationPreference turns one event on or off on one channel for a
+// user. Without a row the notification is delivered.
+type NotificationPreference struct {
+	ID      uint                `gorm:"primaryKey;autoIncrement"`
+	UserID  uint                `gorm:"not null;uniqueIndex:idx_notification_preference"`
+	User    *User               `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Event   NotificationEvent   `gorm:"type:varchar(32);not null;uniqueIndex:idx_notification_preference"`
+	Channel NotificationChannel `gorm:"type:varchar(16);not null;uniqueIndex:idx_notification_preference"`
+	Enabled bool                `gorm:"not null"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// NotificationDelivery is a rendered message waiting to be sent, or the
+// record of one that was. DedupKey keeps scheduled notifications such as
+// reminders from being queued twice.
+type NotificationDelivery struct {
+	ID            uint                       `gorm:"primaryKey;autoIncrement"`
+	UserID        uint                       `gorm:"not null;index"`
+	Event         NotificationEvent          `gorm:"type:varchar(32);not null"`
+	Channel       NotificationChannel        `gorm:"type:varchar(16);not null"`
+	Recipient     string                     `gorm:"type:varchar(255);not null"`
+	Subject       string                     `gorm:"type:varchar(255);not null"`
+	Body          string                     `gorm:"type:text;not null"`
+	DedupKey      *string                    `gorm:"type:varchar(191);uniqueIndex"`
+	Status        NotificationDeliveryStatus `gorm:"type:varchar(16);not null;default:'Pending';index:idx_notification_due"`
+	Attempts      int                        `gorm:"not null;default:0"`
+	NextAttemptAt time.Time                  `gorm:"not null;index:idx_notification_due"`
+	LastError     string                     `gorm:"type:text"`
+	SentAt        *time.Time
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// Notification is an entry in a user's in-app inbox.
+type Notification struct {
+	ID       uint              `gorm:"primaryKey;autoIncrement"`
+	UserID   uint              `gorm:"not null;index:idx_notification_inbox"`
+	User     *User             `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Event    NotificationEvent `gorm:"type:varchar(32);not null"`
+	Title    string            `gorm:"type:varchar(255);not null"`
+	Body     string            `gorm:"type:text;not null"`
+	Link     string            `gorm:"type:varchar(255)"`
+	DedupKey *string           `gorm:"type:varchar(191);uniqueIndex"`
+	ReadAt   *time.Time        `gorm:"index:idx_notification_inbox"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// PasswordResetToken is a single-use token sent by email for the forgot
+// password flow. Only the SHA-256 hash of the token is stored.
+type PasswordResetToken struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	UserID    uint   `gorm:"not null;index"`
+	User      *User  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	TokenHash string `gorm:"type:char(64);uniqueIndex;not null"`
+
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (t *PasswordResetToken) IsValid() bool {
+	return t.UsedAt == nil && time.Now().Before(t.ExpiresAt)
+}
@@ -0,0 +1,139 @@
+package model
+
+import "time"
+
+type Permission string
+
+// Permission registry. Routes are guarded by these names; which roles hold
+// them is stored in role_permissions and editable by HR.
+const (
+	PermDepartmentsRead  Permission = "departments:read"
+	PermDepartmentsWrite Permission = "departments:write"
+
+	PermUsersRead             Permission = "users:read"
+	PermUsersWrite            Permission = "users:write"
+	PermUsersReadDepartment   Permission = "users:read-department"
+	PermUsersCreateDepartment Permission = "users:create-department"
+
+	PermTrainingPlansRead  Permission = "training-plans:read"
+	PermTrainingPlansWrite Permission = "training-plans:write"
+
+	PermRegistrationsWrite           Permission = "registrations:write"
+	PermRegistrationsWriteDepartment Permission = "registrations:write-department"
+
+	PermRecordsRead            Permission = "records:read"
+	PermRecordsReadDepartment  Permission = "records:read-department"
+	PermRecordsReadOwn         Permission = "records:read-own"
+	PermRecordsWrite           Permission = "records:write"
+	PermRecordsWriteDepartment Permission = "records:write-department"
+	PermRecordsExport          Permission = "records:export"
+
+	PermCertificatesApprove Permission = "certificates:approve"
+	PermCertificatesSubmit  Permission = "certificates:submit"
+
+	PermRolesManage Permission = "roles:manage"
+
+	PermAuditRead Permission = "audit:read"
+
+	PermJobsManage Permission = "jobs:manage"
+
+	PermWebhooksManage Permission = "webhooks:manage"
+
+	PermEnrollmentsRequest           Permission = "enrollments:request"
+	PermEnrollmentsApproveDepartment Permission = "enrollments:approve-department"
+	PermEnrollmentsApprove           Permission = "enrollments:approve"
+)
+
+type PermissionDefinition struct {
+	Name        Permission
+	Description string
+}
+
+var PermissionRegistry = []PermissionDefinition{
+	{PermDepartmentsRead, "View departments"},
+	{PermDepartmentsWrite, "Create, update and delete departments"},
+	{PermUsersRead, "View all users"},
+	{PermUsersWrite, "Create, update, delete and unlock any user"},
+	{PermUsersReadDepartment, "View users in own department"},
+	{PermUsersCreateDepartment, "Create staff in own department"},
+	{PermTrainingPlansRead, "View training plans"},
+	{PermTrainingPlansWrite, "Create, update and delete training plans"},
+	{PermRegistrationsWrite, "Register any user to a training plan"},
+	{PermRegistrationsWriteDepartment, "Register users of own department to a training plan"},
+	{PermRecordsRead, "View all training records"},
+	{PermRecordsReadDepartment, "View training records of own department"},
+	{PermRecordsReadOwn, "View own training records"},
+	{PermRecordsWrite, "Update and delete any training record"},
+	{PermRecordsWriteDepartment, "Update and delete training records of own department"},
+	{PermRecordsExport, "Search and export training records"},
+	{PermCertificatesApprove, "Review, approve and reject certificates"},
+	{PermCertificatesSubmit, "Upload and delete own certificates"},
+	{PermRolesManage, "Manage roles and their permissions"},
+	{PermAuditRead, "Search and export the audit log"},
+	{PermJobsManage, "View, run and reschedule background jobs"},
+	{PermWebhooksManage, "Manage webhook subscriptions and replay deliveries"},
+	{PermEnrollmentsRequest, "Browse the training catalog and request enrollment"},
+	{PermEnrollmentsApproveDepartment, "Approve or reject enrollment requests of own department"},
+	{PermEnrollmentsApprove, "Escalate and override any enrollment request"},
+}
+
+func (p Permission) IsValid() bool {
+	for _, def := range PermissionRegistry {
+		if def.Name == p {
+			return true
+		}
+	}
+	return false
+}
+
+// AppRole is a named set of permissions. The three built-in roles are
+// system roles; HR can create more, e.g. "Training Coordinator".
+type AppRole struct {
+	ID          uint             `gorm:"primaryKey;autoIncrement"`
+	Name        string           `gorm:"type:varchar(52);uniqueIndex;not null"`
+	Description string           `gorm:"type:varchar(255)"`
+	IsSystem    bool             `gorm:"default:false"`
+	Permissions []RolePermission `gorm:"foreignKey:RoleName;references:Name;constraint:OnDelete:CASCADE"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+type RolePermission struct {
+	ID         uint       `gorm:"primaryKey;autoIncrement"`
+	RoleName   string     `gorm:"type:varchar(52);not null;uniqueIndex:idx_role_permission"`
+	Permission Permission `gorm:"type:varchar(64);not null;uniqueIndex:idx_role_permission"`
+}
+
+// DefaultRolePermissions are always granted to the system roles. HR admins
+// hold every registered permission.
+func DefaultRolePermissions(role Role) []Permission {
+	switch role {
+	case RoleHRAdmin:
+		permissions := make([]Permission, 0, len(PermissionRegistry))
+		for _, def := range PermissionRegistry {
+			permissions = append(permissions, def.Name)
+		}
+		return permissions
+	case RoleDepartmentManager:
+		return []Permission{
+			PermTrainingPlansRead,
+			PermUsersReadDepartment,
+			PermUsersCreateDepartment,
+			PermRegistrationsWriteDepartment,
+			PermRecordsReadDepartment,
+			PermRecordsWriteDepartment,
+			PermRecordsReadOwn,
+			PermCertificatesSubmit,
+			PermEnrollmentsRequest,
+			PermEnrollmentsApproveDepartment,
+		}
+	case RoleStaff:
+		return []Permission{
+			PermRecordsReadOwn,
+			PermCertificatesSubmit,
+			PermEnrollmentsRequest,
+		}
+	}
+	return nil
+}
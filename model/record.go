@@ -8,6 +8,12 @@ const (
 	RecordStatusRegister RecordStatus = "Register"
 	RecordStatusAttended RecordStatus = "Attended"
 	RecordStatusAbsent   RecordStatus = "Absent"
+	// RecordStatusWaitlisted holds a registration made after the plan was
+	// full. Waitlisted records are promoted to Register in FIFO order.
+	RecordStatusWaitlisted RecordStatus = "Waitlisted"
+	// RecordStatusCancelled keeps the registration history of a cancelled
+	// training plan. Cancelled records never hold a seat.
+	RecordStatusCancelled RecordStatus = "Cancelled"
 )
 
 type Record struct {
@@ -16,10 +22,16 @@ type Record struct {
 	User           *User        `gorm:"foreignKey:UserID" json:"user,omitempty"`
 	TrainingPlanID uint         `gorm:"not null" json:"trainingPlanId"`
 	TrainingPlan   *TrainingPlan `gorm:"foreignKey:TrainingPlanID" json:"trainingPlan,omitempty"`
-	Status         RecordStatus `gorm:"type:enum('Register','Attended','Absent');not null;default:'Register'" json:"status"`
+	Status         RecordStatus `gorm:"type:enum('Register','Attended','Absent','Waitlisted','Cancelled');not null;default:'Register'" json:"status"`
 	Evaluation     *string      `gorm:"type:text" json:"evaluation,omitempty"`
 	PreTestScore  *int         `gorm:"type:int" json:"preTestScore,omitempty"`
 	PostTestScore *int         `gorm:"type:int" json:"postTestScore,omitempty"`
+	// CreditedHours is computed from session attendance, or taken from the
+	// plan's NumberOfHours when a plan without sessions is marked Attended.
+	CreditedHours  float64      `gorm:"type:decimal(6,2);not null;default:0" json:"creditedHours"`
+	// RSVPStatus is the attendee's answer to the calendar invitation, read
+	// back from the calendar provider; nil until it was first read.
+	RSVPStatus     *string      `gorm:"type:varchar(16)" json:"rsvpStatus,omitempty"`
 	CreatedAt      time.Time    `gorm:"autoCreateTime" json:"createdAt"`
 	UpdatedAt      time.Time    `gorm:"autoUpdateTime" json:"updatedAt"`
 }
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// RecoveryCode is a one-time backup code for two-factor authentication.
+type RecoveryCode struct {
+	ID       uint   `gorm:"primaryKey;autoIncrement"`
+	UserID   uint   `gorm:"not null;index"`
+	User     *User  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	CodeHash string `gorm:"type:char(64);not null;index"`
+	UsedAt   *time.Time
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
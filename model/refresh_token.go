@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// RefreshToken is a server-side record of an issued refresh token. Tokens
+// are rotated on every use; all tokens issued from the same login share a
+// FamilyID so that reuse of a rotated token revokes the whole chain.
+type RefreshToken struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	UserID    uint   `gorm:"not null;index"`
+	User      *User  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	TokenHash string `gorm:"type:char(64);uniqueIndex;not null"`
+	FamilyID  string `gorm:"type:varchar(64);index;not null"`
+
+	ExpiresAt    time.Time `gorm:"not null"`
+	Revoked      bool      `gorm:"default:false"`
+	ReplacedByID *uint
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+func (t *RefreshToken) IsValid() bool {
+	return !t.Revoked && time.Now().Before(t.ExpiresAt)
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// ScheduledJob is the persisted state of a background job. Jobs are
+// registered in code; the schedule and Enabled can be changed at runtime.
+// LockedBy and LockedUntil make sure only one instance runs a job at a time.
+type ScheduledJob struct {
+	Name        string `gorm:"primaryKey;type:varchar(64)"`
+	Description string `gorm:"type:varchar(255)"`
+	// Schedule is a five-field cron expression in the application timezone
+	Schedule string `gorm:"type:varchar(64);not null"`
+	Enabled  bool   `gorm:"not null;default:true"`
+
+	NextRunAt   time.Time `gorm:"not null;index"`
+	LockedBy    *string   `gorm:"type:varchar(128)"`
+	LockedUntil *time.Time
+
+	LastStartedAt  *time.Time
+	LastFinishedAt *time.Time
+	LastSuccessAt  *time.Time
+	LastError      string `gorm:"type:text"`
+	RunCount       int    `gorm:"not null;default:0"`
+	FailureCount   int    `gorm:"not null;default:0"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
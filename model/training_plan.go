@@ -4,6 +4,7 @@ import "time"
 
 type TrainingPlanCategory string
 type TrainingPlanType string
+type TrainingPlanStatus string
 
 const (
 	// Category
@@ -32,6 +33,39 @@ const (
 	TypeOnline       TrainingPlanType = "Online/Virtual"
 )
 
+const (
+	// Status. Plans start as Draft and only Published plans take
+	// registrations; Cancelled and Completed are final.
+	TrainingPlanDraft     TrainingPlanStatus = "Draft"
+	TrainingPlanPublished TrainingPlanStatus = "Published"
+	TrainingPlanCancelled TrainingPlanStatus = "Cancelled"
+	TrainingPlanCompleted TrainingPlanStatus = "Completed"
+)
+
+// CanTransitionTo reports whether a plan may move from s to next.
+func (s TrainingPlanStatus) CanTransitionTo(next TrainingPlanStatus) bool {
+	switch s {
+	case TrainingPlanDraft:
+		return next == TrainingPlanPublished || next == TrainingPlanCancelled
+	case TrainingPlanPublished:
+		return next == TrainingPlanCancelled || next == TrainingPlanCompleted
+	}
+	return false
+}
+
+// IsFinal reports whether the plan and its records are locked.
+func (s TrainingPlanStatus) IsFinal() bool {
+	return s == TrainingPlanCancelled || s == TrainingPlanCompleted
+}
+
+func (s TrainingPlanStatus) IsValid() bool {
+	switch s {
+	case TrainingPlanDraft, TrainingPlanPublished, TrainingPlanCancelled, TrainingPlanCompleted:
+		return true
+	}
+	return false
+}
+
 type TrainingPlan struct {
 	ID                int            `gorm:"primaryKey;autoIncrement"`
 	Name              string         `gorm:"type:varchar(52);not null"`
@@ -53,7 +87,7 @@ type TrainingPlan struct {
 		'พัฒนาขีดความสามารถระดับบริหาร',
 		'การเงินและการบัญชี'
 	)"`
-	Date              time.Time `gorm:"type:date;not null"`
+	Date              time.Time `gorm:"type:date;not null;uniqueIndex:idx_series_date"`
 	Content       string 	  `gorm:"type:text"`
 	NumberOfDays      int `gorm:"default:1"`
 	NumberOfHours     *int 
@@ -63,7 +97,20 @@ type TrainingPlan struct {
 	NumberOfPerson    int `gorm:"default:0"`
 	CostPerPerson     *int `gorm:"type:int"`
 
+	// existing plans predate the lifecycle and were already live
+	Status             TrainingPlanStatus `gorm:"type:enum('Draft','Published','Cancelled','Completed');not null;default:'Published';index"`
+	CancellationReason *string            `gorm:"type:text"`
+
+	// SeriesID links a generated instance (and the template) to its
+	// recurring series; ClonedFromID is the plan this one was copied from.
+	SeriesID     *uint `gorm:"uniqueIndex:idx_series_date"`
+	ClonedFromID *int  `gorm:"index"`
+
 	CalendarEventID *string `gorm:"type:varchar(128);index"`
+	// Sequence is the iCalendar SEQUENCE of the plan's event; it grows with
+	// every change to the plan or its records so subscribed calendars update
+	// in place.
+	Sequence int `gorm:"not null;default:0"`
 
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// TrainingPlanSeries generates recurring copies of a template training plan,
+// e.g. a yearly safety course. Instances link back through
+// TrainingPlan.SeriesID; GeneratedThrough is the last date generated.
+type TrainingPlanSeries struct {
+	ID               uint       `gorm:"primaryKey;autoIncrement"`
+	Name             string     `gorm:"type:varchar(52);not null"`
+	TemplatePlanID   int        `gorm:"not null;index"`
+	RRule            string     `gorm:"type:varchar(255);not null"`
+	StartDate        time.Time  `gorm:"type:date;not null"`
+	HorizonMonths    int        `gorm:"not null;default:12"`
+	AutoPublish      bool       `gorm:"default:false"`
+	Active           bool       `gorm:"default:true"`
+	GeneratedThrough *time.Time `gorm:"type:date"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
@@ -0,0 +1,74 @@
+package model
+
+import "testing"
+
+func TestTrainingPlanStatusCanTransitionTo(t *testing.T) {
+	statuses := []TrainingPlanStatus{
+		TrainingPlanDraft,
+		TrainingPlanPublished,
+		TrainingPlanCancelled,
+		TrainingPlanCompleted,
+	}
+
+	// allowed lists every permitted move; any pair not listed is refused
+	allowed := map[TrainingPlanStatus][]TrainingPlanStatus{
+		TrainingPlanDraft:     {TrainingPlanPublished, TrainingPlanCancelled},
+		TrainingPlanPublished: {TrainingPlanCancelled, TrainingPlanCompleted},
+	}
+
+	for _, from := range statuses {
+		for _, to := range statuses {
+			want := false
+			for _, next := range allowed[from] {
+				if next == to {
+					want = true
+				}
+			}
+
+			if got := from.CanTransitionTo(to); got != want {
+				t.Errorf("%s -> %s: got %v, want %v", from, to, got, want)
+			}
+		}
+	}
+
+	for _, from := range statuses {
+		if from.CanTransitionTo("Archived") {
+			t.Errorf("%s -> Archived allowed", from)
+		}
+	}
+	if TrainingPlanStatus("").CanTransitionTo(TrainingPlanPublished) {
+		t.Error("empty status -> Published allowed")
+	}
+}
+
+func TestTrainingPlanStatusIsFinal(t *testing.T) {
+	tests := []struct {
+		status TrainingPlanStatus
+		final  bool
+		valid  bool
+	}{
+		{TrainingPlanDraft, false, true},
+		{TrainingPlanPublished, false, true},
+		{TrainingPlanCancelled, true, true},
+		{TrainingPlanCompleted, true, true},
+		{"Archived", false, false},
+		{"", false, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.status.IsFinal(); got != tt.final {
+			t.Errorf("%q.IsFinal() = %v, want %v", tt.status, got, tt.final)
+		}
+		if got := tt.status.IsValid(); got != tt.valid {
+			t.Errorf("%q.IsValid() = %v, want %v", tt.status, got, tt.valid)
+		}
+		// a final plan never moves again
+		if tt.final {
+			for _, next := range []TrainingPlanStatus{TrainingPlanDraft, TrainingPlanPublished, TrainingPlanCancelled, TrainingPlanCompleted} {
+				if tt.status.CanTransitionTo(next) {
+					t.Errorf("final %s -> %s allowed", tt.status, next)
+				}
+			}
+		}
+	}
+}
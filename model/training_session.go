@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+type AttendanceStatus string
+
+const (
+	AttendancePresent AttendanceStatus = "Present"
+	AttendanceAbsent  AttendanceStatus = "Absent"
+)
+
+// TrainingSession is one meeting of a multi-day training plan. StartTime
+// and EndTime are wall-clock times ("15:04") on Date.
+type TrainingSession struct {
+	ID             uint          `gorm:"primaryKey;autoIncrement" json:"id"`
+	TrainingPlanID uint          `gorm:"not null;index" json:"trainingPlanId"`
+	TrainingPlan   *TrainingPlan `gorm:"foreignKey:TrainingPlanID" json:"trainingPlan,omitempty"`
+	Date           time.Time     `gorm:"type:date;not null" json:"date"`
+	StartTime      string        `gorm:"type:char(5);not null" json:"startTime"`
+	EndTime        string        `gorm:"type:char(5);not null" json:"endTime"`
+	Location       *string       `gorm:"type:text" json:"location,omitempty"`
+	Trainer        *string       `gorm:"type:varchar(255)" json:"trainer,omitempty"`
+	CreatedAt      time.Time     `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt      time.Time     `gorm:"autoUpdateTime" json:"updatedAt"`
+}
+
+// Hours returns the session length, or zero when the times are invalid.
+func (s TrainingSession) Hours() float64 {
+	start, err := time.Parse("15:04", s.StartTime)
+	if err != nil {
+		return 0
+	}
+	end, err := time.Parse("15:04", s.EndTime)
+	if err != nil || !end.After(start) {
+		return 0
+	}
+	return end.Sub(start).Hours()
+}
+
+// SessionAttendance marks whether the holder of a record attended one
+// session. Sessions without a row have not been marked yet.
+type SessionAttendance struct {
+	ID                uint             `gorm:"primaryKey;autoIncrement" json:"id"`
+	TrainingSessionID uint             `gorm:"not null;uniqueIndex:idx_session_record" json:"trainingSessionId"`
+	RecordID          uint             `gorm:"not null;uniqueIndex:idx_session_record;index" json:"recordId"`
+	Status            AttendanceStatus `gorm:"type:enum('Present','Absent');not null" json:"status"`
+	MarkedByID        *uint            `json:"markedById,omitempty"`
+	CreatedAt         time.Time        `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt         time.Time        `gorm:"autoUpdateTime" json:"updatedAt"`
+}
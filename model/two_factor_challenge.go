@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// TwoFactorChallenge tracks a challenge token of the two-factor login step
+// so it can be redeemed only once and stops working after too many wrong
+// codes.
+type TwoFactorChallenge struct {
+	ID         string `gorm:"primaryKey;type:varchar(64)"`
+	UserID     uint   `gorm:"not null;index"`
+	User       *User  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Failures   int    `gorm:"not null;default:0"`
+	ConsumedAt *time.Time
+	ExpiresAt  time.Time `gorm:"not null;index"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
@@ -35,7 +35,7 @@ type User struct {
 	Email         string        `gorm:"type:varchar(52);unique;not null" json:"email"`
 	EmployeeID    string        `gorm:"type:varchar(52);unique;not null" json:"employeeID"`
 	Phone         string        `gorm:"type:varchar(20)" json:"phone"`
-	Role          Role          `gorm:"type:enum('Hr(admin)','DepartmentHead(manager)','Staff');not null;default:'Staff'" json:"role"`
+	Role          Role          `gorm:"type:varchar(52);not null;default:'Staff';index" json:"role"`
 	Status        UserStatus    `gorm:"type:enum('Active','Inactive','Suspended');not null;default:'Active'" json:"status"`
 	Position      string        `gorm:"type:varchar(100)" json:"position"`
 	GoogleID      string        `gorm:"type:varchar(120)" json:"googleId,omitempty"`
@@ -45,12 +45,28 @@ type User struct {
 	CreatedBy     CreatedByType `gorm:"type:enum('self','admin','manager');not null;default:'self'" json:"createdBy"`
 	CreatedByID   *uint         `gorm:"index" json:"createdById,omitempty"`
 	Error         int16         `gorm:"type:smallint;default:0" json:"error"`
+	LockedUntil   *time.Time    `gorm:"type:timestamp null" json:"lockedUntil,omitempty"`
 	CreatedAt     int64         `gorm:"autoCreateTime" json:"createdAt"`
 	UpdatedAt     int64         `gorm:"autoUpdateTime" json:"updatedAt"`
 	Certificates  []Certificate `gorm:"foreignKey:UserID" json:"certificates,omitempty"`
 	IsProfileComplete bool `gorm:"default:false" json:"isProfileComplete"`
+	MustChangePassword bool `gorm:"default:false" json:"mustChangePassword"`
+	TOTPSecret      string `gorm:"column:totp_secret;type:varchar(64)" json:"-"`
+	TOTPEnabled     bool   `gorm:"column:totp_enabled;default:false" json:"totpEnabled"`
+	TOTPLastCounter int64  `gorm:"column:totp_last_counter;default:0" json:"-"`
+	// Language of the notifications sent to the user ("th" or "en").
+	Language string `gorm:"type:varchar(5);not null;default:'th'" json:"language"`
 }
 
+// IsLocked reports whether the account is inside a login lockout window.
+func (u *User) IsLocked() bool {
+	return u.LockedUntil != nil && time.Now().Before(*u.LockedUntil)
+}
+
+// IsValid reports whether r is one of the built-in system roles. Custom
+// roles are stored in app_roles.
 func (r Role) IsValid() bool {
 	return r == RoleHRAdmin || r == RoleDepartmentManager || r == RoleStaff
 }
+
+var SystemRoles = []Role{RoleHRAdmin, RoleDepartmentManager, RoleStaff}
@@ -0,0 +1,101 @@
+package model
+
+import "time"
+
+// WebhookEvent is an event type outside systems can subscribe to.
+type WebhookEvent string
+
+const (
+	WebhookRecordAttended        WebhookEvent = "record.attended"
+	WebhookCertificateUploaded   WebhookEvent = "certificate.uploaded"
+	WebhookCertificateApproved   WebhookEvent = "certificate.approved"
+	WebhookCertificateRejected   WebhookEvent = "certificate.rejected"
+	WebhookTrainingPlanPublished WebhookEvent = "training_plan.published"
+	WebhookTrainingPlanCancelled WebhookEvent = "training_plan.cancelled"
+	WebhookTrainingPlanCompleted WebhookEvent = "training_plan.completed"
+)
+
+// WebhookEvents lists every event a subscription can select.
+var WebhookEvents = []WebhookEvent{
+	WebhookRecordAttended,
+	WebhookCertificateUploaded,
+	WebhookCertificateApproved,
+	WebhookCertificateRejected,
+	WebhookTrainingPlanPublished,
+	WebhookTrainingPlanCancelled,
+	WebhookTrainingPlanCompleted,
+}
+
+func (e WebhookEvent) IsValid() bool {
+	for _, event := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookSubscription sends the selected events to URL. Every request is
+// signed with Secret so the receiver can verify it came from us.
+type WebhookSubscription struct {
+	ID          uint   `gorm:"primaryKey;autoIncrement"`
+	Name        string `gorm:"type:varchar(100);not null"`
+	URL         string `gorm:"type:varchar(500);not null"`
+	Secret      string `gorm:"type:varchar(128);not null"`
+	Active      bool   `gorm:"not null;default:true"`
+	CreatedByID uint   `gorm:"not null"`
+
+	Events []WebhookSubscriptionEvent `gorm:"foreignKey:SubscriptionID;constraint:OnDelete:CASCADE"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+type WebhookSubscriptionEvent struct {
+	ID             uint         `gorm:"primaryKey;autoIncrement"`
+	SubscriptionID uint         `gorm:"not null;uniqueIndex:idx_webhook_subscription_event"`
+	Event          WebhookEvent `gorm:"type:varchar(64);not null;uniqueIndex:idx_webhook_subscription_event;index"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending WebhookDeliveryStatus = "Pending"
+	WebhookDeliverySent    WebhookDeliveryStatus = "Sent"
+	WebhookDeliveryFailed  WebhookDeliveryStatus = "Failed"
+)
+
+// WebhookDelivery is one event sent to one subscription, kept as the
+// delivery log. Payload is the exact body that is signed and sent.
+type WebhookDelivery struct {
+	ID             uint                 `gorm:"primaryKey;autoIncrement"`
+	SubscriptionID uint                 `gorm:"not null;index"`
+	Subscription   *WebhookSubscription `gorm:"foreignKey:SubscriptionID;constraint:OnDelete:CASCADE"`
+	Event          WebhookEvent         `gorm:"type:varchar(64);not null"`
+	// EventID is shared by the deliveries of one event and by replays, so
+	// receivers can drop duplicates.
+	EventID string `gorm:"type:varchar(64);not null;index"`
+	Payload string `gorm:"type:mediumtext;not null"`
+	// ReplayOfID points at the delivery this one replays.
+	ReplayOfID *uint `gorm:"index"`
+
+	Status         WebhookDeliveryStatus `gorm:"type:varchar(16);not null;index:idx_webhook_delivery_due"`
+	Attempts       int                   `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time             `gorm:"not null;index:idx_webhook_delivery_due"`
+	ResponseStatus *int
+	ResponseBody   string `gorm:"type:text"`
+	LastError      string `gorm:"type:text"`
+	DeliveredAt    *time.Time
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// WebhookMessage is an event queued in the same transaction as the change
+// it reports, so a rolled back change sends nothing and a committed one is
+// never lost. Data is called once the change has been written, so IDs
+// created by it are filled in.
+type WebhookMessage struct {
+	Event WebhookEvent
+	Data  func() interface{}
+}
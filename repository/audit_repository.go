@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"training-plan-api/data/request"
+	"training-plan-api/model"
+
+	"gorm.io/gorm"
+)
+
+type AuditRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewAuditRepositoryImpl(db *gorm.DB) AuditRepository {
+	return &AuditRepositoryImpl{Db: db}
+}
+
+// Save implements AuditRepository.
+func (r *AuditRepositoryImpl) Save(event *model.AuditEvent) error {
+	return r.Db.Create(event).Error
+}
+
+// Search implements AuditRepository.
+func (r *AuditRepositoryImpl) Search(
+	req request.AuditFilterRequest,
+) ([]model.AuditEvent, int64, error) {
+
+	var events []model.AuditEvent
+	var total int64
+
+	query := r.Db.Model(&model.AuditEvent{})
+
+	if req.ActorID != nil {
+		query = query.Where("actor_id = ?", *req.ActorID)
+	}
+
+	if len(req.Actions) > 0 {
+		query = query.Where("action IN ?", req.Actions)
+	}
+
+	if req.EntityType != "" {
+		query = query.Where("entity_type = ?", req.EntityType)
+	}
+
+	if req.EntityID != "" {
+		query = query.Where("entity_id = ?", req.EntityID)
+	}
+
+	if req.RequestID != "" {
+		query = query.Where("request_id = ?", req.RequestID)
+	}
+
+	if req.StartDate != nil {
+		query = query.Where("created_at >= ?", req.StartDate)
+	}
+	if req.EndDate != nil {
+		query = query.Where("created_at <= ?", req.EndDate)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (req.Page - 1) * req.Limit
+
+	err := query.
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(req.Limit).
+		Find(&events).
+		Error
+
+	return events, total, err
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"errors"
+	"time"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+
+	"gorm.io/gorm"
+)
+
+type CalendarFeedTokenRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewCalendarFeedTokenRepositoryImpl(db *gorm.DB) CalendarFeedTokenRepository {
+	return &CalendarFeedTokenRepositoryImpl{Db: db}
+}
+
+// Replace implements CalendarFeedTokenRepository. Any previous token of the
+// same user and scope stops working.
+func (r *CalendarFeedTokenRepositoryImpl) Replace(token *model.CalendarFeedToken) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Where("user_id = ? AND scope = ?", token.UserID, token.Scope).
+			Delete(&model.CalendarFeedToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// FindByTokenHash implements CalendarFeedTokenRepository.
+func (r *CalendarFeedTokenRepositoryImpl) FindByTokenHash(tokenHash string) (*model.CalendarFeedToken, error) {
+	var token model.CalendarFeedToken
+
+	err := r.Db.Preload("User").Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helper.NotFound("calendar feed not found")
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// FindByUser implements CalendarFeedTokenRepository.
+func (r *CalendarFeedTokenRepositoryImpl) FindByUser(userId uint) ([]model.CalendarFeedToken, error) {
+	var tokens []model.CalendarFeedToken
+	err := r.Db.Where("user_id = ?", userId).Order("scope ASC").Find(&tokens).Error
+	return tokens, err
+}
+
+// Delete implements CalendarFeedTokenRepository.
+func (r *CalendarFeedTokenRepositoryImpl) Delete(userId uint, scope model.CalendarFeedScope) error {
+	result := r.Db.
+		Where("user_id = ? AND scope = ?", userId, scope).
+		Delete(&model.CalendarFeedToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return helper.NotFound("calendar feed not found")
+	}
+	return nil
+}
+
+// Touch implements CalendarFeedTokenRepository.
+func (r *CalendarFeedTokenRepositoryImpl) Touch(id uint, usedAt time.Time) error {
+	return r.Db.Model(&model.CalendarFeedToken{}).
+		Where("id = ?", id).
+		Update("last_used_at", usedAt).Error
+}
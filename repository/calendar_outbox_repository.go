@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"errors"
+	"time"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CalendarOutboxRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewCalendarOutboxRepositoryImpl(db *gorm.DB) CalendarOutboxRepository {
+	return &CalendarOutboxRepositoryImpl{Db: db}
+}
+
+// Enqueue implements CalendarOutboxRepository.
+func (r *CalendarOutboxRepositoryImpl) Enqueue(trainingPlanId int, op model.CalendarOperation, eventID *string) error {
+	return enqueueCalendarSync(r.Db, trainingPlanId, op, eventID)
+}
+
+// FindById implements CalendarOutboxRepository.
+func (r *CalendarOutboxRepositoryImpl) FindById(id uint) (*model.CalendarOutbox, error) {
+	var entry model.CalendarOutbox
+
+	err := r.Db.First(&entry, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, helper.NotFound("calendar sync entry not found")
+	}
+
+	return &entry, err
+}
+
+// ClaimDue implements CalendarOutboxRepository. Claimed rows are pushed
+// lease into the future, so a second worker skips them while they are
+// being processed and picks them up again if this worker dies.
+//
+// Only the oldest pending row of a plan is claimed. The entries of one plan
+// are therefore processed one at a time and in order, and two workers never
+// create an event for the same plan.
+func (r *CalendarOutboxRepositoryImpl) ClaimDue(now time.Time, lease time.Duration, limit int) ([]model.CalendarOutbox, error) {
+	var entries []model.CalendarOutbox
+
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", model.CalendarOutboxPending, now).
+			Where("NOT EXISTS (?)", tx.Table("calendar_outboxes AS earlier").
+				Select("1").
+				Where("earlier.training_plan_id = calendar_outboxes.training_plan_id").
+				Where("earlier.status = ? AND earlier.id < calendar_outboxes.id", model.CalendarOutboxPending)).
+			Order("id ASC").
+			Limit(limit).
+			Find(&entries).Error; err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(entries))
+		for _, entry := range entries {
+			ids = append(ids, entry.ID)
+		}
+
+		return tx.Model(&model.CalendarOutbox{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+
+	return entries, err
+}
+
+// MarkDone implements CalendarOutboxRepository.
+func (r *CalendarOutboxRepositoryImpl) MarkDone(id uint, attempts int) error {
+	now := time.Now()
+	return r.Db.Model(&model.CalendarOutbox{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       model.CalendarOutboxDone,
+			"attempts":     attempts,
+			"last_error":   "",
+			"processed_at": &now,
+		}).Error
+}
+
+// MarkRetry implements CalendarOutboxRepository.
+func (r *CalendarOutboxRepositoryImpl) MarkRetry(id uint, attempts int, nextAttemptAt time.Time, lastError string) error {
+	return r.Db.Model(&model.CalendarOutbox{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      lastError,
+		}).Error
+}
+
+// MarkFailed implements CalendarOutboxRepository.
+func (r *CalendarOutboxRepositoryImpl) MarkFailed(id uint, attempts int, lastError string) error {
+	now := time.Now()
+	return r.Db.Model(&model.CalendarOutbox{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       model.CalendarOutboxFailed,
+			"attempts":     attempts,
+			"last_error":   lastError,
+			"processed_at": &now,
+		}).Error
+}
+
+// Requeue implements CalendarOutboxRepository. Only failed rows can be
+// requeued; their attempt count starts over.
+func (r *CalendarOutboxRepositoryImpl) Requeue(id uint) error {
+	result := r.Db.Model(&model.CalendarOutbox{}).
+		Where("id = ? AND status = ?", id, model.CalendarOutboxFailed).
+		Updates(map[string]interface{}{
+			"status":          model.CalendarOutboxPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+			"processed_at":    nil,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return helper.BadRequest("only failed calendar syncs can be retried")
+	}
+	return nil
+}
+
+// FindFailedPaginated implements CalendarOutboxRepository.
+func (r *CalendarOutboxRepositoryImpl) FindFailedPaginated(offset, limit int) ([]model.CalendarOutbox, int64, error) {
+	var entries []model.CalendarOutbox
+	var total int64
+
+	query := r.Db.Model(&model.CalendarOutbox{}).Where("status = ?", model.CalendarOutboxFailed)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("processed_at DESC, id DESC").Offset(offset).Limit(limit).Find(&entries).Error
+	return entries, total, err
+}
+
+// enqueueCalendarSync writes an outbox row with db, which may be the
+// transaction of the plan change that caused it.
+func enqueueCalendarSync(db *gorm.DB, trainingPlanId int, op model.CalendarOperation, eventID *string) error {
+	return db.Create(&model.CalendarOutbox{
+		TrainingPlanID: trainingPlanId,
+		Operation:      op,
+		EventID:        eventID,
+		Status:         model.CalendarOutboxPending,
+		NextAttemptAt:  time.Now(),
+	}).Error
+}
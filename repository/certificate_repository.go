@@ -2,10 +2,13 @@ package repository
 
 import (
 	"errors"
+	"time"
+	"training-plan-api/data/request"
 	"training-plan-api/helper"
 	"training-plan-api/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CertificateRepositoryImpl struct {
@@ -17,8 +20,19 @@ func NewCertificateRepositoryImpl(db *gorm.DB) CertificateRepository {
 
 
 
-func (r *CertificateRepositoryImpl) Save(certificate *model.Certificate) error {
-	return r.Db.Create(certificate).Error
+// Save implements CertificateRepository. The first version is stored with
+// the certificate.
+func (r *CertificateRepositoryImpl) Save(certificate *model.Certificate, webhooks ...model.WebhookMessage) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(certificate).Error; err != nil {
+			return err
+		}
+		version := model.NewCertificateVersion(certificate)
+		if err := tx.Create(&version).Error; err != nil {
+			return err
+		}
+		return enqueueWebhooks(tx, webhooks)
+	})
 }
 
 func (r *CertificateRepositoryImpl) FindById(id int) (*model.Certificate, error) {
@@ -28,6 +42,7 @@ func (r *CertificateRepositoryImpl) FindById(id int) (*model.Certificate, error)
 		Preload("User").
 		Preload("User.Department").
 		Preload("Training").
+		Preload("ReviewedBy").
 		First(&certificate, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -46,6 +61,7 @@ func (r *CertificateRepositoryImpl) FindByUserId(userId int) ([]model.Certificat
 		Preload("User").
 		Preload("User.Department").
 		Preload("Training").
+		Preload("ReviewedBy").
 		Where("user_id = ?", userId).
 		Order("created_at DESC").
 		Find(&certificates).
@@ -54,35 +70,115 @@ func (r *CertificateRepositoryImpl) FindByUserId(userId int) ([]model.Certificat
 	return certificates, err
 }
 
+// Delete implements CertificateRepository. The version history is deleted
+// with the certificate.
 func (r *CertificateRepositoryImpl) Delete(id int) error {
-	result := r.Db.Delete(&model.Certificate{}, id)
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("certificate_id = ?", id).Delete(&model.CertificateVersion{}).Error; err != nil {
+			return err
+		}
 
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return helper.NotFound("certificate not found")
-	}
+		result := tx.Delete(&model.Certificate{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return helper.NotFound("certificate not found")
+		}
+		return nil
+	})
+}
+
+// Review implements CertificateRepository. It stores the status, reason and
+// reviewer set on certificate, provided the version is still pending; a
+// certificate reviewed or resubmitted in the meantime is left alone.
+//
+// A non-nil record is the training record the certificate is evidence for.
+// It is created or updated in the same transaction and linked to the
+// certificate, and webhooks are queued with the review.
+func (r *CertificateRepositoryImpl) Review(certificate *model.Certificate, record *model.Record, webhooks ...model.WebhookMessage) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if record != nil {
+			if err := saveEvidencedRecord(tx, record); err != nil {
+				return err
+			}
+			certificate.RecordID = &record.ID
+		}
 
-	return nil
+		result := tx.Model(&model.Certificate{}).
+			Where("id = ? AND status = ? AND version = ?", certificate.ID, model.CertPending, certificate.Version).
+			Updates(map[string]interface{}{
+				"status":           certificate.Status,
+				"rejection_reason": certificate.RejectionReason,
+				"reviewed_by_id":   certificate.ReviewedByID,
+				"reviewed_at":      certificate.ReviewedAt,
+				"record_id":        certificate.RecordID,
+				"category":         certificate.Category,
+				"expiry_date":      certificate.ExpiryDate,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return helper.BadRequest("certificate is no longer pending")
+		}
+
+		version := model.NewCertificateVersion(certificate)
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "certificate_id"}, {Name: "version"}},
+			DoUpdates: clause.AssignmentColumns([]string{"status", "rejection_reason", "reviewed_by_id", "reviewed_at"}),
+		}).Create(&version).Error; err != nil {
+			return err
+		}
+		return enqueueWebhooks(tx, webhooks)
+	})
 }
 
-func (r *CertificateRepositoryImpl) UpdateStatus(
-	id int,
-	status model.CertificateStatus,
-) error {
-	result := r.Db.Model(&model.Certificate{}).
-		Where("id = ?", id).
-		Update("status", status)
+// Resubmit implements CertificateRepository. previous is the rejected
+// version being replaced; it is stored first for certificates uploaded
+// before versions were kept.
+func (r *CertificateRepositoryImpl) Resubmit(certificate *model.Certificate, previous model.CertificateVersion, webhooks ...model.WebhookMessage) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&previous).Error; err != nil {
+			return err
+		}
 
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return helper.NotFound("certificate not found")
-	}
+		result := tx.Model(&model.Certificate{}).
+			Where("id = ? AND status = ? AND version = ?", certificate.ID, model.CertRejected, previous.Version).
+			Updates(map[string]interface{}{
+				"image":            certificate.Image,
+				"description":      certificate.Description,
+				"status":           model.CertPending,
+				"version":          certificate.Version,
+				"rejection_reason": nil,
+				"reviewed_by_id":   nil,
+				"reviewed_at":      nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return helper.BadRequest("certificate is no longer rejected")
+		}
 
-	return nil
+		version := model.NewCertificateVersion(certificate)
+		if err := tx.Create(&version).Error; err != nil {
+			return err
+		}
+		return enqueueWebhooks(tx, webhooks)
+	})
+}
+
+// FindVersions implements CertificateRepository. Versions are returned
+// oldest first.
+func (r *CertificateRepositoryImpl) FindVersions(certificateId uint) ([]model.CertificateVersion, error) {
+	var versions []model.CertificateVersion
+	err := r.Db.
+		Preload("ReviewedBy").
+		Where("certificate_id = ?", certificateId).
+		Order("version ASC").
+		Find(&versions).Error
+	return versions, err
 }
 
 func (r *CertificateRepositoryImpl) FindAllPending(
@@ -107,6 +203,7 @@ func (r *CertificateRepositoryImpl) FindAllPending(
 		Preload("User").
 		Preload("User.Department").
 		Preload("Training").
+		Preload("ReviewedBy").
 		Where("status = ?", model.CertPending).
 		Order("certificates.created_at DESC").
 		Offset(offset).
@@ -123,3 +220,126 @@ func (r *CertificateRepositoryImpl) FindAllPending(
 
 
 
+
+// CountPendingSince implements CertificateRepository. It counts the
+// certificates uploaded before the given time and still waiting for review.
+func (r *CertificateRepositoryImpl) CountPendingSince(before time.Time) (int64, error) {
+	var count int64
+	err := r.Db.Model(&model.Certificate{}).
+		Where("status = ? AND created_at < ?", model.CertPending, before).
+		Count(&count).Error
+	return count, err
+}
+
+// FindApprovedExternalByUser implements CertificateRepository.
+func (r *CertificateRepositoryImpl) FindApprovedExternalByUser(userId uint) ([]model.Certificate, error) {
+	var certificates []model.Certificate
+	err := r.Db.
+		Where("user_id = ? AND kind = ? AND status = ?", userId, model.CertKindExternal, model.CertApproved).
+		Order("issue_date DESC").
+		Find(&certificates).Error
+	return certificates, err
+}
+
+// FindApprovedExternal implements CertificateRepository. It applies the
+// department, category and date filters of a record search, matching the
+// date range against the issue date.
+func (r *CertificateRepositoryImpl) FindApprovedExternal(req request.RecordFilterRequest) ([]model.Certificate, error) {
+	var certificates []model.Certificate
+
+	query := r.Db.
+		Model(&model.Certificate{}).
+		Preload("User").
+		Preload("User.Department").
+		Joins("JOIN users ON users.id = certificates.user_id").
+		Where("certificates.kind = ? AND certificates.status = ?", model.CertKindExternal, model.CertApproved)
+
+	if len(req.DepartmentIDs) > 0 {
+		query = query.Where("users.department_id IN ?", req.DepartmentIDs)
+	}
+	if len(req.Categories) > 0 {
+		query = query.Where("certificates.category IN ?", req.Categories)
+	}
+	if req.StartDate != nil && req.EndDate != nil {
+		query = query.Where("certificates.issue_date BETWEEN ? AND ?", req.StartDate, req.EndDate)
+	}
+
+	err := query.Order("certificates.issue_date DESC").Find(&certificates).Error
+	return certificates, err
+}
+
+// FindExpiring implements CertificateRepository. It returns approved
+// certificates expiring between from and to, or already expired when from
+// is nil, that were not renewed by a later approved certificate of the same
+// category. A departmentId of zero means every department.
+func (r *CertificateRepositoryImpl) FindExpiring(from *time.Time, to time.Time, departmentId int) ([]model.Certificate, error) {
+	var certificates []model.Certificate
+
+	query := r.Db.
+		Model(&model.Certificate{}).
+		Preload("User").
+		Preload("User.Department").
+		Preload("Training").
+		Joins("JOIN users ON users.id = certificates.user_id").
+		Where("certificates.status = ? AND certificates.expiry_date <= ?", model.CertApproved, to.Format("2006-01-02")).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM certificates renewal
+			WHERE renewal.user_id = certificates.user_id
+			AND renewal.category = certificates.category
+			AND renewal.status = ?
+			AND (renewal.expiry_date IS NULL OR renewal.expiry_date > certificates.expiry_date)
+		)`, model.CertApproved)
+
+	if from != nil {
+		query = query.Where("certificates.expiry_date >= ?", from.Format("2006-01-02"))
+	}
+	if departmentId != 0 {
+		query = query.Where("users.department_id = ?", departmentId)
+	}
+
+	err := query.Order("certificates.expiry_date ASC, certificates.id ASC").Find(&certificates).Error
+	return certificates, err
+}
+
+// saveEvidencedRecord creates record, or stores its status and credited
+// hours, and refreshes the plan's attendees when the record took a seat.
+func saveEvidencedRecord(tx *gorm.DB, record *model.Record) error {
+	if record.ID == 0 {
+		if err := tx.Create(record).Error; err != nil {
+			return err
+		}
+		if err := bumpSequence(tx, record.TrainingPlanID); err != nil {
+			return err
+		}
+	} else {
+		var old model.Record
+		if err := tx.Select("status").First(&old, record.ID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&model.Record{}).
+			Where("id = ?", record.ID).
+			Updates(map[string]interface{}{
+				"status":         record.Status,
+				"credited_hours": record.CreditedHours,
+			}).Error; err != nil {
+			return err
+		}
+
+		if old.Status == record.Status {
+			return nil
+		}
+		if err := bumpSequence(tx, record.TrainingPlanID); err != nil {
+			return err
+		}
+		if holdsSeat(old.Status) == holdsSeat(record.Status) {
+			return nil
+		}
+	}
+
+	var plan model.TrainingPlan
+	if err := tx.First(&plan, record.TrainingPlanID).Error; err != nil {
+		return err
+	}
+	return syncAttendees(tx, &plan)
+}
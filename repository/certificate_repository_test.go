@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+)
+
+const (
+	updateCertificates    = "UPDATE `certificates`"
+	insertCertificateVers = "INSERT INTO `certificate_versions`"
+)
+
+// updatesCertificate lets the guarded update of the certificate row affect
+// rows rows; every other write affects one.
+func updatesCertificate(rows int64) func(query string) int64 {
+	return func(query string) int64 {
+		if strings.HasPrefix(query, updateCertificates) {
+			return rows
+		}
+		return 1
+	}
+}
+
+// guardArgs returns the id, status and version the certificate update was
+// guarded by, the last three arguments of its WHERE clause.
+func guardArgs(t *testing.T, statement recordedStatement) []interface{} {
+	t.Helper()
+
+	if !strings.Contains(statement.query, "WHERE id = ? AND status = ? AND version = ?") {
+		t.Fatalf("certificate update is not guarded by status and version: %s", statement.query)
+	}
+	args := statement.args[len(statement.args)-3:]
+	return []interface{}{args[0], args[1], args[2]}
+}
+
+func TestCertificateReviewGuard(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    int64
+		wantErr bool
+	}{
+		{"pending at the reviewed version", 1, false},
+		{"resubmitted or reviewed by someone else", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, rec := newRecordingDB(t, updatesCertificate(tt.rows))
+			repo := NewCertificateRepositoryImpl(db)
+
+			reviewer := uint(9)
+			now := time.Now()
+			reason := "blurry scan"
+			certificate := &model.Certificate{
+				ID:              4,
+				Version:         2,
+				Status:          model.CertRejected,
+				RejectionReason: &reason,
+				ReviewedByID:    &reviewer,
+				ReviewedAt:      &now,
+			}
+
+			err := repo.Review(certificate, nil)
+
+			updates := rec.writes(updateCertificates)
+			if len(updates) != 1 {
+				t.Fatalf("%d certificate updates, want 1", len(updates))
+			}
+			want := []interface{}{int64(4), string(model.CertPending), int64(2)}
+			if got := guardArgs(t, updates[0]); !equalValues(got, want) {
+				t.Errorf("guarded by %v, want id, Pending and the reviewed version %v", got, want)
+			}
+
+			versions := rec.writes(insertCertificateVers)
+			if tt.wantErr {
+				assertBadRequest(t, err)
+				if len(versions) != 0 {
+					t.Error("a version was stored for a review that did not apply")
+				}
+				if rec.committed || !rec.rolledBack {
+					t.Error("transaction was not rolled back")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("review: %v", err)
+			}
+			if len(versions) != 1 || !strings.Contains(versions[0].query, "ON DUPLICATE KEY UPDATE") {
+				t.Errorf("version writes = %v, want one upsert of the reviewed version", versions)
+			}
+			if !rec.committed {
+				t.Error("transaction was not committed")
+			}
+		})
+	}
+}
+
+func TestCertificateResubmitGuard(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    int64
+		wantErr bool
+	}{
+		{"rejected at the previous version", 1, false},
+		{"resubmitted twice at once", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, rec := newRecordingDB(t, updatesCertificate(tt.rows))
+			repo := NewCertificateRepositoryImpl(db)
+
+			reason := "blurry scan"
+			previous := model.CertificateVersion{CertificateID: 4, Version: 2, Status: model.CertRejected, RejectionReason: &reason}
+			certificate := &model.Certificate{ID: 4, Version: 3, Status: model.CertPending, Image: "certificates/user_1/2.pdf"}
+
+			err := repo.Resubmit(certificate, previous)
+
+			updates := rec.writes(updateCertificates)
+			if len(updates) != 1 {
+				t.Fatalf("%d certificate updates, want 1", len(updates))
+			}
+			want := []interface{}{int64(4), string(model.CertRejected), int64(2)}
+			if got := guardArgs(t, updates[0]); !equalValues(got, want) {
+				t.Errorf("guarded by %v, want id, Rejected and the previous version %v", got, want)
+			}
+			if !containsValue(updates[0].args, int64(3)) {
+				t.Errorf("update args %v do not move the certificate to version 3", updates[0].args)
+			}
+
+			versions := rec.writes(insertCertificateVers)
+			if tt.wantErr {
+				assertBadRequest(t, err)
+				if len(versions) != 1 {
+					t.Errorf("%d version writes, want only the previous version before the guard", len(versions))
+				}
+				if rec.committed || !rec.rolledBack {
+					t.Error("transaction was not rolled back")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("resubmit: %v", err)
+			}
+			// the previous version is kept if missing, then the new one added
+			if len(versions) != 2 {
+				t.Fatalf("%d version writes, want 2", len(versions))
+			}
+			if !containsValue(versions[0].args, int64(2)) || !containsValue(versions[1].args, int64(3)) {
+				t.Errorf("versions written %v then %v, want 2 then 3", versions[0].args, versions[1].args)
+			}
+			if !rec.committed {
+				t.Error("transaction was not committed")
+			}
+		})
+	}
+}
+
+func assertBadRequest(t *testing.T, err error) {
+	t.Helper()
+	appErr, ok := err.(*helper.AppError)
+	if !ok || appErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("err = %v, want 400", err)
+	}
+}
+
+func equalValues(got, want []interface{}) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsValue(values []driver.Value, want interface{}) bool {
+	for _, value := range values {
+		if value == want {
+			return true
+		}
+	}
+	return false
+}
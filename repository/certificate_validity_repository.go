@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"training-plan-api/helper"
+	"training-plan-api/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CertificateValidityRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewCertificateValidityRepositoryImpl(db *gorm.DB) CertificateValidityRepository {
+	return &CertificateValidityRepositoryImpl{Db: db}
+}
+
+// FindAll implements CertificateValidityRepository.
+func (r *CertificateValidityRepositoryImpl) FindAll() ([]model.CertificateValidity, error) {
+	var validities []model.CertificateValidity
+	err := r.Db.Order("category ASC").Find(&validities).Error
+	return validities, err
+}
+
+// Save implements CertificateValidityRepository. It creates the validity of
+// the category or replaces its months.
+func (r *CertificateValidityRepositoryImpl) Save(validity *model.CertificateValidity) error {
+	return r.Db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "category"}},
+		DoUpdates: clause.AssignmentColumns([]string{"months", "updated_at"}),
+	}).Create(validity).Error
+}
+
+// Delete implements CertificateValidityRepository.
+func (r *CertificateValidityRepositoryImpl) Delete(category model.TrainingPlanCategory) error {
+	result := r.Db.Where("category = ?", category).Delete(&model.CertificateValidity{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return helper.NotFound("certificate validity not found")
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"errors"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EnrollmentRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewEnrollmentRepositoryImpl(db *gorm.DB) EnrollmentRepository {
+	return &EnrollmentRepositoryImpl{Db: db}
+}
+
+// Create implements EnrollmentRepository.
+func (r *EnrollmentRepositoryImpl) Create(req *model.EnrollmentRequest, event *model.EnrollmentRequestEvent) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Events").Create(req).Error; err != nil {
+			return err
+		}
+		event.EnrollmentRequestID = req.ID
+		return tx.Create(event).Error
+	})
+}
+
+// FindById implements EnrollmentRepository.
+func (r *EnrollmentRepositoryImpl) FindById(id uint) (*model.EnrollmentRequest, error) {
+	var req model.EnrollmentRequest
+
+	err := r.Db.
+		Preload("User").
+		Preload("User.Department").
+		Preload("TrainingPlan").
+		Preload("Events", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC, id ASC")
+		}).
+		First(&req, id).
+		Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, helper.NotFound("enrollment request not found")
+	}
+
+	return &req, err
+}
+
+// ExistsOpen implements EnrollmentRepository.
+func (r *EnrollmentRepositoryImpl) ExistsOpen(userId uint, trainingPlanId uint) bool {
+	var count int64
+	r.Db.Model(&model.EnrollmentRequest{}).
+		Where("user_id = ? AND training_plan_id = ? AND status IN ?", userId, trainingPlanId,
+			[]model.EnrollmentStatus{model.EnrollmentPending, model.EnrollmentEscalated}).
+		Count(&count)
+
+	return count > 0
+}
+
+// FindPaginated implements EnrollmentRepository.
+func (r *EnrollmentRepositoryImpl) FindPaginated(filter EnrollmentFilter, offset, limit int) ([]model.EnrollmentRequest, int64, error) {
+	var requests []model.EnrollmentRequest
+	var total int64
+
+	query := r.Db.
+		Model(&model.EnrollmentRequest{}).
+		Preload("User").
+		Preload("User.Department").
+		Preload("TrainingPlan").
+		Joins("JOIN users ON users.id = enrollment_requests.user_id")
+
+	if filter.UserID != nil {
+		query = query.Where("enrollment_requests.user_id = ?", *filter.UserID)
+	}
+	if filter.DepartmentID != nil {
+		query = query.Where("users.department_id = ?", *filter.DepartmentID)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("enrollment_requests.status IN ?", filter.Statuses)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Order("enrollment_requests.created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&requests).
+		Error
+
+	return requests, total, err
+}
+
+// Transition implements EnrollmentRepository. The status update only
+// applies while the request is still in event.FromStatus, so two reviewers
+// acting at once cannot both succeed.
+func (r *EnrollmentRepositoryImpl) Transition(req *model.EnrollmentRequest, event *model.EnrollmentRequestEvent) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		return transitionEnrollment(tx, req, event)
+	})
+}
+
+// Approve implements EnrollmentRepository. The requester is registered on
+// the plan and the request moves to Approved in one transaction, so a
+// request changed by someone else in the meantime leaves no registration
+// behind. The records created for the requester are returned, none when
+// they were already on the plan, together with the waitlisted records that
+// were promoted on the way.
+func (r *EnrollmentRepositoryImpl) Approve(req *model.EnrollmentRequest, event *model.EnrollmentRequestEvent) ([]model.Record, []model.Record, error) {
+	var created, promoted []model.Record
+
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		created, promoted, err = registerWithCapacity(tx, req.TrainingPlanID, []uint{req.UserID})
+		if err != nil {
+			return err
+		}
+
+		if len(created) > 0 {
+			req.RecordID = &created[0].ID
+		} else {
+			var existing model.Record
+			if err := tx.Select("id").
+				Where("user_id = ? AND training_plan_id = ?", req.UserID, req.TrainingPlanID).
+				First(&existing).Error; err != nil {
+				return err
+			}
+			req.RecordID = &existing.ID
+		}
+
+		return transitionEnrollment(tx, req, event)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return created, promoted, nil
+}
+
+// Reject implements EnrollmentRepository. Rejecting an approved request
+// deletes the registration it created, hands the freed seat to the
+// waitlist and moves the request in one transaction, so a request changed
+// by someone else in the meantime keeps its registration. The promoted
+// records are returned.
+func (r *EnrollmentRepositoryImpl) Reject(req *model.EnrollmentRequest, event *model.EnrollmentRequestEvent) ([]model.Record, error) {
+	var promoted []model.Record
+
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		if req.RecordID != nil {
+			var record model.Record
+			err := tx.First(&record, *req.RecordID).Error
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				// the registration was already removed
+			case err != nil:
+				return err
+			default:
+				if promoted, err = deleteAndPromote(tx, &record); err != nil {
+					return err
+				}
+			}
+			req.RecordID = nil
+		}
+
+		return transitionEnrollment(tx, req, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return promoted, nil
+}
+
+// transitionEnrollment applies the status update of Transition inside tx.
+func transitionEnrollment(tx *gorm.DB, req *model.EnrollmentRequest, event *model.EnrollmentRequestEvent) error {
+	result := tx.Model(&model.EnrollmentRequest{}).
+		Where("id = ? AND status = ?", req.ID, event.FromStatus).
+		Updates(map[string]interface{}{
+			"status":          req.Status,
+			"decided_by_id":   req.DecidedByID,
+			"decision_reason": req.DecisionReason,
+			"decided_at":      req.DecidedAt,
+			"record_id":       req.RecordID,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return helper.BadRequest("enrollment request was changed by someone else, please reload")
+	}
+
+	event.EnrollmentRequestID = req.ID
+	return tx.Create(event).Error
+}
+
+// CancelOpenByTrainingPlan cancels every open request of a training plan,
+// e.g. when the plan itself is cancelled. One event is written per request
+// from the given template; the ids of the cancelled requests are returned.
+func (r *EnrollmentRepositoryImpl) CancelOpenByTrainingPlan(trainingPlanId uint, event model.EnrollmentRequestEvent) ([]uint, error) {
+	var ids []uint
+
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		var open []model.EnrollmentRequest
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("training_plan_id = ? AND status IN ?", trainingPlanId,
+				[]model.EnrollmentStatus{model.EnrollmentPending, model.EnrollmentEscalated}).
+			Find(&open).Error; err != nil {
+			return err
+		}
+		if len(open) == 0 {
+			return nil
+		}
+
+		events := make([]model.EnrollmentRequestEvent, 0, len(open))
+		for _, req := range open {
+			ids = append(ids, req.ID)
+
+			e := event
+			e.EnrollmentRequestID = req.ID
+			e.FromStatus = req.Status
+			e.ToStatus = model.EnrollmentCancelled
+			events = append(events, e)
+		}
+
+		if err := tx.Model(&model.EnrollmentRequest{}).
+			Where("id IN ?", ids).
+			Update("status", model.EnrollmentCancelled).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&events).Error
+	})
+
+	return ids, err
+}
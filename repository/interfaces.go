@@ -1,7 +1,9 @@
 package repository
 
 import (
+	"time"
 	"training-plan-api/data/request"
+	"training-plan-api/helper"
 	"training-plan-api/model"
 )
 
@@ -13,14 +15,91 @@ type DepartmentStaffCount struct {
 	Staffs    []model.User `gorm:"-"`
 }
 
+// TrainingPlanSeatCount summarises the records of one training plan.
+// Registered counts every record holding a seat, i.e. all records that are
+// neither waitlisted nor cancelled.
+type TrainingPlanSeatCount struct {
+	TrainingPlanID uint
+	Registered     int64
+	Attended       int64
+	Waitlisted     int64
+	Cancelled      int64
+}
+
 type TrainingPlanRepository interface {
 	Save(trainingPlan *model.TrainingPlan) error
 	FindById(id int) (*model.TrainingPlan, error)
-	FindPaginated(offset, limit int) ([]model.TrainingPlan, int64, error)
+	FindPaginated(statuses []model.TrainingPlanStatus, offset, limit int) ([]model.TrainingPlan, int64, error)
+	FindUpcomingPaginated(from time.Time, offset, limit int) ([]model.TrainingPlan, int64, error)
 	Update(trainingPlan *model.TrainingPlan) error
+	Transition(id int, to model.TrainingPlanStatus, reason *string, webhooks ...model.WebhookMessage) ([]model.Record, error)
+	SetCalendarEventID(id int, eventID *string) error
+	AttachCalendarEvent(id int, eventID string) (bool, error)
+	FindOnCalendar(from time.Time) ([]model.TrainingPlan, error)
+	FindPublishedOn(date time.Time) ([]model.TrainingPlan, error)
+	FindEndedOn(date time.Time) ([]model.TrainingPlan, error)
+	FindUpcomingByCategory(category model.TrainingPlanCategory, from time.Time) ([]model.TrainingPlan, error)
+	SaveWithSessions(trainingPlan *model.TrainingPlan, sessions []model.TrainingSession) error
+	FindBySeries(seriesId uint) ([]model.TrainingPlan, error)
+	ExistsInSeries(seriesId uint, date time.Time) bool
 	Delete(id int) error
 }
 
+type NotificationRepository interface {
+	FindPreferences(userId uint) ([]model.NotificationPreference, error)
+	FindOptedOut(userIds []uint, event model.NotificationEvent, channel model.NotificationChannel) ([]uint, error)
+	SavePreferences(prefs []model.NotificationPreference) error
+	Enqueue(deliveries []model.NotificationDelivery) error
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]model.NotificationDelivery, error)
+	MarkSent(id uint, attempts int) error
+	MarkRetry(id uint, attempts int, nextAttemptAt time.Time, lastError string) error
+	MarkFailed(id uint, attempts int, lastError string) error
+	CreateInbox(notifications []model.Notification) error
+	FindInboxPaginated(userId uint, unreadOnly bool, offset, limit int) ([]model.Notification, int64, error)
+	CountUnread(userId uint) (int64, error)
+	MarkRead(userId uint, id uint) error
+	MarkAllRead(userId uint) (int64, error)
+}
+
+type CalendarFeedTokenRepository interface {
+	Replace(token *model.CalendarFeedToken) error
+	FindByTokenHash(tokenHash string) (*model.CalendarFeedToken, error)
+	FindByUser(userId uint) ([]model.CalendarFeedToken, error)
+	Delete(userId uint, scope model.CalendarFeedScope) error
+	Touch(id uint, usedAt time.Time) error
+}
+
+type CalendarOutboxRepository interface {
+	Enqueue(trainingPlanId int, op model.CalendarOperation, eventID *string) error
+	FindById(id uint) (*model.CalendarOutbox, error)
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]model.CalendarOutbox, error)
+	MarkDone(id uint, attempts int) error
+	MarkRetry(id uint, attempts int, nextAttemptAt time.Time, lastError string) error
+	MarkFailed(id uint, attempts int, lastError string) error
+	Requeue(id uint) error
+	FindFailedPaginated(offset, limit int) ([]model.CalendarOutbox, int64, error)
+}
+
+type TrainingPlanSeriesRepository interface {
+	Save(series *model.TrainingPlanSeries) error
+	FindById(id uint) (*model.TrainingPlanSeries, error)
+	FindPaginated(offset, limit int) ([]model.TrainingPlanSeries, int64, error)
+	FindActive() ([]model.TrainingPlanSeries, error)
+	Update(series *model.TrainingPlanSeries) error
+}
+
+type TrainingSessionRepository interface {
+	Save(session *model.TrainingSession) error
+	FindById(id uint) (*model.TrainingSession, error)
+	FindByTrainingPlan(trainingPlanId uint) ([]model.TrainingSession, error)
+	HasSessions(trainingPlanId uint) bool
+	Update(session *model.TrainingSession) error
+	Delete(id uint) error
+	FindAttendanceBySession(sessionId uint) ([]model.SessionAttendance, error)
+	FindAttendanceByTrainingPlan(trainingPlanId uint) ([]model.SessionAttendance, error)
+	SaveAttendance(attendance []model.SessionAttendance) error
+}
+
 type DepartmentRepository interface {
 	Save(department *model.Department) error
 	FindById(departmentId int) (*model.Department, error)
@@ -32,12 +111,18 @@ type DepartmentRepository interface {
 }
 
 type CertificateRepository interface {
-	Save(certificate *model.Certificate) error
+	Save(certificate *model.Certificate, webhooks ...model.WebhookMessage) error
 	FindById(id int) (*model.Certificate, error)
 	FindByUserId(userId int) ([]model.Certificate, error)
 	Delete(id int) error
 	FindAllPending(offset, limit int) ([]model.Certificate, int64, error)
-	UpdateStatus(id int, status model.CertificateStatus) error
+	Review(certificate *model.Certificate, record *model.Record, webhooks ...model.WebhookMessage) error
+	Resubmit(certificate *model.Certificate, previous model.CertificateVersion, webhooks ...model.WebhookMessage) error
+	FindVersions(certificateId uint) ([]model.CertificateVersion, error)
+	FindApprovedExternalByUser(userId uint) ([]model.Certificate, error)
+	FindApprovedExternal(req request.RecordFilterRequest) ([]model.Certificate, error)
+	FindExpiring(from *time.Time, to time.Time, departmentId int) ([]model.Certificate, error)
+	CountPendingSince(before time.Time) (int64, error)
 }
 
 type UserRepository interface {
@@ -48,6 +133,7 @@ type UserRepository interface {
 	Delete(userId uint) error
 	FindById(userId uint) (*model.User, error)
 	FindByIdWithDepartment(userId uint) (*model.User, error)
+	FindByIds(userIds []uint) ([]model.User, error)
 	FindByEmail(email string) (*model.User, error)
 	FindByEmployeeID(employeeID string) (*model.User, error)
 	FindAllPaginated(offset, limit int) ([]model.User, int64, error)
@@ -55,15 +141,129 @@ type UserRepository interface {
 	ExistsByEmail(email string) bool
 	ExistsByEmployeeID(employeeID string) bool
 	FindAllWithFilters(params request.UserTableQueryParams) ([]model.User, int64, error)
+	ResetLoginFailures(userID uint) error
+	RegisterLoginFailure(userID uint, policy helper.LockoutPolicy) (*time.Time, error)
+	UpdatePassword(userID uint, hashedPassword string, mustChangePassword bool) error
+	UpdateTwoFactor(userID uint, secret string, enabled bool) error
+	AdvanceTOTPCounter(userID uint, counter int64) error
+	UpdateLanguage(userID uint, language string) error
+	FindActiveByRoles(roles []string, departmentId int) ([]model.User, error)
+}
+
+type CertificateValidityRepository interface {
+	FindAll() ([]model.CertificateValidity, error)
+	Save(validity *model.CertificateValidity) error
+	Delete(category model.TrainingPlanCategory) error
 }
 
 type RecordRepository interface {
 	Save(record *model.Record) error
 	FindById(id int) (*model.Record, error)
-	Update(record *model.Record) error
+	Update(record *model.Record, webhooks ...model.WebhookMessage) error
 	Delete(id int) error
 	Exists(userId uint, trainingPlanId uint) bool
+	FindByUserAndTrainingPlan(userId uint, trainingPlanId uint) (*model.Record, error)
+	FindByTrainingPlan(trainingPlanId uint) ([]model.Record, error)
+	UpdateAttendanceSummary(id uint, status model.RecordStatus, creditedHours float64, webhooks ...model.WebhookMessage) error
+	UpdateRSVPStatus(id uint, rsvpStatus *string) error
+	RegisterWithCapacity(trainingPlanId uint, userIds []uint) ([]model.Record, []model.Record, error)
+	DeleteAndPromote(id int) ([]model.Record, error)
+	PromoteWaitlisted(trainingPlanId uint) ([]model.Record, error)
+	CountSeats(trainingPlanIds []uint) (map[uint]TrainingPlanSeatCount, error)
 	FindByManagerDepartment(departmetnID int, offset, limit int) ([]model.Record, int64, error)
 	FindByUserId(userID uint, offset, limit int) ([]model.Record, int64, error)
+	SumAttendedHours(userID uint) (float64, error)
+	HasUpcomingInCategory(userId uint, category model.TrainingPlanCategory, from time.Time) bool
 	Search(req request.RecordFilterRequest) ([]model.Record, int64, error)
 }
+
+type RefreshTokenRepository interface {
+	Save(token *model.RefreshToken) error
+	FindByTokenHash(tokenHash string) (*model.RefreshToken, error)
+	Rotate(oldTokenID uint, newToken *model.RefreshToken) error
+	Revoke(id uint) error
+	RevokeFamily(familyID string) error
+	RevokeAllByUser(userID uint) error
+}
+
+type PasswordResetRepository interface {
+	Save(token *model.PasswordResetToken) error
+	FindByTokenHash(tokenHash string) (*model.PasswordResetToken, error)
+	MarkUsed(id uint) error
+	InvalidateByUser(userID uint) error
+}
+
+type TwoFactorChallengeRepository interface {
+	Save(challenge *model.TwoFactorChallenge) error
+	FindUsable(id string, maxFailures int) (*model.TwoFactorChallenge, error)
+	RegisterFailure(id string) error
+	Consume(id string, maxFailures int) error
+}
+
+type RecoveryCodeRepository interface {
+	ReplaceForUser(userID uint, codeHashes []string) error
+	Use(userID uint, codeHash string) error
+	CountUnused(userID uint) (int64, error)
+	DeleteByUser(userID uint) error
+}
+
+type RoleRepository interface {
+	FindAll() ([]model.AppRole, error)
+	FindByName(name string) (*model.AppRole, error)
+	ExistsByName(name string) bool
+	Save(role *model.AppRole) error
+	UpdateDescription(name string, description string) error
+	Delete(name string) error
+	ReplacePermissions(name string, permissions []model.Permission) error
+	FindAllRolePermissions() ([]model.RolePermission, error)
+	CountUsers(name string) (int64, error)
+}
+
+type AuditRepository interface {
+	Save(event *model.AuditEvent) error
+	Search(req request.AuditFilterRequest) ([]model.AuditEvent, int64, error)
+}
+
+// EnrollmentFilter narrows an enrollment request listing; nil fields match all.
+type EnrollmentFilter struct {
+	UserID       *uint
+	DepartmentID *int
+	Statuses     []model.EnrollmentStatus
+}
+
+type EnrollmentRepository interface {
+	Create(req *model.EnrollmentRequest, event *model.EnrollmentRequestEvent) error
+	FindById(id uint) (*model.EnrollmentRequest, error)
+	ExistsOpen(userId uint, trainingPlanId uint) bool
+	FindPaginated(filter EnrollmentFilter, offset, limit int) ([]model.EnrollmentRequest, int64, error)
+	Transition(req *model.EnrollmentRequest, event *model.EnrollmentRequestEvent) error
+	Approve(req *model.EnrollmentRequest, event *model.EnrollmentRequestEvent) ([]model.Record, []model.Record, error)
+	Reject(req *model.EnrollmentRequest, event *model.EnrollmentRequestEvent) ([]model.Record, error)
+	CancelOpenByTrainingPlan(trainingPlanId uint, event model.EnrollmentRequestEvent) ([]uint, error)
+}
+
+type ScheduledJobRepository interface {
+	Ensure(job *model.ScheduledJob) error
+	FindAll() ([]model.ScheduledJob, error)
+	FindByName(name string) (*model.ScheduledJob, error)
+	Acquire(name, owner string, now time.Time, lease time.Duration) (bool, error)
+	Release(name, owner string, finishedAt, nextRunAt time.Time, runErr error) error
+	Update(name string, schedule string, enabled bool, nextRunAt time.Time) error
+	Trigger(name string, now time.Time) error
+}
+
+type WebhookRepository interface {
+	Save(subscription *model.WebhookSubscription) error
+	Update(subscription *model.WebhookSubscription) error
+	Delete(id uint) error
+	FindById(id uint) (*model.WebhookSubscription, error)
+	FindAll() ([]model.WebhookSubscription, error)
+	FindActiveByEvent(event model.WebhookEvent) ([]model.WebhookSubscription, error)
+	Enqueue(deliveries []model.WebhookDelivery) error
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]model.WebhookDelivery, error)
+	MarkSent(id uint, attempts int, result helper.WebhookResult) error
+	MarkRetry(id uint, attempts int, nextAttemptAt time.Time, result helper.WebhookResult, lastError string) error
+	MarkFailed(id uint, attempts int, result helper.WebhookResult, lastError string) error
+	FindDeliveryById(id uint) (*model.WebhookDelivery, error)
+	FindDeliveriesPaginated(subscriptionId uint, status model.WebhookDeliveryStatus, offset, limit int) ([]model.WebhookDelivery, int64, error)
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"errors"
+	"time"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewNotificationRepositoryImpl(db *gorm.DB) NotificationRepository {
+	return &NotificationRepositoryImpl{Db: db}
+}
+
+// FindPreferences implements NotificationRepository.
+func (r *NotificationRepositoryImpl) FindPreferences(userId uint) ([]model.NotificationPreference, error) {
+	var prefs []model.NotificationPreference
+	err := r.Db.Where("user_id = ?", userId).Find(&prefs).Error
+	return prefs, err
+}
+
+// FindOptedOut implements NotificationRepository. It returns the users
+// among userIds who turned event off on channel.
+func (r *NotificationRepositoryImpl) FindOptedOut(userIds []uint, event model.NotificationEvent, channel model.NotificationChannel) ([]uint, error) {
+	var ids []uint
+	if len(userIds) == 0 {
+		return ids, nil
+	}
+
+	err := r.Db.Model(&model.NotificationPreference{}).
+		Where("user_id IN ? AND event = ? AND channel = ? AND enabled = ?", userIds, event, channel, false).
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// SavePreferences implements NotificationRepository. Existing preferences
+// for the same event and channel are overwritten.
+func (r *NotificationRepositoryImpl) SavePreferences(prefs []model.NotificationPreference) error {
+	if len(prefs) == 0 {
+		return nil
+	}
+
+	return r.Db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "event"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(&prefs).Error
+}
+
+// Enqueue implements NotificationRepository. Deliveries whose DedupKey was
+// queued before are skipped.
+func (r *NotificationRepositoryImpl) Enqueue(deliveries []model.NotificationDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.Db.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+}
+
+// ClaimDue implements NotificationRepository. Claimed rows are pushed
+// lease into the future, so a second worker skips them while they are
+// being sent and picks them up again if this worker dies.
+func (r *NotificationRepositoryImpl) ClaimDue(now time.Time, lease time.Duration, limit int) ([]model.NotificationDelivery, error) {
+	var deliveries []model.NotificationDelivery
+
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", model.NotificationPending, now).
+			Order("id ASC").
+			Limit(limit).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(deliveries))
+		for _, delivery := range deliveries {
+			ids = append(ids, delivery.ID)
+		}
+
+		return tx.Model(&model.NotificationDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+
+	return deliveries, err
+}
+
+// MarkSent implements NotificationRepository.
+func (r *NotificationRepositoryImpl) MarkSent(id uint, attempts int) error {
+	now := time.Now()
+	return r.Db.Model(&model.NotificationDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     model.NotificationSent,
+			"attempts":   attempts,
+			"last_error": "",
+			"sent_at":    &now,
+		}).Error
+}
+
+// MarkRetry implements NotificationRepository.
+func (r *NotificationRepositoryImpl) MarkRetry(id uint, attempts int, nextAttemptAt time.Time, lastError string) error {
+	return r.Db.Model(&model.NotificationDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      lastError,
+		}).Error
+}
+
+// MarkFailed implements NotificationRepository.
+func (r *NotificationRepositoryImpl) MarkFailed(id uint, attempts int, lastError string) error {
+	return r.Db.Model(&model.NotificationDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     model.NotificationFailed,
+			"attempts":   attempts,
+			"last_error": lastError,
+		}).Error
+}
+
+// CreateInbox implements NotificationRepository. Notifications whose
+// DedupKey was stored before are skipped and keep a zero ID. Rows are
+// inserted one by one so the IDs of the others stay correct.
+func (r *NotificationRepositoryImpl) CreateInbox(notifications []model.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		for i := range notifications {
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&notifications[i])
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				notifications[i].ID = 0
+			}
+		}
+		return nil
+	})
+}
+
+// FindInboxPaginated implements NotificationRepository.
+func (r *NotificationRepositoryImpl) FindInboxPaginated(userId uint, unreadOnly bool, offset, limit int) ([]model.Notification, int64, error) {
+	var notifications []model.Notification
+	var total int64
+
+	query := r.Db.Model(&model.Notification{}).Where("user_id = ?", userId)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&notifications).Error
+	return notifications, total, err
+}
+
+// CountUnread implements NotificationRepository.
+func (r *NotificationRepositoryImpl) CountUnread(userId uint) (int64, error) {
+	var count int64
+	err := r.Db.Model(&model.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userId).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkRead implements NotificationRepository. Only the owner's
+// notifications can be marked; marking a read notification again is a
+// no-op.
+func (r *NotificationRepositoryImpl) MarkRead(userId uint, id uint) error {
+	var notification model.Notification
+	if err := r.Db.Where("id = ? AND user_id = ?", id, userId).First(&notification).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return helper.NotFound("notification not found")
+		}
+		return err
+	}
+	if notification.ReadAt != nil {
+		return nil
+	}
+
+	return r.Db.Model(&model.Notification{}).
+		Where("id = ?", id).
+		Update("read_at", time.Now()).Error
+}
+
+// MarkAllRead implements NotificationRepository. It returns how many
+// notifications were marked.
+func (r *NotificationRepositoryImpl) MarkAllRead(userId uint) (int64, error) {
+	result := r.Db.Model(&model.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userId).
+		Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"errors"
+	"time"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+
+	"gorm.io/gorm"
+)
+
+type PasswordResetRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewPasswordResetRepositoryImpl(db *gorm.DB) PasswordResetRepository {
+	return &PasswordResetRepositoryImpl{Db: db}
+}
+
+// Save implements PasswordResetRepository.
+func (r *PasswordResetRepositoryImpl) Save(token *model.PasswordResetToken) error {
+	return r.Db.Create(token).Error
+}
+
+// FindByTokenHash implements PasswordResetRepository.
+func (r *PasswordResetRepositoryImpl) FindByTokenHash(tokenHash string) (*model.PasswordResetToken, error) {
+	var token model.PasswordResetToken
+
+	err := r.Db.Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helper.BadRequest("Invalid or expired reset token")
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// MarkUsed implements PasswordResetRepository. It fails if the token was
+// consumed concurrently.
+func (r *PasswordResetRepositoryImpl) MarkUsed(id uint) error {
+	result := r.Db.Model(&model.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return helper.BadRequest("Invalid or expired reset token")
+	}
+
+	return nil
+}
+
+// InvalidateByUser implements PasswordResetRepository.
+func (r *PasswordResetRepositoryImpl) InvalidateByUser(userID uint) error {
+	return r.Db.Model(&model.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
@@ -2,11 +2,14 @@ package repository
 
 import (
 	"errors"
+	"sort"
+	"time"
 	"training-plan-api/data/request"
 	"training-plan-api/helper"
 	"training-plan-api/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type RecordRepositoryImpl struct {
@@ -93,6 +96,30 @@ func (r *RecordRepositoryImpl) FindByUserId(userID uint, offset int, limit int)
 }
 
 
+// SumAttendedHours implements RecordRepository.
+func (r *RecordRepositoryImpl) SumAttendedHours(userID uint) (float64, error) {
+	var hours float64
+	err := r.Db.Model(&model.Record{}).
+		Select("COALESCE(SUM(credited_hours), 0)").
+		Where("user_id = ? AND status = ?", userID, model.RecordStatusAttended).
+		Scan(&hours).Error
+	return hours, err
+}
+
+// HasUpcomingInCategory implements RecordRepository. It reports whether the
+// user holds a seat on, or is waitlisted for, a plan of category starting
+// on or after from.
+func (r *RecordRepositoryImpl) HasUpcomingInCategory(userId uint, category model.TrainingPlanCategory, from time.Time) bool {
+	var count int64
+	r.Db.Model(&model.Record{}).
+		Joins("JOIN training_plans ON training_plans.id = records.training_plan_id").
+		Where("records.user_id = ? AND records.status <> ?", userId, model.RecordStatusCancelled).
+		Where("training_plans.category = ? AND training_plans.date >= ?", category, from.Format("2006-01-02")).
+		Count(&count)
+
+	return count > 0
+}
+
 // FindByManagerDepartment implements RecordRepository.
 func (r *RecordRepositoryImpl) FindByManagerDepartment(departmetnID int, offset int, limit int) ([]model.Record, int64, error) {
 	var records []model.Record
@@ -136,6 +163,63 @@ func (r *RecordRepositoryImpl) Exists(userId uint, trainingPlanId uint) bool {
 	return count > 0
 }
 
+// FindByUserAndTrainingPlan implements RecordRepository.
+func (r *RecordRepositoryImpl) FindByUserAndTrainingPlan(userId uint, trainingPlanId uint) (*model.Record, error) {
+	var record model.Record
+
+	err := r.Db.
+		Where("user_id = ? AND training_plan_id = ?", userId, trainingPlanId).
+		First(&record).
+		Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, helper.NotFound("record not found")
+	}
+
+	return &record, err
+}
+
+// FindByTrainingPlan implements RecordRepository.
+func (r *RecordRepositoryImpl) FindByTrainingPlan(trainingPlanId uint) ([]model.Record, error) {
+	var records []model.Record
+
+	err := r.Db.
+		Preload("User").
+		Preload("User.Department").
+		Where("training_plan_id = ?", trainingPlanId).
+		Order("id ASC").
+		Find(&records).
+		Error
+
+	return records, err
+}
+
+// UpdateAttendanceSummary implements RecordRepository.
+func (r *RecordRepositoryImpl) UpdateAttendanceSummary(id uint, status model.RecordStatus, creditedHours float64, webhooks ...model.WebhookMessage) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		var old model.Record
+		if err := tx.Select("status", "training_plan_id").First(&old, id).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&model.Record{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{
+				"status":         status,
+				"credited_hours": creditedHours,
+			}).Error; err != nil {
+			return err
+		}
+
+		if old.Status != status {
+			if err := bumpSequence(tx, old.TrainingPlanID); err != nil {
+				return err
+			}
+		}
+		return enqueueWebhooks(tx, webhooks)
+	})
+}
+
 // FindById implements RecordRepository.
 func (r *RecordRepositoryImpl) FindById(id int) (*model.Record, error) {
 	var record model.Record
@@ -160,6 +244,354 @@ func (r *RecordRepositoryImpl) Save(record *model.Record) error {
 }
 
 // Update implements RecordRepository.
-func (r *RecordRepositoryImpl) Update(record *model.Record) error {
-	return r.Db.Save(record).Error
+func (r *RecordRepositoryImpl) Update(record *model.Record, webhooks ...model.WebhookMessage) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		var old model.Record
+		if err := tx.Select("status").First(&old, record.ID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Save(record).Error; err != nil {
+			return err
+		}
+		if err := enqueueWebhooks(tx, webhooks); err != nil {
+			return err
+		}
+
+		if old.Status == record.Status {
+			return nil
+		}
+		if err := bumpSequence(tx, record.TrainingPlanID); err != nil {
+			return err
+		}
+		if holdsSeat(old.Status) == holdsSeat(record.Status) {
+			return nil
+		}
+
+		var plan model.TrainingPlan
+		if err := tx.First(&plan, record.TrainingPlanID).Error; err != nil {
+			return err
+		}
+		return syncAttendees(tx, &plan)
+	})
+}
+
+// UpdateRSVPStatus implements RecordRepository.
+func (r *RecordRepositoryImpl) UpdateRSVPStatus(id uint, rsvpStatus *string) error {
+	return r.Db.Model(&model.Record{}).
+		Where("id = ?", id).
+		Update("rsvp_status", rsvpStatus).Error
+}
+
+// RegisterWithCapacity registers users on a training plan in one
+// transaction. The plan row is locked so concurrent registrations cannot
+// oversell seats; users beyond NumberOfPerson are waitlisted and users
+// already on the plan are skipped. A NumberOfPerson of zero means unlimited.
+// Waitlisted records that took a freed seat first are returned as promoted.
+func (r *RecordRepositoryImpl) RegisterWithCapacity(trainingPlanId uint, userIds []uint) ([]model.Record, []model.Record, error) {
+	var created, promoted []model.Record
+
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		created, promoted, err = registerWithCapacity(tx, trainingPlanId, userIds)
+		return err
+	})
+
+	return created, promoted, err
+}
+
+// registerWithCapacity does the work of RegisterWithCapacity inside tx, so
+// other changes can commit together with the registration.
+func registerWithCapacity(tx *gorm.DB, trainingPlanId uint, userIds []uint) ([]model.Record, []model.Record, error) {
+	plan, err := lockTrainingPlan(tx, trainingPlanId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if plan.Status != model.TrainingPlanPublished {
+		return nil, nil, helper.BadRequest("training plan is not open for registration")
+	}
+
+	// anyone already queued gets a freed seat before new registrants
+	promoted, err := promoteWaitlisted(tx, plan)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var existing []uint
+	if err := tx.Model(&model.Record{}).
+		Where("training_plan_id = ? AND user_id IN ?", trainingPlanId, userIds).
+		Pluck("user_id", &existing).Error; err != nil {
+		return nil, nil, err
+	}
+
+	occupied, err := countOccupiedSeats(tx, trainingPlanId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	created := assignSeats(plan, occupied, userIds, existing)
+	seated := len(promoted)
+	for i := range created {
+		if err := tx.Create(&created[i]).Error; err != nil {
+			return nil, nil, err
+		}
+		if holdsSeat(created[i].Status) {
+			seated++
+		}
+	}
+
+	if len(created) > 0 || len(promoted) > 0 {
+		if err := bumpSequence(tx, trainingPlanId); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// new attendees are invited on the calendar event
+	if seated > 0 {
+		if err := syncAttendees(tx, plan); err != nil {
+			return nil, nil, err
+		}
+	}
+	return created, promoted, nil
+}
+
+// assignSeats builds the records of new registrants in the order given:
+// they take the plan's free seats and are waitlisted once it is full.
+// Users already on the plan, or listed twice, get no record.
+func assignSeats(plan *model.TrainingPlan, occupied int64, userIds []uint, existing []uint) []model.Record {
+	skip := make(map[uint]bool, len(existing))
+	for _, id := range existing {
+		skip[id] = true
+	}
+
+	var records []model.Record
+	for _, userId := range userIds {
+		if skip[userId] {
+			continue
+		}
+		skip[userId] = true
+
+		record := model.Record{
+			UserID:         userId,
+			TrainingPlanID: uint(plan.ID),
+			Status:         model.RecordStatusRegister,
+		}
+		if plan.NumberOfPerson > 0 && occupied >= int64(plan.NumberOfPerson) {
+			record.Status = model.RecordStatusWaitlisted
+		} else {
+			occupied++
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// DeleteAndPromote deletes a record and, if that frees a seat, promotes
+// the longest-waiting waitlisted records of the same plan.
+func (r *RecordRepositoryImpl) DeleteAndPromote(id int) ([]model.Record, error) {
+	var promoted []model.Record
+
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		var record model.Record
+		if err := tx.First(&record, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return helper.NotFound("record not found")
+			}
+			return err
+		}
+
+		var err error
+		promoted, err = deleteAndPromote(tx, &record)
+		return err
+	})
+
+	return promoted, err
+}
+
+// deleteAndPromote does the work of DeleteAndPromote inside tx, so other
+// changes can commit together with the deletion.
+func deleteAndPromote(tx *gorm.DB, record *model.Record) ([]model.Record, error) {
+	plan, err := lockTrainingPlan(tx, record.TrainingPlanID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Where("record_id = ?", record.ID).Delete(&model.SessionAttendance{}).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.Delete(&model.Record{}, record.ID).Error; err != nil {
+		return nil, err
+	}
+
+	if err := bumpSequence(tx, record.TrainingPlanID); err != nil {
+		return nil, err
+	}
+
+	promoted, err := promoteWaitlisted(tx, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	// the attendee list of the calendar event changed
+	if holdsSeat(record.Status) || len(promoted) > 0 {
+		if err := syncAttendees(tx, plan); err != nil {
+			return nil, err
+		}
+	}
+	return promoted, nil
+}
+
+// PromoteWaitlisted fills any free seats of a plan from its waitlist, e.g.
+// after its capacity was raised.
+func (r *RecordRepositoryImpl) PromoteWaitlisted(trainingPlanId uint) ([]model.Record, error) {
+	var promoted []model.Record
+
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		plan, err := lockTrainingPlan(tx, trainingPlanId)
+		if err != nil {
+			return err
+		}
+
+		promoted, err = promoteWaitlisted(tx, plan)
+		if err != nil || len(promoted) == 0 {
+			return err
+		}
+		if err := bumpSequence(tx, trainingPlanId); err != nil {
+			return err
+		}
+		return syncAttendees(tx, plan)
+	})
+
+	return promoted, err
+}
+
+// CountSeats implements RecordRepository.
+func (r *RecordRepositoryImpl) CountSeats(trainingPlanIds []uint) (map[uint]TrainingPlanSeatCount, error) {
+	counts := make(map[uint]TrainingPlanSeatCount, len(trainingPlanIds))
+	if len(trainingPlanIds) == 0 {
+		return counts, nil
+	}
+
+	var rows []TrainingPlanSeatCount
+	err := r.Db.Model(&model.Record{}).
+		Select(
+			"training_plan_id, "+
+				"SUM(CASE WHEN status NOT IN ? THEN 1 ELSE 0 END) AS registered, "+
+				"SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS attended, "+
+				"SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS waitlisted, "+
+				"SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS cancelled",
+			[]model.RecordStatus{model.RecordStatusWaitlisted, model.RecordStatusCancelled},
+			model.RecordStatusAttended,
+			model.RecordStatusWaitlisted,
+			model.RecordStatusCancelled,
+		).
+		Where("training_plan_id IN ?", trainingPlanIds).
+		Group("training_plan_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.TrainingPlanID] = row
+	}
+	return counts, nil
+}
+
+func lockTrainingPlan(tx *gorm.DB, trainingPlanId uint) (*model.TrainingPlan, error) {
+	var plan model.TrainingPlan
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&plan, trainingPlanId).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helper.NotFound("training plan not found")
+		}
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func countOccupiedSeats(tx *gorm.DB, trainingPlanId uint) (int64, error) {
+	var occupied int64
+	err := tx.Model(&model.Record{}).
+		Where("training_plan_id = ? AND status NOT IN ?", trainingPlanId,
+			[]model.RecordStatus{model.RecordStatusWaitlisted, model.RecordStatusCancelled}).
+		Count(&occupied).Error
+	return occupied, err
+}
+
+// holdsSeat reports whether a record is on the plan's attendee list.
+func holdsSeat(status model.RecordStatus) bool {
+	return status != model.RecordStatusWaitlisted && status != model.RecordStatusCancelled
+}
+
+// syncAttendees queues an update of the plan's calendar event after its
+// attendee list changed. Only published plans are on the calendar.
+func syncAttendees(tx *gorm.DB, plan *model.TrainingPlan) error {
+	if plan.Status != model.TrainingPlanPublished {
+		return nil
+	}
+	return enqueueCalendarSync(tx, plan.ID, model.CalendarOpUpsert, nil)
+}
+
+// promoteWaitlisted must run inside a transaction holding the plan lock.
+func promoteWaitlisted(tx *gorm.DB, plan *model.TrainingPlan) ([]model.Record, error) {
+	var waitlisted []model.Record
+	if err := tx.
+		Where("training_plan_id = ? AND status = ?", plan.ID, model.RecordStatusWaitlisted).
+		Find(&waitlisted).Error; err != nil {
+		return nil, err
+	}
+	if len(waitlisted) == 0 {
+		return nil, nil
+	}
+
+	occupied, err := countOccupiedSeats(tx, uint(plan.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	waitlisted = nextInLine(plan, occupied, waitlisted)
+	if len(waitlisted) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uint, 0, len(waitlisted))
+	for i := range waitlisted {
+		ids = append(ids, waitlisted[i].ID)
+		waitlisted[i].Status = model.RecordStatusRegister
+	}
+
+	if err := tx.Model(&model.Record{}).
+		Where("id IN ?", ids).
+		Update("status", model.RecordStatusRegister).Error; err != nil {
+		return nil, err
+	}
+
+	return waitlisted, nil
+}
+
+// nextInLine returns the waitlisted records that get the plan's free seats,
+// longest waiting first. Records queued in the same instant go by id.
+func nextInLine(plan *model.TrainingPlan, occupied int64, waitlisted []model.Record) []model.Record {
+	queue := append([]model.Record(nil), waitlisted...)
+	sort.SliceStable(queue, func(i, j int) bool {
+		if !queue[i].CreatedAt.Equal(queue[j].CreatedAt) {
+			return queue[i].CreatedAt.Before(queue[j].CreatedAt)
+		}
+		return queue[i].ID < queue[j].ID
+	})
+
+	if plan.NumberOfPerson <= 0 {
+		return queue
+	}
+	free := int64(plan.NumberOfPerson) - occupied
+	if free <= 0 {
+		return nil
+	}
+	if free < int64(len(queue)) {
+		queue = queue[:free]
+	}
+	return queue
 }
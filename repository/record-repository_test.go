@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	"training-plan-api/model"
+)
+
+func TestAssignSeats(t *testing.T) {
+	const (
+		register   = model.RecordStatusRegister
+		waitlisted = model.RecordStatusWaitlisted
+	)
+
+	tests := []struct {
+		name     string
+		capacity int
+		occupied int64
+		userIds  []uint
+		existing []uint
+		want     map[uint]model.RecordStatus
+		order    []uint
+	}{
+		{
+			name:     "unlimited plan seats everyone",
+			capacity: 0,
+			occupied: 40,
+			userIds:  []uint{1, 2, 3},
+			want:     map[uint]model.RecordStatus{1: register, 2: register, 3: register},
+			order:    []uint{1, 2, 3},
+		},
+		{
+			name:     "free seats go in request order",
+			capacity: 3,
+			occupied: 1,
+			userIds:  []uint{5, 6, 7, 8},
+			want:     map[uint]model.RecordStatus{5: register, 6: register, 7: waitlisted, 8: waitlisted},
+			order:    []uint{5, 6, 7, 8},
+		},
+		{
+			name:     "full plan waitlists everyone",
+			capacity: 2,
+			occupied: 2,
+			userIds:  []uint{1, 2},
+			want:     map[uint]model.RecordStatus{1: waitlisted, 2: waitlisted},
+			order:    []uint{1, 2},
+		},
+		{
+			name:     "over-full plan waitlists everyone",
+			capacity: 2,
+			occupied: 3,
+			userIds:  []uint{1},
+			want:     map[uint]model.RecordStatus{1: waitlisted},
+			order:    []uint{1},
+		},
+		{
+			name:     "users on the plan are skipped without taking a seat",
+			capacity: 2,
+			occupied: 1,
+			userIds:  []uint{1, 2, 3},
+			existing: []uint{1},
+			want:     map[uint]model.RecordStatus{2: register, 3: waitlisted},
+			order:    []uint{2, 3},
+		},
+		{
+			name:     "repeated users are registered once",
+			capacity: 5,
+			userIds:  []uint{4, 4, 9, 4},
+			want:     map[uint]model.RecordStatus{4: register, 9: register},
+			order:    []uint{4, 9},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := &model.TrainingPlan{ID: 7, NumberOfPerson: tt.capacity}
+			records := assignSeats(plan, tt.occupied, tt.userIds, tt.existing)
+
+			order := make([]uint, 0, len(records))
+			for _, record := range records {
+				order = append(order, record.UserID)
+				if record.TrainingPlanID != 7 {
+					t.Errorf("user %d: training plan = %d, want 7", record.UserID, record.TrainingPlanID)
+				}
+				if record.Status != tt.want[record.UserID] {
+					t.Errorf("user %d: status = %s, want %s", record.UserID, record.Status, tt.want[record.UserID])
+				}
+			}
+			if !reflect.DeepEqual(order, tt.order) {
+				t.Errorf("registered %v, want %v", order, tt.order)
+			}
+		})
+	}
+}
+
+func TestNextInLine(t *testing.T) {
+	base := time.Date(2026, time.March, 1, 9, 0, 0, 0, time.UTC)
+	queued := func(id uint, minutes int) model.Record {
+		return model.Record{ID: id, Status: model.RecordStatusWaitlisted, CreatedAt: base.Add(time.Duration(minutes) * time.Minute)}
+	}
+
+	// listed out of order on purpose: 12 waited longest, 10 and 11 joined
+	// in the same instant
+	waitlist := []model.Record{queued(11, 5), queued(13, 9), queued(12, 1), queued(10, 5)}
+
+	tests := []struct {
+		name     string
+		capacity int
+		occupied int64
+		want     []uint
+	}{
+		{"no free seat", 20, 20, nil},
+		{"over-full plan", 20, 22, nil},
+		{"one free seat goes to the longest waiting", 20, 19, []uint{12}},
+		{"ties go by id", 20, 17, []uint{12, 10, 11}},
+		{"more seats than waiting", 20, 5, []uint{12, 10, 11, 13}},
+		{"unlimited plan promotes everyone", 0, 50, []uint{12, 10, 11, 13}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := &model.TrainingPlan{NumberOfPerson: tt.capacity}
+			promoted := nextInLine(plan, tt.occupied, waitlist)
+
+			var got []uint
+			for _, record := range promoted {
+				got = append(got, record.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("promoted %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if waitlist[0].ID != 11 {
+		t.Error("nextInLine reordered the caller's slice")
+	}
+}
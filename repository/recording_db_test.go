@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// recordingDB is a database/sql driver that answers every statement
+// without a database. It keeps the statements run and lets a test decide
+// how many rows each write affects, so the guards of a repository can be
+// checked without MySQL. Queries return no rows.
+type recordingDB struct {
+	mu         sync.Mutex
+	statements []recordedStatement
+	committed  bool
+	rolledBack bool
+
+	// rowsAffected answers writes; nil affects one row each
+	rowsAffected func(query string) int64
+}
+
+type recordedStatement struct {
+	query string
+	args  []driver.Value
+}
+
+// newRecordingDB opens a MySQL flavoured gorm.DB on top of a recordingDB.
+func newRecordingDB(t *testing.T, rowsAffected func(query string) int64) (*gorm.DB, *recordingDB) {
+	t.Helper()
+
+	rec := &recordingDB{rowsAffected: rowsAffected}
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sql.OpenDB(recordingConnector{rec}),
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("open recording db: %v", err)
+	}
+	return db, rec
+}
+
+// writes returns the statements that start with prefix, e.g. "UPDATE
+// `certificates`".
+func (r *recordingDB) writes(prefix string) []recordedStatement {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var found []recordedStatement
+	for _, statement := range r.statements {
+		if strings.HasPrefix(statement.query, prefix) {
+			found = append(found, statement)
+		}
+	}
+	return found
+}
+
+type recordingConnector struct {
+	db *recordingDB
+}
+
+func (c recordingConnector) Connect(context.Context) (driver.Conn, error) {
+	return &recordingConn{db: c.db}, nil
+}
+
+func (c recordingConnector) Driver() driver.Driver {
+	return recordingDriver{}
+}
+
+type recordingDriver struct{}
+
+func (recordingDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("recording driver is opened through its connector")
+}
+
+type recordingConn struct {
+	db *recordingDB
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("recording driver does not prepare statements")
+}
+
+func (c *recordingConn) Close() error {
+	return nil
+}
+
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	return recordingTx{db: c.db}, nil
+}
+
+func (c *recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	values := make([]driver.Value, 0, len(args))
+	for _, arg := range args {
+		values = append(values, arg.Value)
+	}
+	c.db.statements = append(c.db.statements, recordedStatement{query: query, args: values})
+
+	affected := int64(1)
+	if c.db.rowsAffected != nil {
+		affected = c.db.rowsAffected(query)
+	}
+	return recordingResult(affected), nil
+}
+
+func (c *recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return emptyRows{}, nil
+}
+
+type recordingTx struct {
+	db *recordingDB
+}
+
+func (tx recordingTx) Commit() error {
+	tx.db.mu.Lock()
+	defer tx.db.mu.Unlock()
+	tx.db.committed = true
+	return nil
+}
+
+func (tx recordingTx) Rollback() error {
+	tx.db.mu.Lock()
+	defer tx.db.mu.Unlock()
+	tx.db.rolledBack = true
+	return nil
+}
+
+// recordingResult reports the rows a write affected; inserts get id 1.
+type recordingResult int64
+
+func (r recordingResult) LastInsertId() (int64, error) { return 1, nil }
+func (r recordingResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string              { return nil }
+func (emptyRows) Close() error                   { return nil }
+func (emptyRows) Next(dest []driver.Value) error { return io.EOF }
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"time"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewRecoveryCodeRepositoryImpl(db *gorm.DB) RecoveryCodeRepository {
+	return &RecoveryCodeRepositoryImpl{Db: db}
+}
+
+// ReplaceForUser implements RecoveryCodeRepository.
+func (r *RecoveryCodeRepositoryImpl) ReplaceForUser(userID uint, codeHashes []string) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]model.RecoveryCode, 0, len(codeHashes))
+		for _, hash := range codeHashes {
+			codes = append(codes, model.RecoveryCode{UserID: userID, CodeHash: hash})
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+
+		return tx.Create(&codes).Error
+	})
+}
+
+// Use implements RecoveryCodeRepository. A code can only be consumed once.
+func (r *RecoveryCodeRepositoryImpl) Use(userID uint, codeHash string) error {
+	result := r.Db.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Limit(1).
+		Update("used_at", time.Now())
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return helper.Unauthorized("Invalid verification code")
+	}
+
+	return nil
+}
+
+// CountUnused implements RecoveryCodeRepository.
+func (r *RecoveryCodeRepositoryImpl) CountUnused(userID uint) (int64, error) {
+	var count int64
+	err := r.Db.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// DeleteByUser implements RecoveryCodeRepository.
+func (r *RecoveryCodeRepositoryImpl) DeleteByUser(userID uint) error {
+	return r.Db.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"errors"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+
+	"gorm.io/gorm"
+)
+
+type RefreshTokenRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewRefreshTokenRepositoryImpl(db *gorm.DB) RefreshTokenRepository {
+	return &RefreshTokenRepositoryImpl{Db: db}
+}
+
+// Save implements RefreshTokenRepository.
+func (r *RefreshTokenRepositoryImpl) Save(token *model.RefreshToken) error {
+	return r.Db.Create(token).Error
+}
+
+// FindByTokenHash implements RefreshTokenRepository.
+func (r *RefreshTokenRepositoryImpl) FindByTokenHash(tokenHash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+
+	err := r.Db.Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helper.Unauthorized("Invalid refresh token")
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// Rotate implements RefreshTokenRepository. The old token is revoked only if
+// it is still active, so two concurrent refreshes cannot both succeed.
+func (r *RefreshTokenRepositoryImpl) Rotate(oldTokenID uint, newToken *model.RefreshToken) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(newToken).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&model.RefreshToken{}).
+			Where("id = ? AND revoked = ?", oldTokenID, false).
+			Updates(map[string]interface{}{
+				"revoked":        true,
+				"replaced_by_id": newToken.ID,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return helper.Unauthorized("Refresh token already used")
+		}
+
+		return nil
+	})
+}
+
+// Revoke implements RefreshTokenRepository.
+func (r *RefreshTokenRepositoryImpl) Revoke(id uint) error {
+	return r.Db.Model(&model.RefreshToken{}).
+		Where("id = ?", id).
+		Update("revoked", true).Error
+}
+
+// RevokeFamily implements RefreshTokenRepository.
+func (r *RefreshTokenRepositoryImpl) RevokeFamily(familyID string) error {
+	return r.Db.Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked = ?", familyID, false).
+		Update("revoked", true).Error
+}
+
+// RevokeAllByUser implements RefreshTokenRepository.
+func (r *RefreshTokenRepositoryImpl) RevokeAllByUser(userID uint) error {
+	return r.Db.Model(&model.RefreshToken{}).
+		Where("user_id = ? AND revoked = ?", userID, false).
+		Update("revoked", true).Error
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+
+	"gorm.io/gorm"
+)
+
+type RoleRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewRoleRepositoryImpl(db *gorm.DB) RoleRepository {
+	return &RoleRepositoryImpl{Db: db}
+}
+
+// FindAll implements RoleRepository.
+func (r *RoleRepositoryImpl) FindAll() ([]model.AppRole, error) {
+	var roles []model.AppRole
+	err := r.Db.Preload("Permissions").Order("is_system DESC, name ASC").Find(&roles).Error
+	return roles, err
+}
+
+// FindByName implements RoleRepository.
+func (r *RoleRepositoryImpl) FindByName(name string) (*model.AppRole, error) {
+	var role model.AppRole
+
+	err := r.Db.Preload("Permissions").Where("name = ?", name).First(&role).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helper.NotFound("role not found")
+		}
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+// ExistsByName implements RoleRepository.
+func (r *RoleRepositoryImpl) ExistsByName(name string) bool {
+	var count int64
+	r.Db.Model(&model.AppRole{}).Where("name = ?", name).Count(&count)
+	return count > 0
+}
+
+// Save implements RoleRepository.
+func (r *RoleRepositoryImpl) Save(role *model.AppRole) error {
+	err := r.Db.Create(role).Error
+	if err != nil && strings.Contains(err.Error(), "Duplicate entry") {
+		return helper.BadRequest("role already exists")
+	}
+	return err
+}
+
+// UpdateDescription implements RoleRepository.
+func (r *RoleRepositoryImpl) UpdateDescription(name string, description string) error {
+	return r.Db.Model(&model.AppRole{}).
+		Where("name = ?", name).
+		Update("description", description).Error
+}
+
+// Delete implements RoleRepository.
+func (r *RoleRepositoryImpl) Delete(name string) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_name = ?", name).Delete(&model.RolePermission{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where("name = ?", name).Delete(&model.AppRole{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return helper.NotFound("role not found")
+		}
+
+		return nil
+	})
+}
+
+// ReplacePermissions implements RoleRepository.
+func (r *RoleRepositoryImpl) ReplacePermissions(name string, permissions []model.Permission) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_name = ?", name).Delete(&model.RolePermission{}).Error; err != nil {
+			return err
+		}
+
+		rows := make([]model.RolePermission, 0, len(permissions))
+		for _, permission := range permissions {
+			rows = append(rows, model.RolePermission{RoleName: name, Permission: permission})
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		return tx.Create(&rows).Error
+	})
+}
+
+// FindAllRolePermissions implements RoleRepository.
+func (r *RoleRepositoryImpl) FindAllRolePermissions() ([]model.RolePermission, error) {
+	var rows []model.RolePermission
+	err := r.Db.Find(&rows).Error
+	return rows, err
+}
+
+// CountUsers implements RoleRepository.
+func (r *RoleRepositoryImpl) CountUsers(name string) (int64, error) {
+	var count int64
+	err := r.Db.Model(&model.User{}).Where("role = ?", name).Count(&count).Error
+	return count, err
+}
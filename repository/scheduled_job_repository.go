@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"errors"
+	"time"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ScheduledJobRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewScheduledJobRepositoryImpl(db *gorm.DB) ScheduledJobRepository {
+	return &ScheduledJobRepositoryImpl{Db: db}
+}
+
+// Ensure implements ScheduledJobRepository. A job stored before keeps its
+// schedule and state; only the description is refreshed.
+func (r *ScheduledJobRepositoryImpl) Ensure(job *model.ScheduledJob) error {
+	return r.Db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"description"}),
+	}).Create(job).Error
+}
+
+// FindAll implements ScheduledJobRepository.
+func (r *ScheduledJobRepositoryImpl) FindAll() ([]model.ScheduledJob, error) {
+	var jobs []model.ScheduledJob
+	err := r.Db.Order("name ASC").Find(&jobs).Error
+	return jobs, err
+}
+
+// FindByName implements ScheduledJobRepository.
+func (r *ScheduledJobRepositoryImpl) FindByName(name string) (*model.ScheduledJob, error) {
+	var job model.ScheduledJob
+	if err := r.Db.Where("name = ?", name).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helper.NotFound("job not found")
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Acquire implements ScheduledJobRepository. The lock is taken with a
+// single conditional update, so of several instances trying at once only
+// one succeeds. A lock whose lease ran out is taken over.
+func (r *ScheduledJobRepositoryImpl) Acquire(name, owner string, now time.Time, lease time.Duration) (bool, error) {
+	until := now.Add(lease)
+	result := r.Db.Model(&model.ScheduledJob{}).
+		Where("name = ? AND enabled = ? AND next_run_at <= ?", name, true, now).
+		Where("locked_until IS NULL OR locked_until < ?", now).
+		Updates(map[string]interface{}{
+			"locked_by":       owner,
+			"locked_until":    until,
+			"last_started_at": now,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// Release implements ScheduledJobRepository. runErr is the job's error, nil
+// when it succeeded.
+func (r *ScheduledJobRepositoryImpl) Release(name, owner string, finishedAt, nextRunAt time.Time, runErr error) error {
+	updates := map[string]interface{}{
+		"locked_by":        nil,
+		"locked_until":     nil,
+		"last_finished_at": finishedAt,
+		"next_run_at":      nextRunAt,
+		"run_count":        gorm.Expr("run_count + 1"),
+	}
+	if runErr != nil {
+		updates["last_error"] = runErr.Error()
+		updates["failure_count"] = gorm.Expr("failure_count + 1")
+	} else {
+		updates["last_error"] = ""
+		updates["last_success_at"] = finishedAt
+	}
+
+	return r.Db.Model(&model.ScheduledJob{}).
+		Where("name = ? AND locked_by = ?", name, owner).
+		Updates(updates).Error
+}
+
+// Update implements ScheduledJobRepository.
+func (r *ScheduledJobRepositoryImpl) Update(name string, schedule string, enabled bool, nextRunAt time.Time) error {
+	return r.Db.Model(&model.ScheduledJob{}).
+		Where("name = ?", name).
+		Updates(map[string]interface{}{
+			"schedule":    schedule,
+			"enabled":     enabled,
+			"next_run_at": nextRunAt,
+		}).Error
+}
+
+// Trigger implements ScheduledJobRepository. The job runs on the next tick
+// of any instance.
+func (r *ScheduledJobRepositoryImpl) Trigger(name string, now time.Time) error {
+	return r.Db.Model(&model.ScheduledJob{}).
+		Where("name = ?", name).
+		Update("next_run_at", now).Error
+}
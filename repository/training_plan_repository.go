@@ -2,6 +2,8 @@ package repository
 
 import (
 	"errors"
+	"time"
+	"training-plan-api/helper"
 	"training-plan-api/model"
 
 	"gorm.io/gorm"
@@ -16,30 +18,172 @@ func NewTrainingPlanRepositoryImpl(db *gorm.DB) TrainingPlanRepository {
 }
 
 // FindPaginated implements TrainingPlanRepository.
-func (r *TrainingPlanRepositoryImpl) FindPaginated(offset int, limit int) ([]model.TrainingPlan, int64, error) {
+// An empty statuses slice returns plans in every status.
+func (r *TrainingPlanRepositoryImpl) FindPaginated(statuses []model.TrainingPlanStatus, offset int, limit int) ([]model.TrainingPlan, int64, error) {
 	var trainingPlans []model.TrainingPlan
 	var total int64
 
-	if err := r.Db.Model(&model.TrainingPlan{}).Count(&total).Error; err != nil {
+	query := r.Db.Model(&model.TrainingPlan{})
+	if len(statuses) > 0 {
+		query = query.Where("status IN ?", statuses)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	err := r.Db.Offset(offset).Limit(limit).Order("created_at DESC").Find(&trainingPlans).Error
+	err := query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&trainingPlans).Error
 	return trainingPlans, total, err
 }
 
-// Delete implements TrainingPlanRepository.
-func (r *TrainingPlanRepositoryImpl) Delete(trainingPlanId int) error {
-	result := r.Db.Delete(&model.TrainingPlan{}, trainingPlanId)
-	if result.Error != nil {
-		return result.Error
-	}
+// FindUpcomingPaginated implements TrainingPlanRepository.
+func (r *TrainingPlanRepositoryImpl) FindUpcomingPaginated(from time.Time, offset int, limit int) ([]model.TrainingPlan, int64, error) {
+	var trainingPlans []model.TrainingPlan
+	var total int64
+
+	query := r.Db.Model(&model.TrainingPlan{}).
+		Where("status = ? AND date >= ?", model.TrainingPlanPublished, from.Format("2006-01-02"))
 
-	if result.RowsAffected == 0 {
-		return errors.New("training plan not found")
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
 	}
 
-	return nil
+	err := query.Offset(offset).Limit(limit).Order("date ASC, id ASC").Find(&trainingPlans).Error
+	return trainingPlans, total, err
+}
+
+// Transition implements TrainingPlanRepository. The plan row is locked so
+// the status check and the record changes see the same state. Cancelling a
+// plan cancels its pending registrations and waitlist; completing it
+// cancels the waitlist only. The affected records are returned with their
+// previous status. webhooks are queued with the status change.
+func (r *TrainingPlanRepositoryImpl) Transition(id int, to model.TrainingPlanStatus, reason *string, webhooks ...model.WebhookMessage) ([]model.Record, error) {
+	var affected []model.Record
+
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		plan, err := lockTrainingPlan(tx, uint(id))
+		if err != nil {
+			return err
+		}
+
+		if !plan.Status.CanTransitionTo(to) {
+			return helper.BadRequest("training plan cannot move from " + string(plan.Status) + " to " + string(to))
+		}
+
+		updates := map[string]interface{}{"status": to, "sequence": gorm.Expr("sequence + 1")}
+		if to == model.TrainingPlanCancelled {
+			// the event is removed through the calendar outbox
+			updates["cancellation_reason"] = reason
+			updates["calendar_event_id"] = nil
+		}
+		if err := tx.Model(&model.TrainingPlan{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		switch {
+		case to == model.TrainingPlanPublished:
+			if err := enqueueCalendarSync(tx, id, model.CalendarOpUpsert, nil); err != nil {
+				return err
+			}
+		case to == model.TrainingPlanCancelled && plan.CalendarEventID != nil:
+			if err := enqueueCalendarSync(tx, id, model.CalendarOpDelete, plan.CalendarEventID); err != nil {
+				return err
+			}
+		}
+		if err := enqueueWebhooks(tx, webhooks); err != nil {
+			return err
+		}
+
+		var released []model.RecordStatus
+		switch to {
+		case model.TrainingPlanCancelled:
+			released = []model.RecordStatus{model.RecordStatusRegister, model.RecordStatusWaitlisted}
+		case model.TrainingPlanCompleted:
+			released = []model.RecordStatus{model.RecordStatusWaitlisted}
+		default:
+			return nil
+		}
+
+		if err := tx.
+			Where("training_plan_id = ? AND status IN ?", id, released).
+			Order("id ASC").
+			Find(&affected).Error; err != nil {
+			return err
+		}
+		if len(affected) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(affected))
+		for _, record := range affected {
+			ids = append(ids, record.ID)
+		}
+
+		return tx.Model(&model.Record{}).
+			Where("id IN ?", ids).
+			Update("status", model.RecordStatusCancelled).Error
+	})
+
+	return affected, err
+}
+
+// SaveWithSessions implements TrainingPlanRepository. The plan and its
+// sessions are created together so a copy is never left half-made.
+func (r *TrainingPlanRepositoryImpl) SaveWithSessions(trainingPlan *model.TrainingPlan, sessions []model.TrainingSession) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(trainingPlan).Error; err != nil {
+			return err
+		}
+		if len(sessions) == 0 {
+			return nil
+		}
+
+		for i := range sessions {
+			sessions[i].TrainingPlanID = uint(trainingPlan.ID)
+		}
+		return tx.Omit("TrainingPlan").Create(&sessions).Error
+	})
+}
+
+// FindBySeries implements TrainingPlanRepository.
+func (r *TrainingPlanRepositoryImpl) FindBySeries(seriesId uint) ([]model.TrainingPlan, error) {
+	var trainingPlans []model.TrainingPlan
+
+	err := r.Db.Where("series_id = ?", seriesId).Order("date ASC").Find(&trainingPlans).Error
+	return trainingPlans, err
+}
+
+// ExistsInSeries implements TrainingPlanRepository.
+func (r *TrainingPlanRepositoryImpl) ExistsInSeries(seriesId uint, date time.Time) bool {
+	var count int64
+	r.Db.Model(&model.TrainingPlan{}).
+		Where("series_id = ? AND date = ?", seriesId, date.Format("2006-01-02")).
+		Count(&count)
+
+	return count > 0
+}
+
+// Delete implements TrainingPlanRepository. A calendar event of the plan
+// is queued for removal in the same transaction.
+func (r *TrainingPlanRepositoryImpl) Delete(trainingPlanId int) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		var trainingPlan model.TrainingPlan
+		if err := tx.Select("id", "calendar_event_id").First(&trainingPlan, trainingPlanId).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return helper.NotFound("training plan not found")
+			}
+			return err
+		}
+
+		if err := tx.Delete(&model.TrainingPlan{}, trainingPlanId).Error; err != nil {
+			return err
+		}
+
+		if trainingPlan.CalendarEventID != nil {
+			return enqueueCalendarSync(tx, trainingPlanId, model.CalendarOpDelete, trainingPlan.CalendarEventID)
+		}
+		return nil
+	})
 }
 
 // FindAll implements TrainingPlanRepository.
@@ -57,7 +201,7 @@ func (r *TrainingPlanRepositoryImpl) FindById(trainingPlanId int) (*model.Traini
 	result := r.Db.First(&trainingPlan, trainingPlanId)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, errors.New("training plan not found")
+			return nil, helper.NotFound("training plan not found")
 		}
 		return nil, result.Error
 	}
@@ -71,20 +215,131 @@ func (r *TrainingPlanRepositoryImpl) Save(trainingPlan *model.TrainingPlan) erro
 	return result.Error
 }
 
-// Update implements TrainingPlanRepository.
+// bumpSequence moves the iCalendar SEQUENCE of the plan's event forward.
+// Calendar feeds derive an event's status from the plan's records as well,
+// so record changes bump it in their transaction too.
+func bumpSequence(tx *gorm.DB, trainingPlanId uint) error {
+	return tx.Model(&model.TrainingPlan{}).
+		Where("id = ?", trainingPlanId).
+		UpdateColumn("sequence", gorm.Expr("sequence + 1")).Error
+}
+
+// trainingPlanLifecycleColumns are only written by Transition and the
+// calendar sync. Update leaves them alone so a stale copy of the plan
+// cannot undo a concurrent status change.
+var trainingPlanLifecycleColumns = []string{"status", "cancellation_reason", "sequence", "calendar_event_id"}
+
+// Update implements TrainingPlanRepository. Changes to a published plan
+// queue a calendar update in the same transaction. The lifecycle columns
+// are not written; the sequence is bumped in the database.
 func (r *TrainingPlanRepositoryImpl) Update(trainingPlan *model.TrainingPlan) error {
-	result := r.Db.
-		Model(&model.TrainingPlan{}).
-		Where("id = ?", trainingPlan.ID).
-		Updates(trainingPlan)
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Model(&model.TrainingPlan{}).
+			Where("id = ?", trainingPlan.ID).
+			Omit(trainingPlanLifecycleColumns...).
+			Updates(trainingPlan)
 
-	if result.Error != nil {
-		return result.Error
-	}
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return errors.New("training plan not found")
+		}
 
-	if result.RowsAffected == 0 {
-		return errors.New("training plan not found")
+		if err := bumpSequence(tx, uint(trainingPlan.ID)); err != nil {
+			return err
+		}
+
+		var current model.TrainingPlan
+		if err := tx.Select("id", "status").First(&current, trainingPlan.ID).Error; err != nil {
+			return err
+		}
+		if current.Status == model.TrainingPlanPublished {
+			return enqueueCalendarSync(tx, trainingPlan.ID, model.CalendarOpUpsert, nil)
+		}
+		return nil
+	})
+}
+
+// SetCalendarEventID implements TrainingPlanRepository. It is used by the
+// calendar sync worker and does not queue another sync.
+func (r *TrainingPlanRepositoryImpl) SetCalendarEventID(trainingPlanId int, eventID *string) error {
+	return r.Db.Model(&model.TrainingPlan{}).
+		Where("id = ?", trainingPlanId).
+		Update("calendar_event_id", eventID).Error
+}
+
+// AttachCalendarEvent implements TrainingPlanRepository. The event ID is
+// stored only while the plan is published or completed. The plan row is
+// locked, so a cancellation either committed before and the caller must
+// delete the event, or runs after and queues its removal.
+func (r *TrainingPlanRepositoryImpl) AttachCalendarEvent(trainingPlanId int, eventID string) (bool, error) {
+	attached := false
+
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		plan, err := lockTrainingPlan(tx, uint(trainingPlanId))
+		if err != nil {
+			return err
+		}
+		if plan.Status != model.TrainingPlanPublished && plan.Status != model.TrainingPlanCompleted {
+			return nil
+		}
+
+		attached = true
+		return tx.Model(&model.TrainingPlan{}).
+			Where("id = ?", trainingPlanId).
+			Update("calendar_event_id", eventID).Error
+	})
+	if _, ok := err.(*helper.AppError); ok {
+		// deleted in the meantime
+		return false, nil
 	}
 
-	return nil
+	return attached, err
+}
+
+// FindOnCalendar returns the published plans from the given date on that
+// have a calendar event.
+func (r *TrainingPlanRepositoryImpl) FindOnCalendar(from time.Time) ([]model.TrainingPlan, error) {
+	var plans []model.TrainingPlan
+	err := r.Db.
+		Where("status = ? AND calendar_event_id IS NOT NULL AND date >= ?", model.TrainingPlanPublished, from).
+		Order("date ASC").
+		Find(&plans).Error
+	return plans, err
+}
+
+// FindEndedOn returns the published and completed plans whose last day is
+// date.
+func (r *TrainingPlanRepositoryImpl) FindEndedOn(date time.Time) ([]model.TrainingPlan, error) {
+	var plans []model.TrainingPlan
+	err := r.Db.
+		Where("status IN ?", []model.TrainingPlanStatus{model.TrainingPlanPublished, model.TrainingPlanCompleted}).
+		Where("DATE_ADD(date, INTERVAL GREATEST(number_of_days, 1) - 1 DAY) = ?", date.Format("2006-01-02")).
+		Order("id ASC").
+		Find(&plans).Error
+	return plans, err
+}
+
+// FindPublishedOn returns the published plans taking place on date.
+func (r *TrainingPlanRepositoryImpl) FindPublishedOn(date time.Time) ([]model.TrainingPlan, error) {
+	var plans []model.TrainingPlan
+	err := r.Db.
+		Where("status = ? AND date = ?", model.TrainingPlanPublished, date.Format("2006-01-02")).
+		Order("id ASC").
+		Find(&plans).Error
+	return plans, err
+}
+
+// FindUpcomingByCategory implements TrainingPlanRepository. It returns the
+// published plans of category starting on or after from, earliest first.
+func (r *TrainingPlanRepositoryImpl) FindUpcomingByCategory(category model.TrainingPlanCategory, from time.Time) ([]model.TrainingPlan, error) {
+	var plans []model.TrainingPlan
+	err := r.Db.
+		Where("status = ? AND category = ? AND date >= ?", model.TrainingPlanPublished, category, from.Format("2006-01-02")).
+		Order("date ASC, id ASC").
+		Find(&plans).Error
+	return plans, err
 }
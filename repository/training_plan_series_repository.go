@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"errors"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+
+	"gorm.io/gorm"
+)
+
+type TrainingPlanSeriesRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewTrainingPlanSeriesRepositoryImpl(db *gorm.DB) TrainingPlanSeriesRepository {
+	return &TrainingPlanSeriesRepositoryImpl{Db: db}
+}
+
+// Save implements TrainingPlanSeriesRepository.
+func (r *TrainingPlanSeriesRepositoryImpl) Save(series *model.TrainingPlanSeries) error {
+	return r.Db.Create(series).Error
+}
+
+// FindById implements TrainingPlanSeriesRepository.
+func (r *TrainingPlanSeriesRepositoryImpl) FindById(id uint) (*model.TrainingPlanSeries, error) {
+	var series model.TrainingPlanSeries
+
+	err := r.Db.First(&series, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, helper.NotFound("training plan series not found")
+	}
+
+	return &series, err
+}
+
+// FindPaginated implements TrainingPlanSeriesRepository.
+func (r *TrainingPlanSeriesRepositoryImpl) FindPaginated(offset, limit int) ([]model.TrainingPlanSeries, int64, error) {
+	var series []model.TrainingPlanSeries
+	var total int64
+
+	if err := r.Db.Model(&model.TrainingPlanSeries{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := r.Db.Offset(offset).Limit(limit).Order("created_at DESC").Find(&series).Error
+	return series, total, err
+}
+
+// FindActive implements TrainingPlanSeriesRepository.
+func (r *TrainingPlanSeriesRepositoryImpl) FindActive() ([]model.TrainingPlanSeries, error) {
+	var series []model.TrainingPlanSeries
+
+	err := r.Db.Where("active = ?", true).Order("id ASC").Find(&series).Error
+	return series, err
+}
+
+// Update implements TrainingPlanSeriesRepository.
+func (r *TrainingPlanSeriesRepositoryImpl) Update(series *model.TrainingPlanSeries) error {
+	return r.Db.Save(series).Error
+}
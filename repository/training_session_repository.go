@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"errors"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TrainingSessionRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewTrainingSessionRepositoryImpl(db *gorm.DB) TrainingSessionRepository {
+	return &TrainingSessionRepositoryImpl{Db: db}
+}
+
+// Save implements TrainingSessionRepository.
+func (r *TrainingSessionRepositoryImpl) Save(session *model.TrainingSession) error {
+	return r.Db.Omit("TrainingPlan").Create(session).Error
+}
+
+// FindById implements TrainingSessionRepository.
+func (r *TrainingSessionRepositoryImpl) FindById(id uint) (*model.TrainingSession, error) {
+	var session model.TrainingSession
+
+	err := r.Db.Preload("TrainingPlan").First(&session, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, helper.NotFound("training session not found")
+	}
+
+	return &session, err
+}
+
+// FindByTrainingPlan implements TrainingSessionRepository.
+func (r *TrainingSessionRepositoryImpl) FindByTrainingPlan(trainingPlanId uint) ([]model.TrainingSession, error) {
+	var sessions []model.TrainingSession
+
+	err := r.Db.
+		Where("training_plan_id = ?", trainingPlanId).
+		Order("date ASC, start_time ASC, id ASC").
+		Find(&sessions).
+		Error
+
+	return sessions, err
+}
+
+// HasSessions implements TrainingSessionRepository.
+func (r *TrainingSessionRepositoryImpl) HasSessions(trainingPlanId uint) bool {
+	var count int64
+	r.Db.Model(&model.TrainingSession{}).
+		Where("training_plan_id = ?", trainingPlanId).
+		Count(&count)
+
+	return count > 0
+}
+
+// Update implements TrainingSessionRepository.
+func (r *TrainingSessionRepositoryImpl) Update(session *model.TrainingSession) error {
+	return r.Db.Omit("TrainingPlan").Save(session).Error
+}
+
+// Delete implements TrainingSessionRepository. The session's attendance is
+// deleted with it.
+func (r *TrainingSessionRepositoryImpl) Delete(id uint) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("training_session_id = ?", id).Delete(&model.SessionAttendance{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Delete(&model.TrainingSession{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return helper.NotFound("training session not found")
+		}
+		return nil
+	})
+}
+
+// FindAttendanceBySession implements TrainingSessionRepository.
+func (r *TrainingSessionRepositoryImpl) FindAttendanceBySession(sessionId uint) ([]model.SessionAttendance, error) {
+	var attendance []model.SessionAttendance
+
+	err := r.Db.Where("training_session_id = ?", sessionId).Find(&attendance).Error
+	return attendance, err
+}
+
+// FindAttendanceByTrainingPlan implements TrainingSessionRepository.
+func (r *TrainingSessionRepositoryImpl) FindAttendanceByTrainingPlan(trainingPlanId uint) ([]model.SessionAttendance, error) {
+	var attendance []model.SessionAttendance
+
+	err := r.Db.
+		Joins("JOIN training_sessions ON training_sessions.id = session_attendances.training_session_id").
+		Where("training_sessions.training_plan_id = ?", trainingPlanId).
+		Find(&attendance).
+		Error
+
+	return attendance, err
+}
+
+// SaveAttendance implements TrainingSessionRepository. Marking a record
+// again for the same session overwrites the previous mark.
+func (r *TrainingSessionRepositoryImpl) SaveAttendance(attendance []model.SessionAttendance) error {
+	if len(attendance) == 0 {
+		return nil
+	}
+
+	return r.Db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "training_session_id"}, {Name: "record_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status", "marked_by_id", "updated_at"}),
+	}).Create(&attendance).Error
+}
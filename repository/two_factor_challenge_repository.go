@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"errors"
+	"time"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+
+	"gorm.io/gorm"
+)
+
+type TwoFactorChallengeRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewTwoFactorChallengeRepositoryImpl(db *gorm.DB) TwoFactorChallengeRepository {
+	return &TwoFactorChallengeRepositoryImpl{Db: db}
+}
+
+// Save implements TwoFactorChallengeRepository. Expired challenges are
+// dropped on the way.
+func (r *TwoFactorChallengeRepositoryImpl) Save(challenge *model.TwoFactorChallenge) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expires_at < ?", time.Now()).Delete(&model.TwoFactorChallenge{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(challenge).Error
+	})
+}
+
+// FindUsable implements TwoFactorChallengeRepository. A challenge is usable
+// until it expires, is consumed or reaches maxFailures wrong codes.
+func (r *TwoFactorChallengeRepositoryImpl) FindUsable(id string, maxFailures int) (*model.TwoFactorChallenge, error) {
+	var challenge model.TwoFactorChallenge
+	err := r.Db.
+		Where("id = ? AND consumed_at IS NULL AND failures < ? AND expires_at > ?", id, maxFailures, time.Now()).
+		First(&challenge).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, helper.Unauthorized("Invalid or expired challenge")
+	}
+	return &challenge, err
+}
+
+// RegisterFailure implements TwoFactorChallengeRepository.
+func (r *TwoFactorChallengeRepositoryImpl) RegisterFailure(id string) error {
+	return r.Db.Model(&model.TwoFactorChallenge{}).
+		Where("id = ?", id).
+		Update("failures", gorm.Expr("failures + 1")).Error
+}
+
+// Consume implements TwoFactorChallengeRepository. Only one request can
+// consume a challenge; the others get an error.
+func (r *TwoFactorChallengeRepositoryImpl) Consume(id string, maxFailures int) error {
+	result := r.Db.Model(&model.TwoFactorChallenge{}).
+		Where("id = ? AND consumed_at IS NULL AND failures < ? AND expires_at > ?", id, maxFailures, time.Now()).
+		Update("consumed_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return helper.Unauthorized("Invalid or expired challenge")
+	}
+	return nil
+}
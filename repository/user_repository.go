@@ -2,7 +2,9 @@ package repository
 
 import (
 	"fmt"
+	"math"
 	"strings"
+	"time"
 	"training-plan-api/data/request"
 	"training-plan-api/helper"
 	"training-plan-api/model"
@@ -99,6 +101,101 @@ func (r *UserRepositoryImpl) UpdateOAuthFields(userID uint, googleID, avatar, pr
 	return nil
 }
 
+// RegisterLoginFailure implements UserRepository. The counter is
+// incremented in the database so concurrent failures are all counted, and
+// the lock is decided from the stored value. It returns the end of the lock
+// it applied, or nil when the account stays unlocked.
+func (r *UserRepositoryImpl) RegisterLoginFailure(userID uint, policy helper.LockoutPolicy) (*time.Time, error) {
+	var lockedUntil *time.Time
+
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).Where("id = ?", userID).
+			Update("error", gorm.Expr("LEAST(error + 1, ?)", math.MaxInt16)).Error; err != nil {
+			return err
+		}
+
+		var user model.User
+		if err := tx.Select("error").First(&user, userID).Error; err != nil {
+			return err
+		}
+
+		lockDuration := policy.LockDuration(int(user.Error))
+		if lockDuration <= 0 {
+			return nil
+		}
+
+		until := time.Now().Add(lockDuration)
+		if err := tx.Model(&model.User{}).Where("id = ?", userID).Update("locked_until", until).Error; err != nil {
+			return err
+		}
+		lockedUntil = &until
+		return nil
+	})
+
+	return lockedUntil, err
+}
+
+func (r *UserRepositoryImpl) ResetLoginFailures(userID uint) error {
+	result := r.Db.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"error":        0,
+		"locked_until": nil,
+	})
+	if result.Error != nil {
+		return helper.InternalServerError("Failed to unlock user")
+	}
+	if result.RowsAffected == 0 {
+		return helper.NotFound("User not found")
+	}
+	return nil
+}
+
+// UpdatePassword stores a new password hash and clears any login lockout.
+func (r *UserRepositoryImpl) UpdatePassword(userID uint, hashedPassword string, mustChangePassword bool) error {
+	result := r.Db.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"password":             hashedPassword,
+		"must_change_password": mustChangePassword,
+		"error":                0,
+		"locked_until":         nil,
+	})
+	if result.Error != nil {
+		return helper.InternalServerError("Failed to update password")
+	}
+	if result.RowsAffected == 0 {
+		return helper.NotFound("User not found")
+	}
+	return nil
+}
+
+func (r *UserRepositoryImpl) UpdateTwoFactor(userID uint, secret string, enabled bool) error {
+	result := r.Db.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"totp_secret":       secret,
+		"totp_enabled":      enabled,
+		"totp_last_counter": 0,
+	})
+	if result.Error != nil {
+		return helper.InternalServerError("Failed to update two-factor settings")
+	}
+	if result.RowsAffected == 0 {
+		return helper.NotFound("User not found")
+	}
+	return nil
+}
+
+// AdvanceTOTPCounter records the last accepted TOTP time step. It fails when
+// the step was already used, which blocks replay of an intercepted code.
+func (r *UserRepositoryImpl) AdvanceTOTPCounter(userID uint, counter int64) error {
+	result := r.Db.Model(&model.User{}).
+		Where("id = ? AND totp_last_counter < ?", userID, counter).
+		Update("totp_last_counter", counter)
+	if result.Error != nil {
+		return helper.InternalServerError("Failed to verify code")
+	}
+	if result.RowsAffected == 0 {
+		return helper.Unauthorized("Verification code already used")
+	}
+	return nil
+}
+
 func (r *UserRepositoryImpl) Delete(userId uint) error {
 	result := r.Db.Delete(&model.User{}, userId)
 	if result.Error != nil {
@@ -122,6 +219,18 @@ func (r *UserRepositoryImpl) FindById(userId uint) (*model.User, error) {
 	return &user, nil
 }
 
+func (r *UserRepositoryImpl) FindByIds(userIds []uint) ([]model.User, error) {
+	var users []model.User
+	if len(userIds) == 0 {
+		return users, nil
+	}
+	err := r.Db.Where("id IN ?", userIds).Find(&users).Error
+	if err != nil {
+		return nil, helper.InternalServerError("Failed to find users")
+	}
+	return users, nil
+}
+
 func (r *UserRepositoryImpl) FindByIdWithDepartment(userId uint) (*model.User, error) {
 	var user model.User
 	err := r.Db.Preload("Department").Preload("Certificates").Preload("Certificates.Training").First(&user, userId).Error
@@ -288,3 +397,26 @@ func (r *UserRepositoryImpl) FindAllWithFilters(params request.UserTableQueryPar
 
 	return users, total, nil
 }
+
+// UpdateLanguage implements UserRepository.
+func (r *UserRepositoryImpl) UpdateLanguage(userID uint, language string) error {
+	return r.Db.Model(&model.User{}).
+		Where("id = ?", userID).
+		Update("language", language).Error
+}
+
+// FindActiveByRoles implements UserRepository. A departmentId of 0 matches
+// every department.
+func (r *UserRepositoryImpl) FindActiveByRoles(roles []string, departmentId int) ([]model.User, error) {
+	var users []model.User
+	if len(roles) == 0 {
+		return users, nil
+	}
+
+	query := r.Db.Where("role IN ? AND status = ?", roles, model.UserStatusActive)
+	if departmentId != 0 {
+		query = query.Where("department_id = ?", departmentId)
+	}
+	err := query.Order("id ASC").Find(&users).Error
+	return users, err
+}
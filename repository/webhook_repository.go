@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewWebhookRepositoryImpl(db *gorm.DB) WebhookRepository {
+	return &WebhookRepositoryImpl{Db: db}
+}
+
+// Save implements WebhookRepository. The subscription is created together
+// with its events.
+func (r *WebhookRepositoryImpl) Save(subscription *model.WebhookSubscription) error {
+	return r.Db.Create(subscription).Error
+}
+
+// Update implements WebhookRepository. The events are replaced by those on
+// subscription.
+func (r *WebhookRepositoryImpl) Update(subscription *model.WebhookSubscription) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.WebhookSubscription{}).
+			Where("id = ?", subscription.ID).
+			Updates(map[string]interface{}{
+				"name":   subscription.Name,
+				"url":    subscription.URL,
+				"secret": subscription.Secret,
+				"active": subscription.Active,
+			}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("subscription_id = ?", subscription.ID).Delete(&model.WebhookSubscriptionEvent{}).Error; err != nil {
+			return err
+		}
+		if len(subscription.Events) == 0 {
+			return nil
+		}
+
+		for i := range subscription.Events {
+			subscription.Events[i].ID = 0
+			subscription.Events[i].SubscriptionID = subscription.ID
+		}
+		return tx.Create(&subscription.Events).Error
+	})
+}
+
+// Delete implements WebhookRepository. The delivery log of the subscription
+// is deleted with it.
+func (r *WebhookRepositoryImpl) Delete(id uint) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("subscription_id = ?", id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("subscription_id = ?", id).Delete(&model.WebhookSubscriptionEvent{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Delete(&model.WebhookSubscription{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return helper.NotFound("webhook subscription not found")
+		}
+		return nil
+	})
+}
+
+// FindById implements WebhookRepository.
+func (r *WebhookRepositoryImpl) FindById(id uint) (*model.WebhookSubscription, error) {
+	var subscription model.WebhookSubscription
+	if err := r.Db.Preload("Events").Where("id = ?", id).First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helper.NotFound("webhook subscription not found")
+		}
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// FindAll implements WebhookRepository.
+func (r *WebhookRepositoryImpl) FindAll() ([]model.WebhookSubscription, error) {
+	var subscriptions []model.WebhookSubscription
+	err := r.Db.Preload("Events").Order("id ASC").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// FindActiveByEvent implements WebhookRepository.
+func (r *WebhookRepositoryImpl) FindActiveByEvent(event model.WebhookEvent) ([]model.WebhookSubscription, error) {
+	return findActiveSubscriptions(r.Db, event)
+}
+
+func findActiveSubscriptions(db *gorm.DB, event model.WebhookEvent) ([]model.WebhookSubscription, error) {
+	var subscriptions []model.WebhookSubscription
+	err := db.
+		Joins("JOIN webhook_subscription_events wse ON wse.subscription_id = webhook_subscriptions.id").
+		Where("webhook_subscriptions.active = ? AND wse.event = ?", true, event).
+		Order("webhook_subscriptions.id ASC").
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// Enqueue implements WebhookRepository.
+func (r *WebhookRepositoryImpl) Enqueue(deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.Db.Create(&deliveries).Error
+}
+
+// ClaimDue implements WebhookRepository. Claimed deliveries are pushed back
+// by lease so other instances skip them while they are being sent.
+func (r *WebhookRepositoryImpl) ClaimDue(now time.Time, lease time.Duration, limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", model.WebhookDeliveryPending, now).
+			Order("id ASC").
+			Limit(limit).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		return tx.Model(&model.WebhookDelivery{}).
+			Where("id IN ?", deliveryIds(deliveries)).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil || len(deliveries) == 0 {
+		return deliveries, err
+	}
+
+	// subscriptions are loaded outside the locking transaction
+	err = r.Db.Preload("Subscription").Order("id ASC").Find(&deliveries, deliveryIds(deliveries)).Error
+	return deliveries, err
+}
+
+// MarkSent implements WebhookRepository.
+func (r *WebhookRepositoryImpl) MarkSent(id uint, attempts int, result helper.WebhookResult) error {
+	now := time.Now()
+	return r.Db.Model(&model.WebhookDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":          model.WebhookDeliverySent,
+			"attempts":        attempts,
+			"response_status": result.StatusCode,
+			"response_body":   result.Body,
+			"last_error":      "",
+			"delivered_at":    &now,
+		}).Error
+}
+
+// MarkRetry implements WebhookRepository.
+func (r *WebhookRepositoryImpl) MarkRetry(id uint, attempts int, nextAttemptAt time.Time, result helper.WebhookResult, lastError string) error {
+	return r.Db.Model(&model.WebhookDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt,
+			"response_status": responseStatus(result),
+			"response_body":   result.Body,
+			"last_error":      lastError,
+		}).Error
+}
+
+// MarkFailed implements WebhookRepository.
+func (r *WebhookRepositoryImpl) MarkFailed(id uint, attempts int, result helper.WebhookResult, lastError string) error {
+	return r.Db.Model(&model.WebhookDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":          model.WebhookDeliveryFailed,
+			"attempts":        attempts,
+			"response_status": responseStatus(result),
+			"response_body":   result.Body,
+			"last_error":      lastError,
+		}).Error
+}
+
+// FindDeliveryById implements WebhookRepository.
+func (r *WebhookRepositoryImpl) FindDeliveryById(id uint) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	if err := r.Db.Where("id = ?", id).First(&delivery).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helper.NotFound("webhook delivery not found")
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// FindDeliveriesPaginated implements WebhookRepository. An empty status
+// matches every delivery.
+func (r *WebhookRepositoryImpl) FindDeliveriesPaginated(subscriptionId uint, status model.WebhookDeliveryStatus, offset, limit int) ([]model.WebhookDelivery, int64, error) {
+	var deliveries []model.WebhookDelivery
+	var total int64
+
+	query := r.Db.Model(&model.WebhookDelivery{}).Where("subscription_id = ?", subscriptionId)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+func deliveryIds(deliveries []model.WebhookDelivery) []uint {
+	ids := make([]uint, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		ids = append(ids, delivery.ID)
+	}
+	return ids
+}
+
+// responseStatus stores no status when the receiver could not be reached.
+func responseStatus(result helper.WebhookResult) *int {
+	if result.StatusCode == 0 {
+		return nil
+	}
+	return &result.StatusCode
+}
+
+// enqueueWebhooks queues one delivery of each message per subscription of
+// its event. It runs in the transaction of the change the messages report.
+func enqueueWebhooks(tx *gorm.DB, messages []model.WebhookMessage) error {
+	for _, message := range messages {
+		subscriptions, err := findActiveSubscriptions(tx, message.Event)
+		if err != nil {
+			return err
+		}
+		if len(subscriptions) == 0 {
+			continue
+		}
+
+		eventID, err := helper.GenerateRandomToken(16)
+		if err != nil {
+			return err
+		}
+
+		payload, err := json.Marshal(response.WebhookPayload{
+			ID:        eventID,
+			Event:     string(message.Event),
+			CreatedAt: time.Now().UTC(),
+			Data:      message.Data(),
+		})
+		if err != nil {
+			return err
+		}
+
+		deliveries := make([]model.WebhookDelivery, 0, len(subscriptions))
+		for _, subscription := range subscriptions {
+			deliveries = append(deliveries, model.WebhookDelivery{
+				SubscriptionID: subscription.ID,
+				Event:          message.Event,
+				EventID:        eventID,
+				Payload:        string(payload),
+				Status:         model.WebhookDeliveryPending,
+				NextAttemptAt:  time.Now(),
+			})
+		}
+		if err := tx.Create(&deliveries).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,11 +2,16 @@ package router
 
 import (
 	"training-plan-api/container"
+	"training-plan-api/middleware"
+	"training-plan-api/model"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 func AdminRoutes(r fiber.Router, deps *container.AppDependencies) {
+	can := func(permissions ...model.Permission) fiber.Handler {
+		return middleware.RequirePermission(deps.PermissionService, permissions...)
+	}
 
 	r.Get("/healthchecker", func(c *fiber.Ctx) error {
 		return c.Status(200).JSON(fiber.Map{
@@ -15,41 +20,114 @@ func AdminRoutes(r fiber.Router, deps *container.AppDependencies) {
 		})
 	})
 
+	// In-app notifications of the signed-in user
+	r.Get("/notifications", deps.NotificationController.FindInbox)
+	r.Get("/notifications/unread-count", deps.NotificationController.CountUnread)
+	r.Put("/notifications/read-all", deps.NotificationController.MarkAllRead)
+	r.Put("/notifications/:notificationId/read", deps.NotificationController.MarkRead)
+
 	// // Dashboard / stats
 	// r.Get("/stats", deps.DepartmentController.GetStats)
 
 	// Department management
-	r.Post("/departments", deps.DepartmentController.Create)
-	r.Put("/departments/:id", deps.DepartmentController.Update)
-	r.Delete("/departments/:id", deps.DepartmentController.Delete)
-	r.Get("/departments", deps.DepartmentController.FindPaginated)
-	r.Get("/departments/:id", deps.DepartmentController.FindById)
+	r.Post("/departments", can(model.PermDepartmentsWrite), deps.DepartmentController.Create)
+	r.Put("/departments/:id", can(model.PermDepartmentsWrite), deps.DepartmentController.Update)
+	r.Delete("/departments/:id", can(model.PermDepartmentsWrite), deps.DepartmentController.Delete)
+	r.Get("/departments", can(model.PermDepartmentsRead), deps.DepartmentController.FindPaginated)
+	r.Get("/departments/:id", can(model.PermDepartmentsRead), deps.DepartmentController.FindById)
 
 	// Department List for assigning to users
-	r.Get("/departments-list", deps.DepartmentController.GetDepartmentsList)
+	r.Get("/departments-list", can(model.PermDepartmentsRead), deps.DepartmentController.GetDepartmentsList)
 
 	// User management (Admin has full CRUD)
-	r.Post("/users", deps.UserController.AdminCreate)
-	r.Put("/users/:id", deps.UserController.AdminUpdate)
-	r.Delete("/users/:id", deps.UserController.AdminDelete)
-	r.Get("/users", deps.UserController.AdminFindAll)
-	r.Get("/users/:id", deps.UserController.AdminFindById)// need to show his all certificates
+	r.Post("/users", can(model.PermUsersWrite), deps.UserController.AdminCreate)
+	r.Put("/users/:id", can(model.PermUsersWrite), deps.UserController.AdminUpdate)
+	r.Delete("/users/:id", can(model.PermUsersWrite), deps.UserController.AdminDelete)
+	r.Put("/users/:id/unlock", can(model.PermUsersWrite), deps.UserController.AdminUnlock)
+	r.Put("/users/:id/password", can(model.PermUsersWrite), deps.PasswordController.AdminReset)
+	r.Put("/users/:id/2fa/reset", can(model.PermUsersWrite), deps.TwoFactorController.AdminReset)
+	r.Get("/users", can(model.PermUsersRead), deps.UserController.AdminFindAll)
+	r.Get("/users/:id", can(model.PermUsersRead), deps.UserController.AdminFindById)// need to show his all certificates
+	r.Get("/users/:id/training-history", can(model.PermRecordsRead), deps.RecordController.FindTrainingHistoryByUser)
 
 	// Training Plan management ///// total registered staff logic for each plan
-	r.Post("/training-plans", deps.TrainingPlanController.Create)
-	r.Put("/training-plans/:trainingPlanId", deps.TrainingPlanController.Update)
-	r.Delete("/training-plans/:trainingPlanId", deps.TrainingPlanController.Delete)
-	r.Get("/training-plans", deps.TrainingPlanController.FindPaginated)
-	r.Get("/training-plans/:trainingPlanId", deps.TrainingPlanController.FindById)
+	r.Post("/training-plans", can(model.PermTrainingPlansWrite), deps.TrainingPlanController.Create)
+	r.Put("/training-plans/:trainingPlanId", can(model.PermTrainingPlansWrite), deps.TrainingPlanController.Update)
+	r.Delete("/training-plans/:trainingPlanId", can(model.PermTrainingPlansWrite), deps.TrainingPlanController.Delete)
+	r.Put("/training-plans/:trainingPlanId/publish", can(model.PermTrainingPlansWrite), deps.TrainingPlanController.Publish)
+	r.Put("/training-plans/:trainingPlanId/cancel", can(model.PermTrainingPlansWrite), deps.TrainingPlanController.Cancel)
+	r.Put("/training-plans/:trainingPlanId/complete", can(model.PermTrainingPlansWrite), deps.TrainingPlanController.Complete)
+	r.Post("/training-plans/:trainingPlanId/clone", can(model.PermTrainingPlansWrite), deps.TrainingPlanController.Clone)
+
+	// Calendar sync
+	r.Get("/calendar-sync/failures", can(model.PermTrainingPlansWrite), deps.CalendarSyncController.FindFailed)
+	r.Post("/calendar-sync/:outboxId/retry", can(model.PermTrainingPlansWrite), deps.CalendarSyncController.Retry)
+	r.Post("/training-plans/:trainingPlanId/calendar-sync", can(model.PermTrainingPlansWrite), deps.CalendarSyncController.Resync)
+	r.Post("/training-plans/:trainingPlanId/calendar-sync/responses", can(model.PermTrainingPlansWrite), deps.CalendarSyncController.RefreshResponses)
+
+	// Webhooks
+	r.Get("/webhooks", can(model.PermWebhooksManage), deps.WebhookController.FindAll)
+	r.Post("/webhooks", can(model.PermWebhooksManage), deps.WebhookController.Create)
+	r.Get("/webhooks/:webhookId", can(model.PermWebhooksManage), deps.WebhookController.FindById)
+	r.Put("/webhooks/:webhookId", can(model.PermWebhooksManage), deps.WebhookController.Update)
+	r.Delete("/webhooks/:webhookId", can(model.PermWebhooksManage), deps.WebhookController.Delete)
+	r.Get("/webhooks/:webhookId/deliveries", can(model.PermWebhooksManage), deps.WebhookController.FindDeliveries)
+	r.Post("/webhooks/:webhookId/deliveries/:deliveryId/replay", can(model.PermWebhooksManage), deps.WebhookController.Replay)
+
+	// Background jobs
+	r.Get("/jobs", can(model.PermJobsManage), deps.ScheduledJobController.FindAll)
+	r.Put("/jobs/:name", can(model.PermJobsManage), deps.ScheduledJobController.Update)
+	r.Post("/jobs/:name/run", can(model.PermJobsManage), deps.ScheduledJobController.Run)
+
+	// Recurring training plans
+	r.Post("/training-plan-series", can(model.PermTrainingPlansWrite), deps.TrainingPlanSeriesController.Create)
+	r.Get("/training-plan-series", can(model.PermTrainingPlansRead), deps.TrainingPlanSeriesController.FindPaginated)
+	r.Get("/training-plan-series/:seriesId", can(model.PermTrainingPlansRead), deps.TrainingPlanSeriesController.FindById)
+	r.Post("/training-plan-series/:seriesId/generate", can(model.PermTrainingPlansWrite), deps.TrainingPlanSeriesController.Generate)
+	r.Put("/training-plan-series/:seriesId/stop", can(model.PermTrainingPlansWrite), deps.TrainingPlanSeriesController.Stop)
+	r.Get("/training-plans", can(model.PermTrainingPlansRead), deps.TrainingPlanController.FindPaginated)
+	r.Get("/training-plans/:trainingPlanId", can(model.PermTrainingPlansRead), deps.TrainingPlanController.FindById)
+	r.Get("/training-plans/:trainingPlanId/calendar.ics", can(model.PermTrainingPlansRead), deps.CalendarFeedController.DownloadTrainingPlan)
+
+	// Training sessions & attendance
+	r.Post("/training-plans/:trainingPlanId/sessions", can(model.PermTrainingPlansWrite), deps.TrainingSessionController.Create)
+	r.Get("/training-plans/:trainingPlanId/sessions", can(model.PermTrainingPlansRead), deps.TrainingSessionController.FindByTrainingPlan)
+	r.Put("/training-sessions/:sessionId", can(model.PermTrainingPlansWrite), deps.TrainingSessionController.Update)
+	r.Delete("/training-sessions/:sessionId", can(model.PermTrainingPlansWrite), deps.TrainingSessionController.Delete)
+	r.Get("/training-sessions/:sessionId/attendance", can(model.PermRecordsRead), deps.TrainingSessionController.FindAttendance)
+	r.Put("/training-sessions/:sessionId/attendance", can(model.PermRecordsWrite), deps.TrainingSessionController.MarkAttendance)
 
 	// // Records
 	// r.Get("/records", deps.RecordController.FindAllPaginated)
-	r.Post("/records/search", deps.RecordController.Search)
-	r.Post("/records/export", deps.RecordController.Export)
+	r.Post("/records/search", can(model.PermRecordsRead), deps.RecordController.Search)
+	r.Post("/records/export", can(model.PermRecordsExport), deps.RecordController.Export)
 
 
 	// // Certificates (approval flow)
-	r.Get("/certificates", deps.CertificateController.FindAllPending)
-	r.Put("/certificates/:id/approve", deps.CertificateController.Approve)
-	r.Put("/certificates/:id/reject", deps.CertificateController.Reject)
+	r.Get("/certificates", can(model.PermCertificatesApprove), deps.CertificateController.FindAllPending)
+	r.Put("/certificates/:id/approve", can(model.PermCertificatesApprove), deps.CertificateController.Approve)
+	r.Put("/certificates/:id/reject", can(model.PermCertificatesApprove), deps.CertificateController.Reject)
+	r.Get("/certificates/:id/history", can(model.PermCertificatesApprove), deps.CertificateController.History)
+	r.Get("/certificates/expiring", can(model.PermCertificatesApprove), deps.CertificateController.FindExpiring)
+	r.Get("/certificate-validities", can(model.PermCertificatesApprove), deps.CertificateController.FindValidities)
+	r.Put("/certificate-validities", can(model.PermCertificatesApprove), deps.CertificateController.SaveValidity)
+	r.Delete("/certificate-validities", can(model.PermCertificatesApprove), deps.CertificateController.DeleteValidity)
+
+	// Enrollment requests (escalate / override)
+	r.Get("/enrollment-requests", can(model.PermEnrollmentsApprove), deps.EnrollmentController.FindForReview)
+	r.Get("/enrollment-requests/:id", can(model.PermEnrollmentsApprove), deps.EnrollmentController.FindById)
+	r.Put("/enrollment-requests/:id/approve", can(model.PermEnrollmentsApprove), deps.EnrollmentController.Approve)
+	r.Put("/enrollment-requests/:id/reject", can(model.PermEnrollmentsApprove), deps.EnrollmentController.Reject)
+	r.Put("/enrollment-requests/:id/escalate", can(model.PermEnrollmentsApprove), deps.EnrollmentController.Escalate)
+
+	// Roles & permissions
+	r.Get("/permissions", can(model.PermRolesManage), deps.RoleController.ListPermissions)
+	r.Get("/roles", can(model.PermRolesManage), deps.RoleController.FindAll)
+	r.Post("/roles", can(model.PermRolesManage), deps.RoleController.Create)
+	r.Put("/roles/:name", can(model.PermRolesManage), deps.RoleController.Update)
+	r.Delete("/roles/:name", can(model.PermRolesManage), deps.RoleController.Delete)
+
+	// Audit log
+	r.Post("/audit-events/search", can(model.PermAuditRead), deps.AuditController.Search)
+	r.Post("/audit-events/export", can(model.PermAuditRead), deps.AuditController.Export)
 }
@@ -7,7 +7,13 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-func AuthRoutes(r fiber.Router, authController *controller.AuthController, oauthController *controller.AuthOAuthController) {
+func AuthRoutes(
+	r fiber.Router,
+	authController *controller.AuthController,
+	oauthController *controller.AuthOAuthController,
+	passwordController *controller.PasswordController,
+	twoFactorController *controller.TwoFactorController,
+) {
 	r.Get("/healthchecker", func(c *fiber.Ctx) error {
 		return c.Status(200).JSON(fiber.Map{
 			"status":  "success",
@@ -17,18 +23,30 @@ func AuthRoutes(r fiber.Router, authController *controller.AuthController, oauth
 
 	auth := r.Group("/auth")
 
-	auth.Post("/admin/login", authController.AdminLogin)
-	auth.Post("/manager/login", authController.ManagerLogin)
+	auth.Post("/admin/login", middleware.LoginRateLimiter(), authController.AdminLogin)
+	auth.Post("/manager/login", middleware.LoginRateLimiter(), authController.ManagerLogin)
 	auth.Post("/manager/register", authController.ManagerRegister)
-	auth.Post("/staff/login", authController.StaffLogin)
+	auth.Post("/staff/login", middleware.LoginRateLimiter(), authController.StaffLogin)
 	auth.Post("/staff/register", authController.StaffRegister)
 
-	// auth.Post("/refresh", authController.Refresh)
+	auth.Post("/refresh", authController.Refresh)
 
-	// auth.Post("/logout", authController.Logout)
+	auth.Post("/logout", authController.Logout)
 
 	auth.Get("/me", middleware.JWTProtected, authController.GetMe)
 
+	auth.Post("/password/forgot", middleware.LoginRateLimiter(), passwordController.Forgot)
+	auth.Post("/password/reset", passwordController.Reset)
+	auth.Put("/password", middleware.JWTProtected, passwordController.Change)
+
+	// Two-factor authentication
+	auth.Post("/2fa/verify", middleware.TwoFactorRateLimiter(), twoFactorController.Verify)
+	auth.Get("/2fa", middleware.JWTProtected, twoFactorController.Status)
+	auth.Post("/2fa/setup", middleware.JWTOrTwoFactorSetup, twoFactorController.Setup)
+	auth.Post("/2fa/activate", middleware.JWTOrTwoFactorSetup, twoFactorController.Activate)
+	auth.Post("/2fa/disable", middleware.JWTProtected, twoFactorController.Disable)
+	auth.Post("/2fa/recovery-codes", middleware.JWTProtected, twoFactorController.RegenerateRecoveryCodes)
+
 	auth.Get("/google/login", oauthController.GoogleLogin)
 	auth.Get("/google/exchange", oauthController.GoogleExchange)
 }
@@ -2,11 +2,19 @@ package router
 
 import (
 	"training-plan-api/container"
+	"training-plan-api/middleware"
+	"training-plan-api/model"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 func ManagerRoutes(r fiber.Router, deps *container.AppDependencies) {
+	can := func(permissions ...model.Permission) fiber.Handler {
+		return middleware.RequirePermission(deps.PermissionService, permissions...)
+	}
+	canAny := func(permissions ...model.Permission) fiber.Handler {
+		return middleware.RequireAnyPermission(deps.PermissionService, permissions...)
+	}
 
 	r.Get("/healthchecker", func(c *fiber.Ctx) error {
 		return c.Status(200).JSON(fiber.Map{
@@ -16,35 +24,71 @@ func ManagerRoutes(r fiber.Router, deps *container.AppDependencies) {
 	})
 
 	// User management (Manager can only create users in their department)
-	r.Post("/users", deps.UserController.ManagerCreate)
-	r.Get("/users", deps.UserController.ManagerFindDepartmentUsers)
+	r.Post("/users", can(model.PermUsersCreateDepartment), deps.UserController.ManagerCreate)
+	r.Get("/users", can(model.PermUsersReadDepartment), deps.UserController.ManagerFindDepartmentUsers)
 
 	// // Department (with staff list)
 	// r.Get("/departments/:id", deps.DepartmentController.FindByIdWithStaff)
 	//training Plan
-	r.Get("/training-plans", deps.TrainingPlanController.FindPaginated)
-	r.Get("/training-plans/:trainingPlanId", deps.TrainingPlanController.FindById)
+	r.Get("/training-plans", can(model.PermTrainingPlansRead), deps.TrainingPlanController.FindPaginated)
+	r.Get("/training-plans/:trainingPlanId", can(model.PermTrainingPlansRead), deps.TrainingPlanController.FindById)
+	r.Get("/training-plans/:trainingPlanId/sessions", can(model.PermTrainingPlansRead), deps.TrainingSessionController.FindByTrainingPlan)
+	r.Get("/training-plans/:trainingPlanId/calendar.ics", can(model.PermTrainingPlansRead), deps.CalendarFeedController.DownloadTrainingPlan)
+
+	// In-app notifications of the signed-in user
+	r.Get("/notifications", deps.NotificationController.FindInbox)
+	r.Get("/notifications/unread-count", deps.NotificationController.CountUnread)
+	r.Put("/notifications/read-all", deps.NotificationController.MarkAllRead)
+	r.Put("/notifications/:notificationId/read", deps.NotificationController.MarkRead)
+
+	// Notification preferences
+	r.Get("/notification-preferences", deps.NotificationController.GetPreferences)
+	r.Put("/notification-preferences", deps.NotificationController.UpdatePreferences)
+
+	// Calendar feeds; the service checks the permission of each scope
+	r.Get("/calendar-feeds", deps.CalendarFeedController.FindMine)
+	r.Post("/calendar-feeds/:scope", deps.CalendarFeedController.Create)
+	r.Delete("/calendar-feeds/:scope", deps.CalendarFeedController.Revoke)
+	r.Get("/training-sessions/:sessionId/attendance", can(model.PermRecordsReadDepartment), deps.TrainingSessionController.FindAttendance)
+	r.Put("/training-sessions/:sessionId/attendance", can(model.PermRecordsWriteDepartment), deps.TrainingSessionController.MarkAttendance)
 
 	// Register staff to training plan
 	r.Post(
 		"/training-plans/:trainingPlanId/registrations",
+		canAny(model.PermRegistrationsWrite, model.PermRegistrationsWriteDepartment),
 		deps.RecordController.RegisterStaff,
 	)
 
+	// Enrollment requests from the department
+	r.Get("/enrollment-requests", can(model.PermEnrollmentsApproveDepartment), deps.EnrollmentController.FindForReview)
+	r.Get("/enrollment-requests/:id", can(model.PermEnrollmentsApproveDepartment), deps.EnrollmentController.FindById)
+	r.Put("/enrollment-requests/:id/approve", can(model.PermEnrollmentsApproveDepartment), deps.EnrollmentController.Approve)
+	r.Put("/enrollment-requests/:id/reject", can(model.PermEnrollmentsApproveDepartment), deps.EnrollmentController.Reject)
+
 	// // Records
-	r.Get("/records",deps.RecordController.FindRecordByCurrentDepartment)
-	r.Get("/records/:id", deps.RecordController.FindById)
-	r.Put("/records/:id", deps.RecordController.Update)
-	r.Delete("/records/:id", deps.RecordController.Delete)
+	r.Get("/records", can(model.PermRecordsReadDepartment), deps.RecordController.FindRecordByCurrentDepartment)
+	r.Get("/records/:id", canAny(model.PermRecordsRead, model.PermRecordsReadDepartment), deps.RecordController.FindById)
+	r.Put("/records/:id", canAny(model.PermRecordsWrite, model.PermRecordsWriteDepartment), deps.RecordController.Update)
+	r.Delete("/records/:id", canAny(model.PermRecordsWrite, model.PermRecordsWriteDepartment), deps.RecordController.Delete)
 
 
 	//as staff 
 		// // Records (own)
-	r.Get("/staffrecords", deps.RecordController.FindByCurrentUser)
-	r.Get("/staffrecords/:id", deps.RecordController.FindById)
+	r.Get("/staffrecords", can(model.PermRecordsReadOwn), deps.RecordController.FindByCurrentUser)
+	r.Get("/training-history", can(model.PermRecordsReadOwn), deps.RecordController.FindTrainingHistory)
+	r.Get("/staffrecords/:id", can(model.PermRecordsReadOwn), deps.RecordController.FindById)
+
+	// // Own enrollment requests
+	r.Post("/training-plans/:trainingPlanId/enrollment-requests", can(model.PermEnrollmentsRequest), deps.EnrollmentController.Request)
+	r.Get("/my-enrollment-requests", can(model.PermEnrollmentsRequest), deps.EnrollmentController.FindMine)
+	r.Put("/my-enrollment-requests/:id/cancel", can(model.PermEnrollmentsRequest), deps.EnrollmentController.Cancel)
 
 	// // Certificates
-	r.Get("/certificates", deps.CertificateController.FindByCurrentUser) // only approved certificates
-	r.Post("/certificates", deps.CertificateController.Upload)
-	r.Delete("/certificates/:id", deps.CertificateController.Delete)
+	r.Get("/certificates", can(model.PermCertificatesSubmit), deps.CertificateController.FindByCurrentUser) // only approved certificates
+	r.Post("/certificates", can(model.PermCertificatesSubmit), deps.CertificateController.Upload)
+	r.Post("/certificates/external", can(model.PermCertificatesSubmit), deps.CertificateController.UploadExternal)
+	r.Delete("/certificates/:id", can(model.PermCertificatesSubmit), deps.CertificateController.Delete)
+	r.Post("/certificates/:id/resubmit", can(model.PermCertificatesSubmit), deps.CertificateController.Resubmit)
+	r.Get("/certificates/:id/history", can(model.PermCertificatesSubmit), deps.CertificateController.History)
+	r.Get("/certificates/expiring", can(model.PermRecordsReadDepartment), deps.CertificateController.FindExpiring)
 }
@@ -0,0 +1,264 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"training-plan-api/container"
+	"training-plan-api/controller"
+	"training-plan-api/helper"
+	"training-plan-api/middleware"
+	"training-plan-api/model"
+	"training-plan-api/repository"
+	"training-plan-api/service"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	ownDepartment   = 10
+	otherDepartment = 20
+
+	hrID               uint = 1
+	managerID          uint = 2
+	recordOwnerID      uint = 3
+	otherStaffID       uint = 4
+	otherManagerID     uint = 5
+	testRecordID            = 1
+	testTrainingPlanID      = 7
+)
+
+var testUsers = map[uint]model.User{
+	hrID:           {ID: hrID, Role: model.RoleHRAdmin, DepartmentID: 1},
+	managerID:      {ID: managerID, Role: model.RoleDepartmentManager, DepartmentID: ownDepartment},
+	recordOwnerID:  {ID: recordOwnerID, Role: model.RoleStaff, DepartmentID: ownDepartment},
+	otherStaffID:   {ID: otherStaffID, Role: model.RoleStaff, DepartmentID: otherDepartment},
+	otherManagerID: {ID: otherManagerID, Role: model.RoleDepartmentManager, DepartmentID: otherDepartment},
+}
+
+type stubRoleRepository struct {
+	repository.RoleRepository
+}
+
+func (stubRoleRepository) FindAllRolePermissions() ([]model.RolePermission, error) {
+	var rows []model.RolePermission
+	for _, role := range model.SystemRoles {
+		for _, permission := range model.DefaultRolePermissions(role) {
+			rows = append(rows, model.RolePermission{RoleName: string(role), Permission: permission})
+		}
+	}
+	return rows, nil
+}
+
+type stubUserRepository struct {
+	repository.UserRepository
+}
+
+func (stubUserRepository) FindById(userId uint) (*model.User, error) {
+	user, ok := testUsers[userId]
+	if !ok {
+		return nil, helper.NotFound("user not found")
+	}
+	return &user, nil
+}
+
+func (stubUserRepository) FindByIds(userIds []uint) ([]model.User, error) {
+	users := make([]model.User, 0, len(userIds))
+	for _, id := range userIds {
+		if user, ok := testUsers[id]; ok {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+type stubRecordRepository struct {
+	repository.RecordRepository
+}
+
+func (stubRecordRepository) FindById(id int) (*model.Record, error) {
+	if id != testRecordID {
+		return nil, helper.NotFound("record not found")
+	}
+	owner := testUsers[recordOwnerID]
+	return &model.Record{
+		ID:             testRecordID,
+		UserID:         recordOwnerID,
+		User:           &owner,
+		TrainingPlanID: testTrainingPlanID,
+		TrainingPlan:   &model.TrainingPlan{ID: testTrainingPlanID},
+		Status:         model.RecordStatusRegister,
+	}, nil
+}
+
+func (stubRecordRepository) Update(record *model.Record, webhooks ...model.WebhookMessage) error {
+	return nil
+}
+
+func (stubRecordRepository) DeleteAndPromote(id int) ([]model.Record, error) {
+	return nil, nil
+}
+
+func (stubRecordRepository) RegisterWithCapacity(trainingPlanId uint, userIds []uint) ([]model.Record, []model.Record, error) {
+	created := make([]model.Record, 0, len(userIds))
+	for i, id := range userIds {
+		created = append(created, model.Record{
+			ID:             uint(100 + i),
+			UserID:         id,
+			TrainingPlanID: trainingPlanId,
+			Status:         model.RecordStatusRegister,
+		})
+	}
+	return created, nil, nil
+}
+
+type stubSessionRepository struct {
+	repository.TrainingSessionRepository
+}
+
+func (stubSessionRepository) HasSessions(trainingPlanId uint) bool {
+	return false
+}
+
+type stubAuditService struct {
+	service.AuditService
+}
+
+func (stubAuditService) Record(actor model.Actor, action model.AuditAction, entityType string, entityID interface{}, before, after interface{}) {
+}
+
+type stubNotificationService struct {
+	service.NotificationService
+}
+
+func (stubNotificationService) NotifyRegistration(trainingPlanId uint, records []model.Record, promoted bool) {
+}
+
+func (stubNotificationService) NotifyRecordUpdated(record *model.Record) {
+}
+
+type stubEventService struct {
+	service.EventService
+}
+
+func (stubEventService) PublishRegistrations(records []model.Record) {
+}
+
+type stubWebhookService struct {
+	service.WebhookService
+}
+
+func (stubWebhookService) RecordWebhook(event model.WebhookEvent, record *model.Record) model.WebhookMessage {
+	return model.WebhookMessage{Event: event}
+}
+
+// newRecordTestApp mounts the real manager and staff routes on top of the
+// record service, with only the repositories stubbed out.
+func newRecordTestApp(t *testing.T) *fiber.App {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	validate := validator.New()
+	permissionService := service.NewPermissionServiceImpl(stubRoleRepository{}, stubAuditService{}, validate)
+	recordService := service.NewRecordServiceImpl(
+		stubRecordRepository{},
+		stubUserRepository{},
+		nil,
+		stubSessionRepository{},
+		permissionService,
+		stubAuditService{},
+		stubNotificationService{},
+		stubEventService{},
+		stubWebhookService{},
+		validate,
+	)
+
+	deps := &container.AppDependencies{
+		RecordController:  controller.NewRecordController(recordService),
+		PermissionService: permissionService,
+	}
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	ManagerRoutes(app.Group("/manager", middleware.JWTProtected), deps)
+	StaffRoutes(app.Group("/staff", middleware.JWTProtected), deps)
+	return app
+}
+
+func TestRecordRoutesAuthorization(t *testing.T) {
+	app := newRecordTestApp(t)
+
+	recordPath := fmt.Sprintf("/manager/records/%d", testRecordID)
+	registrationPath := fmt.Sprintf("/manager/training-plans/%d/registrations", testTrainingPlanID)
+	updateBody := `{"status":"Register"}`
+	ownRegistration := fmt.Sprintf(`{"userIds":[%d]}`, recordOwnerID)
+	mixedRegistration := fmt.Sprintf(`{"userIds":[%d,%d]}`, recordOwnerID, otherStaffID)
+
+	tests := []struct {
+		name   string
+		caller uint
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"hr reads record", hrID, http.MethodGet, recordPath, "", http.StatusOK},
+		{"manager reads own department record", managerID, http.MethodGet, recordPath, "", http.StatusOK},
+		{"manager reads other department record", otherManagerID, http.MethodGet, recordPath, "", http.StatusForbidden},
+		{"staff reads record on manager route", recordOwnerID, http.MethodGet, recordPath, "", http.StatusForbidden},
+
+		{"hr updates record", hrID, http.MethodPut, recordPath, updateBody, http.StatusOK},
+		{"manager updates own department record", managerID, http.MethodPut, recordPath, updateBody, http.StatusOK},
+		{"manager updates other department record", otherManagerID, http.MethodPut, recordPath, updateBody, http.StatusForbidden},
+		{"staff updates own record", recordOwnerID, http.MethodPut, recordPath, updateBody, http.StatusForbidden},
+
+		{"hr deletes record", hrID, http.MethodDelete, recordPath, "", http.StatusOK},
+		{"manager deletes own department record", managerID, http.MethodDelete, recordPath, "", http.StatusOK},
+		{"manager deletes other department record", otherManagerID, http.MethodDelete, recordPath, "", http.StatusForbidden},
+		{"staff deletes own record", recordOwnerID, http.MethodDelete, recordPath, "", http.StatusForbidden},
+
+		{"hr reads staffrecord", hrID, http.MethodGet, "/manager/staffrecords/1", "", http.StatusOK},
+		{"manager reads own department staffrecord", managerID, http.MethodGet, "/manager/staffrecords/1", "", http.StatusOK},
+		{"manager reads other department staffrecord", otherManagerID, http.MethodGet, "/manager/staffrecords/1", "", http.StatusForbidden},
+		{"staff reads own staffrecord", recordOwnerID, http.MethodGet, "/manager/staffrecords/1", "", http.StatusOK},
+		{"staff reads someone else's staffrecord", otherStaffID, http.MethodGet, "/manager/staffrecords/1", "", http.StatusForbidden},
+
+		{"hr reads record on staff route", hrID, http.MethodGet, "/staff/records/1", "", http.StatusOK},
+		{"manager reads own department record on staff route", managerID, http.MethodGet, "/staff/records/1", "", http.StatusOK},
+		{"manager reads other department record on staff route", otherManagerID, http.MethodGet, "/staff/records/1", "", http.StatusForbidden},
+		{"staff reads own record", recordOwnerID, http.MethodGet, "/staff/records/1", "", http.StatusOK},
+		{"staff reads someone else's record", otherStaffID, http.MethodGet, "/staff/records/1", "", http.StatusForbidden},
+
+		{"hr registers staff", hrID, http.MethodPost, registrationPath, mixedRegistration, http.StatusCreated},
+		{"manager registers own department staff", managerID, http.MethodPost, registrationPath, ownRegistration, http.StatusCreated},
+		{"manager registers staff across departments", managerID, http.MethodPost, registrationPath, mixedRegistration, http.StatusForbidden},
+		{"manager registers other department staff", otherManagerID, http.MethodPost, registrationPath, ownRegistration, http.StatusForbidden},
+		{"staff registers staff", recordOwnerID, http.MethodPost, registrationPath, ownRegistration, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := helper.GenerateAccessToken(tt.caller, string(testUsers[tt.caller].Role))
+			if err != nil {
+				t.Fatalf("generate token: %v", err)
+			}
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.want {
+				t.Errorf("%s %s as user %d: got status %d, want %d", tt.method, tt.path, tt.caller, resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
@@ -17,21 +17,32 @@ func RegisterRoutes(app *fiber.App, deps *container.AppDependencies) {
 	app.Post("/auth/google/exchange", deps.AuthOAuthController.GoogleExchange)
 	app.Post("/user/complete-profile", middleware.JWTProtected, deps.UserController.CompleteProfile)
     api.Get("/departments-list", deps.DepartmentController.GetDepartmentsList)
+	// Calendar clients cannot send a JWT; the feed token authenticates
+	api.Get("/calendar/feeds/:token", deps.CalendarFeedController.Feed)
 	
-	AuthRoutes(api, deps.AuthController, deps.AuthOAuthController)
-	// Role-based routes with JWT and role middleware
+	// Live updates; EventSource cannot send headers so the token may come
+	// from the query string
+	api.Get("/events",
+		middleware.JWTFromQuery,
+		middleware.JWTProtected,
+		middleware.RequirePasswordChanged(deps.UserRepository),
+		deps.EventController.Stream,
+	)
+
+	AuthRoutes(api, deps.AuthController, deps.AuthOAuthController, deps.PasswordController, deps.TwoFactorController)
+	// Authenticated route groups; each route checks its own permissions
 	AdminRoutes(
-		api.Group("/admin", middleware.JWTProtected, middleware.AdminOnly),
+		api.Group("/admin", middleware.JWTProtected, middleware.RequirePasswordChanged(deps.UserRepository)),
 		deps,
 	)
 
 	ManagerRoutes(
-		api.Group("/manager", middleware.JWTProtected, middleware.ManagerOnly),
+		api.Group("/manager", middleware.JWTProtected, middleware.RequirePasswordChanged(deps.UserRepository)),
 		deps,
 	)
 
 	StaffRoutes(
-		api.Group("/staff", middleware.JWTProtected, middleware.RequireProfileComplete(deps.UserRepository) ),
+		api.Group("/staff", middleware.JWTProtected, middleware.RequireProfileComplete(deps.UserRepository), middleware.RequirePasswordChanged(deps.UserRepository) ),
 		deps,
 	)
 }
@@ -2,11 +2,16 @@ package router
 
 import (
 	"training-plan-api/container"
+	"training-plan-api/middleware"
+	"training-plan-api/model"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 func StaffRoutes(r fiber.Router, deps *container.AppDependencies) {
+	can := func(permissions ...model.Permission) fiber.Handler {
+		return middleware.RequirePermission(deps.PermissionService, permissions...)
+	}
 
 	r.Get("/healthchecker", func(c *fiber.Ctx) error {
 		return c.Status(200).JSON(fiber.Map{
@@ -17,12 +22,41 @@ func StaffRoutes(r fiber.Router, deps *container.AppDependencies) {
 
 	
 
+	// Training catalog & enrollment requests
+	r.Get("/training-plans", can(model.PermEnrollmentsRequest), deps.TrainingPlanController.FindCatalog)
+	r.Get("/training-plans/:trainingPlanId", can(model.PermEnrollmentsRequest), deps.TrainingPlanController.FindById)
+	r.Get("/training-plans/:trainingPlanId/sessions", can(model.PermEnrollmentsRequest), deps.TrainingSessionController.FindByTrainingPlan)
+	r.Get("/training-plans/:trainingPlanId/calendar.ics", can(model.PermEnrollmentsRequest), deps.CalendarFeedController.DownloadTrainingPlan)
+	r.Post("/training-plans/:trainingPlanId/enrollment-requests", can(model.PermEnrollmentsRequest), deps.EnrollmentController.Request)
+	r.Get("/enrollment-requests", can(model.PermEnrollmentsRequest), deps.EnrollmentController.FindMine)
+	r.Get("/enrollment-requests/:id", can(model.PermEnrollmentsRequest), deps.EnrollmentController.FindById)
+	r.Put("/enrollment-requests/:id/cancel", can(model.PermEnrollmentsRequest), deps.EnrollmentController.Cancel)
+
+	// In-app notifications of the signed-in user
+	r.Get("/notifications", deps.NotificationController.FindInbox)
+	r.Get("/notifications/unread-count", deps.NotificationController.CountUnread)
+	r.Put("/notifications/read-all", deps.NotificationController.MarkAllRead)
+	r.Put("/notifications/:notificationId/read", deps.NotificationController.MarkRead)
+
+	// Notification preferences
+	r.Get("/notification-preferences", deps.NotificationController.GetPreferences)
+	r.Put("/notification-preferences", deps.NotificationController.UpdatePreferences)
+
+	// Calendar feeds; the service checks the permission of each scope
+	r.Get("/calendar-feeds", deps.CalendarFeedController.FindMine)
+	r.Post("/calendar-feeds/:scope", deps.CalendarFeedController.Create)
+	r.Delete("/calendar-feeds/:scope", deps.CalendarFeedController.Revoke)
+
 	// // Records (own)
-	r.Get("/records", deps.RecordController.FindByCurrentUser)
-	r.Get("/records/:id", deps.RecordController.FindById)
+	r.Get("/records", can(model.PermRecordsReadOwn), deps.RecordController.FindByCurrentUser)
+	r.Get("/training-history", can(model.PermRecordsReadOwn), deps.RecordController.FindTrainingHistory)
+	r.Get("/records/:id", can(model.PermRecordsReadOwn), deps.RecordController.FindById)
 
 	// // Certificates
-	r.Get("/certificates", deps.CertificateController.FindByCurrentUser) // only approved certificates
-	r.Post("/certificates", deps.CertificateController.Upload)
-	r.Delete("/certificates/:id", deps.CertificateController.Delete)
+	r.Get("/certificates", can(model.PermCertificatesSubmit), deps.CertificateController.FindByCurrentUser) // only approved certificates
+	r.Post("/certificates", can(model.PermCertificatesSubmit), deps.CertificateController.Upload)
+	r.Post("/certificates/external", can(model.PermCertificatesSubmit), deps.CertificateController.UploadExternal)
+	r.Delete("/certificates/:id", can(model.PermCertificatesSubmit), deps.CertificateController.Delete)
+	r.Post("/certificates/:id/resubmit", can(model.PermCertificatesSubmit), deps.CertificateController.Resubmit)
+	r.Get("/certificates/:id/history", can(model.PermCertificatesSubmit), deps.CertificateController.History)
 }
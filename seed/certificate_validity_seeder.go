@@ -0,0 +1,21 @@
+package seed
+
+import (
+	"log"
+
+	"training-plan-api/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SeedCertificateValidities adds the default validity periods of
+// categories HR has not configured yet.
+func SeedCertificateValidities(db *gorm.DB) {
+	rows := append([]model.CertificateValidity(nil), model.DefaultCertificateValidities...)
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+		log.Fatal(" Failed to seed certificate validities:", err)
+	}
+
+	log.Println(" Certificate validities seeded")
+}
@@ -0,0 +1,41 @@
+package seed
+
+import (
+	"log"
+
+	"training-plan-api/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SeedPermissions makes sure the system roles exist and hold their default
+// permissions. It only adds rows, so grants made by HR are left untouched.
+func SeedPermissions(db *gorm.DB) {
+	for _, role := range model.SystemRoles {
+		appRole := model.AppRole{
+			Name:     string(role),
+			IsSystem: true,
+		}
+		if err := db.Where("name = ?", appRole.Name).
+			Attrs(model.AppRole{IsSystem: true}).
+			FirstOrCreate(&appRole).Error; err != nil {
+			log.Fatal(" Failed to seed role:", err)
+		}
+
+		defaults := model.DefaultRolePermissions(role)
+		rows := make([]model.RolePermission, 0, len(defaults))
+		for _, permission := range defaults {
+			rows = append(rows, model.RolePermission{
+				RoleName:   appRole.Name,
+				Permission: permission,
+			})
+		}
+
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+			log.Fatal(" Failed to seed role permissions:", err)
+		}
+	}
+
+	log.Println(" Role permissions seeded")
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"training-plan-api/data/request"
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+	"training-plan-api/repository"
+
+	"github.com/xuri/excelize/v2"
+)
+
+type AuditServiceImpl struct {
+	repo     repository.AuditRepository
+	userRepo repository.UserRepository
+}
+
+func NewAuditServiceImpl(
+	repo repository.AuditRepository,
+	userRepo repository.UserRepository,
+) AuditService {
+	return &AuditServiceImpl{
+		repo:     repo,
+		userRepo: userRepo,
+	}
+}
+
+// Record implements AuditService. before and after are snapshots taken with
+// helper.AuditSnapshot (or any JSON-serialisable value); pass nil for the
+// side that does not exist. Failures are logged rather than returned because
+// the audited change has already been committed.
+func (s *AuditServiceImpl) Record(
+	actor model.Actor,
+	action model.AuditAction,
+	entityType string,
+	entityID interface{},
+	before, after interface{},
+) {
+	event := &model.AuditEvent{
+		ActorRole:  string(actor.Role),
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   fmt.Sprint(entityID),
+		Changes:    helper.AuditDiff(helper.AuditSnapshot(before), helper.AuditSnapshot(after)),
+		IP:         actor.IP,
+		RequestID:  actor.RequestID,
+	}
+	if actor.UserID != 0 {
+		actorID := actor.UserID
+		event.ActorID = &actorID
+	}
+
+	if err := s.repo.Save(event); err != nil {
+		log.Printf("audit: failed to record %s %s/%s: %v", action, entityType, event.EntityID, err)
+	}
+}
+
+// Search implements AuditService.
+func (s *AuditServiceImpl) Search(
+	req request.AuditFilterRequest,
+) (response.PaginatedResponse[response.AuditEventResponse], error) {
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 || req.Limit > 100 {
+		req.Limit = 20
+	}
+
+	events, total, err := s.repo.Search(req)
+	if err != nil {
+		return response.PaginatedResponse[response.AuditEventResponse]{}, err
+	}
+
+	return response.PaginatedResponse[response.AuditEventResponse]{
+		Items: s.toResponses(events),
+		Meta: response.PaginationMeta{
+			Page:       req.Page,
+			Limit:      req.Limit,
+			TotalItems: total,
+			TotalPages: int(math.Ceil(float64(total) / float64(req.Limit))),
+		},
+	}, nil
+}
+
+// Export implements AuditService.
+func (s *AuditServiceImpl) Export(req request.AuditFilterRequest) (*excelize.File, error) {
+
+	// Ignore pagination for export
+	req.Page = 1
+	req.Limit = 1000000
+
+	events, _, err := s.repo.Search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	f := excelize.NewFile()
+	sheet := "Audit"
+	f.SetSheetName("Sheet1", sheet)
+
+	headers := []string{
+		"Time",
+		"Actor ID",
+		"Actor Name",
+		"Actor Role",
+		"Action",
+		"Entity Type",
+		"Entity ID",
+		"Changes",
+		"IP",
+		"Request ID",
+	}
+
+	for col, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(col+1, 1)
+		f.SetCellValue(sheet, cell, header)
+	}
+
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{
+			Bold: true,
+		},
+		Fill: excelize.Fill{
+			Type:    "pattern",
+			Color:   []string{"#E9EFF7"},
+			Pattern: 1,
+		},
+	})
+	f.SetCellStyle(sheet, "A1", "J1", headerStyle)
+
+	for i, e := range s.toResponses(events) {
+		var actorID interface{}
+		if e.ActorID != nil {
+			actorID = *e.ActorID
+		}
+
+		values := []interface{}{
+			e.CreatedAt.Format("2006-01-02 15:04:05"),
+			actorID,
+			e.ActorName,
+			e.ActorRole,
+			e.Action,
+			e.EntityType,
+			e.EntityID,
+			string(e.Changes),
+			e.IP,
+			e.RequestID,
+		}
+
+		for col, val := range values {
+			cell, _ := excelize.CoordinatesToCellName(col+1, i+2)
+			f.SetCellValue(sheet, cell, val)
+		}
+	}
+
+	for i := 1; i <= len(headers); i++ {
+		col, _ := excelize.ColumnNumberToName(i)
+		f.SetColWidth(sheet, col, col, 22)
+	}
+	f.SetColWidth(sheet, "H", "H", 80)
+
+	f.SetPanes(sheet, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	})
+
+	return f, nil
+}
+
+func (s *AuditServiceImpl) toResponses(events []model.AuditEvent) []response.AuditEventResponse {
+	actorIDs := make([]uint, 0, len(events))
+	for _, e := range events {
+		if e.ActorID != nil {
+			actorIDs = append(actorIDs, *e.ActorID)
+		}
+	}
+
+	names := make(map[uint]string)
+	if users, err := s.userRepo.FindByIds(actorIDs); err == nil {
+		for _, u := range users {
+			names[u.ID] = u.Name
+		}
+	}
+
+	items := make([]response.AuditEventResponse, 0, len(events))
+	for _, e := range events {
+		changes := json.RawMessage(e.Changes)
+		if len(changes) == 0 {
+			changes = json.RawMessage("{}")
+		}
+
+		item := response.AuditEventResponse{
+			ID:         e.ID,
+			ActorID:    e.ActorID,
+			ActorRole:  e.ActorRole,
+			Action:     string(e.Action),
+			EntityType: e.EntityType,
+			EntityID:   e.EntityID,
+			Changes:    changes,
+			IP:         e.IP,
+			RequestID:  e.RequestID,
+			CreatedAt:  e.CreatedAt,
+		}
+		if e.ActorID != nil {
+			item.ActorName = names[*e.ActorID]
+		}
+
+		items = append(items, item)
+	}
+	return items
+}
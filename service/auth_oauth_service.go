@@ -52,17 +52,17 @@ func (s *AuthOAuthServiceImpl) GetGoogleLoginURL(state string) string {
 	return s.oauthConfig.AuthCodeURL(state, oauth2.AccessTypeOffline)
 }
 
-func (s *AuthOAuthServiceImpl) HandleGoogleCallback(code string) (string, *model.User, error) {
+func (s *AuthOAuthServiceImpl) HandleGoogleCallback(code string) (*model.User, error) {
 	ctx := context.Background()
 
 	token, err := s.oauthConfig.Exchange(ctx, code)
 	if err != nil {
-		return "", nil, helper.Unauthorized("Failed to exchange Google token")
+		return nil, helper.Unauthorized("Failed to exchange Google token")
 	}
 
 	userInfo, err := s.fetchGoogleUserInfo(ctx, token)
 	if err != nil {
-		return "", nil, err
+		return nil, err
 	}
 
 	user, err := s.userRepo.FindByEmail(userInfo.Email)
@@ -87,16 +87,16 @@ newUser := &model.User{
 	IsProfileComplete: false,
 }
 			if err := s.userRepo.Save(newUser); err != nil {
-				return "", nil, err
+				return nil, err
 			}
 			user = newUser
 		} else {
-			return "", nil, err
+			return nil, err
 		}
 	}
 
 	if user.Status != model.UserStatusActive {
-		return "", nil, helper.Unauthorized("Account is deactivated")
+		return nil, helper.Unauthorized("Account is deactivated")
 	}
 
 	if strings.ToLower(user.Provider) != "google" || user.GoogleID == "" || user.Avatar == "" {
@@ -105,7 +105,7 @@ newUser := &model.User{
 			nameUpdate = userInfo.Name
 		}
 		if err := s.userRepo.UpdateOAuthFields(user.ID, userInfo.ID, userInfo.Picture, "google", nameUpdate); err != nil {
-			return "", nil, err
+			return nil, err
 		}
 		user.GoogleID = userInfo.ID
 		user.Avatar = userInfo.Picture
@@ -115,12 +115,7 @@ newUser := &model.User{
 		}
 	}
 
-	jwtToken, err := helper.GenerateAccessToken(user.ID, string(user.Role))
-	if err != nil {
-		return "", nil, helper.InternalServerError("Failed to generate access token")
-	}
-
-	return jwtToken, user, nil
+	return user, nil
 }
 
 func (s *AuthOAuthServiceImpl) fetchGoogleUserInfo(ctx context.Context, token *oauth2.Token) (googleUserInfo, error) {
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"log"
+	"time"
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+	"training-plan-api/repository"
+)
+
+type AuthTokenServiceImpl struct {
+	refreshTokenRepo repository.RefreshTokenRepository
+	userRepo         repository.UserRepository
+}
+
+func NewAuthTokenServiceImpl(
+	refreshTokenRepo repository.RefreshTokenRepository,
+	userRepo repository.UserRepository,
+) AuthTokenService {
+	return &AuthTokenServiceImpl{
+		refreshTokenRepo: refreshTokenRepo,
+		userRepo:         userRepo,
+	}
+}
+
+// IssueTokens starts a new refresh token family for a fresh login.
+func (s *AuthTokenServiceImpl) IssueTokens(user *model.User) (response.TokenResponse, error) {
+	familyID, err := helper.GenerateRandomToken(24)
+	if err != nil {
+		return response.TokenResponse{}, helper.InternalServerError("Failed to generate refresh token")
+	}
+
+	refreshToken, record, err := s.newRefreshToken(user.ID, familyID)
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+
+	if err := s.refreshTokenRepo.Save(record); err != nil {
+		return response.TokenResponse{}, helper.InternalServerError("Failed to store refresh token")
+	}
+
+	return s.buildTokenResponse(user, refreshToken)
+}
+
+// Refresh rotates a refresh token. Presenting a token that was already
+// rotated is treated as theft and revokes every token in its family.
+func (s *AuthTokenServiceImpl) Refresh(refreshToken string) (response.TokenResponse, *model.User, error) {
+	if refreshToken == "" {
+		return response.TokenResponse{}, nil, helper.Unauthorized("Refresh token required")
+	}
+
+	tokenRecord, err := s.refreshTokenRepo.FindByTokenHash(helper.HashToken(refreshToken))
+	if err != nil {
+		return response.TokenResponse{}, nil, err
+	}
+
+	if tokenRecord.Revoked {
+		log.Println("refresh token reuse detected, revoking family for userID:", tokenRecord.UserID)
+		if err := s.refreshTokenRepo.RevokeFamily(tokenRecord.FamilyID); err != nil {
+			log.Println("failed to revoke refresh token family:", err)
+		}
+		return response.TokenResponse{}, nil, helper.Unauthorized("Refresh token is invalid or expired")
+	}
+
+	if !tokenRecord.IsValid() {
+		return response.TokenResponse{}, nil, helper.Unauthorized("Refresh token is invalid or expired")
+	}
+
+	user, err := s.userRepo.FindById(tokenRecord.UserID)
+	if err != nil {
+		return response.TokenResponse{}, nil, helper.Unauthorized("User not found")
+	}
+
+	if user.Status != model.UserStatusActive {
+		_ = s.refreshTokenRepo.RevokeFamily(tokenRecord.FamilyID)
+		return response.TokenResponse{}, nil, helper.Unauthorized("Account is deactivated")
+	}
+
+	newRefreshToken, newRecord, err := s.newRefreshToken(user.ID, tokenRecord.FamilyID)
+	if err != nil {
+		return response.TokenResponse{}, nil, err
+	}
+
+	if err := s.refreshTokenRepo.Rotate(tokenRecord.ID, newRecord); err != nil {
+		if _, ok := err.(*helper.AppError); ok {
+			_ = s.refreshTokenRepo.RevokeFamily(tokenRecord.FamilyID)
+			return response.TokenResponse{}, nil, err
+		}
+		return response.TokenResponse{}, nil, helper.InternalServerError("Failed to store refresh token")
+	}
+
+	tokens, err := s.buildTokenResponse(user, newRefreshToken)
+	if err != nil {
+		return response.TokenResponse{}, nil, err
+	}
+
+	return tokens, user, nil
+}
+
+// Revoke ends the session that owns the given refresh token.
+func (s *AuthTokenServiceImpl) Revoke(refreshToken string) error {
+	if refreshToken == "" {
+		return nil
+	}
+
+	tokenRecord, err := s.refreshTokenRepo.FindByTokenHash(helper.HashToken(refreshToken))
+	if err != nil {
+		// unknown tokens are already logged out
+		return nil
+	}
+
+	return s.refreshTokenRepo.RevokeFamily(tokenRecord.FamilyID)
+}
+
+// RevokeAllForUser signs the user out of every device.
+func (s *AuthTokenServiceImpl) RevokeAllForUser(userID uint) error {
+	return s.refreshTokenRepo.RevokeAllByUser(userID)
+}
+
+func (s *AuthTokenServiceImpl) newRefreshToken(userID uint, familyID string) (string, *model.RefreshToken, error) {
+	refreshToken, err := helper.GenerateRefreshToken()
+	if err != nil {
+		return "", nil, helper.InternalServerError("Failed to generate refresh token")
+	}
+
+	record := &model.RefreshToken{
+		UserID:    userID,
+		TokenHash: helper.HashToken(refreshToken),
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(helper.RefreshTokenExpiry),
+	}
+
+	return refreshToken, record, nil
+}
+
+func (s *AuthTokenServiceImpl) buildTokenResponse(user *model.User, refreshToken string) (response.TokenResponse, error) {
+	accessToken, err := helper.GenerateAccessToken(user.ID, string(user.Role))
+	if err != nil {
+		return response.TokenResponse{}, helper.InternalServerError("Failed to generate access token")
+	}
+
+	return response.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(helper.AccessTokenExpiry.Seconds()),
+	}, nil
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+	"time"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+	"training-plan-api/repository"
+)
+
+// memoryRefreshTokenRepository keeps refresh tokens in memory and rotates
+// them under the same guard as the database: only an active token rotates.
+type memoryRefreshTokenRepository struct {
+	repository.RefreshTokenRepository
+
+	tokens map[uint]*model.RefreshToken
+	nextID uint
+}
+
+func newMemoryRefreshTokenRepository() *memoryRefreshTokenRepository {
+	return &memoryRefreshTokenRepository{tokens: make(map[uint]*model.RefreshToken)}
+}
+
+func (r *memoryRefreshTokenRepository) Save(token *model.RefreshToken) error {
+	r.nextID++
+	token.ID = r.nextID
+	saved := *token
+	r.tokens[token.ID] = &saved
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) FindByTokenHash(tokenHash string) (*model.RefreshToken, error) {
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			found := *token
+			return &found, nil
+		}
+	}
+	return nil, helper.Unauthorized("Invalid refresh token")
+}
+
+func (r *memoryRefreshTokenRepository) Rotate(oldTokenID uint, newToken *model.RefreshToken) error {
+	old := r.tokens[oldTokenID]
+	if old.Revoked {
+		return helper.Unauthorized("Refresh token already used")
+	}
+	if err := r.Save(newToken); err != nil {
+		return err
+	}
+	old.Revoked = true
+	old.ReplacedByID = &newToken.ID
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) RevokeFamily(familyID string) error {
+	for _, token := range r.tokens {
+		if token.FamilyID == familyID {
+			token.Revoked = true
+		}
+	}
+	return nil
+}
+
+// active counts the tokens of a family that can still be used.
+func (r *memoryRefreshTokenRepository) active(familyID string) int {
+	count := 0
+	for _, token := range r.tokens {
+		if token.FamilyID == familyID && token.IsValid() {
+			count++
+		}
+	}
+	return count
+}
+
+type memoryUserRepository struct {
+	repository.UserRepository
+
+	users map[uint]*model.User
+}
+
+func (r *memoryUserRepository) FindById(userId uint) (*model.User, error) {
+	user, ok := r.users[userId]
+	if !ok {
+		return nil, helper.NotFound("user not found")
+	}
+	found := *user
+	return &found, nil
+}
+
+func newTestAuthTokenService(t *testing.T) (*AuthTokenServiceImpl, *memoryRefreshTokenRepository, *model.User) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	user := &model.User{ID: 1, Role: model.RoleStaff, Status: model.UserStatusActive}
+	tokens := newMemoryRefreshTokenRepository()
+	users := &memoryUserRepository{users: map[uint]*model.User{user.ID: user}}
+
+	svc := NewAuthTokenServiceImpl(tokens, users).(*AuthTokenServiceImpl)
+	return svc, tokens, user
+}
+
+func assertUnauthorized(t *testing.T, err error) {
+	t.Helper()
+	appErr, ok := err.(*helper.AppError)
+	if !ok || appErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("err = %v, want 401", err)
+	}
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	svc, tokens, user := newTestAuthTokenService(t)
+
+	issued, err := svc.IssueTokens(user)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	rotated, refreshed, err := svc.Refresh(issued.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if refreshed.ID != user.ID {
+		t.Errorf("refreshed user = %d, want %d", refreshed.ID, user.ID)
+	}
+	if rotated.RefreshToken == issued.RefreshToken {
+		t.Error("refresh returned the same refresh token")
+	}
+
+	old, _ := tokens.FindByTokenHash(helper.HashToken(issued.RefreshToken))
+	current, _ := tokens.FindByTokenHash(helper.HashToken(rotated.RefreshToken))
+	if !old.Revoked || old.ReplacedByID == nil || *old.ReplacedByID != current.ID {
+		t.Errorf("old token revoked = %v, replaced by %v, want revoked and replaced by %d", old.Revoked, old.ReplacedByID, current.ID)
+	}
+	if current.FamilyID != old.FamilyID {
+		t.Error("rotated token left the family")
+	}
+
+	if _, _, err := svc.Refresh(rotated.RefreshToken); err != nil {
+		t.Errorf("refresh with the rotated token: %v", err)
+	}
+}
+
+func TestRefreshRejectsAndRevokesFamily(t *testing.T) {
+	tests := []struct {
+		name string
+		// present returns the token presented to Refresh after the family
+		// was issued and rotated once.
+		present      func(t *testing.T, svc *AuthTokenServiceImpl, tokens *memoryRefreshTokenRepository, user *model.User, issued, rotated string) string
+		revokeFamily bool
+	}{
+		{
+			name: "reused rotated token",
+			present: func(t *testing.T, svc *AuthTokenServiceImpl, tokens *memoryRefreshTokenRepository, user *model.User, issued, rotated string) string {
+				return issued
+			},
+			revokeFamily: true,
+		},
+		{
+			name: "deactivated user",
+			present: func(t *testing.T, svc *AuthTokenServiceImpl, tokens *memoryRefreshTokenRepository, user *model.User, issued, rotated string) string {
+				user.Status = model.UserStatusInactive
+				return rotated
+			},
+			revokeFamily: true,
+		},
+		{
+			name: "expired token",
+			present: func(t *testing.T, svc *AuthTokenServiceImpl, tokens *memoryRefreshTokenRepository, user *model.User, issued, rotated string) string {
+				current, _ := tokens.FindByTokenHash(helper.HashToken(rotated))
+				tokens.tokens[current.ID].ExpiresAt = time.Now().Add(-time.Minute)
+				return rotated
+			},
+		},
+		{
+			name: "unknown token",
+			present: func(t *testing.T, svc *AuthTokenServiceImpl, tokens *memoryRefreshTokenRepository, user *model.User, issued, rotated string) string {
+				return "not-a-refresh-token"
+			},
+		},
+		{
+			name: "empty token",
+			present: func(t *testing.T, svc *AuthTokenServiceImpl, tokens *memoryRefreshTokenRepository, user *model.User, issued, rotated string) string {
+				return ""
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, tokens, user := newTestAuthTokenService(t)
+
+			issued, err := svc.IssueTokens(user)
+			if err != nil {
+				t.Fatalf("issue: %v", err)
+			}
+			rotated, _, err := svc.Refresh(issued.RefreshToken)
+			if err != nil {
+				t.Fatalf("refresh: %v", err)
+			}
+			current, _ := tokens.FindByTokenHash(helper.HashToken(rotated.RefreshToken))
+
+			_, _, err = svc.Refresh(tt.present(t, svc, tokens, user, issued.RefreshToken, rotated.RefreshToken))
+			assertUnauthorized(t, err)
+
+			if revoked := tokens.tokens[current.ID].Revoked; revoked != tt.revokeFamily {
+				t.Errorf("current token of the family revoked = %v, want %v", revoked, tt.revokeFamily)
+			}
+		})
+	}
+}
+
+// TestRefreshLosingRotationRevokesFamily covers two refreshes racing with
+// the same token: the one whose rotation finds the token already revoked
+// treats it as reuse.
+func TestRefreshLosingRotationRevokesFamily(t *testing.T) {
+	svc, tokens, user := newTestAuthTokenService(t)
+
+	issued, err := svc.IssueTokens(user)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	token, _ := tokens.FindByTokenHash(helper.HashToken(issued.RefreshToken))
+
+	// the other refresh rotated between our lookup and our rotation
+	racing := &racingRefreshTokenRepository{memoryRefreshTokenRepository: tokens}
+	svc.refreshTokenRepo = racing
+
+	_, _, err = svc.Refresh(issued.RefreshToken)
+	assertUnauthorized(t, err)
+
+	if active := tokens.active(token.FamilyID); active != 0 {
+		t.Errorf("%d tokens of the family still active, want none", active)
+	}
+}
+
+// racingRefreshTokenRepository rotates the token once on behalf of a
+// concurrent refresh right before the caller's own rotation.
+type racingRefreshTokenRepository struct {
+	*memoryRefreshTokenRepository
+}
+
+func (r *racingRefreshTokenRepository) Rotate(oldTokenID uint, newToken *model.RefreshToken) error {
+	winner := &model.RefreshToken{UserID: newToken.UserID, FamilyID: newToken.FamilyID, TokenHash: "winner", ExpiresAt: newToken.ExpiresAt}
+	if err := r.memoryRefreshTokenRepository.Rotate(oldTokenID, winner); err != nil {
+		return err
+	}
+	return r.memoryRefreshTokenRepository.Rotate(oldTokenID, newToken)
+}
@@ -0,0 +1,326 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+	"training-plan-api/repository"
+)
+
+// calendarFeedLimit caps the records or plans read for one feed.
+const calendarFeedLimit = 1000
+
+type CalendarFeedServiceImpl struct {
+	repo              repository.CalendarFeedTokenRepository
+	trainingPlanRepo  repository.TrainingPlanRepository
+	recordRepo        repository.RecordRepository
+	permissionService PermissionService
+	auditService      AuditService
+	location          *time.Location
+	apiBaseURL        string
+	appBaseURL        string
+}
+
+func NewCalendarFeedServiceImpl(
+	repo repository.CalendarFeedTokenRepository,
+	trainingPlanRepo repository.TrainingPlanRepository,
+	recordRepo repository.RecordRepository,
+	permissionService PermissionService,
+	auditService AuditService,
+	location *time.Location,
+	apiBaseURL string,
+	appBaseURL string,
+) CalendarFeedService {
+	if apiBaseURL == "" {
+		apiBaseURL = "http://localhost:8080"
+	}
+	if appBaseURL == "" {
+		appBaseURL = "http://localhost:3000"
+	}
+
+	return &CalendarFeedServiceImpl{
+		repo:              repo,
+		trainingPlanRepo:  trainingPlanRepo,
+		recordRepo:        recordRepo,
+		permissionService: permissionService,
+		auditService:      auditService,
+		location:          location,
+		apiBaseURL:        strings.TrimRight(apiBaseURL, "/"),
+		appBaseURL:        strings.TrimRight(appBaseURL, "/"),
+	}
+}
+
+// calendarFeedPermissions lists the permissions of which a user needs one
+// to hold a feed of each scope.
+var calendarFeedPermissions = map[model.CalendarFeedScope][]model.Permission{
+	model.CalendarFeedPersonal:     {model.PermRecordsReadOwn},
+	model.CalendarFeedDepartment:   {model.PermRecordsReadDepartment, model.PermRecordsRead},
+	model.CalendarFeedOrganisation: {model.PermEnrollmentsRequest, model.PermTrainingPlansRead},
+}
+
+// CreateFeed implements CalendarFeedService. The feed URL is only returned
+// here; creating the feed again replaces the previous URL.
+func (s *CalendarFeedServiceImpl) CreateFeed(actor model.Actor, scope string) (response.CalendarFeedResponse, error) {
+	feedScope := model.CalendarFeedScope(scope)
+	if !feedScope.IsValid() {
+		return response.CalendarFeedResponse{}, helper.BadRequest("invalid calendar feed scope")
+	}
+
+	if !s.permissionService.HasAnyPermission(string(actor.Role), calendarFeedPermissions[feedScope]...) {
+		return response.CalendarFeedResponse{}, helper.Forbidden("you are not allowed to subscribe to this calendar feed")
+	}
+
+	plain, err := helper.GenerateRandomToken(32)
+	if err != nil {
+		return response.CalendarFeedResponse{}, err
+	}
+
+	token := &model.CalendarFeedToken{
+		UserID:    actor.UserID,
+		Scope:     feedScope,
+		TokenHash: helper.HashToken(plain),
+	}
+	if err := s.repo.Replace(token); err != nil {
+		return response.CalendarFeedResponse{}, err
+	}
+
+	s.auditService.Record(actor, model.AuditCreate, model.AuditEntityCalendarFeed, token.ID,
+		nil, map[string]interface{}{"userId": actor.UserID, "scope": feedScope},
+	)
+
+	resp := toCalendarFeedResponse(*token)
+	resp.URL = s.apiBaseURL + "/api/v1/calendar/feeds/" + plain + ".ics"
+	return resp, nil
+}
+
+// FindMine implements CalendarFeedService.
+func (s *CalendarFeedServiceImpl) FindMine(actor model.Actor) ([]response.CalendarFeedResponse, error) {
+	tokens, err := s.repo.FindByUser(actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]response.CalendarFeedResponse, 0, len(tokens))
+	for _, token := range tokens {
+		items = append(items, toCalendarFeedResponse(token))
+	}
+	return items, nil
+}
+
+// RevokeFeed implements CalendarFeedService.
+func (s *CalendarFeedServiceImpl) RevokeFeed(actor model.Actor, scope string) error {
+	feedScope := model.CalendarFeedScope(scope)
+	if !feedScope.IsValid() {
+		return helper.BadRequest("invalid calendar feed scope")
+	}
+
+	if err := s.repo.Delete(actor.UserID, feedScope); err != nil {
+		return err
+	}
+
+	s.auditService.Record(actor, model.AuditDelete, model.AuditEntityCalendarFeed, actor.UserID,
+		map[string]interface{}{"userId": actor.UserID, "scope": feedScope}, nil,
+	)
+	return nil
+}
+
+// Feed implements CalendarFeedService. The token owner must still be active
+// and hold the scope's permission, so a feed stops working when its owner
+// loses access.
+func (s *CalendarFeedServiceImpl) Feed(plain string) (string, error) {
+	token, err := s.repo.FindByTokenHash(helper.HashToken(plain))
+	if err != nil {
+		return "", err
+	}
+
+	user := token.User
+	if user == nil || user.Status != model.UserStatusActive ||
+		!s.permissionService.HasAnyPermission(string(user.Role), calendarFeedPermissions[token.Scope]...) {
+		return "", helper.NotFound("calendar feed not found")
+	}
+
+	var name string
+	var events []helper.ICSEvent
+
+	switch token.Scope {
+	case model.CalendarFeedPersonal:
+		name = "Trainings - " + user.Name
+		events, err = s.personalEvents(user.ID)
+	case model.CalendarFeedDepartment:
+		name = "Department trainings"
+		events, err = s.departmentEvents(user.DepartmentID)
+	case model.CalendarFeedOrganisation:
+		name = "Training catalog"
+		events, err = s.organisationEvents()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	_ = s.repo.Touch(token.ID, time.Now())
+
+	return helper.BuildICSCalendar(name, events), nil
+}
+
+// TrainingPlanICS implements CalendarFeedService. Drafts are only available
+// to those who may see them.
+func (s *CalendarFeedServiceImpl) TrainingPlanICS(actor model.Actor, trainingPlanId int) (string, error) {
+	trainingPlan, err := s.trainingPlanRepo.FindById(trainingPlanId)
+	if err != nil {
+		return "", err
+	}
+
+	if trainingPlan.Status == model.TrainingPlanDraft &&
+		!s.permissionService.HasPermission(string(actor.Role), model.PermTrainingPlansWrite) {
+		return "", helper.NotFound("training plan not found")
+	}
+
+	status := helper.ICSStatusConfirmed
+	switch trainingPlan.Status {
+	case model.TrainingPlanDraft:
+		status = helper.ICSStatusTentative
+	case model.TrainingPlanCancelled:
+		status = helper.ICSStatusCancelled
+	}
+
+	return helper.BuildICSCalendar(trainingPlan.Name, []helper.ICSEvent{
+		s.icsEvent(trainingPlan, status),
+	}), nil
+}
+
+func (s *CalendarFeedServiceImpl) personalEvents(userId uint) ([]helper.ICSEvent, error) {
+	records, _, err := s.recordRepo.FindByUserId(userId, 0, calendarFeedLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]helper.ICSEvent, 0, len(records))
+	for _, record := range records {
+		if record.TrainingPlan == nil || record.TrainingPlan.Status == model.TrainingPlanDraft {
+			continue
+		}
+
+		status := helper.ICSStatusConfirmed
+		switch {
+		case record.TrainingPlan.Status == model.TrainingPlanCancelled || record.Status == model.RecordStatusCancelled:
+			status = helper.ICSStatusCancelled
+		case record.Status == model.RecordStatusWaitlisted:
+			status = helper.ICSStatusTentative
+		}
+
+		events = append(events, s.icsEvent(record.TrainingPlan, status))
+	}
+	return events, nil
+}
+
+// departmentEvents lists each plan the department's staff are on once; it
+// is confirmed while anyone holds a seat.
+func (s *CalendarFeedServiceImpl) departmentEvents(departmentId int) ([]helper.ICSEvent, error) {
+	records, _, err := s.recordRepo.FindByManagerDepartment(departmentId, 0, calendarFeedLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make(map[int]string)
+	plans := make([]*model.TrainingPlan, 0)
+	for _, record := range records {
+		plan := record.TrainingPlan
+		if plan == nil || plan.Status == model.TrainingPlanDraft {
+			continue
+		}
+
+		status := helper.ICSStatusCancelled
+		switch {
+		case plan.Status == model.TrainingPlanCancelled:
+		case holdsSeat(record.Status):
+			status = helper.ICSStatusConfirmed
+		case record.Status == model.RecordStatusWaitlisted:
+			status = helper.ICSStatusTentative
+		}
+
+		current, seen := statuses[plan.ID]
+		if !seen {
+			plans = append(plans, plan)
+		}
+		if !seen || icsStatusRank[status] > icsStatusRank[current] {
+			statuses[plan.ID] = status
+		}
+	}
+
+	events := make([]helper.ICSEvent, 0, len(plans))
+	for _, plan := range plans {
+		events = append(events, s.icsEvent(plan, statuses[plan.ID]))
+	}
+	return events, nil
+}
+
+// icsStatusRank orders statuses so a department feed shows the strongest
+// status of its staff.
+var icsStatusRank = map[string]int{
+	helper.ICSStatusCancelled: 0,
+	helper.ICSStatusTentative: 1,
+	helper.ICSStatusConfirmed: 2,
+}
+
+func (s *CalendarFeedServiceImpl) organisationEvents() ([]helper.ICSEvent, error) {
+	plans, _, err := s.trainingPlanRepo.FindPaginated([]model.TrainingPlanStatus{
+		model.TrainingPlanPublished,
+		model.TrainingPlanCompleted,
+		model.TrainingPlanCancelled,
+	}, 0, calendarFeedLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]helper.ICSEvent, 0, len(plans))
+	for i := range plans {
+		status := helper.ICSStatusConfirmed
+		if plans[i].Status == model.TrainingPlanCancelled {
+			status = helper.ICSStatusCancelled
+		}
+		events = append(events, s.icsEvent(&plans[i], status))
+	}
+	return events, nil
+}
+
+// icsEvent describes a plan as a feed entry. The UID only depends on the
+// plan ID so every feed and download refers to the same event.
+func (s *CalendarFeedServiceImpl) icsEvent(trainingPlan *model.TrainingPlan, status string) helper.ICSEvent {
+	start, end := trainingPlanTimes(trainingPlan, s.location)
+	link := fmt.Sprintf("%s/training-plans/%d", s.appBaseURL, trainingPlan.ID)
+
+	event := helper.ICSEvent{
+		UID:          fmt.Sprintf("training-plan-%d@%s", trainingPlan.ID, s.uidDomain()),
+		Sequence:     trainingPlan.Sequence,
+		Status:       status,
+		Title:        trainingPlan.Name,
+		Description:  strings.TrimSpace(trainingPlan.Content + "\n\n" + link),
+		URL:          link,
+		Start:        start,
+		End:          end,
+		LastModified: trainingPlan.UpdatedAt,
+	}
+	if trainingPlan.Location != nil {
+		event.Location = *trainingPlan.Location
+	}
+	return event
+}
+
+func (s *CalendarFeedServiceImpl) uidDomain() string {
+	if u, err := url.Parse(s.appBaseURL); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return "training-plan-api"
+}
+
+func toCalendarFeedResponse(token model.CalendarFeedToken) response.CalendarFeedResponse {
+	return response.CalendarFeedResponse{
+		Scope:      string(token.Scope),
+		CreatedAt:  token.CreatedAt,
+		LastUsedAt: token.LastUsedAt,
+	}
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+	"training-plan-api/repository"
+)
+
+const feedDepartment = 10
+
+type feedTokenRepository struct {
+	repository.CalendarFeedTokenRepository
+
+	owner *model.User
+}
+
+// FindByTokenHash hands out a token of the scope named by the plain token.
+func (r feedTokenRepository) FindByTokenHash(tokenHash string) (*model.CalendarFeedToken, error) {
+	for _, scope := range []model.CalendarFeedScope{model.CalendarFeedPersonal, model.CalendarFeedDepartment, model.CalendarFeedOrganisation} {
+		if helper.HashToken(string(scope)) == tokenHash {
+			return &model.CalendarFeedToken{ID: 1, UserID: r.owner.ID, User: r.owner, Scope: scope, TokenHash: tokenHash}, nil
+		}
+	}
+	return nil, helper.NotFound("calendar feed not found")
+}
+
+func (feedTokenRepository) Touch(id uint, usedAt time.Time) error {
+	return nil
+}
+
+type feedTrainingPlanRepository struct {
+	repository.TrainingPlanRepository
+
+	plan *model.TrainingPlan
+}
+
+func (r feedTrainingPlanRepository) FindById(id int) (*model.TrainingPlan, error) {
+	plan := *r.plan
+	return &plan, nil
+}
+
+func (r feedTrainingPlanRepository) FindPaginated(statuses []model.TrainingPlanStatus, offset, limit int) ([]model.TrainingPlan, int64, error) {
+	return []model.TrainingPlan{*r.plan}, 1, nil
+}
+
+type feedRecordRepository struct {
+	repository.RecordRepository
+
+	records []model.Record
+}
+
+func (r feedRecordRepository) FindByUserId(userID uint, offset int, limit int) ([]model.Record, int64, error) {
+	var mine []model.Record
+	for _, record := range r.records {
+		if record.UserID == userID {
+			mine = append(mine, record)
+		}
+	}
+	return mine, int64(len(mine)), nil
+}
+
+func (r feedRecordRepository) FindByManagerDepartment(departmentId int, offset int, limit int) ([]model.Record, int64, error) {
+	return r.records, int64(len(r.records)), nil
+}
+
+// feedEvent returns the SEQUENCE and STATUS a calendar gives the event of
+// the plan with the given id.
+func feedEvent(t *testing.T, ics string, trainingPlanId int) (string, string) {
+	t.Helper()
+
+	uid := fmt.Sprintf("UID:training-plan-%d@", trainingPlanId)
+	for _, vevent := range strings.Split(ics, "BEGIN:VEVENT")[1:] {
+		if !strings.Contains(vevent, uid) {
+			continue
+		}
+		var sequence, status string
+		for _, line := range strings.Split(vevent, "\r\n") {
+			if strings.HasPrefix(line, "SEQUENCE:") {
+				sequence = strings.TrimPrefix(line, "SEQUENCE:")
+			}
+			if strings.HasPrefix(line, "STATUS:") {
+				status = strings.TrimPrefix(line, "STATUS:")
+			}
+		}
+		return sequence, status
+	}
+	t.Fatalf("no event of plan %d in\n%s", trainingPlanId, ics)
+	return "", ""
+}
+
+// TestFeedsShareTheSequenceOfAPlan checks that every feed and the single
+// plan download count an event's SEQUENCE the same way, whatever status
+// each derives, so no copy of the event shadows another in a client.
+func TestFeedsShareTheSequenceOfAPlan(t *testing.T) {
+	owner := &model.User{ID: 1, Role: model.RoleHRAdmin, Status: model.UserStatusActive, DepartmentID: feedDepartment}
+	plan := &model.TrainingPlan{
+		ID:        7,
+		Name:      "Fire safety",
+		Status:    model.TrainingPlanPublished,
+		Date:      time.Date(2026, time.November, 2, 9, 0, 0, 0, time.UTC),
+		Sequence:  5,
+		UpdatedAt: time.Date(2026, time.October, 1, 9, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name         string
+		recordStatus model.RecordStatus
+		planStatus   model.TrainingPlanStatus
+		personal     string
+		department   string
+		organisation string
+	}{
+		{"registered", model.RecordStatusRegister, model.TrainingPlanPublished, helper.ICSStatusConfirmed, helper.ICSStatusConfirmed, helper.ICSStatusConfirmed},
+		{"waitlisted", model.RecordStatusWaitlisted, model.TrainingPlanPublished, helper.ICSStatusTentative, helper.ICSStatusTentative, helper.ICSStatusConfirmed},
+		{"registration cancelled", model.RecordStatusCancelled, model.TrainingPlanPublished, helper.ICSStatusCancelled, helper.ICSStatusCancelled, helper.ICSStatusConfirmed},
+		{"plan cancelled", model.RecordStatusCancelled, model.TrainingPlanCancelled, helper.ICSStatusCancelled, helper.ICSStatusCancelled, helper.ICSStatusCancelled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := *plan
+			current.Status = tt.planStatus
+			record := model.Record{
+				ID:             3,
+				UserID:         owner.ID,
+				TrainingPlanID: uint(current.ID),
+				TrainingPlan:   &current,
+				Status:         tt.recordStatus,
+				UpdatedAt:      current.UpdatedAt.Add(time.Hour),
+			}
+
+			svc := NewCalendarFeedServiceImpl(
+				feedTokenRepository{owner: owner},
+				feedTrainingPlanRepository{plan: &current},
+				feedRecordRepository{records: []model.Record{record}},
+				defaultPermissionService{},
+				noopAuditService{},
+				time.UTC,
+				"",
+				"",
+			)
+
+			feeds := []struct {
+				scope model.CalendarFeedScope
+				want  string
+			}{
+				{model.CalendarFeedPersonal, tt.personal},
+				{model.CalendarFeedDepartment, tt.department},
+				{model.CalendarFeedOrganisation, tt.organisation},
+			}
+			for _, feed := range feeds {
+				ics, err := svc.Feed(string(feed.scope))
+				if err != nil {
+					t.Fatalf("%s feed: %v", feed.scope, err)
+				}
+				sequence, status := feedEvent(t, ics, current.ID)
+				if sequence != "5" {
+					t.Errorf("%s feed: SEQUENCE = %s, want the plan's 5", feed.scope, sequence)
+				}
+				if status != feed.want {
+					t.Errorf("%s feed: STATUS = %s, want %s", feed.scope, status, feed.want)
+				}
+			}
+
+			ics, err := svc.TrainingPlanICS(model.Actor{UserID: owner.ID, Role: owner.Role}, current.ID)
+			if err != nil {
+				t.Fatalf("download: %v", err)
+			}
+			if sequence, _ := feedEvent(t, ics, current.ID); sequence != "5" {
+				t.Errorf("download: SEQUENCE = %s, want the plan's 5", sequence)
+			}
+		})
+	}
+}
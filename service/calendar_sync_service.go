@@ -0,0 +1,443 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+	"training-plan-api/repository"
+)
+
+const (
+	calendarSyncBatchSize = 20
+	calendarSyncLease     = 5 * time.Minute
+	calendarSyncTimeout   = 30 * time.Second
+)
+
+type CalendarSyncServiceImpl struct {
+	outboxRepo       repository.CalendarOutboxRepository
+	trainingPlanRepo repository.TrainingPlanRepository
+	recordRepo       repository.RecordRepository
+	sessionRepo      repository.TrainingSessionRepository
+	calendar         helper.CalendarProvider
+	auditService     AuditService
+	retry            helper.RetryPolicy
+	location         *time.Location
+	appBaseURL       string
+}
+
+func NewCalendarSyncServiceImpl(
+	outboxRepo repository.CalendarOutboxRepository,
+	trainingPlanRepo repository.TrainingPlanRepository,
+	recordRepo repository.RecordRepository,
+	sessionRepo repository.TrainingSessionRepository,
+	calendar helper.CalendarProvider,
+	auditService AuditService,
+	retry helper.RetryPolicy,
+	location *time.Location,
+	appBaseURL string,
+) CalendarSyncService {
+	if appBaseURL == "" {
+		appBaseURL = "http://localhost:3000"
+	}
+
+	return &CalendarSyncServiceImpl{
+		outboxRepo:       outboxRepo,
+		trainingPlanRepo: trainingPlanRepo,
+		recordRepo:       recordRepo,
+		sessionRepo:      sessionRepo,
+		calendar:         calendar,
+		auditService:     auditService,
+		retry:            retry,
+		location:         location,
+		appBaseURL:       strings.TrimRight(appBaseURL, "/"),
+	}
+}
+
+// Run processes the outbox every interval until ctx is cancelled.
+func (s *CalendarSyncServiceImpl) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ProcessDue(ctx); err != nil {
+			log.Println("Calendar sync failed:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunResponses reads attendee responses back every interval until ctx is
+// cancelled.
+func (s *CalendarSyncServiceImpl) RunResponses(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.SyncResponses(ctx); err != nil {
+			log.Println("Calendar response sync failed:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncResponses implements CalendarSyncService. It copies the attendees'
+// invitation responses of every upcoming plan on the calendar into their
+// records and returns how many records changed. A plan whose event cannot
+// be read is logged and skipped.
+func (s *CalendarSyncServiceImpl) SyncResponses(ctx context.Context) (int, error) {
+	today := time.Now()
+	if s.location != nil {
+		today = today.In(s.location)
+	}
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
+
+	plans, err := s.trainingPlanRepo.FindOnCalendar(today)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for i := range plans {
+		n, err := s.syncPlanResponses(ctx, &plans[i])
+		if err != nil {
+			log.Printf("Calendar response sync of plan %d failed: %v", plans[i].ID, err)
+			continue
+		}
+		changed += n
+	}
+	return changed, nil
+}
+
+// RefreshResponses implements CalendarSyncService.
+func (s *CalendarSyncServiceImpl) RefreshResponses(ctx context.Context, trainingPlanId int) (int, error) {
+	trainingPlan, err := s.trainingPlanRepo.FindById(trainingPlanId)
+	if err != nil {
+		return 0, err
+	}
+
+	if trainingPlan.CalendarEventID == nil {
+		return 0, helper.BadRequest("training plan has no calendar event")
+	}
+
+	return s.syncPlanResponses(ctx, trainingPlan)
+}
+
+func (s *CalendarSyncServiceImpl) syncPlanResponses(ctx context.Context, trainingPlan *model.TrainingPlan) (int, error) {
+	callCtx, cancel := context.WithTimeout(ctx, calendarSyncTimeout)
+	defer cancel()
+
+	responses, err := s.calendar.Responses(callCtx, *trainingPlan.CalendarEventID)
+	if err != nil {
+		return 0, err
+	}
+	// the provider cannot read responses back
+	if responses == nil {
+		return 0, nil
+	}
+
+	records, err := s.recordRepo.FindByTrainingPlan(uint(trainingPlan.ID))
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, record := range records {
+		if record.User == nil || !holdsSeat(record.Status) {
+			continue
+		}
+
+		rsvp, ok := responses[strings.ToLower(record.User.Email)]
+		if !ok || (record.RSVPStatus != nil && *record.RSVPStatus == rsvp) {
+			continue
+		}
+
+		if err := s.recordRepo.UpdateRSVPStatus(record.ID, &rsvp); err != nil {
+			return changed, err
+		}
+		changed++
+	}
+	return changed, nil
+}
+
+// ProcessDue implements CalendarSyncService. It returns how many entries
+// were attempted.
+func (s *CalendarSyncServiceImpl) ProcessDue(ctx context.Context) (int, error) {
+	entries, err := s.outboxRepo.ClaimDue(time.Now(), calendarSyncLease, calendarSyncBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, entry := range entries {
+		attempts := entry.Attempts + 1
+
+		callCtx, cancel := context.WithTimeout(ctx, calendarSyncTimeout)
+		err := s.process(callCtx, entry)
+		cancel()
+
+		switch {
+		case err == nil:
+			err = s.outboxRepo.MarkDone(entry.ID, attempts)
+		case s.retry.Exhausted(attempts):
+			log.Printf("Calendar sync %d (%s plan %d) failed permanently: %v", entry.ID, entry.Operation, entry.TrainingPlanID, err)
+			err = s.outboxRepo.MarkFailed(entry.ID, attempts, err.Error())
+		default:
+			err = s.outboxRepo.MarkRetry(entry.ID, attempts, time.Now().Add(s.retry.Delay(attempts)), err.Error())
+		}
+		if err != nil {
+			return len(entries), err
+		}
+	}
+
+	return len(entries), nil
+}
+
+// Resync implements CalendarSyncService. It queues the plan's event to be
+// rewritten from the current plan, recreating it if it went missing.
+func (s *CalendarSyncServiceImpl) Resync(actor model.Actor, trainingPlanId int, recreate bool) error {
+	trainingPlan, err := s.trainingPlanRepo.FindById(trainingPlanId)
+	if err != nil {
+		return err
+	}
+
+	if trainingPlan.Status != model.TrainingPlanPublished && trainingPlan.Status != model.TrainingPlanCompleted {
+		return helper.BadRequest("only published or completed training plans are on the calendar")
+	}
+
+	// forget the old event so the worker creates a fresh one
+	if recreate && trainingPlan.CalendarEventID != nil {
+		if err := s.trainingPlanRepo.SetCalendarEventID(trainingPlanId, nil); err != nil {
+			return err
+		}
+		if err := s.outboxRepo.Enqueue(trainingPlanId, model.CalendarOpDelete, trainingPlan.CalendarEventID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.outboxRepo.Enqueue(trainingPlanId, model.CalendarOpUpsert, nil); err != nil {
+		return err
+	}
+
+	s.auditService.Record(actor, model.AuditUpdate, model.AuditEntityTrainingPlan, trainingPlanId,
+		nil, map[string]interface{}{"calendarResync": true, "recreate": recreate},
+	)
+	return nil
+}
+
+// Retry implements CalendarSyncService.
+func (s *CalendarSyncServiceImpl) Retry(actor model.Actor, id uint) error {
+	entry, err := s.outboxRepo.FindById(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.outboxRepo.Requeue(id); err != nil {
+		return err
+	}
+
+	s.auditService.Record(actor, model.AuditUpdate, model.AuditEntityTrainingPlan, entry.TrainingPlanID,
+		nil, map[string]interface{}{"calendarRetry": id},
+	)
+	return nil
+}
+
+// FindFailed implements CalendarSyncService.
+func (s *CalendarSyncServiceImpl) FindFailed(page, limit int) (response.PaginatedResponse[response.CalendarSyncFailureResponse], error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+
+	entries, total, err := s.outboxRepo.FindFailedPaginated((page-1)*limit, limit)
+	if err != nil {
+		return response.PaginatedResponse[response.CalendarSyncFailureResponse]{}, err
+	}
+
+	items := make([]response.CalendarSyncFailureResponse, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, response.CalendarSyncFailureResponse{
+			ID:             entry.ID,
+			TrainingPlanID: entry.TrainingPlanID,
+			Operation:      string(entry.Operation),
+			EventID:        entry.EventID,
+			Attempts:       entry.Attempts,
+			LastError:      entry.LastError,
+			CreatedAt:      entry.CreatedAt,
+			FailedAt:       entry.ProcessedAt,
+		})
+	}
+
+	return response.PaginatedResponse[response.CalendarSyncFailureResponse]{
+		Items: items,
+		Meta: response.PaginationMeta{
+			Page:       page,
+			Limit:      limit,
+			TotalItems: total,
+			TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+		},
+	}, nil
+}
+
+func (s *CalendarSyncServiceImpl) process(ctx context.Context, entry model.CalendarOutbox) error {
+	switch entry.Operation {
+	case model.CalendarOpDelete:
+		if entry.EventID == nil {
+			return nil
+		}
+		return s.calendar.DeleteEvent(ctx, *entry.EventID)
+
+	case model.CalendarOpUpsert:
+		trainingPlan, err := s.trainingPlanRepo.FindById(entry.TrainingPlanID)
+		if isNotFound(err) {
+			// deleted since; its delete entry removes the event
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// cancelled since; its delete entry removes the event
+		if trainingPlan.Status != model.TrainingPlanPublished && trainingPlan.Status != model.TrainingPlanCompleted {
+			return nil
+		}
+		if trainingPlan.Date.IsZero() {
+			return nil
+		}
+
+		event, err := s.calendarEvent(trainingPlan)
+		if err != nil {
+			return err
+		}
+		if trainingPlan.CalendarEventID != nil {
+			return s.calendar.UpdateEvent(ctx, *trainingPlan.CalendarEventID, event)
+		}
+
+		eventID, err := s.calendar.CreateEvent(ctx, event)
+		if err != nil {
+			return err
+		}
+
+		// providers without stored events return no ID
+		if eventID == "" {
+			return nil
+		}
+
+		attached, err := s.trainingPlanRepo.AttachCalendarEvent(trainingPlan.ID, eventID)
+		if err != nil {
+			return err
+		}
+		if !attached {
+			// cancelled or deleted while the event was being created; no
+			// delete entry was queued since the plan had no event yet
+			return s.calendar.DeleteEvent(ctx, eventID)
+		}
+		return nil
+	}
+
+	log.Printf("Calendar sync %d: unknown operation %q", entry.ID, entry.Operation)
+	return nil
+}
+
+// calendarEvent describes a plan for the calendar provider, with everyone
+// holding a seat as attendee.
+func (s *CalendarSyncServiceImpl) calendarEvent(trainingPlan *model.TrainingPlan) (helper.CalendarEvent, error) {
+	start, end := trainingPlanTimes(trainingPlan, s.location)
+
+	records, err := s.recordRepo.FindByTrainingPlan(uint(trainingPlan.ID))
+	if err != nil {
+		return helper.CalendarEvent{}, err
+	}
+
+	attendees := make([]helper.CalendarAttendee, 0, len(records))
+	for _, record := range records {
+		if record.User == nil || record.User.Email == "" || !holdsSeat(record.Status) {
+			continue
+		}
+		attendees = append(attendees, helper.CalendarAttendee{
+			Email: record.User.Email,
+			Name:  record.User.Name,
+		})
+	}
+
+	trainer, err := s.trainer(trainingPlan)
+	if err != nil {
+		return helper.CalendarEvent{}, err
+	}
+
+	link := fmt.Sprintf("%s/training-plans/%d", s.appBaseURL, trainingPlan.ID)
+
+	description := trainingPlan.Content
+	if trainer != "" {
+		description += "\n\nTrainer: " + trainer
+	}
+	description += "\n\n" + link
+
+	event := helper.CalendarEvent{
+		Title:       trainingPlan.Name,
+		Description: strings.TrimSpace(description),
+		URL:         link,
+		Start:       start,
+		End:         end,
+		Attendees:   attendees,
+	}
+	if trainingPlan.Location != nil {
+		event.Location = *trainingPlan.Location
+	}
+	return event, nil
+}
+
+// trainingPlanTimes returns when a plan takes place on the calendar. Plans
+// without NumberOfHours are shown as an 8-hour day.
+func trainingPlanTimes(trainingPlan *model.TrainingPlan, location *time.Location) (time.Time, time.Time) {
+	hours := 8
+	if trainingPlan.NumberOfHours != nil {
+		hours = *trainingPlan.NumberOfHours
+	}
+
+	start := trainingPlan.Date
+	if location != nil {
+		start = start.In(location)
+	}
+	return start, start.Add(time.Duration(hours) * time.Hour)
+}
+
+// trainer names the plan's trainers: those of its sessions, or else the
+// speaker institute.
+func (s *CalendarSyncServiceImpl) trainer(trainingPlan *model.TrainingPlan) (string, error) {
+	sessions, err := s.sessionRepo.FindByTrainingPlan(uint(trainingPlan.ID))
+	if err != nil {
+		return "", err
+	}
+
+	var trainers []string
+	seen := make(map[string]bool)
+	for _, session := range sessions {
+		if session.Trainer == nil || *session.Trainer == "" || seen[*session.Trainer] {
+			continue
+		}
+		seen[*session.Trainer] = true
+		trainers = append(trainers, *session.Trainer)
+	}
+
+	if len(trainers) == 0 && trainingPlan.SpeakerInstitute != nil {
+		return *trainingPlan.SpeakerInstitute, nil
+	}
+	return strings.Join(trainers, ", "), nil
+}
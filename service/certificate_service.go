@@ -6,6 +6,7 @@ import (
 	"math"
 	"mime/multipart"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"training-plan-api/data/request"
@@ -17,20 +18,82 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
+// CertificateSettings configures how certificates count as attendance and
+// how expiring certificates are renewed.
+type CertificateSettings struct {
+	// EvidenceTypes lists, comma separated, the training types for which an
+	// approved certificate marks the record Attended
+	EvidenceTypes string
+	// RenewalDays is how long before a certificate expires its holder is
+	// registered on the next plan of the same category
+	RenewalDays int
+}
+
 type CertificateServiceImpl struct {
-	repo     repository.CertificateRepository
-	validate *validator.Validate
-	storage  helper.Storage
+	repo         repository.CertificateRepository
+	recordRepo   repository.RecordRepository
+	validityRepo     repository.CertificateValidityRepository
+	trainingPlanRepo repository.TrainingPlanRepository
+	userRepo         repository.UserRepository
+	permissionService PermissionService
+	auditService AuditService
+	notificationService NotificationService
+	eventService        EventService
+	webhookService      WebhookService
+	validate     *validator.Validate
+	storage      helper.Storage
+	location     *time.Location
+	// evidenceTypes are the training types for which an approved
+	// certificate counts as attendance
+	evidenceTypes map[model.TrainingPlanType]bool
+	renewalDays   int
 }
 func NewCertificateServiceImpl(
 	repo repository.CertificateRepository,
+	recordRepo repository.RecordRepository,
+	validityRepo repository.CertificateValidityRepository,
+	trainingPlanRepo repository.TrainingPlanRepository,
+	userRepo repository.UserRepository,
+	permissionService PermissionService,
+	auditService AuditService,
+	notificationService NotificationService,
+	eventService EventService,
+	webhookService WebhookService,
 	validate *validator.Validate,
 	storage helper.Storage,
+	location *time.Location,
+	settings CertificateSettings,
 ) CertificateService {
+	if settings.EvidenceTypes == "" {
+		settings.EvidenceTypes = string(model.TypePublic) + "," + string(model.TypeOnline)
+	}
+	if settings.RenewalDays <= 0 {
+		settings.RenewalDays = 30
+	}
+
+	types := map[model.TrainingPlanType]bool{}
+	for _, t := range strings.Split(settings.EvidenceTypes, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types[model.TrainingPlanType(t)] = true
+		}
+	}
+
 	return &CertificateServiceImpl{
-		repo:     repo,
-		validate: validate,
-		storage:  storage,
+		repo:         repo,
+		recordRepo:   recordRepo,
+		validityRepo:     validityRepo,
+		trainingPlanRepo: trainingPlanRepo,
+		userRepo:         userRepo,
+		permissionService: permissionService,
+		auditService: auditService,
+		notificationService: notificationService,
+		eventService:        eventService,
+		webhookService:      webhookService,
+		validate:     validate,
+		storage:      storage,
+		location:     location,
+		evidenceTypes: types,
+		renewalDays:   settings.RenewalDays,
 	}
 }
 
@@ -38,7 +101,7 @@ func NewCertificateServiceImpl(
 
 // ================= ADMIN =================
 
-func (c *CertificateServiceImpl) Approve(certificateID int) error {
+func (c *CertificateServiceImpl) Approve(actor model.Actor, certificateID int) error {
 	cert, err := c.repo.FindById(certificateID)
 	if err != nil {
 		return err
@@ -48,10 +111,40 @@ func (c *CertificateServiceImpl) Approve(certificateID int) error {
 		return helper.BadRequest("certificate is not pending")
 	}
 
-	return c.repo.UpdateStatus(certificateID, model.CertApproved)
+	record, recordBefore, err := c.evidencedRecord(cert)
+	if err != nil {
+		return err
+	}
+
+	before := certificateReviewFields(cert)
+	c.review(actor, cert, model.CertApproved, nil)
+	if err := c.applyValidity(cert); err != nil {
+		return err
+	}
+	webhooks := []model.WebhookMessage{c.webhookService.CertificateWebhook(model.WebhookCertificateApproved, cert)}
+	if record != nil {
+		webhooks = append(webhooks, c.webhookService.RecordWebhook(model.WebhookRecordAttended, record))
+	}
+	if err := c.repo.Review(cert, record, webhooks...); err != nil {
+		return err
+	}
+
+	c.auditService.Record(actor, model.AuditApprove, model.AuditEntityCertificate, certificateID, before, certificateReviewFields(cert))
+	if record != nil {
+		c.recordAttended(actor, record, recordBefore)
+	}
+	c.notificationService.NotifyCertificate(model.NotifyCertificateApproved, cert, "")
+	c.eventService.PublishCertificate(model.EventCertificateApproved, cert)
+	return nil
 }
 
-func (c *CertificateServiceImpl) Reject(certificateID int) error {
+// Reject keeps the certificate and its file so the owner can see why it was
+// rejected and resubmit it.
+func (c *CertificateServiceImpl) Reject(actor model.Actor, certificateID int, req request.RejectCertificateRequest) error {
+	if err := c.validate.Struct(req); err != nil {
+		return helper.ValidationError(helper.FormatValidationError(err))
+	}
+
 	cert, err := c.repo.FindById(certificateID)
 	if err != nil {
 		return err
@@ -61,19 +154,120 @@ func (c *CertificateServiceImpl) Reject(certificateID int) error {
 		return helper.BadRequest("certificate is not pending")
 	}
 
-	if err := c.repo.Delete(certificateID); err != nil {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return helper.BadRequest("rejection reason is required")
+	}
+
+	before := certificateReviewFields(cert)
+	c.review(actor, cert, model.CertRejected, &reason)
+	if err := c.repo.Review(cert, nil, c.webhookService.CertificateWebhook(model.WebhookCertificateRejected, cert)); err != nil {
 		return err
 	}
 
-	if cert.Image != "" {
-		if err := c.storage.Delete(cert.Image); err != nil {
-			log.Println("⚠ failed to delete certificate file:", err)
+	c.auditService.Record(actor, model.AuditReject, model.AuditEntityCertificate, certificateID, before, certificateReviewFields(cert))
+	c.notificationService.NotifyCertificate(model.NotifyCertificateRejected, cert, reason)
+	c.eventService.PublishCertificate(model.EventCertificateRejected, cert)
+	return nil
+}
+
+// applyValidity sets the category of an approved internal certificate
+// from its plan and, unless the holder gave one, its expiry date from the
+// validity of the category. The validity runs from the issue date of
+// external certificates and from the plan date of internal ones.
+func (c *CertificateServiceImpl) applyValidity(cert *model.Certificate) error {
+	if cert.Category == nil && cert.Training != nil {
+		category := cert.Training.Category
+		cert.Category = &category
+	}
+	if cert.ExpiryDate != nil || cert.Category == nil {
+		return nil
+	}
+
+	validities, err := c.validityRepo.FindAll()
+	if err != nil {
+		return err
+	}
+
+	months := 0
+	for _, validity := range validities {
+		if validity.Category == *cert.Category {
+			months = validity.Months
 		}
 	}
+	if months == 0 {
+		// the category does not expire
+		return nil
+	}
 
+	start := c.today(*cert.ReviewedAt)
+	if cert.IssueDate != nil {
+		start = *cert.IssueDate
+	} else if cert.Training != nil {
+		start = cert.Training.Date
+	}
+	expiry := start.AddDate(0, months, 0)
+	cert.ExpiryDate = &expiry
 	return nil
 }
 
+// evidencedRecord returns the training record an approval of cert marks
+// Attended, or nil when the plan's type does not accept certificates as
+// evidence or the record is already Attended. The second result is the
+// audit snapshot of the record before, nil for a record not created yet.
+func (c *CertificateServiceImpl) evidencedRecord(cert *model.Certificate) (*model.Record, map[string]interface{}, error) {
+	plan := cert.Training
+	if cert.TrainingID == nil || plan == nil || !c.evidenceTypes[plan.Type] {
+		return nil, nil, nil
+	}
+
+	trainingID := *cert.TrainingID
+	record := &model.Record{UserID: cert.UserID, TrainingPlanID: trainingID}
+	var before map[string]interface{}
+	if c.recordRepo.Exists(cert.UserID, trainingID) {
+		existing, err := c.recordRepo.FindByUserAndTrainingPlan(cert.UserID, trainingID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if existing.Status == model.RecordStatusAttended {
+			// keep the hours already credited from session attendance
+			cert.RecordID = &existing.ID
+			return nil, nil, nil
+		}
+		record = existing
+		before = helper.AuditSnapshot(recordFields(record))
+	}
+
+	record.Status = model.RecordStatusAttended
+	record.CreditedHours = 0
+	if plan.NumberOfHours != nil {
+		record.CreditedHours = float64(*plan.NumberOfHours)
+	}
+	record.TrainingPlan = plan
+	return record, before, nil
+}
+
+// recordAttended runs the side effects of a record marked Attended by a
+// certificate approval.
+func (c *CertificateServiceImpl) recordAttended(actor model.Actor, record *model.Record, before map[string]interface{}) {
+	action := model.AuditUpdate
+	if before == nil {
+		action = model.AuditCreate
+	}
+	c.auditService.Record(actor, action, model.AuditEntityRecord, int(record.ID), before, recordFields(record))
+	c.eventService.PublishRegistrations([]model.Record{*record})
+}
+
+// review sets the outcome of the current version on cert.
+func (c *CertificateServiceImpl) review(actor model.Actor, cert *model.Certificate, status model.CertificateStatus, reason *string) {
+	now := time.Now()
+	reviewer := actor.UserID
+	cert.Status = status
+	cert.RejectionReason = reason
+	cert.ReviewedByID = &reviewer
+	cert.ReviewedAt = &now
+}
+
 func (c *CertificateServiceImpl) FindAllPending(
 	page, limit int,
 ) (response.PaginatedResponse[response.CertificateResponse], error) {
@@ -94,32 +288,7 @@ func (c *CertificateServiceImpl) FindAllPending(
 
 	items := make([]response.CertificateResponse, 0, len(certs))
 	for _, cert := range certs {
-		resp := response.CertificateResponse{
-			ID:          cert.ID,
-			UserID:      cert.UserID,
-			Image:       cert.Image,
-			Description: cert.Description,
-			Status:      string(cert.Status),
-			CreatedAt:   cert.CreatedAt,
-			UpdatedAt:   cert.UpdatedAt,
-		}
-
-		if cert.User != nil {
-			resp.UserName = cert.User.Name
-			resp.EmployeeID = cert.User.EmployeeID
-		}
-		if cert.User.Department != nil {
-			resp.Department = cert.User.Department.Name
-			resp.Division = string(cert.User.Department.Division)
-		}
-		if cert.Training != nil {
-			resp.TrainingID = cert.TrainingID
-			resp.TrainingName = cert.Training.Name
-			resp.Category = string(cert.Training.Category)
-		}
-
-
-		items = append(items, resp)
+		items = append(items, toCertificateResponse(cert))
 	}
 
 	return response.PaginatedResponse[response.CertificateResponse]{
@@ -146,87 +315,104 @@ func (c *CertificateServiceImpl) FindByCurrentUser(
 
 	responses := make([]response.CertificateResponse, 0, len(certificates))
 	for _, cert := range certificates {
-		resp := response.CertificateResponse{
-			ID:          cert.ID,
-			UserID:      cert.UserID,
-			Image:       cert.Image,
-			Description: cert.Description,
-			Status:      string(cert.Status),
-			CreatedAt:   cert.CreatedAt,
-			UpdatedAt:   cert.UpdatedAt,
-		}
-
-		if cert.User != nil {
-			resp.UserName = cert.User.Name
-			resp.EmployeeID = cert.User.EmployeeID
-		}
-		if cert.User.Department != nil {
-			resp.Department = cert.User.Department.Name
-			resp.Division = string(cert.User.Department.Division)
-		}
-		if cert.Training != nil {
-			resp.TrainingID = cert.TrainingID
-			resp.TrainingName = cert.Training.Name
-			resp.Category = string(cert.Training.Category)
-		}
-
-		responses = append(responses, resp)
+		responses = append(responses, toCertificateResponse(cert))
 	}
 
 	return responses, nil
 }
 
 func (c *CertificateServiceImpl) Upload(
-	userID uint,
+	actor model.Actor,
 	req request.CreateCertificateRequest,
 	fileHeader *multipart.FileHeader,
 ) error {
 
+	userID := actor.UserID
+
 	if err := c.validate.Struct(req); err != nil {
 		return helper.ValidationError(helper.FormatValidationError(err))
 	}
 
-	file, err := fileHeader.Open()
+	objectPath, err := c.storeFile(userID, fileHeader)
 	if err != nil {
-		return helper.BadRequest("Failed to open uploaded file")
+		return err
 	}
-	defer file.Close()
 
-	ext := filepath.Ext(fileHeader.Filename)
-	objectPath := fmt.Sprintf(
-		"certificates/user_%d/%d%s",
-		userID,
-		time.Now().Unix(),
-		ext,
-	)
+	certificate := &model.Certificate{
+		UserID:      userID,
+		Kind:        model.CertKindInternal,
+		TrainingID:  &req.TrainingID,
+		Image:       "uploads/" + objectPath,
+		Description: req.Description,
+		ExpiryDate:  req.ExpiryDate,
+		Status:      model.CertPending,
+		Version:     1,
+	}
 
-	if _, err := c.storage.Upload(
-		objectPath,
-		file,
-		fileHeader.Header.Get("Content-Type"),
-	); err != nil {
-		return helper.Internal("Failed to upload certificate")
+	return c.submit(actor, certificate, objectPath)
+}
+
+// UploadExternal submits a certificate of a course taken outside the
+// company for review. It goes through the same approval queue as internal
+// certificates.
+func (c *CertificateServiceImpl) UploadExternal(
+	actor model.Actor,
+	req request.CreateExternalCertificateRequest,
+	fileHeader *multipart.FileHeader,
+) error {
+	if err := c.validate.Struct(req); err != nil {
+		return helper.ValidationError(helper.FormatValidationError(err))
+	}
+
+	if req.ExpiryDate != nil && !req.ExpiryDate.After(req.IssueDate) {
+		return helper.BadRequest("expiry date must be after the issue date")
 	}
 
+	objectPath, err := c.storeFile(actor.UserID, fileHeader)
+	if err != nil {
+		return err
+	}
+
+	issuer := strings.TrimSpace(req.Issuer)
+	title := strings.TrimSpace(req.Title)
+	category := model.TrainingPlanCategory(req.Category)
+	hours := req.Hours
+	issueDate := req.IssueDate
+
 	certificate := &model.Certificate{
-		UserID:      userID,
-		TrainingID:  req.TrainingID,
+		UserID:      actor.UserID,
+		Kind:        model.CertKindExternal,
+		Issuer:      &issuer,
+		Title:       &title,
+		IssueDate:   &issueDate,
+		ExpiryDate:  req.ExpiryDate,
+		Hours:       &hours,
+		Category:    &category,
 		Image:       "uploads/" + objectPath,
 		Description: req.Description,
 		Status:      model.CertPending,
+		Version:     1,
 	}
 
-	if err := c.repo.Save(certificate); err != nil {
+	return c.submit(actor, certificate, objectPath)
+}
+
+// submit stores a newly uploaded certificate, removing its file again when
+// that fails.
+func (c *CertificateServiceImpl) submit(actor model.Actor, certificate *model.Certificate, objectPath string) error {
+	if err := c.repo.Save(certificate, c.webhookService.CertificateWebhook(model.WebhookCertificateUploaded, certificate)); err != nil {
 		_ = c.storage.Delete(objectPath)
 		return err
 	}
 
+	c.auditService.Record(actor, model.AuditCreate, model.AuditEntityCertificate, certificate.ID, nil, certificate)
+	c.eventService.PublishCertificate(model.EventCertificateUploaded, certificate)
 	return nil
 }
 
 func (c *CertificateServiceImpl) Delete(
+	actor model.Actor,
 	certificateID int,
-	userID uint,
 ) error {
 
 	certificate, err := c.repo.FindById(certificateID)
@@ -234,17 +420,379 @@ func (c *CertificateServiceImpl) Delete(
 		return err
 	}
 
-	if certificate.UserID != userID {
+	if certificate.UserID != actor.UserID {
 		return helper.Forbidden("You don't have permission to delete this certificate")
 	}
 
+	versions, err := c.repo.FindVersions(certificate.ID)
+	if err != nil {
+		return err
+	}
+
 	if err := c.repo.Delete(certificateID); err != nil {
 		return err
 	}
 
-	if certificate.Image != "" {
-		_ = c.storage.Delete(certificate.Image)
+	c.auditService.Record(actor, model.AuditDelete, model.AuditEntityCertificate, certificateID, certificate, nil)
+
+	// every submitted file goes, not only the current one
+	images := map[string]bool{certificate.Image: true}
+	for _, version := range versions {
+		images[version.Image] = true
+	}
+	for image := range images {
+		if image != "" {
+			_ = c.storage.Delete(image)
+		}
 	}
 
 	return nil
 }
+
+// Resubmit replaces the file of a rejected certificate with a new version
+// and sends it back for review. Earlier versions stay in the history.
+func (c *CertificateServiceImpl) Resubmit(
+	actor model.Actor,
+	certificateID int,
+	req request.ResubmitCertificateRequest,
+	fileHeader *multipart.FileHeader,
+) error {
+	if err := c.validate.Struct(req); err != nil {
+		return helper.ValidationError(helper.FormatValidationError(err))
+	}
+
+	certificate, err := c.repo.FindById(certificateID)
+	if err != nil {
+		return err
+	}
+
+	if certificate.UserID != actor.UserID {
+		return helper.Forbidden("You don't have permission to resubmit this certificate")
+	}
+	if certificate.Status != model.CertRejected {
+		return helper.BadRequest("only rejected certificates can be resubmitted")
+	}
+
+	objectPath, err := c.storeFile(actor.UserID, fileHeader)
+	if err != nil {
+		return err
+	}
+
+	previous := model.NewCertificateVersion(certificate)
+	before := certificateReviewFields(certificate)
+
+	certificate.Image = "uploads/" + objectPath
+	if req.Description != nil {
+		certificate.Description = req.Description
+	}
+	certificate.Version++
+	certificate.Status = model.CertPending
+	certificate.RejectionReason = nil
+	certificate.ReviewedByID = nil
+	certificate.ReviewedBy = nil
+	certificate.ReviewedAt = nil
+
+	if err := c.repo.Resubmit(certificate, previous, c.webhookService.CertificateWebhook(model.WebhookCertificateUploaded, certificate)); err != nil {
+		_ = c.storage.Delete(objectPath)
+		return err
+	}
+
+	c.auditService.Record(actor, model.AuditUpdate, model.AuditEntityCertificate, certificateID, before, certificateReviewFields(certificate))
+	c.eventService.PublishCertificate(model.EventCertificateUploaded, certificate)
+	return nil
+}
+
+// FindHistory returns a certificate with every submitted version and its
+// review. Owners see their own certificates; reviewers see all.
+func (c *CertificateServiceImpl) FindHistory(actor model.Actor, certificateID int) (response.CertificateHistoryResponse, error) {
+	certificate, err := c.repo.FindById(certificateID)
+	if err != nil {
+		return response.CertificateHistoryResponse{}, err
+	}
+
+	if certificate.UserID != actor.UserID &&
+		!c.permissionService.HasPermission(string(actor.Role), model.PermCertificatesApprove) {
+		return response.CertificateHistoryResponse{}, helper.Forbidden("You don't have permission to view this certificate")
+	}
+
+	versions, err := c.repo.FindVersions(certificate.ID)
+	if err != nil {
+		return response.CertificateHistoryResponse{}, err
+	}
+	// certificates uploaded before versions were kept have none stored
+	if len(versions) == 0 {
+		versions = []model.CertificateVersion{model.NewCertificateVersion(certificate)}
+		versions[0].ReviewedBy = certificate.ReviewedBy
+		versions[0].CreatedAt = certificate.CreatedAt
+	}
+
+	items := make([]response.CertificateVersionResponse, 0, len(versions))
+	for _, version := range versions {
+		item := response.CertificateVersionResponse{
+			Version:         version.Version,
+			Image:           version.Image,
+			Description:     version.Description,
+			Status:          string(version.Status),
+			RejectionReason: version.RejectionReason,
+			ReviewedByID:    version.ReviewedByID,
+			ReviewedAt:      version.ReviewedAt,
+			SubmittedAt:     version.CreatedAt,
+		}
+		if version.ReviewedBy != nil {
+			item.ReviewedByName = version.ReviewedBy.Name
+		}
+		items = append(items, item)
+	}
+
+	return response.CertificateHistoryResponse{
+		Certificate: toCertificateResponse(*certificate),
+		Versions:    items,
+	}, nil
+}
+
+// FindValidities returns the validity period of every category that
+// expires.
+func (c *CertificateServiceImpl) FindValidities() ([]response.CertificateValidityResponse, error) {
+	validities, err := c.validityRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]response.CertificateValidityResponse, 0, len(validities))
+	for _, validity := range validities {
+		responses = append(responses, response.CertificateValidityResponse{
+			Category:  string(validity.Category),
+			Months:    validity.Months,
+			UpdatedAt: validity.UpdatedAt,
+		})
+	}
+	return responses, nil
+}
+
+// SaveValidity sets the validity period of a category. Certificates
+// approved before keep their expiry date.
+func (c *CertificateServiceImpl) SaveValidity(actor model.Actor, req request.SaveCertificateValidityRequest) error {
+	if err := c.validate.Struct(req); err != nil {
+		return helper.ValidationError(helper.FormatValidationError(err))
+	}
+
+	validity := &model.CertificateValidity{
+		Category: model.TrainingPlanCategory(strings.TrimSpace(req.Category)),
+		Months:   req.Months,
+	}
+	if err := c.validityRepo.Save(validity); err != nil {
+		return err
+	}
+
+	c.auditService.Record(actor, model.AuditUpdate, model.AuditEntityCertValidity, string(validity.Category), nil, validity)
+	return nil
+}
+
+// DeleteValidity makes certificates of a category approved from now on
+// never expire, unless the holder gives an expiry date.
+func (c *CertificateServiceImpl) DeleteValidity(actor model.Actor, category string) error {
+	category = strings.TrimSpace(category)
+	if err := c.validityRepo.Delete(model.TrainingPlanCategory(category)); err != nil {
+		return err
+	}
+
+	c.auditService.Record(actor, model.AuditDelete, model.AuditEntityCertValidity, category, nil, nil)
+	return nil
+}
+
+// FindExpiring reports, per department, the certificates expiring within
+// days or already expired that were not renewed. Reviewers see every
+// department, or the one of departmentId when it is not zero; managers
+// only see their own.
+func (c *CertificateServiceImpl) FindExpiring(actor model.Actor, days int, departmentId int) ([]response.ExpiringDepartmentResponse, error) {
+	if days <= 0 {
+		days = c.renewalDays
+	}
+
+	if !c.permissionService.HasPermission(string(actor.Role), model.PermCertificatesApprove) {
+		user, err := c.userRepo.FindById(actor.UserID)
+		if err != nil {
+			return nil, err
+		}
+		departmentId = user.DepartmentID
+	}
+
+	today := c.today(time.Now())
+	certificates, err := c.repo.FindExpiring(nil, today.AddDate(0, 0, days), departmentId)
+	if err != nil {
+		return nil, err
+	}
+
+	departments := make([]response.ExpiringDepartmentResponse, 0)
+	index := make(map[int]int)
+	for _, cert := range certificates {
+		var id int
+		var name string
+		if cert.User != nil {
+			id = cert.User.DepartmentID
+			if cert.User.Department != nil {
+				name = cert.User.Department.Name
+			}
+		}
+
+		i, ok := index[id]
+		if !ok {
+			i = len(departments)
+			index[id] = i
+			departments = append(departments, response.ExpiringDepartmentResponse{
+				DepartmentID: id,
+				Department:   name,
+				Certificates: []response.ExpiringCertificateResponse{},
+			})
+		}
+
+		expiry := time.Date(cert.ExpiryDate.Year(), cert.ExpiryDate.Month(), cert.ExpiryDate.Day(), 0, 0, 0, 0, today.Location())
+		departments[i].Certificates = append(departments[i].Certificates, response.ExpiringCertificateResponse{
+			CertificateResponse: toCertificateResponse(cert),
+			DaysLeft:            int(math.Round(expiry.Sub(today).Hours() / 24)),
+		})
+	}
+
+	return departments, nil
+}
+
+// RegisterRenewals registers holders of certificates expiring within the
+// renewal window on the next published plan of the same category. Holders
+// already registered on an upcoming plan of that category are left alone.
+func (c *CertificateServiceImpl) RegisterRenewals(now time.Time) error {
+	today := c.today(now)
+
+	certificates, err := c.repo.FindExpiring(nil, today.AddDate(0, 0, c.renewalDays), 0)
+	if err != nil {
+		return err
+	}
+
+	upcoming := make(map[model.TrainingPlanCategory][]model.TrainingPlan)
+	for _, cert := range certificates {
+		if cert.Category == nil {
+			continue
+		}
+		category := *cert.Category
+
+		if c.recordRepo.HasUpcomingInCategory(cert.UserID, category, today) {
+			continue
+		}
+
+		plans, ok := upcoming[category]
+		if !ok {
+			if plans, err = c.trainingPlanRepo.FindUpcomingByCategory(category, today); err != nil {
+				return err
+			}
+			upcoming[category] = plans
+		}
+		if len(plans) == 0 {
+			continue
+		}
+
+		planId := uint(plans[0].ID)
+		created, promoted, err := c.recordRepo.RegisterWithCapacity(planId, []uint{cert.UserID})
+		if err != nil {
+			log.Printf("certificate renewal: register user %d on plan %d: %v", cert.UserID, planId, err)
+			continue
+		}
+
+		for i := range created {
+			c.auditService.Record(model.SystemActor, model.AuditCreate, model.AuditEntityRecord, created[i].ID, nil, created[i])
+		}
+		for _, p := range promoted {
+			c.auditService.Record(model.SystemActor, model.AuditUpdate, model.AuditEntityRecord, p.ID,
+				map[string]interface{}{"status": model.RecordStatusWaitlisted},
+				map[string]interface{}{"status": p.Status},
+			)
+		}
+		c.notificationService.NotifyRegistration(planId, created, false)
+		c.notificationService.NotifyRegistration(planId, promoted, true)
+		c.eventService.PublishRegistrations(append(created, promoted...))
+	}
+	return nil
+}
+
+func (c *CertificateServiceImpl) today(now time.Time) time.Time {
+	if c.location != nil {
+		now = now.In(c.location)
+	}
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
+
+// storeFile uploads a certificate file of userID and returns its object path.
+func (c *CertificateServiceImpl) storeFile(userID uint, fileHeader *multipart.FileHeader) (string, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", helper.BadRequest("Failed to open uploaded file")
+	}
+	defer file.Close()
+
+	ext := filepath.Ext(fileHeader.Filename)
+	objectPath := fmt.Sprintf(
+		"certificates/user_%d/%d%s",
+		userID,
+		time.Now().UnixNano(),
+		ext,
+	)
+
+	if _, err := c.storage.Upload(
+		objectPath,
+		file,
+		fileHeader.Header.Get("Content-Type"),
+	); err != nil {
+		return "", helper.Internal("Failed to upload certificate")
+	}
+	return objectPath, nil
+}
+
+func certificateReviewFields(cert *model.Certificate) map[string]interface{} {
+	return map[string]interface{}{
+		"status":          cert.Status,
+		"version":         cert.Version,
+		"image":           cert.Image,
+		"rejectionReason": cert.RejectionReason,
+		"reviewedById":    cert.ReviewedByID,
+	}
+}
+
+func toCertificateResponse(cert model.Certificate) response.CertificateResponse {
+	resp := response.CertificateResponse{
+		ID:              cert.ID,
+		UserID:          cert.UserID,
+		Image:           cert.Image,
+		Description:     cert.Description,
+		Kind:            string(cert.Kind),
+		TrainingID:      cert.TrainingID,
+		TrainingName:    cert.TrainingName(),
+		Issuer:          cert.Issuer,
+		IssueDate:       cert.IssueDate,
+		ExpiryDate:      cert.ExpiryDate,
+		Hours:           cert.Hours,
+		Status:          string(cert.Status),
+		Version:         cert.Version,
+		RejectionReason: cert.RejectionReason,
+		ReviewedByID:    cert.ReviewedByID,
+		ReviewedAt:      cert.ReviewedAt,
+		RecordID:        cert.RecordID,
+		CreatedAt:       cert.CreatedAt,
+		UpdatedAt:       cert.UpdatedAt,
+	}
+
+	if cert.User != nil {
+		resp.UserName = cert.User.Name
+		resp.EmployeeID = cert.User.EmployeeID
+		if cert.User.Department != nil {
+			resp.Department = cert.User.Department.Name
+			resp.Division = string(cert.User.Department.Division)
+		}
+	}
+	if cert.Category != nil {
+		resp.Category = string(*cert.Category)
+	} else if cert.Training != nil {
+		resp.Category = string(cert.Training.Category)
+	}
+	if cert.ReviewedBy != nil {
+		resp.ReviewedByName = cert.ReviewedBy.Name
+	}
+	return resp
+}
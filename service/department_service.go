@@ -12,22 +12,25 @@ import (
 )
 
 type DepartmentServiceImpl struct {
-	repo     repository.DepartmentRepository
-	validate *validator.Validate
+	repo         repository.DepartmentRepository
+	auditService AuditService
+	validate     *validator.Validate
 }
 
 func NewDepartmentServiceImpl(
 	repo repository.DepartmentRepository,
+	auditService AuditService,
 	validate *validator.Validate,
 ) DepartmentService {
 	return &DepartmentServiceImpl{
-		repo:     repo,
-		validate: validate,
+		repo:         repo,
+		auditService: auditService,
+		validate:     validate,
 	}
 }
 
 // Create implements DepartmentService.
-func (d *DepartmentServiceImpl) Create(req request.CreateDepartmentRequest) error {
+func (d *DepartmentServiceImpl) Create(actor model.Actor, req request.CreateDepartmentRequest) error {
 	if err := d.validate.Struct(req); err != nil {
 		return helper.ValidationError(
 			helper.FormatValidationError(err),
@@ -43,11 +46,13 @@ func (d *DepartmentServiceImpl) Create(req request.CreateDepartmentRequest) erro
 	if err != nil {
 		return err
 	}
+
+	d.auditService.Record(actor, model.AuditCreate, model.AuditEntityDepartment, department.ID, nil, department)
 	return nil
 }
 
 // Delete implements DepartmentService.
-func (d *DepartmentServiceImpl) Delete(departmentId int) error {
+func (d *DepartmentServiceImpl) Delete(actor model.Actor, departmentId int) error {
 	staffCount, err := d.repo.FindByIdWithStaffCount(departmentId)
 	if err != nil {
 		return err
@@ -59,6 +64,12 @@ func (d *DepartmentServiceImpl) Delete(departmentId int) error {
 	if err != nil {
 		return err
 	}
+
+	d.auditService.Record(actor, model.AuditDelete, model.AuditEntityDepartment, departmentId, model.Department{
+		ID:       staffCount.ID,
+		Name:     staffCount.Name,
+		Division: staffCount.Division,
+	}, nil)
 	return nil
 }
 
@@ -156,7 +167,7 @@ func (d *DepartmentServiceImpl) FindDepartmentList() ([]response.DepartmentListI
 }
 
 // Update implements DepartmentService.
-func (d *DepartmentServiceImpl) Update(departmentId int, req request.UpdateDepartmentRequest) error {
+func (d *DepartmentServiceImpl) Update(actor model.Actor, departmentId int, req request.UpdateDepartmentRequest) error {
 	if err := d.validate.Struct(req); err != nil {
 		return helper.ValidationError(
 			helper.FormatValidationError(err),
@@ -168,6 +179,8 @@ func (d *DepartmentServiceImpl) Update(departmentId int, req request.UpdateDepar
 		return err
 	}
 
+	before := helper.AuditSnapshot(department)
+
 	department.Name = req.Name
 	department.Division = req.Division
 
@@ -175,5 +188,7 @@ func (d *DepartmentServiceImpl) Update(departmentId int, req request.UpdateDepar
 	if err != nil {
 		return err
 	}
+
+	d.auditService.Record(actor, model.AuditUpdate, model.AuditEntityDepartment, departmentId, before, department)
 	return nil
 }
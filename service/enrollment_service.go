@@ -0,0 +1,497 @@
+package service
+
+import (
+	"math"
+	"net/http"
+	"strings"
+	"time"
+	"training-plan-api/data/request"
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+	"training-plan-api/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type EnrollmentServiceImpl struct {
+	repo              repository.EnrollmentRepository
+	recordRepo        repository.RecordRepository
+	trainingPlanRepo  repository.TrainingPlanRepository
+	userRepo          repository.UserRepository
+	permissionService PermissionService
+	auditService      AuditService
+	notificationService NotificationService
+	eventService        EventService
+	validate          *validator.Validate
+}
+
+func NewEnrollmentServiceImpl(
+	repo repository.EnrollmentRepository,
+	recordRepo repository.RecordRepository,
+	trainingPlanRepo repository.TrainingPlanRepository,
+	userRepo repository.UserRepository,
+	permissionService PermissionService,
+	auditService AuditService,
+	notificationService NotificationService,
+	eventService EventService,
+	validate *validator.Validate,
+) EnrollmentService {
+	return &EnrollmentServiceImpl{
+		repo:              repo,
+		recordRepo:        recordRepo,
+		trainingPlanRepo:  trainingPlanRepo,
+		userRepo:          userRepo,
+		permissionService: permissionService,
+		auditService:      auditService,
+		notificationService: notificationService,
+		eventService:        eventService,
+		validate:          validate,
+	}
+}
+
+// Request implements EnrollmentService.
+func (s *EnrollmentServiceImpl) Request(
+	actor model.Actor,
+	trainingPlanId uint,
+	req request.CreateEnrollmentRequest,
+) (response.EnrollmentRequestResponse, error) {
+
+	if err := s.validate.Struct(req); err != nil {
+		return response.EnrollmentRequestResponse{}, helper.ValidationError(helper.FormatValidationError(err))
+	}
+
+	plan, err := s.trainingPlanRepo.FindById(int(trainingPlanId))
+	if err != nil {
+		return response.EnrollmentRequestResponse{}, helper.NotFound("training plan not found")
+	}
+
+	if plan.Status != model.TrainingPlanPublished {
+		return response.EnrollmentRequestResponse{}, helper.BadRequest("training plan is not open for enrollment")
+	}
+
+	today := time.Now().Truncate(24 * time.Hour)
+	if plan.Date.Before(today) {
+		return response.EnrollmentRequestResponse{}, helper.BadRequest("training plan is no longer open for enrollment")
+	}
+
+	if _, err := s.recordRepo.FindByUserAndTrainingPlan(actor.UserID, trainingPlanId); err == nil {
+		return response.EnrollmentRequestResponse{}, helper.BadRequest("you are already registered for this training plan")
+	}
+
+	if s.repo.ExistsOpen(actor.UserID, trainingPlanId) {
+		return response.EnrollmentRequestResponse{}, helper.BadRequest("you already have an open request for this training plan")
+	}
+
+	enrollment := &model.EnrollmentRequest{
+		UserID:         actor.UserID,
+		TrainingPlanID: trainingPlanId,
+		Status:         model.EnrollmentPending,
+		Reason:         strings.TrimSpace(req.Reason),
+	}
+	event := &model.EnrollmentRequestEvent{
+		ActorID:   actor.UserID,
+		ActorRole: string(actor.Role),
+		Action:    model.EnrollmentActionRequest,
+		ToStatus:  model.EnrollmentPending,
+		Reason:    enrollment.Reason,
+	}
+
+	if err := s.repo.Create(enrollment, event); err != nil {
+		return response.EnrollmentRequestResponse{}, err
+	}
+
+	s.auditService.Record(actor, model.AuditCreate, model.AuditEntityEnrollment, enrollment.ID, nil,
+		map[string]interface{}{"userId": enrollment.UserID, "trainingPlanId": trainingPlanId, "status": enrollment.Status},
+	)
+
+	return s.FindById(actor, enrollment.ID)
+}
+
+// Cancel implements EnrollmentService.
+func (s *EnrollmentServiceImpl) Cancel(actor model.Actor, id uint, req request.EnrollmentDecisionRequest) error {
+	enrollment, err := s.repo.FindById(id)
+	if err != nil {
+		return err
+	}
+
+	if enrollment.UserID != actor.UserID {
+		return helper.Forbidden("you can only cancel your own enrollment requests")
+	}
+	if !enrollment.Status.IsOpen() {
+		return helper.BadRequest("only open enrollment requests can be cancelled")
+	}
+
+	return s.transition(actor, enrollment, model.EnrollmentActionCancel, model.EnrollmentCancelled, req.Reason)
+}
+
+// Approve implements EnrollmentService. Reviewers holding
+// enrollments:approve may also approve a request that was rejected.
+func (s *EnrollmentServiceImpl) Approve(actor model.Actor, id uint, req request.EnrollmentDecisionRequest) error {
+	if err := s.validate.Struct(req); err != nil {
+		return helper.ValidationError(helper.FormatValidationError(err))
+	}
+
+	enrollment, err := s.repo.FindById(id)
+	if err != nil {
+		return err
+	}
+
+	hr, err := s.authorizeReview(actor, enrollment)
+	if err != nil {
+		return err
+	}
+
+	action := model.EnrollmentActionApprove
+	switch {
+	case enrollment.Status == model.EnrollmentPending:
+	case enrollment.Status == model.EnrollmentEscalated && hr:
+	case enrollment.Status == model.EnrollmentRejected && hr:
+		action = model.EnrollmentActionOverride
+	case enrollment.Status == model.EnrollmentEscalated:
+		return helper.Forbidden("this request was escalated to HR")
+	default:
+		return helper.BadRequest("enrollment request cannot be approved in status " + string(enrollment.Status))
+	}
+
+	if action == model.EnrollmentActionOverride && strings.TrimSpace(req.Reason) == "" {
+		return helper.BadRequest("a reason is required to override a decision")
+	}
+
+	event := decide(actor, enrollment, action, model.EnrollmentApproved, req.Reason)
+	created, promoted, err := s.repo.Approve(enrollment, event)
+	if err != nil {
+		return err
+	}
+
+	if len(created) > 0 {
+		s.auditService.Record(actor, model.AuditCreate, model.AuditEntityRecord, created[0].ID, nil, created[0])
+		s.notificationService.NotifyRegistration(enrollment.TrainingPlanID, created, false)
+	}
+	for _, p := range promoted {
+		s.auditService.Record(actor, model.AuditUpdate, model.AuditEntityRecord, p.ID,
+			map[string]interface{}{"status": model.RecordStatusWaitlisted},
+			map[string]interface{}{"status": p.Status},
+		)
+	}
+	s.notificationService.NotifyRegistration(enrollment.TrainingPlanID, promoted, true)
+	s.eventService.PublishRegistrations(append(created, promoted...))
+	s.auditDecision(actor, enrollment, event)
+	return nil
+}
+
+// Reject implements EnrollmentService. Reviewers holding
+// enrollments:approve may also reject an approved request, which removes
+// the registration it created.
+func (s *EnrollmentServiceImpl) Reject(actor model.Actor, id uint, req request.EnrollmentDecisionRequest) error {
+	if err := s.validate.Struct(req); err != nil {
+		return helper.ValidationError(helper.FormatValidationError(err))
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		return helper.BadRequest("a reason is required to reject a request")
+	}
+
+	enrollment, err := s.repo.FindById(id)
+	if err != nil {
+		return err
+	}
+
+	hr, err := s.authorizeReview(actor, enrollment)
+	if err != nil {
+		return err
+	}
+
+	action := model.EnrollmentActionReject
+	switch {
+	case enrollment.Status == model.EnrollmentPending:
+	case enrollment.Status == model.EnrollmentEscalated && hr:
+	case enrollment.Status == model.EnrollmentApproved && hr:
+		action = model.EnrollmentActionOverride
+	case enrollment.Status == model.EnrollmentEscalated:
+		return helper.Forbidden("this request was escalated to HR")
+	default:
+		return helper.BadRequest("enrollment request cannot be rejected in status " + string(enrollment.Status))
+	}
+
+	if action == model.EnrollmentActionOverride && enrollment.TrainingPlan != nil && enrollment.TrainingPlan.Status.IsFinal() {
+		return helper.BadRequest("the training plan is already " + strings.ToLower(string(enrollment.TrainingPlan.Status)))
+	}
+
+	// the registration of an approved request goes with the rejection
+	recordID := enrollment.RecordID
+
+	event := decide(actor, enrollment, action, model.EnrollmentRejected, req.Reason)
+	promoted, err := s.repo.Reject(enrollment, event)
+	if err != nil {
+		return err
+	}
+
+	if recordID != nil {
+		s.auditService.Record(actor, model.AuditDelete, model.AuditEntityRecord, *recordID,
+			map[string]interface{}{"userId": enrollment.UserID, "trainingPlanId": enrollment.TrainingPlanID}, nil,
+		)
+		for _, p := range promoted {
+			s.auditService.Record(actor, model.AuditUpdate, model.AuditEntityRecord, p.ID,
+				map[string]interface{}{"status": model.RecordStatusWaitlisted},
+				map[string]interface{}{"status": p.Status},
+			)
+		}
+		s.notificationService.NotifyRegistration(enrollment.TrainingPlanID, promoted, true)
+		s.eventService.PublishRegistrations(append([]model.Record{{
+			ID:             *recordID,
+			UserID:         enrollment.UserID,
+			TrainingPlanID: enrollment.TrainingPlanID,
+		}}, promoted...))
+	}
+	s.auditDecision(actor, enrollment, event)
+	return nil
+}
+
+// Escalate implements EnrollmentService. An escalated request leaves the
+// department manager's queue and can only be decided by HR.
+func (s *EnrollmentServiceImpl) Escalate(actor model.Actor, id uint, req request.EnrollmentDecisionRequest) error {
+	if err := s.validate.Struct(req); err != nil {
+		return helper.ValidationError(helper.FormatValidationError(err))
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		return helper.BadRequest("a reason is required to escalate a request")
+	}
+
+	if !s.permissionService.HasPermission(string(actor.Role), model.PermEnrollmentsApprove) {
+		return helper.Forbidden("you are not allowed to escalate enrollment requests")
+	}
+
+	enrollment, err := s.repo.FindById(id)
+	if err != nil {
+		return err
+	}
+
+	if enrollment.Status != model.EnrollmentPending {
+		return helper.BadRequest("only pending enrollment requests can be escalated")
+	}
+
+	return s.transition(actor, enrollment, model.EnrollmentActionEscalate, model.EnrollmentEscalated, req.Reason)
+}
+
+// FindById implements EnrollmentService.
+func (s *EnrollmentServiceImpl) FindById(actor model.Actor, id uint) (response.EnrollmentRequestResponse, error) {
+	enrollment, err := s.repo.FindById(id)
+	if err != nil {
+		return response.EnrollmentRequestResponse{}, err
+	}
+
+	if enrollment.UserID != actor.UserID {
+		if _, err := s.authorizeReview(actor, enrollment); err != nil {
+			return response.EnrollmentRequestResponse{}, err
+		}
+	}
+
+	resp := toEnrollmentResponse(*enrollment)
+	resp.History = make([]response.EnrollmentEventResponse, 0, len(enrollment.Events))
+	for _, e := range enrollment.Events {
+		resp.History = append(resp.History, response.EnrollmentEventResponse{
+			ActorID:    e.ActorID,
+			ActorRole:  e.ActorRole,
+			Action:     string(e.Action),
+			FromStatus: string(e.FromStatus),
+			ToStatus:   string(e.ToStatus),
+			Reason:     e.Reason,
+			CreatedAt:  e.CreatedAt,
+		})
+	}
+
+	return resp, nil
+}
+
+// FindMine implements EnrollmentService.
+func (s *EnrollmentServiceImpl) FindMine(
+	actor model.Actor,
+	page int,
+	limit int,
+) (response.PaginatedResponse[response.EnrollmentRequestResponse], error) {
+	userID := actor.UserID
+	return s.findPaginated(repository.EnrollmentFilter{UserID: &userID}, page, limit)
+}
+
+// FindForReview implements EnrollmentService. Callers holding
+// enrollments:approve see every request; department reviewers only see
+// requests from their own department.
+func (s *EnrollmentServiceImpl) FindForReview(
+	actor model.Actor,
+	status string,
+	page int,
+	limit int,
+) (response.PaginatedResponse[response.EnrollmentRequestResponse], error) {
+
+	filter := repository.EnrollmentFilter{}
+	if status != "" {
+		filter.Statuses = []model.EnrollmentStatus{model.EnrollmentStatus(status)}
+	}
+
+	role := string(actor.Role)
+	if !s.permissionService.HasPermission(role, model.PermEnrollmentsApprove) {
+		if !s.permissionService.HasPermission(role, model.PermEnrollmentsApproveDepartment) {
+			return response.PaginatedResponse[response.EnrollmentRequestResponse]{},
+				helper.Forbidden("you are not allowed to review enrollment requests")
+		}
+
+		reviewer, err := s.userRepo.FindById(actor.UserID)
+		if err != nil {
+			return response.PaginatedResponse[response.EnrollmentRequestResponse]{}, err
+		}
+		filter.DepartmentID = &reviewer.DepartmentID
+	}
+
+	return s.findPaginated(filter, page, limit)
+}
+
+func (s *EnrollmentServiceImpl) findPaginated(
+	filter repository.EnrollmentFilter,
+	page int,
+	limit int,
+) (response.PaginatedResponse[response.EnrollmentRequestResponse], error) {
+
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	requests, total, err := s.repo.FindPaginated(filter, offset, limit)
+	if err != nil {
+		return response.PaginatedResponse[response.EnrollmentRequestResponse]{}, err
+	}
+
+	items := make([]response.EnrollmentRequestResponse, 0, len(requests))
+	for _, r := range requests {
+		items = append(items, toEnrollmentResponse(r))
+	}
+
+	return response.PaginatedResponse[response.EnrollmentRequestResponse]{
+		Items: items,
+		Meta: response.PaginationMeta{
+			Page:       page,
+			Limit:      limit,
+			TotalItems: total,
+			TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+		},
+	}, nil
+}
+
+// authorizeReview reports whether the caller reviews with HR authority
+// (enrollments:approve) or as a manager of the requester's department.
+// Nobody reviews their own request.
+func (s *EnrollmentServiceImpl) authorizeReview(actor model.Actor, enrollment *model.EnrollmentRequest) (bool, error) {
+	role := string(actor.Role)
+
+	if enrollment.UserID == actor.UserID {
+		return false, helper.Forbidden("you cannot review your own enrollment request")
+	}
+
+	if s.permissionService.HasPermission(role, model.PermEnrollmentsApprove) {
+		return true, nil
+	}
+
+	if s.permissionService.HasPermission(role, model.PermEnrollmentsApproveDepartment) && enrollment.User != nil {
+		reviewer, err := s.userRepo.FindById(actor.UserID)
+		if err != nil {
+			return false, err
+		}
+		if reviewer.DepartmentID == enrollment.User.DepartmentID {
+			return false, nil
+		}
+	}
+
+	return false, helper.Forbidden("you do not have access to this enrollment request")
+}
+
+func (s *EnrollmentServiceImpl) transition(
+	actor model.Actor,
+	enrollment *model.EnrollmentRequest,
+	action model.EnrollmentAction,
+	to model.EnrollmentStatus,
+	reason string,
+) error {
+	event := decide(actor, enrollment, action, to, reason)
+	if err := s.repo.Transition(enrollment, event); err != nil {
+		return err
+	}
+
+	s.auditDecision(actor, enrollment, event)
+	return nil
+}
+
+// decide moves enrollment to status to and returns the event recording it.
+// Nothing is stored.
+func decide(
+	actor model.Actor,
+	enrollment *model.EnrollmentRequest,
+	action model.EnrollmentAction,
+	to model.EnrollmentStatus,
+	reason string,
+) *model.EnrollmentRequestEvent {
+	from := enrollment.Status
+	reason = strings.TrimSpace(reason)
+
+	enrollment.Status = to
+	if action != model.EnrollmentActionCancel {
+		now := time.Now()
+		enrollment.DecidedByID = &actor.UserID
+		enrollment.DecisionReason = reason
+		enrollment.DecidedAt = &now
+	}
+
+	return &model.EnrollmentRequestEvent{
+		ActorID:    actor.UserID,
+		ActorRole:  string(actor.Role),
+		Action:     action,
+		FromStatus: from,
+		ToStatus:   to,
+		Reason:     reason,
+	}
+}
+
+func (s *EnrollmentServiceImpl) auditDecision(actor model.Actor, enrollment *model.EnrollmentRequest, event *model.EnrollmentRequestEvent) {
+	s.auditService.Record(actor, model.AuditAction(event.Action), model.AuditEntityEnrollment, enrollment.ID,
+		map[string]interface{}{"status": event.FromStatus},
+		map[string]interface{}{"status": event.ToStatus, "reason": event.Reason, "recordId": enrollment.RecordID},
+	)
+}
+
+func toEnrollmentResponse(r model.EnrollmentRequest) response.EnrollmentRequestResponse {
+	resp := response.EnrollmentRequestResponse{
+		ID:             r.ID,
+		UserID:         r.UserID,
+		TrainingPlanID: r.TrainingPlanID,
+		Status:         string(r.Status),
+		Reason:         r.Reason,
+		DecidedByID:    r.DecidedByID,
+		DecisionReason: r.DecisionReason,
+		DecidedAt:      r.DecidedAt,
+		RecordID:       r.RecordID,
+		CreatedAt:      r.CreatedAt,
+		UpdatedAt:      r.UpdatedAt,
+	}
+
+	if r.User != nil {
+		resp.EmployeeID = r.User.EmployeeID
+		resp.EmployeeName = r.User.Name
+		if r.User.Department != nil {
+			resp.Department = r.User.Department.Name
+		}
+	}
+	if r.TrainingPlan != nil {
+		resp.TrainingPlanName = r.TrainingPlan.Name
+		resp.TrainingDate = r.TrainingPlan.Date
+	}
+
+	return resp
+}
+
+func isNotFound(err error) bool {
+	appErr, ok := err.(*helper.AppError)
+	return ok && appErr.StatusCode == http.StatusNotFound
+}
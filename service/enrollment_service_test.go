@@ -0,0 +1,341 @@
+package service
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"training-plan-api/data/request"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+	"training-plan-api/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	enrollmentDepartment = 10
+	enrollmentPlanID     = 7
+
+	requesterID          uint = 1
+	departmentManagerID  uint = 2
+	otherManagerID       uint = 3
+	hrAdminID            uint = 4
+	approvedRecordID     uint = 30
+	promotedFromWaitlist uint = 31
+)
+
+var enrollmentUsers = map[uint]model.User{
+	requesterID:         {ID: requesterID, Role: model.RoleStaff, DepartmentID: enrollmentDepartment},
+	departmentManagerID: {ID: departmentManagerID, Role: model.RoleDepartmentManager, DepartmentID: enrollmentDepartment},
+	otherManagerID:      {ID: otherManagerID, Role: model.RoleDepartmentManager, DepartmentID: enrollmentDepartment + 1},
+	hrAdminID:           {ID: hrAdminID, Role: model.RoleHRAdmin, DepartmentID: 1},
+}
+
+// defaultPermissionService grants each system role its default permissions.
+type defaultPermissionService struct {
+	PermissionService
+}
+
+func (defaultPermissionService) HasPermission(role string, permission model.Permission) bool {
+	for _, granted := range model.DefaultRolePermissions(model.Role(role)) {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func (p defaultPermissionService) HasAnyPermission(role string, permissions ...model.Permission) bool {
+	for _, permission := range permissions {
+		if p.HasPermission(role, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+type enrollmentUserRepository struct {
+	repository.UserRepository
+}
+
+func (enrollmentUserRepository) FindById(userId uint) (*model.User, error) {
+	user, ok := enrollmentUsers[userId]
+	if !ok {
+		return nil, helper.NotFound("user not found")
+	}
+	return &user, nil
+}
+
+// memoryEnrollmentRepository holds one request and applies transitions
+// under the same guard as the database: only from the status the decision
+// was based on.
+type memoryEnrollmentRepository struct {
+	repository.EnrollmentRepository
+
+	request model.EnrollmentRequest
+	events  []model.EnrollmentRequestEvent
+	// changedBy moves the request to this status right before the next
+	// write, as if another reviewer decided first.
+	changedBy model.EnrollmentStatus
+	// registered is set once Approve or Reject changed the registration.
+	registered *bool
+}
+
+func (r *memoryEnrollmentRepository) FindById(id uint) (*model.EnrollmentRequest, error) {
+	if id != r.request.ID {
+		return nil, helper.NotFound("enrollment request not found")
+	}
+	found := r.request
+	requester := enrollmentUsers[found.UserID]
+	found.User = &requester
+	found.TrainingPlan = &model.TrainingPlan{ID: enrollmentPlanID, Status: model.TrainingPlanPublished}
+	return &found, nil
+}
+
+func (r *memoryEnrollmentRepository) Transition(req *model.EnrollmentRequest, event *model.EnrollmentRequestEvent) error {
+	if r.changedBy != "" {
+		r.request.Status = r.changedBy
+	}
+	if r.request.Status != event.FromStatus {
+		return helper.BadRequest("enrollment request was changed by someone else, please reload")
+	}
+	r.request.Status = req.Status
+	r.request.RecordID = req.RecordID
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *memoryEnrollmentRepository) Approve(req *model.EnrollmentRequest, event *model.EnrollmentRequestEvent) ([]model.Record, []model.Record, error) {
+	recordID := approvedRecordID
+	req.RecordID = &recordID
+	if err := r.Transition(req, event); err != nil {
+		return nil, nil, err
+	}
+
+	registered := true
+	r.registered = &registered
+	return []model.Record{{ID: recordID, UserID: req.UserID, TrainingPlanID: req.TrainingPlanID, Status: model.RecordStatusRegister}}, nil, nil
+}
+
+func (r *memoryEnrollmentRepository) Reject(req *model.EnrollmentRequest, event *model.EnrollmentRequestEvent) ([]model.Record, error) {
+	hadRecord := req.RecordID != nil
+	req.RecordID = nil
+	if err := r.Transition(req, event); err != nil {
+		return nil, err
+	}
+	if !hadRecord {
+		return nil, nil
+	}
+
+	registered := false
+	r.registered = &registered
+	return []model.Record{{ID: promotedFromWaitlist, UserID: 9, TrainingPlanID: req.TrainingPlanID, Status: model.RecordStatusRegister}}, nil
+}
+
+func newTestEnrollmentService(repo *memoryEnrollmentRepository) (*EnrollmentServiceImpl, *recordingAuditService, *recordingNotificationService, *recordingEventService) {
+	audit := &recordingAuditService{}
+	notifications := &recordingNotificationService{}
+	events := &recordingEventService{}
+
+	svc := NewEnrollmentServiceImpl(
+		repo,
+		nil,
+		nil,
+		enrollmentUserRepository{},
+		defaultPermissionService{},
+		audit,
+		notifications,
+		events,
+		validator.New(),
+	).(*EnrollmentServiceImpl)
+	return svc, audit, notifications, events
+}
+
+func newEnrollmentRequest(status model.EnrollmentStatus) model.EnrollmentRequest {
+	req := model.EnrollmentRequest{ID: 1, UserID: requesterID, TrainingPlanID: enrollmentPlanID, Status: status}
+	if status == model.EnrollmentApproved {
+		recordID := approvedRecordID
+		req.RecordID = &recordID
+	}
+	return req
+}
+
+func TestEnrollmentTransitions(t *testing.T) {
+	type operation func(svc *EnrollmentServiceImpl, actor model.Actor, reason string) error
+
+	approve := func(svc *EnrollmentServiceImpl, actor model.Actor, reason string) error {
+		return svc.Approve(actor, 1, request.EnrollmentDecisionRequest{Reason: reason})
+	}
+	reject := func(svc *EnrollmentServiceImpl, actor model.Actor, reason string) error {
+		return svc.Reject(actor, 1, request.EnrollmentDecisionRequest{Reason: reason})
+	}
+	escalate := func(svc *EnrollmentServiceImpl, actor model.Actor, reason string) error {
+		return svc.Escalate(actor, 1, request.EnrollmentDecisionRequest{Reason: reason})
+	}
+	cancel := func(svc *EnrollmentServiceImpl, actor model.Actor, reason string) error {
+		return svc.Cancel(actor, 1, request.EnrollmentDecisionRequest{Reason: reason})
+	}
+
+	const (
+		pending   = model.EnrollmentPending
+		escalated = model.EnrollmentEscalated
+		approved  = model.EnrollmentApproved
+		rejected  = model.EnrollmentRejected
+		cancelled = model.EnrollmentCancelled
+	)
+
+	tests := []struct {
+		name       string
+		from       model.EnrollmentStatus
+		caller     uint
+		op         operation
+		reason     string
+		wantStatus model.EnrollmentStatus
+		wantAction model.EnrollmentAction
+		// wantCode is the status code of the expected error; zero when the
+		// transition succeeds
+		wantCode int
+	}{
+		{"manager approves pending", pending, departmentManagerID, approve, "", approved, model.EnrollmentActionApprove, 0},
+		{"hr approves pending", pending, hrAdminID, approve, "", approved, model.EnrollmentActionApprove, 0},
+		{"hr approves escalated", escalated, hrAdminID, approve, "", approved, model.EnrollmentActionApprove, 0},
+		{"manager approves escalated", escalated, departmentManagerID, approve, "", escalated, "", http.StatusForbidden},
+		{"hr overrides rejection", rejected, hrAdminID, approve, "seat freed up", approved, model.EnrollmentActionOverride, 0},
+		{"hr overrides rejection without reason", rejected, hrAdminID, approve, " ", rejected, "", http.StatusBadRequest},
+		{"manager approves rejected", rejected, departmentManagerID, approve, "", rejected, "", http.StatusBadRequest},
+		{"hr approves approved", approved, hrAdminID, approve, "", approved, "", http.StatusBadRequest},
+		{"hr approves cancelled", cancelled, hrAdminID, approve, "", cancelled, "", http.StatusBadRequest},
+		{"manager of another department approves", pending, otherManagerID, approve, "", pending, "", http.StatusForbidden},
+		{"requester approves own request", pending, requesterID, approve, "", pending, "", http.StatusForbidden},
+
+		{"manager rejects pending", pending, departmentManagerID, reject, "no budget", rejected, model.EnrollmentActionReject, 0},
+		{"manager rejects without reason", pending, departmentManagerID, reject, "", pending, "", http.StatusBadRequest},
+		{"hr rejects escalated", escalated, hrAdminID, reject, "no budget", rejected, model.EnrollmentActionReject, 0},
+		{"manager rejects escalated", escalated, departmentManagerID, reject, "no budget", escalated, "", http.StatusForbidden},
+		{"hr overrides approval", approved, hrAdminID, reject, "wrong plan", rejected, model.EnrollmentActionOverride, 0},
+		{"manager rejects approved", approved, departmentManagerID, reject, "wrong plan", approved, "", http.StatusBadRequest},
+		{"hr rejects rejected", rejected, hrAdminID, reject, "again", rejected, "", http.StatusBadRequest},
+
+		{"hr escalates pending", pending, hrAdminID, escalate, "needs budget", escalated, model.EnrollmentActionEscalate, 0},
+		{"hr escalates approved", approved, hrAdminID, escalate, "needs budget", approved, "", http.StatusBadRequest},
+		{"manager escalates pending", pending, departmentManagerID, escalate, "needs budget", pending, "", http.StatusForbidden},
+
+		{"requester cancels pending", pending, requesterID, cancel, "", cancelled, model.EnrollmentActionCancel, 0},
+		{"requester cancels escalated", escalated, requesterID, cancel, "", cancelled, model.EnrollmentActionCancel, 0},
+		{"requester cancels approved", approved, requesterID, cancel, "", approved, "", http.StatusBadRequest},
+		{"manager cancels request", pending, departmentManagerID, cancel, "", pending, "", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryEnrollmentRepository{request: newEnrollmentRequest(tt.from)}
+			svc, _, _, _ := newTestEnrollmentService(repo)
+			actor := model.Actor{UserID: tt.caller, Role: enrollmentUsers[tt.caller].Role}
+
+			err := tt.op(svc, actor, tt.reason)
+
+			if tt.wantCode != 0 {
+				appErr, ok := err.(*helper.AppError)
+				if !ok || appErr.StatusCode != tt.wantCode {
+					t.Fatalf("err = %v, want %d", err, tt.wantCode)
+				}
+				if len(repo.events) != 0 {
+					t.Errorf("rejected transition recorded %d events", len(repo.events))
+				}
+			} else if err != nil {
+				t.Fatalf("err = %v, want success", err)
+			}
+
+			if repo.request.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", repo.request.Status, tt.wantStatus)
+			}
+			if tt.wantCode == 0 {
+				event := repo.events[len(repo.events)-1]
+				if event.Action != tt.wantAction || event.FromStatus != tt.from || event.ToStatus != tt.wantStatus {
+					t.Errorf("event = %s %s -> %s, want %s %s -> %s", event.Action, event.FromStatus, event.ToStatus, tt.wantAction, tt.from, tt.wantStatus)
+				}
+			}
+		})
+	}
+}
+
+func TestRejectOverrideRemovesRegistration(t *testing.T) {
+	repo := &memoryEnrollmentRepository{request: newEnrollmentRequest(model.EnrollmentApproved)}
+	svc, audit, notifications, events := newTestEnrollmentService(repo)
+
+	hr := model.Actor{UserID: hrAdminID, Role: model.RoleHRAdmin}
+	if err := svc.Reject(hr, 1, request.EnrollmentDecisionRequest{Reason: "wrong plan"}); err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+
+	if repo.registered == nil || *repo.registered {
+		t.Error("registration was not removed with the rejection")
+	}
+	if repo.request.RecordID != nil {
+		t.Errorf("record id = %d, want none", *repo.request.RecordID)
+	}
+
+	wantAudit := []auditedChange{
+		{model.AuditDelete, approvedRecordID},
+		{model.AuditUpdate, promotedFromWaitlist},
+		{model.AuditAction(model.EnrollmentActionOverride), uint(1)},
+	}
+	if !reflect.DeepEqual(audit.changes, wantAudit) {
+		t.Errorf("audited %v, want %v", audit.changes, wantAudit)
+	}
+	if want := []notifiedRegistration{{[]uint{9}, true}}; !reflect.DeepEqual(notifications.registrations, want) {
+		t.Errorf("notified %v, want %v", notifications.registrations, want)
+	}
+	if want := []uint{requesterID, 9}; !reflect.DeepEqual(events.published, want) {
+		t.Errorf("published %v, want %v", events.published, want)
+	}
+}
+
+// TestDecisionLosingRaceHasNoSideEffects covers a reviewer whose decision
+// was based on a status someone else changed in the meantime.
+func TestDecisionLosingRaceHasNoSideEffects(t *testing.T) {
+	hr := model.Actor{UserID: hrAdminID, Role: model.RoleHRAdmin}
+
+	tests := []struct {
+		name      string
+		from      model.EnrollmentStatus
+		changedTo model.EnrollmentStatus
+		decide    func(svc *EnrollmentServiceImpl) error
+	}{
+		{
+			name:      "approve after a cancel",
+			from:      model.EnrollmentPending,
+			changedTo: model.EnrollmentCancelled,
+			decide: func(svc *EnrollmentServiceImpl) error {
+				return svc.Approve(hr, 1, request.EnrollmentDecisionRequest{})
+			},
+		},
+		{
+			name:      "override rejection after another override",
+			from:      model.EnrollmentApproved,
+			changedTo: model.EnrollmentRejected,
+			decide: func(svc *EnrollmentServiceImpl) error {
+				return svc.Reject(hr, 1, request.EnrollmentDecisionRequest{Reason: "wrong plan"})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryEnrollmentRepository{request: newEnrollmentRequest(tt.from), changedBy: tt.changedTo}
+			svc, audit, notifications, events := newTestEnrollmentService(repo)
+
+			if err := tt.decide(svc); err == nil {
+				t.Fatal("decision on a stale status succeeded")
+			}
+
+			if repo.registered != nil {
+				t.Error("registration changed although the decision failed")
+			}
+			if len(audit.changes) != 0 || len(notifications.registrations) != 0 || len(events.published) != 0 {
+				t.Errorf("side effects of a failed decision: audit %v, notifications %v, events %v",
+					audit.changes, notifications.registrations, events.published)
+			}
+		})
+	}
+}
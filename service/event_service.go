@@ -0,0 +1,144 @@
+package service
+
+import (
+	"encoding/json"
+	"log"
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+	"training-plan-api/repository"
+)
+
+type EventServiceImpl struct {
+	broker            helper.EventBroker
+	userRepo          repository.UserRepository
+	permissionService PermissionService
+}
+
+func NewEventServiceImpl(
+	broker helper.EventBroker,
+	userRepo repository.UserRepository,
+	permissionService PermissionService,
+) EventService {
+	return &EventServiceImpl{
+		broker:            broker,
+		userRepo:          userRepo,
+		permissionService: permissionService,
+	}
+}
+
+// Publish implements EventService. Like notifications, failures are only
+// logged; the change has already been committed.
+func (s *EventServiceImpl) Publish(eventType model.EventType, data interface{}, audience model.EventAudience) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("events: %s: %v", eventType, err)
+		return
+	}
+
+	event := helper.Event{
+		Type: string(eventType),
+		Data: payload,
+	}
+	if audience.UserID != 0 {
+		event.UserIDs = []uint{audience.UserID}
+	}
+	if audience.Permission != "" {
+		event.Permissions = []string{string(audience.Permission)}
+	}
+	if audience.DepartmentPermission != "" && audience.UserID != 0 {
+		user, err := s.userRepo.FindById(audience.UserID)
+		if err != nil {
+			log.Printf("events: %s user %d: %v", eventType, audience.UserID, err)
+		} else if user.DepartmentID != 0 {
+			event.DepartmentPermissions = []string{string(audience.DepartmentPermission)}
+			event.DepartmentID = user.DepartmentID
+		}
+	}
+
+	s.broker.Publish(event)
+}
+
+// PublishRegistrations implements EventService. Records are sent with
+// their current status; pass an empty status for deleted records.
+func (s *EventServiceImpl) PublishRegistrations(records []model.Record) {
+	for _, record := range records {
+		s.Publish(model.EventRegistrationChanged, response.RegistrationEventData{
+			RecordID:       record.ID,
+			UserID:         record.UserID,
+			TrainingPlanID: record.TrainingPlanID,
+			Status:         string(record.Status),
+		}, model.EventAudience{
+			UserID:               record.UserID,
+			Permission:           model.PermRecordsRead,
+			DepartmentPermission: model.PermRecordsReadDepartment,
+		})
+	}
+}
+
+// PublishCertificate implements EventService. Certificate reviewers and the
+// owner receive it.
+func (s *EventServiceImpl) PublishCertificate(eventType model.EventType, certificate *model.Certificate) {
+	s.Publish(eventType, response.CertificateEventData{
+		CertificateID: certificate.ID,
+		UserID:        certificate.UserID,
+		Kind:          string(certificate.Kind),
+		TrainingID:    certificate.TrainingID,
+		Status:        string(certificate.Status),
+	}, model.EventAudience{
+		UserID:     certificate.UserID,
+		Permission: model.PermCertificatesApprove,
+	})
+}
+
+// Subscribe implements EventService. The returned channel carries only the
+// events actor may see and is closed by the returned function.
+func (s *EventServiceImpl) Subscribe(actor model.Actor) (<-chan helper.Event, func(), error) {
+	user, err := s.userRepo.FindById(actor.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.Status != model.UserStatusActive {
+		return nil, nil, helper.Forbidden("account is not active")
+	}
+
+	events, unsubscribe := s.broker.Subscribe()
+	visible := make(chan helper.Event, cap(events))
+
+	go func() {
+		defer close(visible)
+		for event := range events {
+			if !s.canSee(actor, user.DepartmentID, event) {
+				continue
+			}
+			select {
+			case visible <- event:
+			default:
+				log.Printf("events: stream of user %d is behind, dropped %s", actor.UserID, event.Type)
+			}
+		}
+	}()
+
+	return visible, unsubscribe, nil
+}
+
+func (s *EventServiceImpl) canSee(actor model.Actor, departmentId int, event helper.Event) bool {
+	for _, id := range event.UserIDs {
+		if id == actor.UserID {
+			return true
+		}
+	}
+	for _, permission := range event.Permissions {
+		if s.permissionService.HasPermission(string(actor.Role), model.Permission(permission)) {
+			return true
+		}
+	}
+	if event.DepartmentID != 0 && event.DepartmentID == departmentId {
+		for _, permission := range event.DepartmentPermissions {
+			if s.permissionService.HasPermission(string(actor.Role), model.Permission(permission)) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -1,65 +1,224 @@
 package service
 
 import (
+	"context"
 	"mime/multipart"
+	"time"
 	"training-plan-api/data/request"
 	"training-plan-api/data/response"
+	"training-plan-api/helper"
 	"training-plan-api/model"
 
 	"github.com/xuri/excelize/v2"
 )
 
 type TrainingPlanService interface {
-	Create(trainingPlan request.CreateTrainingPlanRequest) error
-	Update(trainingPlanId int, trainingPlan request.UpdateTrainingPlanRequest) error
-	Delete(trainingPlanId int) error
-	FindById(trainingPlanId int) (response.TrainingPlanResponse, error)
-	FindPaginated(page, pageSize int) (response.PaginatedResponse[response.TrainingPlanResponse], error)
+	Create(actor model.Actor, trainingPlan request.CreateTrainingPlanRequest) error
+	Update(actor model.Actor, trainingPlanId int, trainingPlan request.UpdateTrainingPlanRequest) error
+	Delete(actor model.Actor, trainingPlanId int) error
+	Publish(actor model.Actor, trainingPlanId int) error
+	Cancel(actor model.Actor, trainingPlanId int, req request.CancelTrainingPlanRequest) error
+	Complete(actor model.Actor, trainingPlanId int) error
+	Clone(actor model.Actor, trainingPlanId int, req request.CloneTrainingPlanRequest) (response.TrainingPlanResponse, error)
+	FindById(actor model.Actor, trainingPlanId int) (response.TrainingPlanResponse, error)
+	FindPaginated(actor model.Actor, status string, page, pageSize int) (response.PaginatedResponse[response.TrainingPlanResponse], error)
+	FindCatalog(page, pageSize int) (response.PaginatedResponse[response.TrainingPlanResponse], error)
+}
+
+type SchedulerService interface {
+	Register(name, description, schedule string, run JobFunc)
+	Run(ctx context.Context, interval time.Duration)
+	RunDue(ctx context.Context)
+	FindJobs() ([]response.ScheduledJobResponse, error)
+	UpdateJob(actor model.Actor, name string, req request.UpdateScheduledJobRequest) (response.ScheduledJobResponse, error)
+	TriggerJob(actor model.Actor, name string) error
+}
+
+type WebhookService interface {
+	Create(actor model.Actor, req request.WebhookSubscriptionRequest) (response.WebhookSubscriptionResponse, error)
+	Update(actor model.Actor, id uint, req request.WebhookSubscriptionRequest) (response.WebhookSubscriptionResponse, error)
+	Delete(actor model.Actor, id uint) error
+	FindAll() ([]response.WebhookSubscriptionResponse, error)
+	FindById(id uint) (response.WebhookSubscriptionResponse, error)
+	FindDeliveries(subscriptionId uint, status string, page, limit int) (response.PaginatedResponse[response.WebhookDeliveryResponse], error)
+	Replay(actor model.Actor, subscriptionId, deliveryId uint) (response.WebhookDeliveryResponse, error)
+	RecordWebhook(event model.WebhookEvent, record *model.Record) model.WebhookMessage
+	CertificateWebhook(event model.WebhookEvent, certificate *model.Certificate) model.WebhookMessage
+	TrainingPlanWebhook(event model.WebhookEvent, trainingPlan *model.TrainingPlan) model.WebhookMessage
+	Run(ctx context.Context, interval time.Duration)
+	ProcessDue(ctx context.Context) (int, error)
+}
+
+type EventService interface {
+	Publish(eventType model.EventType, data interface{}, audience model.EventAudience)
+	PublishRegistrations(records []model.Record)
+	PublishCertificate(eventType model.EventType, certificate *model.Certificate)
+	Subscribe(actor model.Actor) (<-chan helper.Event, func(), error)
+}
+
+type NotificationService interface {
+	NotifyRegistration(trainingPlanId uint, records []model.Record, promoted bool)
+	NotifyRecordUpdated(record *model.Record)
+	NotifyTrainingPlan(event model.NotificationEvent, trainingPlan *model.TrainingPlan, userIds []uint, reason string)
+	NotifyCertificate(event model.NotificationEvent, certificate *model.Certificate, reason string)
+	SendReminders(now time.Time) error
+	SendAttendanceReminders(now time.Time) error
+	SendPendingCertificateReminders(now time.Time) error
+	SendCertificateExpiryReminders(now time.Time) error
+	Run(ctx context.Context, interval time.Duration)
+	ProcessDue(ctx context.Context) (int, error)
+	GetPreferences(actor model.Actor) (response.NotificationPreferencesResponse, error)
+	UpdatePreferences(actor model.Actor, req request.UpdateNotificationPreferencesRequest) (response.NotificationPreferencesResponse, error)
+	FindInbox(actor model.Actor, unreadOnly bool, page, limit int) (response.PaginatedResponse[response.NotificationResponse], error)
+	CountUnread(actor model.Actor) (response.UnreadCountResponse, error)
+	MarkRead(actor model.Actor, id uint) (response.UnreadCountResponse, error)
+	MarkAllRead(actor model.Actor) (response.UnreadCountResponse, error)
+}
+
+type CalendarFeedService interface {
+	CreateFeed(actor model.Actor, scope string) (response.CalendarFeedResponse, error)
+	FindMine(actor model.Actor) ([]response.CalendarFeedResponse, error)
+	RevokeFeed(actor model.Actor, scope string) error
+	Feed(token string) (string, error)
+	TrainingPlanICS(actor model.Actor, trainingPlanId int) (string, error)
+}
+
+type CalendarSyncService interface {
+	Run(ctx context.Context, interval time.Duration)
+	ProcessDue(ctx context.Context) (int, error)
+	RunResponses(ctx context.Context, interval time.Duration)
+	SyncResponses(ctx context.Context) (int, error)
+	RefreshResponses(ctx context.Context, trainingPlanId int) (int, error)
+	Resync(actor model.Actor, trainingPlanId int, recreate bool) error
+	Retry(actor model.Actor, id uint) error
+	FindFailed(page, limit int) (response.PaginatedResponse[response.CalendarSyncFailureResponse], error)
+}
+
+type TrainingPlanSeriesService interface {
+	Create(actor model.Actor, req request.CreateTrainingPlanSeriesRequest) (response.TrainingPlanSeriesResponse, error)
+	FindById(seriesId uint) (response.TrainingPlanSeriesResponse, error)
+	FindPaginated(page, limit int) (response.PaginatedResponse[response.TrainingPlanSeriesResponse], error)
+	Generate(actor model.Actor, seriesId uint) (response.TrainingPlanSeriesResponse, error)
+	GenerateAll(actor model.Actor) error
+	Stop(actor model.Actor, seriesId uint) error
+}
+
+type TrainingSessionService interface {
+	Create(actor model.Actor, trainingPlanId int, req request.CreateTrainingSessionRequest) (response.TrainingSessionResponse, error)
+	Update(actor model.Actor, sessionId uint, req request.UpdateTrainingSessionRequest) (response.TrainingSessionResponse, error)
+	Delete(actor model.Actor, sessionId uint) error
+	FindByTrainingPlan(actor model.Actor, trainingPlanId int) ([]response.TrainingSessionResponse, error)
+	FindAttendance(actor model.Actor, sessionId uint) ([]response.SessionAttendanceResponse, error)
+	MarkAttendance(actor model.Actor, sessionId uint, req request.MarkAttendanceRequest) error
 }
 
 type DepartmentService interface {
-	Create(department request.CreateDepartmentRequest) error
-	Update(departmentId int, department request.UpdateDepartmentRequest) error
-	Delete(departmentId int) error
+	Create(actor model.Actor, department request.CreateDepartmentRequest) error
+	Update(actor model.Actor, departmentId int, department request.UpdateDepartmentRequest) error
+	Delete(actor model.Actor, departmentId int) error
 	FindById(departmentId int) (response.DepartmentResponse, error)
 	FindPaginated(page, pageSize int) (response.PaginatedResponse[response.DepartmentResponse], error)
 	FindDepartmentList() ([]response.DepartmentListItem, error)
 }
 
 type UserService interface {
-	AdminCreate(req request.CreateUserRequest, creatorID uint) error
-	AdminUpdate(userID uint, req request.UpdateUserRequest) error
-	AdminDelete(userID uint) error
+	AdminCreate(actor model.Actor, req request.CreateUserRequest) error
+	AdminUpdate(actor model.Actor, userID uint, req request.UpdateUserRequest) error
+	AdminDelete(actor model.Actor, userID uint) error
+	AdminUnlock(actor model.Actor, userID uint) error
 	AdminFindAll(page, pageSize int) (response.PaginatedResponse[response.UserListResponse], error)
 	AdminFindById(userID uint) (response.UserResponse, error)
 	AdminFindAllForTable(params request.UserTableQueryParams) (response.PaginatedResponse[response.UserTableResponse], error)
-	ManagerCreate(req request.ManagerCreateUserRequest, managerID uint, managerDepartmentID int) error
+	ManagerCreate(actor model.Actor, req request.ManagerCreateUserRequest, managerDepartmentID int) error
 	ManagerFindByDepartment(departmentID, page, pageSize int) (response.PaginatedResponse[response.UserListResponse], error)
-	CompleteProfile(userID uint, req request.CompleteProfileRequest) error
+	CompleteProfile(actor model.Actor, req request.CompleteProfileRequest) error
+}
+
+type PermissionService interface {
+	HasPermission(role string, permission model.Permission) bool
+	HasAnyPermission(role string, permissions ...model.Permission) bool
+	RolesWithPermission(permission model.Permission) []string
+	ListPermissions() []response.PermissionResponse
+	FindRoles() ([]response.RoleResponse, error)
+	CreateRole(actor model.Actor, req request.CreateRoleRequest) (response.RoleResponse, error)
+	UpdateRole(actor model.Actor, name string, req request.UpdateRoleRequest) (response.RoleResponse, error)
+	DeleteRole(actor model.Actor, name string) error
+}
+
+type AuditService interface {
+	Record(actor model.Actor, action model.AuditAction, entityType string, entityID interface{}, before, after interface{})
+	Search(req request.AuditFilterRequest) (response.PaginatedResponse[response.AuditEventResponse], error)
+	Export(req request.AuditFilterRequest) (*excelize.File, error)
+}
+
+type EnrollmentService interface {
+	Request(actor model.Actor, trainingPlanId uint, req request.CreateEnrollmentRequest) (response.EnrollmentRequestResponse, error)
+	Cancel(actor model.Actor, id uint, req request.EnrollmentDecisionRequest) error
+	Approve(actor model.Actor, id uint, req request.EnrollmentDecisionRequest) error
+	Reject(actor model.Actor, id uint, req request.EnrollmentDecisionRequest) error
+	Escalate(actor model.Actor, id uint, req request.EnrollmentDecisionRequest) error
+	FindById(actor model.Actor, id uint) (response.EnrollmentRequestResponse, error)
+	FindMine(actor model.Actor, page, limit int) (response.PaginatedResponse[response.EnrollmentRequestResponse], error)
+	FindForReview(actor model.Actor, status string, page, limit int) (response.PaginatedResponse[response.EnrollmentRequestResponse], error)
 }
 
 type AuthOAuthService interface {
 	GetGoogleLoginURL(state string) string
-	HandleGoogleCallback(code string) (string, *model.User, error)
+	HandleGoogleCallback(code string) (*model.User, error)
+}
+
+type PasswordService interface {
+	ForgotPassword(req request.ForgotPasswordRequest) error
+	ResetPassword(req request.ResetPasswordRequest) error
+	ChangePassword(userID uint, req request.ChangePasswordRequest) (*model.User, error)
+	AdminResetPassword(actor model.Actor, userID uint, req request.AdminResetPasswordRequest) error
+}
+
+type TwoFactorService interface {
+	IsRequired(role model.Role) bool
+	Status(userID uint) (response.TwoFactorStatusResponse, error)
+	Setup(userID uint) (response.TwoFactorSetupResponse, error)
+	Activate(userID uint, req request.TwoFactorCodeRequest) (*model.User, []string, error)
+	IssueChallenge(user *model.User, purpose string) (string, error)
+	Verify(challengeToken string, code string) (*model.User, error)
+	Disable(userID uint, req request.TwoFactorCodeRequest) error
+	RegenerateRecoveryCodes(userID uint, req request.TwoFactorCodeRequest) ([]string, error)
+	AdminReset(actor model.Actor, userID uint) error
+}
+
+type AuthTokenService interface {
+	IssueTokens(user *model.User) (response.TokenResponse, error)
+	Refresh(refreshToken string) (response.TokenResponse, *model.User, error)
+	Revoke(refreshToken string) error
+	RevokeAllForUser(userID uint) error
 }
 
 type CertificateService interface {
 	FindByCurrentUser(userID uint) ([]response.CertificateResponse, error)
-	Upload(userID uint, req request.CreateCertificateRequest, file *multipart.FileHeader) error
-	Delete(certificateID int, userID uint) error
+	Upload(actor model.Actor, req request.CreateCertificateRequest, file *multipart.FileHeader) error
+	Delete(actor model.Actor, certificateID int) error
 	FindAllPending(	page int,limit int,) (response.PaginatedResponse[response.CertificateResponse], error)
-	Approve(certificateID int) error
-	Reject(certificateID int) error
+	Approve(actor model.Actor, certificateID int) error
+	Reject(actor model.Actor, certificateID int, req request.RejectCertificateRequest) error
+	UploadExternal(actor model.Actor, req request.CreateExternalCertificateRequest, file *multipart.FileHeader) error
+	Resubmit(actor model.Actor, certificateID int, req request.ResubmitCertificateRequest, file *multipart.FileHeader) error
+	FindHistory(actor model.Actor, certificateID int) (response.CertificateHistoryResponse, error)
+	FindValidities() ([]response.CertificateValidityResponse, error)
+	SaveValidity(actor model.Actor, req request.SaveCertificateValidityRequest) error
+	DeleteValidity(actor model.Actor, category string) error
+	FindExpiring(actor model.Actor, days int, departmentId int) ([]response.ExpiringDepartmentResponse, error)
+	RegisterRenewals(now time.Time) error
 }
 
 type RecordService interface {
-	RegisterStaff(trainingPlanId uint, req request.RegisterStaffRequest) error
-	FindById(id int) (response.RecordResponseFinal, error)
-	Update(id int, req request.UpdateRecordRequest) error
-	Delete(id int) error
+	RegisterStaff(actor model.Actor, trainingPlanId uint, req request.RegisterStaffRequest) (response.RegistrationResponse, error)
+	FindById(actor model.Actor, id int) (response.RecordResponseFinal, error)
+	Update(actor model.Actor, id int, req request.UpdateRecordRequest) error
+	Delete(actor model.Actor, id int) error
  	FindByManager(managerID uint,page int,limit int,) (response.PaginatedResponse[response.AdminRecordResponse], error)	
 	FindByUser(userID uint, page int, limit int) (response.PaginatedResponse[response.StaffRecordResponse], error)
 	Search(req request.RecordFilterRequest) (response.PaginatedResponse[response.AdminRecordResponse], error)
 	Export(req request.RecordFilterRequest) (*excelize.File, error)
+	FindTrainingHistory(userID uint) (response.TrainingHistoryResponse, error)
 
 }
\ No newline at end of file
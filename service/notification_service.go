@@ -0,0 +1,672 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+	"training-plan-api/data/request"
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+	"training-plan-api/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	notificationBatchSize = 50
+	notificationLease     = 5 * time.Minute
+)
+
+// ReminderSettings controls when the scheduled reminders are sent.
+type ReminderSettings struct {
+	// DaysBefore is when registrants are first reminded of a training;
+	// they are reminded again the day before.
+	DaysBefore int
+	// AttendanceDaysAfter is how long after a plan ends managers are asked
+	// to fill in attendance and scores.
+	AttendanceDaysAfter int
+	// CertificatePendingDays is how long a certificate may wait for review
+	// before reviewers are reminded.
+	CertificatePendingDays int
+	// CertificateExpiryDays is when holders and their managers are first
+	// told a certificate expires; they are told again a week before.
+	CertificateExpiryDays int
+}
+
+type NotificationServiceImpl struct {
+	repo              repository.NotificationRepository
+	userRepo          repository.UserRepository
+	trainingPlanRepo  repository.TrainingPlanRepository
+	recordRepo        repository.RecordRepository
+	certificateRepo   repository.CertificateRepository
+	permissionService PermissionService
+	eventService      EventService
+	mailer            helper.Mailer
+	retry             helper.RetryPolicy
+	validate          *validator.Validate
+	location          *time.Location
+	appBaseURL        string
+	reminders         ReminderSettings
+}
+
+func NewNotificationServiceImpl(
+	repo repository.NotificationRepository,
+	userRepo repository.UserRepository,
+	trainingPlanRepo repository.TrainingPlanRepository,
+	recordRepo repository.RecordRepository,
+	certificateRepo repository.CertificateRepository,
+	permissionService PermissionService,
+	eventService EventService,
+	mailer helper.Mailer,
+	retry helper.RetryPolicy,
+	validate *validator.Validate,
+	location *time.Location,
+	appBaseURL string,
+	reminders ReminderSettings,
+) NotificationService {
+	if appBaseURL == "" {
+		appBaseURL = "http://localhost:3000"
+	}
+	if reminders.DaysBefore <= 0 {
+		reminders.DaysBefore = 1
+	}
+	if reminders.AttendanceDaysAfter <= 0 {
+		reminders.AttendanceDaysAfter = 1
+	}
+	if reminders.CertificatePendingDays <= 0 {
+		reminders.CertificatePendingDays = 3
+	}
+	if reminders.CertificateExpiryDays <= 0 {
+		reminders.CertificateExpiryDays = 30
+	}
+
+	return &NotificationServiceImpl{
+		repo:              repo,
+		userRepo:          userRepo,
+		trainingPlanRepo:  trainingPlanRepo,
+		recordRepo:        recordRepo,
+		certificateRepo:   certificateRepo,
+		permissionService: permissionService,
+		eventService:      eventService,
+		mailer:            mailer,
+		retry:             retry,
+		validate:          validate,
+		location:          location,
+		appBaseURL:        strings.TrimRight(appBaseURL, "/"),
+		reminders:         reminders,
+	}
+}
+
+// NotifyRegistration implements NotificationService. promoted marks
+// records that just left the waitlist.
+func (s *NotificationServiceImpl) NotifyRegistration(trainingPlanId uint, records []model.Record, promoted bool) {
+	if len(records) == 0 {
+		return
+	}
+
+	trainingPlan, err := s.trainingPlanRepo.FindById(int(trainingPlanId))
+	if err != nil {
+		log.Printf("notification: training plan %d: %v", trainingPlanId, err)
+		return
+	}
+
+	for _, record := range records {
+		data := s.trainingPlanData(trainingPlan)
+		data.Status = string(record.Status)
+		if promoted {
+			data.Status = "Promoted"
+		}
+		s.notify(model.NotifyRegistration, []uint{record.UserID}, data, "")
+	}
+}
+
+// NotifyRecordUpdated implements NotificationService. The record's owner is
+// told its new status.
+func (s *NotificationServiceImpl) NotifyRecordUpdated(record *model.Record) {
+	trainingPlan, err := s.trainingPlanRepo.FindById(int(record.TrainingPlanID))
+	if err != nil {
+		log.Printf("notification: training plan %d: %v", record.TrainingPlanID, err)
+		return
+	}
+
+	data := s.trainingPlanData(trainingPlan)
+	data.Status = string(record.Status)
+	s.notify(model.NotifyRecordUpdated, []uint{record.UserID}, data, "")
+}
+
+// NotifyTrainingPlan implements NotificationService.
+func (s *NotificationServiceImpl) NotifyTrainingPlan(event model.NotificationEvent, trainingPlan *model.TrainingPlan, userIds []uint, reason string) {
+	data := s.trainingPlanData(trainingPlan)
+	data.Reason = reason
+	s.notify(event, userIds, data, "")
+}
+
+// NotifyCertificate implements NotificationService.
+func (s *NotificationServiceImpl) NotifyCertificate(event model.NotificationEvent, certificate *model.Certificate, reason string) {
+	data := helper.NotificationData{
+		Reason: reason,
+		Link:   s.appBaseURL + "/certificates",
+	}
+	if certificate.Kind == model.CertKindExternal {
+		data.TrainingName = certificate.TrainingName()
+		if certificate.IssueDate != nil {
+			data.TrainingDate = *certificate.IssueDate
+		}
+	} else if certificate.Training != nil {
+		data.TrainingName = certificate.Training.Name
+		data.TrainingDate = certificate.Training.Date
+	} else if certificate.TrainingID != nil {
+		if trainingPlan, err := s.trainingPlanRepo.FindById(int(*certificate.TrainingID)); err == nil {
+			data.TrainingName = trainingPlan.Name
+			data.TrainingDate = trainingPlan.Date
+		}
+	}
+
+	s.notify(event, []uint{certificate.UserID}, data, "")
+}
+
+// SendReminders implements NotificationService. Everyone holding a seat on
+// a plan is reminded DaysBefore days and one day before it starts; running
+// it again the same day queues nothing new.
+func (s *NotificationServiceImpl) SendReminders(now time.Time) error {
+	today := s.today(now)
+
+	days := []int{s.reminders.DaysBefore}
+	if s.reminders.DaysBefore != 1 {
+		days = append(days, 1)
+	}
+
+	for _, daysBefore := range days {
+		plans, err := s.trainingPlanRepo.FindPublishedOn(today.AddDate(0, 0, daysBefore))
+		if err != nil {
+			return err
+		}
+
+		for i := range plans {
+			records, err := s.recordRepo.FindByTrainingPlan(uint(plans[i].ID))
+			if err != nil {
+				return err
+			}
+
+			userIds := make([]uint, 0, len(records))
+			for _, record := range records {
+				if holdsSeat(record.Status) {
+					userIds = append(userIds, record.UserID)
+				}
+			}
+
+			dedup := fmt.Sprintf("%s:%d:%s:%dd", model.NotifyTrainingReminder, plans[i].ID, plans[i].Date.Format("2006-01-02"), daysBefore)
+			s.notify(model.NotifyTrainingReminder, userIds, s.trainingPlanData(&plans[i]), dedup)
+		}
+	}
+	return nil
+}
+
+// SendAttendanceReminders implements NotificationService. For plans that
+// ended AttendanceDaysAfter days ago, the managers of each department whose
+// participants still lack attendance or test scores are asked once to fill
+// them in. Departments without a manager fall back to HR.
+func (s *NotificationServiceImpl) SendAttendanceReminders(now time.Time) error {
+	plans, err := s.trainingPlanRepo.FindEndedOn(s.today(now).AddDate(0, 0, -s.reminders.AttendanceDaysAfter))
+	if err != nil {
+		return err
+	}
+
+	managerRoles := s.permissionService.RolesWithPermission(model.PermRecordsWriteDepartment)
+	hrRoles := s.permissionService.RolesWithPermission(model.PermRecordsWrite)
+
+	for i := range plans {
+		records, err := s.recordRepo.FindByTrainingPlan(uint(plans[i].ID))
+		if err != nil {
+			return err
+		}
+
+		// incomplete records per department
+		pending := make(map[int]int)
+		for _, record := range records {
+			if record.User == nil || !recordIncomplete(record) {
+				continue
+			}
+			pending[record.User.DepartmentID]++
+		}
+
+		for departmentId, count := range pending {
+			managers, err := s.userRepo.FindActiveByRoles(managerRoles, departmentId)
+			if err != nil {
+				return err
+			}
+			if len(managers) == 0 {
+				if managers, err = s.userRepo.FindActiveByRoles(hrRoles, 0); err != nil {
+					return err
+				}
+			}
+
+			userIds := make([]uint, 0, len(managers))
+			for _, manager := range managers {
+				userIds = append(userIds, manager.ID)
+			}
+
+			data := s.trainingPlanData(&plans[i])
+			data.Count = count
+			dedup := fmt.Sprintf("%s:%d:%d", model.NotifyAttendanceReminder, plans[i].ID, departmentId)
+			s.notify(model.NotifyAttendanceReminder, userIds, data, dedup)
+		}
+	}
+	return nil
+}
+
+// SendPendingCertificateReminders implements NotificationService. Reviewers
+// are told once a day how many certificates have waited longer than
+// CertificatePendingDays.
+func (s *NotificationServiceImpl) SendPendingCertificateReminders(now time.Time) error {
+	today := s.today(now)
+
+	count, err := s.certificateRepo.CountPendingSince(now.AddDate(0, 0, -s.reminders.CertificatePendingDays))
+	if err != nil || count == 0 {
+		return err
+	}
+
+	reviewers, err := s.userRepo.FindActiveByRoles(s.permissionService.RolesWithPermission(model.PermCertificatesApprove), 0)
+	if err != nil {
+		return err
+	}
+
+	userIds := make([]uint, 0, len(reviewers))
+	for _, reviewer := range reviewers {
+		userIds = append(userIds, reviewer.ID)
+	}
+
+	data := helper.NotificationData{
+		Count: int(count),
+		Days:  s.reminders.CertificatePendingDays,
+		Link:  s.appBaseURL + "/admin/certificates",
+	}
+	dedup := fmt.Sprintf("%s:%s", model.NotifyCertificatesPending, today.Format("2006-01-02"))
+	s.notify(model.NotifyCertificatesPending, userIds, data, dedup)
+	return nil
+}
+
+// SendCertificateExpiryReminders implements NotificationService. Holders
+// of certificates expiring in CertificateExpiryDays days and in a week,
+// and the managers of their department, are told once per certificate and
+// day count. Certificates already renewed are skipped.
+func (s *NotificationServiceImpl) SendCertificateExpiryReminders(now time.Time) error {
+	today := s.today(now)
+
+	days := []int{s.reminders.CertificateExpiryDays}
+	if s.reminders.CertificateExpiryDays != 7 {
+		days = append(days, 7)
+	}
+
+	managerRoles := s.permissionService.RolesWithPermission(model.PermRecordsReadDepartment)
+
+	for _, daysLeft := range days {
+		date := today.AddDate(0, 0, daysLeft)
+		certificates, err := s.certificateRepo.FindExpiring(&date, date, 0)
+		if err != nil {
+			return err
+		}
+
+		for i := range certificates {
+			certificate := &certificates[i]
+			data := helper.NotificationData{
+				TrainingName: certificate.TrainingName(),
+				ExpiryDate:   *certificate.ExpiryDate,
+				Days:         daysLeft,
+				Link:         s.appBaseURL + "/certificates",
+			}
+			dedup := fmt.Sprintf("%d:%s:%dd", certificate.ID, certificate.ExpiryDate.Format("2006-01-02"), daysLeft)
+			s.notify(model.NotifyCertificateExpiring, []uint{certificate.UserID}, data, string(model.NotifyCertificateExpiring)+":"+dedup)
+
+			if certificate.User == nil {
+				continue
+			}
+			managers, err := s.userRepo.FindActiveByRoles(managerRoles, certificate.User.DepartmentID)
+			if err != nil {
+				return err
+			}
+
+			userIds := make([]uint, 0, len(managers))
+			for _, manager := range managers {
+				if manager.ID != certificate.UserID {
+					userIds = append(userIds, manager.ID)
+				}
+			}
+
+			data.EmployeeName = certificate.User.Name
+			data.Link = s.appBaseURL + "/certificates/expiring"
+			s.notify(model.NotifyCertificateExpiringStaff, userIds, data, string(model.NotifyCertificateExpiringStaff)+":"+dedup)
+		}
+	}
+	return nil
+}
+
+// today returns the start of now's day in the application timezone.
+func (s *NotificationServiceImpl) today(now time.Time) time.Time {
+	if s.location != nil {
+		now = now.In(s.location)
+	}
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
+
+// recordIncomplete reports whether a participant of an ended plan still
+// needs attendance or, having attended, a test score.
+func recordIncomplete(record model.Record) bool {
+	switch record.Status {
+	case model.RecordStatusRegister:
+		return true
+	case model.RecordStatusAttended:
+		return record.PreTestScore == nil || record.PostTestScore == nil
+	}
+	return false
+}
+
+// Run sends due notifications every interval until ctx is cancelled.
+func (s *NotificationServiceImpl) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ProcessDue(ctx); err != nil {
+			log.Println("Notification delivery failed:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessDue implements NotificationService. It returns how many
+// deliveries were attempted.
+func (s *NotificationServiceImpl) ProcessDue(ctx context.Context) (int, error) {
+	deliveries, err := s.repo.ClaimDue(time.Now(), notificationLease, notificationBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+
+		attempts := delivery.Attempts + 1
+
+		err := s.mailer.Send(helper.MailMessage{
+			To:       []string{delivery.Recipient},
+			Subject:  delivery.Subject,
+			TextBody: delivery.Body,
+		})
+
+		switch {
+		case err == nil:
+			err = s.repo.MarkSent(delivery.ID, attempts)
+		case s.retry.Exhausted(attempts):
+			log.Printf("Notification %d (%s to user %d) failed permanently: %v", delivery.ID, delivery.Event, delivery.UserID, err)
+			err = s.repo.MarkFailed(delivery.ID, attempts, err.Error())
+		default:
+			err = s.repo.MarkRetry(delivery.ID, attempts, time.Now().Add(s.retry.Delay(attempts)), err.Error())
+		}
+		if err != nil {
+			return len(deliveries), err
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// FindInbox implements NotificationService.
+func (s *NotificationServiceImpl) FindInbox(actor model.Actor, unreadOnly bool, page, limit int) (response.PaginatedResponse[response.NotificationResponse], error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+
+	notifications, total, err := s.repo.FindInboxPaginated(actor.UserID, unreadOnly, (page-1)*limit, limit)
+	if err != nil {
+		return response.PaginatedResponse[response.NotificationResponse]{}, err
+	}
+
+	items := make([]response.NotificationResponse, 0, len(notifications))
+	for _, notification := range notifications {
+		items = append(items, toNotificationResponse(notification))
+	}
+
+	return response.PaginatedResponse[response.NotificationResponse]{
+		Items: items,
+		Meta: response.PaginationMeta{
+			Page:       page,
+			Limit:      limit,
+			TotalItems: total,
+			TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+		},
+	}, nil
+}
+
+// CountUnread implements NotificationService.
+func (s *NotificationServiceImpl) CountUnread(actor model.Actor) (response.UnreadCountResponse, error) {
+	count, err := s.repo.CountUnread(actor.UserID)
+	if err != nil {
+		return response.UnreadCountResponse{}, err
+	}
+	return response.UnreadCountResponse{Unread: count}, nil
+}
+
+// MarkRead implements NotificationService.
+func (s *NotificationServiceImpl) MarkRead(actor model.Actor, id uint) (response.UnreadCountResponse, error) {
+	if err := s.repo.MarkRead(actor.UserID, id); err != nil {
+		return response.UnreadCountResponse{}, err
+	}
+	return s.CountUnread(actor)
+}
+
+// MarkAllRead implements NotificationService.
+func (s *NotificationServiceImpl) MarkAllRead(actor model.Actor) (response.UnreadCountResponse, error) {
+	if _, err := s.repo.MarkAllRead(actor.UserID); err != nil {
+		return response.UnreadCountResponse{}, err
+	}
+	return s.CountUnread(actor)
+}
+
+// GetPreferences implements NotificationService. Every event and channel
+// is listed; those without a saved preference are enabled.
+func (s *NotificationServiceImpl) GetPreferences(actor model.Actor) (response.NotificationPreferencesResponse, error) {
+	user, err := s.userRepo.FindById(actor.UserID)
+	if err != nil {
+		return response.NotificationPreferencesResponse{}, err
+	}
+
+	prefs, err := s.repo.FindPreferences(actor.UserID)
+	if err != nil {
+		return response.NotificationPreferencesResponse{}, err
+	}
+
+	saved := make(map[string]bool, len(prefs))
+	for _, pref := range prefs {
+		saved[string(pref.Event)+"/"+string(pref.Channel)] = pref.Enabled
+	}
+
+	items := make([]response.NotificationPreferenceItem, 0, len(model.NotificationEvents)*len(model.NotificationChannels))
+	for _, event := range model.NotificationEvents {
+		for _, channel := range model.NotificationChannels {
+			enabled, ok := saved[string(event)+"/"+string(channel)]
+			items = append(items, response.NotificationPreferenceItem{
+				Event:   string(event),
+				Channel: string(channel),
+				Enabled: !ok || enabled,
+			})
+		}
+	}
+
+	return response.NotificationPreferencesResponse{
+		Language:    helper.NormalizeLanguage(user.Language),
+		Preferences: items,
+	}, nil
+}
+
+// UpdatePreferences implements NotificationService. Only the listed
+// events and channels change.
+func (s *NotificationServiceImpl) UpdatePreferences(actor model.Actor, req request.UpdateNotificationPreferencesRequest) (response.NotificationPreferencesResponse, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return response.NotificationPreferencesResponse{}, helper.ValidationError(helper.FormatValidationError(err))
+	}
+
+	prefs := make([]model.NotificationPreference, 0, len(req.Preferences))
+	for _, item := range req.Preferences {
+		event := model.NotificationEvent(item.Event)
+		channel := model.NotificationChannel(item.Channel)
+		if !event.IsValid() {
+			return response.NotificationPreferencesResponse{}, helper.BadRequest("unknown notification event " + item.Event)
+		}
+		if !channel.IsValid() {
+			return response.NotificationPreferencesResponse{}, helper.BadRequest("unknown notification channel " + item.Channel)
+		}
+
+		prefs = append(prefs, model.NotificationPreference{
+			UserID:  actor.UserID,
+			Event:   event,
+			Channel: channel,
+			Enabled: *item.Enabled,
+		})
+	}
+
+	if req.Language != nil {
+		if err := s.userRepo.UpdateLanguage(actor.UserID, *req.Language); err != nil {
+			return response.NotificationPreferencesResponse{}, err
+		}
+	}
+
+	if err := s.repo.SavePreferences(prefs); err != nil {
+		return response.NotificationPreferencesResponse{}, err
+	}
+
+	return s.GetPreferences(actor)
+}
+
+// notify renders event for each user in their language, queues it for email
+// and adds it to their inbox, skipping the channels they turned off.
+// Failures are logged only; the change being notified about has already
+// been committed.
+func (s *NotificationServiceImpl) notify(event model.NotificationEvent, userIds []uint, data helper.NotificationData, dedupKey string) {
+	if len(userIds) == 0 {
+		return
+	}
+
+	skip := make(map[model.NotificationChannel]map[uint]bool, len(model.NotificationChannels))
+	for _, channel := range model.NotificationChannels {
+		optedOut, err := s.repo.FindOptedOut(userIds, event, channel)
+		if err != nil {
+			log.Printf("notification: %s preferences: %v", event, err)
+			return
+		}
+		skip[channel] = make(map[uint]bool, len(optedOut))
+		for _, id := range optedOut {
+			skip[channel][id] = true
+		}
+	}
+
+	users, err := s.userRepo.FindByIds(userIds)
+	if err != nil {
+		log.Printf("notification: %s recipients: %v", event, err)
+		return
+	}
+
+	dedup := func(userId uint, channel model.NotificationChannel) *string {
+		if dedupKey == "" {
+			return nil
+		}
+		key := fmt.Sprintf("%s:%d:%s", dedupKey, userId, channel)
+		return &key
+	}
+
+	deliveries := make([]model.NotificationDelivery, 0, len(users))
+	inbox := make([]model.Notification, 0, len(users))
+	for _, user := range users {
+		if user.Status != model.UserStatusActive {
+			continue
+		}
+
+		data.RecipientName = user.Name
+		subject, body, err := helper.RenderNotification(string(event), user.Language, data)
+		if err != nil {
+			log.Printf("notification: %s: %v", event, err)
+			return
+		}
+
+		if !skip[model.NotificationChannelEmail][user.ID] && user.Email != "" {
+			deliveries = append(deliveries, model.NotificationDelivery{
+				UserID:        user.ID,
+				Event:         event,
+				Channel:       model.NotificationChannelEmail,
+				Recipient:     user.Email,
+				Subject:       subject,
+				Body:          body,
+				DedupKey:      dedup(user.ID, model.NotificationChannelEmail),
+				Status:        model.NotificationPending,
+				NextAttemptAt: time.Now(),
+			})
+		}
+
+		if !skip[model.NotificationChannelInApp][user.ID] {
+			inbox = append(inbox, model.Notification{
+				UserID:   user.ID,
+				Event:    event,
+				Title:    subject,
+				Body:     body,
+				Link:     data.Link,
+				DedupKey: dedup(user.ID, model.NotificationChannelInApp),
+			})
+		}
+	}
+
+	if err := s.repo.Enqueue(deliveries); err != nil {
+		log.Printf("notification: failed to queue %s: %v", event, err)
+	}
+	if err := s.repo.CreateInbox(inbox); err != nil {
+		log.Printf("notification: failed to add %s to inboxes: %v", event, err)
+		return
+	}
+
+	for _, notification := range inbox {
+		// skipped as a duplicate
+		if notification.ID == 0 {
+			continue
+		}
+		s.eventService.Publish(model.EventNotificationCreated, toNotificationResponse(notification),
+			model.EventAudience{UserID: notification.UserID},
+		)
+	}
+}
+
+func toNotificationResponse(notification model.Notification) response.NotificationResponse {
+	return response.NotificationResponse{
+		ID:        notification.ID,
+		Event:     string(notification.Event),
+		Title:     notification.Title,
+		Body:      notification.Body,
+		Link:      notification.Link,
+		Read:      notification.ReadAt != nil,
+		ReadAt:    notification.ReadAt,
+		CreatedAt: notification.CreatedAt,
+	}
+}
+
+func (s *NotificationServiceImpl) trainingPlanData(trainingPlan *model.TrainingPlan) helper.NotificationData {
+	data := helper.NotificationData{
+		TrainingName: trainingPlan.Name,
+		TrainingDate: trainingPlan.Date,
+		Link:         fmt.Sprintf("%s/training-plans/%d", s.appBaseURL, trainingPlan.ID),
+	}
+	if trainingPlan.Location != nil {
+		data.Location = *trainingPlan.Location
+	}
+	return data
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+	"training-plan-api/data/request"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+	"training-plan-api/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type PasswordServiceImpl struct {
+	userRepo     repository.UserRepository
+	resetRepo    repository.PasswordResetRepository
+	tokenService AuthTokenService
+	auditService AuditService
+	mailer       helper.Mailer
+	appBaseURL   string
+	validate     *validator.Validate
+}
+
+func NewPasswordServiceImpl(
+	userRepo repository.UserRepository,
+	resetRepo repository.PasswordResetRepository,
+	tokenService AuthTokenService,
+	auditService AuditService,
+	mailer helper.Mailer,
+	appBaseURL string,
+	validate *validator.Validate,
+) PasswordService {
+	if appBaseURL == "" {
+		appBaseURL = "http://localhost:3000"
+	}
+
+	return &PasswordServiceImpl{
+		userRepo:     userRepo,
+		resetRepo:    resetRepo,
+		tokenService: tokenService,
+		auditService: auditService,
+		mailer:       mailer,
+		appBaseURL:   strings.TrimRight(appBaseURL, "/"),
+		validate:     validate,
+	}
+}
+
+// ForgotPassword emails a reset link. It never reveals whether the email
+// belongs to an account.
+func (s *PasswordServiceImpl) ForgotPassword(req request.ForgotPasswordRequest) error {
+	if err := s.validate.Struct(req); err != nil {
+		return helper.ValidationError(helper.FormatValidationError(err))
+	}
+
+	user, err := s.userRepo.FindByEmail(req.Email)
+	if err != nil || user.Status != model.UserStatusActive {
+		return nil
+	}
+
+	if err := s.resetRepo.InvalidateByUser(user.ID); err != nil {
+		return helper.InternalServerError("Failed to create reset token")
+	}
+
+	resetToken, err := helper.GenerateRandomToken(32)
+	if err != nil {
+		return helper.InternalServerError("Failed to create reset token")
+	}
+
+	if err := s.resetRepo.Save(&model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: helper.HashToken(resetToken),
+		ExpiresAt: time.Now().Add(helper.PasswordResetTokenExpiry),
+	}); err != nil {
+		return helper.InternalServerError("Failed to create reset token")
+	}
+
+	resetURL := s.appBaseURL + "/reset-password?token=" + url.QueryEscape(resetToken)
+	body := fmt.Sprintf(
+		"Hello %s,\n\nWe received a request to reset your password. Open the link below to choose a new one:\n\n%s\n\nThe link expires in %d minutes and can be used once. If you did not request this, you can ignore this email.\n",
+		user.Name,
+		resetURL,
+		int(helper.PasswordResetTokenExpiry.Minutes()),
+	)
+
+	if err := s.mailer.Send(helper.MailMessage{
+		To:       []string{user.Email},
+		Subject:  "Reset your password",
+		TextBody: body,
+	}); err != nil {
+		log.Println("failed to send password reset email for userID:", user.ID, "err:", err)
+	}
+
+	return nil
+}
+
+// ResetPassword consumes a reset token and signs the user out everywhere.
+func (s *PasswordServiceImpl) ResetPassword(req request.ResetPasswordRequest) error {
+	if err := s.validate.Struct(req); err != nil {
+		return helper.ValidationError(helper.FormatValidationError(err))
+	}
+
+	resetToken, err := s.resetRepo.FindByTokenHash(helper.HashToken(req.Token))
+	if err != nil {
+		return err
+	}
+
+	if !resetToken.IsValid() {
+		return helper.BadRequest("Invalid or expired reset token")
+	}
+
+	if err := s.resetRepo.MarkUsed(resetToken.ID); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdatePassword(resetToken.UserID, helper.GeneratePassword(req.NewPassword), false); err != nil {
+		return err
+	}
+
+	return s.tokenService.RevokeAllForUser(resetToken.UserID)
+}
+
+// ChangePassword updates the password of a signed-in user and ends their
+// other sessions. Accounts without a password (Google sign-in) may set one
+// without the current password.
+func (s *PasswordServiceImpl) ChangePassword(userID uint, req request.ChangePasswordRequest) (*model.User, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, helper.ValidationError(helper.FormatValidationError(err))
+	}
+
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Password != "" && !helper.ComparePassword(user.Password, req.CurrentPassword) {
+		return nil, helper.BadRequest("Current password is incorrect")
+	}
+
+	if req.CurrentPassword == req.NewPassword {
+		return nil, helper.BadRequest("New password must be different from the current password")
+	}
+
+	if err := s.userRepo.UpdatePassword(userID, helper.GeneratePassword(req.NewPassword), false); err != nil {
+		return nil, err
+	}
+
+	if err := s.tokenService.RevokeAllForUser(userID); err != nil {
+		return nil, err
+	}
+
+	user.MustChangePassword = false
+	return user, nil
+}
+
+// AdminResetPassword sets a temporary password that must be changed on the
+// next login.
+func (s *PasswordServiceImpl) AdminResetPassword(actor model.Actor, userID uint, req request.AdminResetPasswordRequest) error {
+	if err := s.validate.Struct(req); err != nil {
+		return helper.ValidationError(helper.FormatValidationError(err))
+	}
+
+	if _, err := s.userRepo.FindById(userID); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdatePassword(userID, helper.GeneratePassword(req.NewPassword), true); err != nil {
+		return err
+	}
+
+	if err := s.resetRepo.InvalidateByUser(userID); err != nil {
+		return helper.InternalServerError("Failed to invalidate reset tokens")
+	}
+
+	if err := s.tokenService.RevokeAllForUser(userID); err != nil {
+		return err
+	}
+
+	s.auditService.Record(actor, model.AuditResetPassword, model.AuditEntityUser, userID,
+		nil, map[string]interface{}{"mustChangePassword": true},
+	)
+	return nil
+}
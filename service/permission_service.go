@@ -0,0 +1,297 @@
+package service
+
+import (
+	"sort"
+	"sync"
+	"time"
+	"training-plan-api/data/request"
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+	"training-plan-api/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// permissionCacheTTL bounds how long an edit made on another instance can
+// take to be seen here. Edits made through this service apply immediately.
+const permissionCacheTTL = time.Minute
+
+type PermissionServiceImpl struct {
+	roleRepo     repository.RoleRepository
+	auditService AuditService
+	validate     *validator.Validate
+
+	mu       sync.RWMutex
+	grants   map[string]map[model.Permission]bool
+	loadedAt time.Time
+}
+
+func NewPermissionServiceImpl(
+	roleRepo repository.RoleRepository,
+	auditService AuditService,
+	validate *validator.Validate,
+) PermissionService {
+	return &PermissionServiceImpl{
+		roleRepo:     roleRepo,
+		auditService: auditService,
+		validate:     validate,
+	}
+}
+
+// HasPermission implements PermissionService.
+func (s *PermissionServiceImpl) HasPermission(role string, permission model.Permission) bool {
+	grants, err := s.roleGrants()
+	if err != nil {
+		return false
+	}
+	return grants[role][permission]
+}
+
+// HasAnyPermission implements PermissionService.
+func (s *PermissionServiceImpl) HasAnyPermission(role string, permissions ...model.Permission) bool {
+	grants, err := s.roleGrants()
+	if err != nil {
+		return false
+	}
+	for _, permission := range permissions {
+		if grants[role][permission] {
+			return true
+		}
+	}
+	return false
+}
+
+// RolesWithPermission implements PermissionService. The roles are sorted by
+// name.
+func (s *PermissionServiceImpl) RolesWithPermission(permission model.Permission) []string {
+	grants, err := s.roleGrants()
+	if err != nil {
+		return nil
+	}
+	roles := make([]string, 0, len(grants))
+	for role, permissions := range grants {
+		if permissions[permission] {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// ListPermissions implements PermissionService.
+func (s *PermissionServiceImpl) ListPermissions() []response.PermissionResponse {
+	items := make([]response.PermissionResponse, 0, len(model.PermissionRegistry))
+	for _, def := range model.PermissionRegistry {
+		items = append(items, response.PermissionResponse{
+			Name:        string(def.Name),
+			Description: def.Description,
+		})
+	}
+	return items
+}
+
+// FindRoles implements PermissionService.
+func (s *PermissionServiceImpl) FindRoles() ([]response.RoleResponse, error) {
+	roles, err := s.roleRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]response.RoleResponse, 0, len(roles))
+	for _, role := range roles {
+		items = append(items, toRoleResponse(role))
+	}
+	return items, nil
+}
+
+// CreateRole implements PermissionService.
+func (s *PermissionServiceImpl) CreateRole(actor model.Actor, req request.CreateRoleRequest) (response.RoleResponse, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return response.RoleResponse{}, helper.ValidationError(helper.FormatValidationError(err))
+	}
+
+	permissions, err := parsePermissions(req.Permissions)
+	if err != nil {
+		return response.RoleResponse{}, err
+	}
+
+	if s.roleRepo.ExistsByName(req.Name) {
+		return response.RoleResponse{}, helper.BadRequest("role already exists")
+	}
+
+	role := model.AppRole{
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	for _, permission := range permissions {
+		role.Permissions = append(role.Permissions, model.RolePermission{
+			RoleName:   req.Name,
+			Permission: permission,
+		})
+	}
+
+	if err := s.roleRepo.Save(&role); err != nil {
+		return response.RoleResponse{}, err
+	}
+
+	s.invalidate()
+
+	created := toRoleResponse(role)
+	s.auditService.Record(actor, model.AuditCreate, model.AuditEntityRole, role.Name, nil, created)
+	return created, nil
+}
+
+// UpdateRole implements PermissionService.
+func (s *PermissionServiceImpl) UpdateRole(actor model.Actor, name string, req request.UpdateRoleRequest) (response.RoleResponse, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return response.RoleResponse{}, helper.ValidationError(helper.FormatValidationError(err))
+	}
+
+	permissions, err := parsePermissions(req.Permissions)
+	if err != nil {
+		return response.RoleResponse{}, err
+	}
+
+	role, err := s.roleRepo.FindByName(name)
+	if err != nil {
+		return response.RoleResponse{}, err
+	}
+
+	// system roles may gain permissions but never lose the ones the
+	// built-in routes depend on
+	if role.IsSystem {
+		granted := make(map[model.Permission]bool, len(permissions))
+		for _, permission := range permissions {
+			granted[permission] = true
+		}
+		for _, required := range model.DefaultRolePermissions(model.Role(role.Name)) {
+			if !granted[required] {
+				return response.RoleResponse{}, helper.BadRequest(
+					"cannot remove permission " + string(required) + " from system role",
+				)
+			}
+		}
+	}
+
+	if req.Description != nil {
+		if err := s.roleRepo.UpdateDescription(name, *req.Description); err != nil {
+			return response.RoleResponse{}, err
+		}
+	}
+
+	if err := s.roleRepo.ReplacePermissions(name, permissions); err != nil {
+		return response.RoleResponse{}, err
+	}
+
+	s.invalidate()
+
+	updated, err := s.roleRepo.FindByName(name)
+	if err != nil {
+		return response.RoleResponse{}, err
+	}
+
+	after := toRoleResponse(*updated)
+	s.auditService.Record(actor, model.AuditUpdate, model.AuditEntityRole, name, toRoleResponse(*role), after)
+	return after, nil
+}
+
+// DeleteRole implements PermissionService.
+func (s *PermissionServiceImpl) DeleteRole(actor model.Actor, name string) error {
+	role, err := s.roleRepo.FindByName(name)
+	if err != nil {
+		return err
+	}
+
+	if role.IsSystem {
+		return helper.BadRequest("system roles cannot be deleted")
+	}
+
+	count, err := s.roleRepo.CountUsers(name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return helper.BadRequest("role is still assigned to users")
+	}
+
+	if err := s.roleRepo.Delete(name); err != nil {
+		return err
+	}
+
+	s.invalidate()
+	s.auditService.Record(actor, model.AuditDelete, model.AuditEntityRole, name, toRoleResponse(*role), nil)
+	return nil
+}
+
+// roleGrants returns the cached role → permission set, reloading it from
+// the database once it is older than permissionCacheTTL.
+func (s *PermissionServiceImpl) roleGrants() (map[string]map[model.Permission]bool, error) {
+	s.mu.RLock()
+	if s.grants != nil && time.Since(s.loadedAt) < permissionCacheTTL {
+		grants := s.grants
+		s.mu.RUnlock()
+		return grants, nil
+	}
+	s.mu.RUnlock()
+
+	rows, err := s.roleRepo.FindAllRolePermissions()
+	if err != nil {
+		return nil, err
+	}
+
+	grants := make(map[string]map[model.Permission]bool)
+	for _, row := range rows {
+		if grants[row.RoleName] == nil {
+			grants[row.RoleName] = make(map[model.Permission]bool)
+		}
+		grants[row.RoleName][row.Permission] = true
+	}
+
+	s.mu.Lock()
+	s.grants = grants
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+
+	return grants, nil
+}
+
+func (s *PermissionServiceImpl) invalidate() {
+	s.mu.Lock()
+	s.grants = nil
+	s.mu.Unlock()
+}
+
+func parsePermissions(names []string) ([]model.Permission, error) {
+	seen := make(map[model.Permission]bool, len(names))
+	permissions := make([]model.Permission, 0, len(names))
+
+	for _, name := range names {
+		permission := model.Permission(name)
+		if !permission.IsValid() {
+			return nil, helper.BadRequest("unknown permission: " + name)
+		}
+		if seen[permission] {
+			continue
+		}
+		seen[permission] = true
+		permissions = append(permissions, permission)
+	}
+
+	return permissions, nil
+}
+
+func toRoleResponse(role model.AppRole) response.RoleResponse {
+	permissions := make([]string, 0, len(role.Permissions))
+	for _, p := range role.Permissions {
+		permissions = append(permissions, string(p.Permission))
+	}
+	sort.Strings(permissions)
+
+	return response.RoleResponse{
+		Name:        role.Name,
+		Description: role.Description,
+		IsSystem:    role.IsSystem,
+		Permissions: permissions,
+	}
+}
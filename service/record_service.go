@@ -1,7 +1,9 @@
 package service
 
 import (
+	"fmt"
 	"math"
+	"strings"
 	"training-plan-api/data/request"
 	"training-plan-api/data/response"
 	"training-plan-api/helper"
@@ -14,20 +16,41 @@ import (
 )
 
 type RecordServiceImpl struct {
-	repo     repository.RecordRepository
-	userRepo repository.UserRepository
-	validate *validator.Validate
+	repo              repository.RecordRepository
+	userRepo          repository.UserRepository
+	certificateRepo   repository.CertificateRepository
+	sessionRepo       repository.TrainingSessionRepository
+	permissionService PermissionService
+	auditService      AuditService
+	notificationService NotificationService
+	eventService        EventService
+	webhookService      WebhookService
+	validate          *validator.Validate
 }
 
 func NewRecordServiceImpl(
 	repo repository.RecordRepository,
 	userRepo repository.UserRepository,
+	certificateRepo repository.CertificateRepository,
+	sessionRepo repository.TrainingSessionRepository,
+	permissionService PermissionService,
+	auditService AuditService,
+	notificationService NotificationService,
+	eventService EventService,
+	webhookService WebhookService,
 	validate *validator.Validate,
 ) RecordService {
 	return &RecordServiceImpl{
-		repo:     repo,
-		userRepo: userRepo,
-		validate: validate,
+		repo:              repo,
+		userRepo:          userRepo,
+		certificateRepo:   certificateRepo,
+		sessionRepo:       sessionRepo,
+		permissionService: permissionService,
+		auditService:      auditService,
+		notificationService: notificationService,
+		eventService:        eventService,
+		webhookService:      webhookService,
+		validate:          validate,
 	}
 }
 
@@ -91,6 +114,8 @@ func (s *RecordServiceImpl) Search(
 			Department:       department,
 			Division:         division,
 			Status:           string(r.Status),
+			CreditedHours:    r.CreditedHours,
+			RSVPStatus:       r.RSVPStatus,
 			Evaluation:       r.Evaluation,
 			PreTestScore:     r.PreTestScore,
 			PostTestScore:    r.PostTestScore,
@@ -134,6 +159,8 @@ func (s *RecordServiceImpl) FindByUser(userID uint, page int, limit int) (respon
 			ID:             r.ID,
 			TrainingPlanID: r.TrainingPlanID,
 			Status:         string(r.Status),
+			CreditedHours:  r.CreditedHours,
+			RSVPStatus:     r.RSVPStatus,
 			Evaluation:     r.Evaluation,
 			PreTestScore:   r.PreTestScore,
 			PostTestScore:  r.PostTestScore,
@@ -183,40 +210,70 @@ func (s *RecordServiceImpl) FindByUser(userID uint, page int, limit int) (respon
 
 
 func (s *RecordServiceImpl) RegisterStaff(
+	actor model.Actor,
 	trainingPlanId uint,
 	req request.RegisterStaffRequest,
-) error {
+) (response.RegistrationResponse, error) {
+
+	result := response.RegistrationResponse{
+		Registered: []uint{},
+		Waitlisted: []uint{},
+		Skipped:    []uint{},
+	}
 
 	if err := s.validate.Struct(req); err != nil {
-		return helper.ValidationError(helper.FormatValidationError(err))
+		return result, helper.ValidationError(helper.FormatValidationError(err))
 	}
 
-	for _, userId := range req.UserIDs {
-		if s.repo.Exists(userId, trainingPlanId) {
-			continue // prevent duplicate registration
-		}
+	if err := s.authorizeRegistration(actor, req.UserIDs); err != nil {
+		return result, err
+	}
+
+	created, promoted, err := s.repo.RegisterWithCapacity(trainingPlanId, req.UserIDs)
+	if err != nil {
+		return result, err
+	}
+
+	handled := make(map[uint]bool, len(created))
+	for i := range created {
+		record := &created[i]
+		handled[record.UserID] = true
 
-		record := &model.Record{
-			UserID:         userId,
-			TrainingPlanID: trainingPlanId,
-			Status:         model.RecordStatusRegister,
+		if record.Status == model.RecordStatusWaitlisted {
+			result.Waitlisted = append(result.Waitlisted, record.UserID)
+		} else {
+			result.Registered = append(result.Registered, record.UserID)
 		}
 
-		if err := s.repo.Save(record); err != nil {
-			return err
+		s.auditService.Record(actor, model.AuditCreate, model.AuditEntityRecord, record.ID, nil, record)
+	}
+
+	for _, userId := range req.UserIDs {
+		if !handled[userId] {
+			handled[userId] = true
+			result.Skipped = append(result.Skipped, userId)
 		}
 	}
 
-	return nil
+	s.auditPromotions(actor, promoted)
+	s.notificationService.NotifyRegistration(trainingPlanId, created, false)
+	s.notificationService.NotifyRegistration(trainingPlanId, promoted, true)
+	s.eventService.PublishRegistrations(append(created, promoted...))
+
+	return result, nil
 }
 
-func (s *RecordServiceImpl) FindById(id int) (response.RecordResponseFinal, error) {
+func (s *RecordServiceImpl) FindById(actor model.Actor, id int) (response.RecordResponseFinal, error) {
 
 	record, err := s.repo.FindById(id)
 	if err != nil {
 		return response.RecordResponseFinal{}, err
 	}
 
+	if err := s.authorizeRecord(actor, record, model.PermRecordsRead, model.PermRecordsReadDepartment, model.PermRecordsReadOwn); err != nil {
+		return response.RecordResponseFinal{}, err
+	}
+
 	resp := mapper.ToRecordResponse(*record)
 	return resp, nil
 
@@ -299,6 +356,7 @@ func (s *RecordServiceImpl) FindById(id int) (response.RecordResponseFinal, erro
 }
 
 func (s *RecordServiceImpl) Update(
+	actor model.Actor,
 	id int,
 	req request.UpdateRecordRequest,
 ) error {
@@ -312,7 +370,35 @@ func (s *RecordServiceImpl) Update(
 		return err
 	}
 
+	if err := s.authorizeRecord(actor, record, model.PermRecordsWrite, model.PermRecordsWriteDepartment, ""); err != nil {
+		return err
+	}
+
+	if err := ensureRecordEditable(record); err != nil {
+		return err
+	}
+
+	// seats are only handed out by the waitlist so capacity is never exceeded
+	if record.Status == model.RecordStatusWaitlisted && req.Status != model.RecordStatusWaitlisted {
+		return helper.BadRequest("waitlisted records are promoted automatically when a seat frees up")
+	}
+
+	// plans with sessions derive the status from session attendance
+	hasSessions := s.sessionRepo.HasSessions(record.TrainingPlanID)
+	if hasSessions && req.Status != record.Status {
+		return helper.BadRequest("status is computed from session attendance, mark attendance per session instead")
+	}
+
+	before := helper.AuditSnapshot(recordFields(record))
+	previousStatus := record.Status
+
 	record.Status = req.Status
+	if !hasSessions {
+		record.CreditedHours = 0
+		if record.Status == model.RecordStatusAttended && record.TrainingPlan != nil && record.TrainingPlan.NumberOfHours != nil {
+			record.CreditedHours = float64(*record.TrainingPlan.NumberOfHours)
+		}
+	}
 	if(req.Evaluation != nil) {
 		record.Evaluation = req.Evaluation
 	}
@@ -322,11 +408,153 @@ func (s *RecordServiceImpl) Update(
 	if(req.PostTestScore != nil) {
 		record.PostTestScore = req.PostTestScore
 	}
-	return s.repo.Update(record)
+	var webhooks []model.WebhookMessage
+	if record.Status == model.RecordStatusAttended && previousStatus != model.RecordStatusAttended {
+		webhooks = append(webhooks, s.webhookService.RecordWebhook(model.WebhookRecordAttended, record))
+	}
+	if err := s.repo.Update(record, webhooks...); err != nil {
+		return err
+	}
+
+	s.auditService.Record(actor, model.AuditUpdate, model.AuditEntityRecord, id, before, recordFields(record))
+	if record.Status != previousStatus && record.UserID != actor.UserID {
+		s.notificationService.NotifyRecordUpdated(record)
+	}
+	if record.Status != previousStatus {
+		s.eventService.PublishRegistrations([]model.Record{*record})
+	}
+	return nil
 }
 
-func (s *RecordServiceImpl) Delete(id int) error {
-	return s.repo.Delete(id)
+func (s *RecordServiceImpl) Delete(actor model.Actor, id int) error {
+	record, err := s.repo.FindById(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.authorizeRecord(actor, record, model.PermRecordsWrite, model.PermRecordsWriteDepartment, ""); err != nil {
+		return err
+	}
+
+	if err := ensureRecordEditable(record); err != nil {
+		return err
+	}
+
+	promoted, err := s.repo.DeleteAndPromote(id)
+	if err != nil {
+		return err
+	}
+
+	s.auditService.Record(actor, model.AuditDelete, model.AuditEntityRecord, id, recordFields(record), nil)
+	s.auditPromotions(actor, promoted)
+	s.notificationService.NotifyRegistration(record.TrainingPlanID, promoted, true)
+
+	deleted := *record
+	deleted.Status = ""
+	s.eventService.PublishRegistrations(append([]model.Record{deleted}, promoted...))
+	return nil
+}
+
+func (s *RecordServiceImpl) auditPromotions(actor model.Actor, promoted []model.Record) {
+	for _, p := range promoted {
+		s.auditService.Record(actor, model.AuditUpdate, model.AuditEntityRecord, p.ID,
+			map[string]interface{}{"status": model.RecordStatusWaitlisted},
+			map[string]interface{}{"status": p.Status},
+		)
+	}
+}
+
+// ensureRecordEditable rejects changes to records of cancelled or completed
+// plans, whose attendance is final.
+func ensureRecordEditable(record *model.Record) error {
+	if record.Status == model.RecordStatusCancelled {
+		return helper.BadRequest("cancelled records cannot be changed")
+	}
+	if record.TrainingPlan != nil && record.TrainingPlan.Status.IsFinal() {
+		return helper.BadRequest("attendance of a " + strings.ToLower(string(record.TrainingPlan.Status)) + " training plan is locked")
+	}
+	return nil
+}
+
+// recordFields strips the preloaded user and training plan so audit diffs
+// only cover the record's own columns.
+func recordFields(record *model.Record) model.Record {
+	fields := *record
+	fields.User = nil
+	fields.TrainingPlan = nil
+	return fields
+}
+
+// authorizeRecord checks the caller against the record owner using the
+// widest scope the caller's role holds: every record, records of users in
+// the caller's department, or the caller's own records. An empty permission
+// disables that scope.
+func (s *RecordServiceImpl) authorizeRecord(
+	actor model.Actor,
+	record *model.Record,
+	all, department, own model.Permission,
+) error {
+	role := string(actor.Role)
+
+	if s.permissionService.HasPermission(role, all) {
+		return nil
+	}
+
+	if own != "" && record.UserID == actor.UserID && s.permissionService.HasPermission(role, own) {
+		return nil
+	}
+
+	if department != "" && record.User != nil && s.permissionService.HasPermission(role, department) {
+		caller, err := s.userRepo.FindById(actor.UserID)
+		if err != nil {
+			return err
+		}
+		if record.User.DepartmentID == caller.DepartmentID {
+			return nil
+		}
+	}
+
+	return helper.Forbidden("you do not have access to this record")
+}
+
+// authorizeRegistration allows callers holding registrations:write to
+// register anyone; callers limited to their department may only register
+// existing users of that department.
+func (s *RecordServiceImpl) authorizeRegistration(actor model.Actor, userIDs []uint) error {
+	role := string(actor.Role)
+
+	if s.permissionService.HasPermission(role, model.PermRegistrationsWrite) {
+		return nil
+	}
+
+	if !s.permissionService.HasPermission(role, model.PermRegistrationsWriteDepartment) {
+		return helper.Forbidden("you are not allowed to register staff")
+	}
+
+	caller, err := s.userRepo.FindById(actor.UserID)
+	if err != nil {
+		return err
+	}
+
+	users, err := s.userRepo.FindByIds(userIDs)
+	if err != nil {
+		return err
+	}
+
+	inDepartment := make(map[uint]bool, len(users))
+	for _, u := range users {
+		if u.DepartmentID == caller.DepartmentID {
+			inDepartment[u.ID] = true
+		}
+	}
+
+	for _, id := range userIDs {
+		if !inDepartment[id] {
+			return helper.Forbidden(fmt.Sprintf("user %d is not in your department", id))
+		}
+	}
+
+	return nil
 }
 
 func (s *RecordServiceImpl) FindByManager(
@@ -347,11 +575,6 @@ func (s *RecordServiceImpl) FindByManager(
 		return response.PaginatedResponse[response.AdminRecordResponse]{}, err
 	}
 
-	if manager.Role != model.RoleDepartmentManager {
-		return response.PaginatedResponse[response.AdminRecordResponse]{},
-			helper.Forbidden("only managers can access this resource")
-	}
-
 	offset := (page - 1) * limit
 
 	records, total, err := s.repo.FindByManagerDepartment(
@@ -370,6 +593,8 @@ func (s *RecordServiceImpl) FindByManager(
 		resp := response.AdminRecordResponse{
 			ID:             r.ID,
 			Status:         string(r.Status),
+			CreditedHours:  r.CreditedHours,
+			RSVPStatus:     r.RSVPStatus,
 			Evaluation:     r.Evaluation,
 			PreTestScore:   r.PreTestScore,
 			PostTestScore:  r.PostTestScore,
@@ -436,6 +661,7 @@ func (s *RecordServiceImpl) Export(
 		"Department",
 		"Division",
 		"Status",
+		"Credited Hours",
 		"Evaluation",
 		"Pre-Test Score",
 		"Post-Test Score",
@@ -463,7 +689,8 @@ func (s *RecordServiceImpl) Export(
 		},
 	})
 
-	f.SetCellStyle(sheet, "A1", "M1", headerStyle)
+	lastHeader, _ := excelize.CoordinatesToCellName(len(headers), 1)
+	f.SetCellStyle(sheet, "A1", lastHeader, headerStyle)
 
 	// ===== Data =====
 	for i, r := range records {
@@ -513,6 +740,7 @@ func (s *RecordServiceImpl) Export(
 			department,
 			division,
 			string(r.Status),
+			r.CreditedHours,
 			r.Evaluation,
 			r.PreTestScore,
 			r.PostTestScore,
@@ -545,5 +773,149 @@ func (s *RecordServiceImpl) Export(
 	})
 	f.SetColStyle(sheet, "C", currencyStyle)
 
+	if err := s.exportExternal(f, req, headerStyle); err != nil {
+		return nil, err
+	}
+
 	return f, nil
 }
+
+// exportExternal adds a sheet of the approved external certificates that
+// match req. External courses count as attended, so a status filter other
+// than Attended leaves the sheet empty.
+func (s *RecordServiceImpl) exportExternal(f *excelize.File, req request.RecordFilterRequest, headerStyle int) error {
+	sheet := "External Training"
+	if _, err := f.NewSheet(sheet); err != nil {
+		return err
+	}
+
+	headers := []string{
+		"Employee ID",
+		"Employee Name",
+		"Position",
+		"Department",
+		"Division",
+		"Course",
+		"Issuer",
+		"Category",
+		"Issue Date",
+		"Expiry Date",
+		"Hours",
+	}
+
+	for col, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(col+1, 1)
+		f.SetCellValue(sheet, cell, header)
+	}
+	lastHeader, _ := excelize.CoordinatesToCellName(len(headers), 1)
+	f.SetCellStyle(sheet, "A1", lastHeader, headerStyle)
+
+	for i := 1; i <= len(headers); i++ {
+		col, _ := excelize.ColumnNumberToName(i)
+		f.SetColWidth(sheet, col, col, 22)
+	}
+
+	if req.Status != nil && *req.Status != "" && *req.Status != string(model.RecordStatusAttended) {
+		return nil
+	}
+
+	certificates, err := s.certificateRepo.FindApprovedExternal(req)
+	if err != nil {
+		return err
+	}
+
+	for i, c := range certificates {
+		row := i + 2
+
+		var employeeID, employeeName, position string
+		var department, division string
+		if c.User != nil {
+			employeeID = c.User.EmployeeID
+			employeeName = c.User.Name
+			position = c.User.Position
+			if c.User.Department != nil {
+				department = c.User.Department.Name
+				division = string(c.User.Department.Division)
+			}
+		}
+
+		var issuer, category, issueDate, expiryDate string
+		var hours float64
+		if c.Issuer != nil {
+			issuer = *c.Issuer
+		}
+		if c.Category != nil {
+			category = string(*c.Category)
+		}
+		if c.IssueDate != nil {
+			issueDate = c.IssueDate.Format("2006-01-02")
+		}
+		if c.ExpiryDate != nil {
+			expiryDate = c.ExpiryDate.Format("2006-01-02")
+		}
+		if c.Hours != nil {
+			hours = *c.Hours
+		}
+
+		values := []interface{}{
+			employeeID,
+			employeeName,
+			position,
+			department,
+			division,
+			c.TrainingName(),
+			issuer,
+			category,
+			issueDate,
+			expiryDate,
+			hours,
+		}
+
+		for col, val := range values {
+			cell, _ := excelize.CoordinatesToCellName(col+1, row)
+			f.SetCellValue(sheet, cell, val)
+		}
+	}
+
+	return nil
+}
+
+// FindTrainingHistory implements RecordService. It totals the hours
+// credited from attended training plans and from approved external
+// certificates of userID.
+func (s *RecordServiceImpl) FindTrainingHistory(userID uint) (response.TrainingHistoryResponse, error) {
+	internalHours, err := s.repo.SumAttendedHours(userID)
+	if err != nil {
+		return response.TrainingHistoryResponse{}, err
+	}
+
+	certificates, err := s.certificateRepo.FindApprovedExternalByUser(userID)
+	if err != nil {
+		return response.TrainingHistoryResponse{}, err
+	}
+
+	history := response.TrainingHistoryResponse{
+		InternalHours: internalHours,
+		External:      make([]response.ExternalTrainingResponse, 0, len(certificates)),
+	}
+	for _, c := range certificates {
+		item := response.ExternalTrainingResponse{
+			CertificateID: c.ID,
+			Title:         c.TrainingName(),
+			Issuer:        c.Issuer,
+			IssueDate:     c.IssueDate,
+			ExpiryDate:    c.ExpiryDate,
+		}
+		if c.Category != nil {
+			item.Category = string(*c.Category)
+		}
+		if c.Hours != nil {
+			item.Hours = *c.Hours
+			history.ExternalHours += *c.Hours
+		}
+		history.External = append(history.External, item)
+	}
+	history.TotalHours = history.InternalHours + history.ExternalHours
+
+	return history, nil
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"training-plan-api/data/request"
+	"training-plan-api/model"
+	"training-plan-api/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// grantingPermissionService grants every permission.
+type grantingPermissionService struct {
+	PermissionService
+}
+
+func (grantingPermissionService) HasPermission(role string, permission model.Permission) bool {
+	return true
+}
+
+// registeringRecordRepository registers every user and promotes a fixed
+// waitlist on the way.
+type registeringRecordRepository struct {
+	repository.RecordRepository
+
+	promoted []model.Record
+}
+
+func (r registeringRecordRepository) RegisterWithCapacity(trainingPlanId uint, userIds []uint) ([]model.Record, []model.Record, error) {
+	created := make([]model.Record, 0, len(userIds))
+	for _, id := range userIds {
+		created = append(created, model.Record{ID: 100 + id, UserID: id, TrainingPlanID: trainingPlanId, Status: model.RecordStatusRegister})
+	}
+	return created, r.promoted, nil
+}
+
+type auditedChange struct {
+	action model.AuditAction
+	id     interface{}
+}
+
+type recordingAuditService struct {
+	AuditService
+
+	changes []auditedChange
+}
+
+func (s *recordingAuditService) Record(actor model.Actor, action model.AuditAction, entityType string, entityID interface{}, before, after interface{}) {
+	s.changes = append(s.changes, auditedChange{action: action, id: entityID})
+}
+
+type notifiedRegistration struct {
+	userIds  []uint
+	promoted bool
+}
+
+type recordingNotificationService struct {
+	NotificationService
+
+	registrations []notifiedRegistration
+}
+
+func (s *recordingNotificationService) NotifyRegistration(trainingPlanId uint, records []model.Record, promoted bool) {
+	if len(records) == 0 {
+		return
+	}
+	s.registrations = append(s.registrations, notifiedRegistration{userIds: recordUserIds(records), promoted: promoted})
+}
+
+type recordingEventService struct {
+	EventService
+
+	published []uint
+}
+
+func (s *recordingEventService) PublishRegistrations(records []model.Record) {
+	s.published = append(s.published, recordUserIds(records)...)
+}
+
+func recordUserIds(records []model.Record) []uint {
+	ids := make([]uint, 0, len(records))
+	for _, record := range records {
+		ids = append(ids, record.UserID)
+	}
+	return ids
+}
+
+func TestRegisterStaffAnnouncesPromotions(t *testing.T) {
+	tests := []struct {
+		name          string
+		promoted      []model.Record
+		wantAudit     []auditedChange
+		wantNotified  []notifiedRegistration
+		wantPublished []uint
+	}{
+		{
+			name:          "nobody promoted",
+			wantAudit:     []auditedChange{{model.AuditCreate, uint(101)}},
+			wantNotified:  []notifiedRegistration{{[]uint{1}, false}},
+			wantPublished: []uint{1},
+		},
+		{
+			name: "waitlisted users took freed seats",
+			promoted: []model.Record{
+				{ID: 50, UserID: 8, TrainingPlanID: 7, Status: model.RecordStatusRegister},
+				{ID: 51, UserID: 9, TrainingPlanID: 7, Status: model.RecordStatusRegister},
+			},
+			wantAudit: []auditedChange{
+				{model.AuditCreate, uint(101)},
+				{model.AuditUpdate, uint(50)},
+				{model.AuditUpdate, uint(51)},
+			},
+			wantNotified:  []notifiedRegistration{{[]uint{1}, false}, {[]uint{8, 9}, true}},
+			wantPublished: []uint{1, 8, 9},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audit := &recordingAuditService{}
+			notifications := &recordingNotificationService{}
+			events := &recordingEventService{}
+
+			svc := NewRecordServiceImpl(
+				registeringRecordRepository{promoted: tt.promoted},
+				nil,
+				nil,
+				nil,
+				grantingPermissionService{},
+				audit,
+				notifications,
+				events,
+				nil,
+				validator.New(),
+			)
+
+			result, err := svc.RegisterStaff(model.Actor{UserID: 2, Role: model.RoleHRAdmin}, 7, request.RegisterStaffRequest{UserIDs: []uint{1}})
+			if err != nil {
+				t.Fatalf("register: %v", err)
+			}
+			if !reflect.DeepEqual(result.Registered, []uint{1}) {
+				t.Errorf("registered = %v, want [1]", result.Registered)
+			}
+
+			if !reflect.DeepEqual(audit.changes, tt.wantAudit) {
+				t.Errorf("audited %v, want %v", audit.changes, tt.wantAudit)
+			}
+			if !reflect.DeepEqual(notifications.registrations, tt.wantNotified) {
+				t.Errorf("notified %v, want %v", notifications.registrations, tt.wantNotified)
+			}
+			if !reflect.DeepEqual(events.published, tt.wantPublished) {
+				t.Errorf("published %v, want %v", events.published, tt.wantPublished)
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"training-plan-api/data/request"
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+	"training-plan-api/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// jobLease is how long a job stays locked to the instance running it. An
+// instance that dies mid-run holds the job until the lease runs out.
+const jobLease = 30 * time.Minute
+
+// JobFunc runs a scheduled job. now is the time the run was started.
+type JobFunc func(ctx context.Context, now time.Time) error
+
+type scheduledJob struct {
+	description string
+	schedule    string
+	run         JobFunc
+}
+
+type SchedulerServiceImpl struct {
+	repo         repository.ScheduledJobRepository
+	auditService AuditService
+	validate     *validator.Validate
+	location     *time.Location
+	owner        string
+
+	mu    sync.RWMutex
+	jobs  map[string]scheduledJob
+	names []string
+}
+
+func NewSchedulerServiceImpl(
+	repo repository.ScheduledJobRepository,
+	auditService AuditService,
+	validate *validator.Validate,
+	location *time.Location,
+) SchedulerService {
+	if location == nil {
+		location = time.Local
+	}
+
+	hostname, _ := os.Hostname()
+	suffix, _ := helper.GenerateRandomToken(4)
+
+	return &SchedulerServiceImpl{
+		repo:         repo,
+		auditService: auditService,
+		validate:     validate,
+		location:     location,
+		owner:        fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), suffix),
+		jobs:         make(map[string]scheduledJob),
+	}
+}
+
+// Register implements SchedulerService. schedule is the default used the
+// first time the job is stored. It panics on an invalid schedule since
+// jobs are registered at startup.
+func (s *SchedulerServiceImpl) Register(name, description, schedule string, run JobFunc) {
+	if _, err := helper.ParseCron(schedule); err != nil {
+		panic(fmt.Sprintf("scheduler: job %s: %v", name, err))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.jobs[name]; !exists {
+		s.names = append(s.names, name)
+	}
+	s.jobs[name] = scheduledJob{description: description, schedule: schedule, run: run}
+}
+
+// Run stores the registered jobs and then runs those that are due every
+// interval until ctx is cancelled.
+func (s *SchedulerServiceImpl) Run(ctx context.Context, interval time.Duration) {
+	if err := s.ensureJobs(); err != nil {
+		log.Println("Scheduler could not store its jobs:", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.RunDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue implements SchedulerService. A due job is run only by the
+// instance that manages to lock it.
+func (s *SchedulerServiceImpl) RunDue(ctx context.Context) {
+	s.mu.RLock()
+	names := append([]string(nil), s.names...)
+	s.mu.RUnlock()
+
+	for _, name := range names {
+		if ctx.Err() != nil {
+			return
+		}
+
+		now := time.Now().In(s.location)
+		locked, err := s.repo.Acquire(name, s.owner, now, jobLease)
+		if err != nil {
+			log.Printf("Scheduler could not lock job %s: %v", name, err)
+			continue
+		}
+		if !locked {
+			continue
+		}
+
+		s.runJob(ctx, name, now)
+	}
+}
+
+func (s *SchedulerServiceImpl) runJob(ctx context.Context, name string, now time.Time) {
+	s.mu.RLock()
+	job := s.jobs[name]
+	s.mu.RUnlock()
+
+	runErr := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		return job.run(ctx, now)
+	}()
+	if runErr != nil {
+		log.Printf("Job %s failed: %v", name, runErr)
+	}
+
+	// the schedule may have been changed while the job ran
+	stored, err := s.repo.FindByName(name)
+	schedule := job.schedule
+	if err == nil {
+		schedule = stored.Schedule
+	}
+
+	finished := time.Now().In(s.location)
+	if err := s.repo.Release(name, s.owner, finished, nextRun(schedule, finished), runErr); err != nil {
+		log.Printf("Scheduler could not release job %s: %v", name, err)
+	}
+}
+
+// FindJobs implements SchedulerService.
+func (s *SchedulerServiceImpl) FindJobs() ([]response.ScheduledJobResponse, error) {
+	jobs, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := make([]response.ScheduledJobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		// jobs no longer registered in code are not shown
+		if _, ok := s.jobs[job.Name]; !ok {
+			continue
+		}
+		items = append(items, toScheduledJobResponse(job))
+	}
+	return items, nil
+}
+
+// UpdateJob implements SchedulerService.
+func (s *SchedulerServiceImpl) UpdateJob(actor model.Actor, name string, req request.UpdateScheduledJobRequest) (response.ScheduledJobResponse, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return response.ScheduledJobResponse{}, helper.ValidationError(helper.FormatValidationError(err))
+	}
+
+	job, err := s.findRegistered(name)
+	if err != nil {
+		return response.ScheduledJobResponse{}, err
+	}
+
+	schedule := job.Schedule
+	if req.Schedule != "" {
+		schedule = strings.Join(strings.Fields(req.Schedule), " ")
+		cron, err := helper.ParseCron(schedule)
+		if err != nil {
+			return response.ScheduledJobResponse{}, helper.BadRequest(err.Error())
+		}
+		if cron.Next(time.Now().In(s.location)).IsZero() {
+			return response.ScheduledJobResponse{}, helper.BadRequest("schedule never runs")
+		}
+	}
+	enabled := job.Enabled
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	if err := s.repo.Update(name, schedule, enabled, nextRun(schedule, time.Now().In(s.location))); err != nil {
+		return response.ScheduledJobResponse{}, err
+	}
+
+	s.auditService.Record(actor, model.AuditUpdate, model.AuditEntityScheduledJob, name,
+		map[string]interface{}{"schedule": job.Schedule, "enabled": job.Enabled},
+		map[string]interface{}{"schedule": schedule, "enabled": enabled},
+	)
+
+	updated, err := s.repo.FindByName(name)
+	if err != nil {
+		return response.ScheduledJobResponse{}, err
+	}
+	return toScheduledJobResponse(*updated), nil
+}
+
+// TriggerJob implements SchedulerService. The job runs within one
+// scheduler interval on whichever instance locks it first.
+func (s *SchedulerServiceImpl) TriggerJob(actor model.Actor, name string) error {
+	job, err := s.findRegistered(name)
+	if err != nil {
+		return err
+	}
+	if !job.Enabled {
+		return helper.BadRequest("job is disabled")
+	}
+
+	if err := s.repo.Trigger(name, time.Now().In(s.location)); err != nil {
+		return err
+	}
+
+	s.auditService.Record(actor, model.AuditRun, model.AuditEntityScheduledJob, name, nil, nil)
+	return nil
+}
+
+func (s *SchedulerServiceImpl) findRegistered(name string) (*model.ScheduledJob, error) {
+	s.mu.RLock()
+	_, ok := s.jobs[name]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, helper.NotFound("job not found")
+	}
+	return s.repo.FindByName(name)
+}
+
+func (s *SchedulerServiceImpl) ensureJobs() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now().In(s.location)
+	for _, name := range s.names {
+		job := s.jobs[name]
+		if err := s.repo.Ensure(&model.ScheduledJob{
+			Name:        name,
+			Description: job.description,
+			Schedule:    job.schedule,
+			Enabled:     true,
+			NextRunAt:   nextRun(job.schedule, now),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nextRun returns the next time schedule is due after t. Schedules that
+// never match are pushed far out rather than run in a loop.
+func nextRun(schedule string, t time.Time) time.Time {
+	cron, err := helper.ParseCron(schedule)
+	if err != nil {
+		return t.AddDate(100, 0, 0)
+	}
+	next := cron.Next(t)
+	if next.IsZero() {
+		return t.AddDate(100, 0, 0)
+	}
+	return next
+}
+
+func toScheduledJobResponse(job model.ScheduledJob) response.ScheduledJobResponse {
+	return response.ScheduledJobResponse{
+		Name:           job.Name,
+		Description:    job.Description,
+		Schedule:       job.Schedule,
+		Enabled:        job.Enabled,
+		Running:        job.LockedUntil != nil && job.LockedUntil.After(time.Now()),
+		NextRunAt:      job.NextRunAt,
+		LastStartedAt:  job.LastStartedAt,
+		LastFinishedAt: job.LastFinishedAt,
+		LastSuccessAt:  job.LastSuccessAt,
+		LastError:      job.LastError,
+		RunCount:       job.RunCount,
+		FailureCount:   job.FailureCount,
+	}
+}
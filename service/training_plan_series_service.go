@@ -0,0 +1,294 @@
+package service
+
+import (
+	"log"
+	"math"
+	"strings"
+	"time"
+	"training-plan-api/data/request"
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/mapper"
+	"training-plan-api/model"
+	"training-plan-api/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const defaultSeriesHorizonMonths = 12
+
+type TrainingPlanSeriesServiceImpl struct {
+	repo                repository.TrainingPlanSeriesRepository
+	trainingPlanRepo    repository.TrainingPlanRepository
+	sessionRepo         repository.TrainingSessionRepository
+	trainingPlanService TrainingPlanService
+	auditService        AuditService
+	validate            *validator.Validate
+	location            *time.Location
+}
+
+func NewTrainingPlanSeriesServiceImpl(
+	repo repository.TrainingPlanSeriesRepository,
+	trainingPlanRepo repository.TrainingPlanRepository,
+	sessionRepo repository.TrainingSessionRepository,
+	trainingPlanService TrainingPlanService,
+	auditService AuditService,
+	validate *validator.Validate,
+	location *time.Location,
+) TrainingPlanSeriesService {
+	return &TrainingPlanSeriesServiceImpl{
+		repo:                repo,
+		trainingPlanRepo:    trainingPlanRepo,
+		sessionRepo:         sessionRepo,
+		trainingPlanService: trainingPlanService,
+		auditService:        auditService,
+		validate:            validate,
+		location:            location,
+	}
+}
+
+// Create implements TrainingPlanSeriesService. The template plan is the
+// first occurrence; later occurrences are generated right away up to the
+// horizon.
+func (s *TrainingPlanSeriesServiceImpl) Create(
+	actor model.Actor,
+	req request.CreateTrainingPlanSeriesRequest,
+) (response.TrainingPlanSeriesResponse, error) {
+
+	if err := s.validate.Struct(req); err != nil {
+		return response.TrainingPlanSeriesResponse{}, helper.ValidationError(helper.FormatValidationError(err))
+	}
+
+	rule, err := helper.ParseRRule(req.RRule)
+	if err != nil {
+		return response.TrainingPlanSeriesResponse{}, helper.BadRequest("invalid rrule: " + err.Error())
+	}
+
+	template, err := s.trainingPlanRepo.FindById(req.TemplatePlanID)
+	if err != nil {
+		return response.TrainingPlanSeriesResponse{}, helper.NotFound("training plan not found")
+	}
+	if template.SeriesID != nil {
+		return response.TrainingPlanSeriesResponse{}, helper.BadRequest("training plan already belongs to a series")
+	}
+	if template.Status == model.TrainingPlanCancelled {
+		return response.TrainingPlanSeriesResponse{}, helper.BadRequest("a cancelled training plan cannot be used as a template")
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = template.Name
+	}
+	horizon := req.HorizonMonths
+	if horizon == 0 {
+		horizon = defaultSeriesHorizonMonths
+	}
+
+	first := template.Date
+	series := &model.TrainingPlanSeries{
+		Name:             name,
+		TemplatePlanID:   template.ID,
+		RRule:            rule.String(),
+		StartDate:        template.Date,
+		HorizonMonths:    horizon,
+		AutoPublish:      req.AutoPublish,
+		Active:           true,
+		GeneratedThrough: &first,
+	}
+
+	if err := s.repo.Save(series); err != nil {
+		return response.TrainingPlanSeriesResponse{}, err
+	}
+
+	template.SeriesID = &series.ID
+	if err := s.trainingPlanRepo.Update(template); err != nil {
+		return response.TrainingPlanSeriesResponse{}, err
+	}
+
+	s.auditService.Record(actor, model.AuditCreate, model.AuditEntitySeries, series.ID, nil, series)
+
+	if err := s.generate(actor, series); err != nil {
+		return response.TrainingPlanSeriesResponse{}, err
+	}
+
+	return s.FindById(series.ID)
+}
+
+// FindById implements TrainingPlanSeriesService.
+func (s *TrainingPlanSeriesServiceImpl) FindById(seriesId uint) (response.TrainingPlanSeriesResponse, error) {
+	series, err := s.repo.FindById(seriesId)
+	if err != nil {
+		return response.TrainingPlanSeriesResponse{}, err
+	}
+
+	instances, err := s.trainingPlanRepo.FindBySeries(series.ID)
+	if err != nil {
+		return response.TrainingPlanSeriesResponse{}, err
+	}
+
+	resp := toTrainingPlanSeriesResponse(*series)
+	resp.Instances = mapper.ToTrainingPlanResponseList(instances)
+	return resp, nil
+}
+
+// FindPaginated implements TrainingPlanSeriesService.
+func (s *TrainingPlanSeriesServiceImpl) FindPaginated(
+	page int,
+	limit int,
+) (response.PaginatedResponse[response.TrainingPlanSeriesResponse], error) {
+
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+
+	series, total, err := s.repo.FindPaginated((page-1)*limit, limit)
+	if err != nil {
+		return response.PaginatedResponse[response.TrainingPlanSeriesResponse]{}, err
+	}
+
+	items := make([]response.TrainingPlanSeriesResponse, 0, len(series))
+	for _, item := range series {
+		items = append(items, toTrainingPlanSeriesResponse(item))
+	}
+
+	return response.PaginatedResponse[response.TrainingPlanSeriesResponse]{
+		Items: items,
+		Meta: response.PaginationMeta{
+			Page:       page,
+			Limit:      limit,
+			TotalItems: total,
+			TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+		},
+	}, nil
+}
+
+// Generate implements TrainingPlanSeriesService.
+func (s *TrainingPlanSeriesServiceImpl) Generate(actor model.Actor, seriesId uint) (response.TrainingPlanSeriesResponse, error) {
+	series, err := s.repo.FindById(seriesId)
+	if err != nil {
+		return response.TrainingPlanSeriesResponse{}, err
+	}
+	if !series.Active {
+		return response.TrainingPlanSeriesResponse{}, helper.BadRequest("training plan series is stopped")
+	}
+
+	if err := s.generate(actor, series); err != nil {
+		return response.TrainingPlanSeriesResponse{}, err
+	}
+
+	return s.FindById(seriesId)
+}
+
+// GenerateAll implements TrainingPlanSeriesService. A failing series is
+// logged and does not stop the others.
+func (s *TrainingPlanSeriesServiceImpl) GenerateAll(actor model.Actor) error {
+	series, err := s.repo.FindActive()
+	if err != nil {
+		return err
+	}
+
+	for i := range series {
+		if err := s.generate(actor, &series[i]); err != nil {
+			log.Printf("series %d: generation failed: %v", series[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// Stop implements TrainingPlanSeriesService. Plans generated so far are
+// kept.
+func (s *TrainingPlanSeriesServiceImpl) Stop(actor model.Actor, seriesId uint) error {
+	series, err := s.repo.FindById(seriesId)
+	if err != nil {
+		return err
+	}
+	if !series.Active {
+		return nil
+	}
+
+	series.Active = false
+	if err := s.repo.Update(series); err != nil {
+		return err
+	}
+
+	s.auditService.Record(actor, model.AuditUpdate, model.AuditEntitySeries, seriesId,
+		map[string]interface{}{"active": true},
+		map[string]interface{}{"active": false},
+	)
+	return nil
+}
+
+// generate copies the template to every occurrence after GeneratedThrough
+// that falls within the series horizon.
+func (s *TrainingPlanSeriesServiceImpl) generate(actor model.Actor, series *model.TrainingPlanSeries) error {
+	rule, err := helper.ParseRRule(series.RRule)
+	if err != nil {
+		return err
+	}
+
+	template, err := s.trainingPlanRepo.FindById(series.TemplatePlanID)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := s.sessionRepo.FindByTrainingPlan(uint(template.ID))
+	if err != nil {
+		return err
+	}
+
+	today := time.Now()
+	if s.location != nil {
+		today = today.In(s.location)
+	}
+	through := today.AddDate(0, series.HorizonMonths, 0)
+
+	for _, date := range rule.Occurrences(series.StartDate, through) {
+		if series.GeneratedThrough != nil && !date.After(*series.GeneratedThrough) {
+			continue
+		}
+
+		if !s.trainingPlanRepo.ExistsInSeries(series.ID, date) {
+			trainingPlan, copies := copyTrainingPlan(template, sessions, date)
+			trainingPlan.SeriesID = &series.ID
+
+			if err := s.trainingPlanRepo.SaveWithSessions(&trainingPlan, copies); err != nil {
+				return err
+			}
+
+			s.auditService.Record(actor, model.AuditCreate, model.AuditEntityTrainingPlan, trainingPlan.ID, nil, trainingPlan)
+
+			if series.AutoPublish {
+				if err := s.trainingPlanService.Publish(actor, trainingPlan.ID); err != nil {
+					log.Printf("series %d: failed to publish plan %d: %v", series.ID, trainingPlan.ID, err)
+				}
+			}
+		}
+
+		generated := date
+		series.GeneratedThrough = &generated
+		if err := s.repo.Update(series); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func toTrainingPlanSeriesResponse(series model.TrainingPlanSeries) response.TrainingPlanSeriesResponse {
+	return response.TrainingPlanSeriesResponse{
+		ID:               series.ID,
+		Name:             series.Name,
+		TemplatePlanID:   series.TemplatePlanID,
+		RRule:            series.RRule,
+		StartDate:        series.StartDate,
+		HorizonMonths:    series.HorizonMonths,
+		AutoPublish:      series.AutoPublish,
+		Active:           series.Active,
+		GeneratedThrough: series.GeneratedThrough,
+		CreatedAt:        series.CreatedAt,
+		UpdatedAt:        series.UpdatedAt,
+	}
+}
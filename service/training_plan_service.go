@@ -1,45 +1,66 @@
 package service
 
 import (
-	"context"
-	"log"
+	"fmt"
 	"math"
+	"strings"
 	"time"
 
 	"training-plan-api/data/request"
 	"training-plan-api/data/response"
 	"training-plan-api/helper"
 	"training-plan-api/mapper"
+	"training-plan-api/model"
 	"training-plan-api/repository"
 
 	"github.com/go-playground/validator/v10"
-	"google.golang.org/api/calendar/v3"
 )
 
 type TrainingPlanServiceImpl struct {
 	repo      repository.TrainingPlanRepository
+	recordRepo repository.RecordRepository
+	enrollmentRepo repository.EnrollmentRepository
+	sessionRepo repository.TrainingSessionRepository
+	permissionService PermissionService
+	auditService AuditService
+	notificationService NotificationService
+	eventService        EventService
+	webhookService      WebhookService
 	validate  *validator.Validate
-	calendar  *calendar.Service
 	location  *time.Location
 }
 
 func NewTrainingPlanServiceImpl(
 	repo repository.TrainingPlanRepository,
+	recordRepo repository.RecordRepository,
+	enrollmentRepo repository.EnrollmentRepository,
+	sessionRepo repository.TrainingSessionRepository,
+	permissionService PermissionService,
+	auditService AuditService,
+	notificationService NotificationService,
+	eventService EventService,
+	webhookService WebhookService,
 	validate *validator.Validate,
-	calendar *calendar.Service,
 	location *time.Location,
 ) TrainingPlanService {
 	return &TrainingPlanServiceImpl{
 		repo:     repo,
+		recordRepo: recordRepo,
+		enrollmentRepo: enrollmentRepo,
+		sessionRepo: sessionRepo,
+		permissionService: permissionService,
+		auditService: auditService,
+		notificationService: notificationService,
+		eventService:        eventService,
+		webhookService:      webhookService,
 		validate: validate,
-		calendar: calendar,
 		location: location,
 	}
 }
 
 
 // CREATE TRAINING PLAN
-func (s *TrainingPlanServiceImpl) Create(req request.CreateTrainingPlanRequest) error {
+func (s *TrainingPlanServiceImpl) Create(actor model.Actor, req request.CreateTrainingPlanRequest) error {
 	if err := s.validate.Struct(req); err != nil {
 		return helper.ValidationError(
 		helper.FormatValidationError(err),
@@ -52,83 +73,204 @@ func (s *TrainingPlanServiceImpl) Create(req request.CreateTrainingPlanRequest)
 		return err
 	}
 
-	// ===== SAFETY CHECKS =====
-	if s.calendar == nil || s.location == nil {
-		log.Println("Calendar not initialized, skipping calendar")
-		return nil
+	s.auditService.Record(actor, model.AuditCreate, model.AuditEntityTrainingPlan, trainingPlan.ID, nil, trainingPlan)
+
+	// new plans are drafts; the calendar event is queued on publish
+	return nil
+}
+
+// PUBLISH TRAINING PLAN
+func (s *TrainingPlanServiceImpl) Publish(actor model.Actor, trainingPlanId int) error {
+	trainingPlan, err := s.repo.FindById(trainingPlanId)
+	if err != nil {
+		return err
 	}
 
-	if trainingPlan.Date.IsZero() {
-		log.Println("Training plan date is zero, skipping calendar")
-		return nil
+	previousStatus := trainingPlan.Status
+	trainingPlan.Status = model.TrainingPlanPublished
+	webhook := s.webhookService.TrainingPlanWebhook(model.WebhookTrainingPlanPublished, trainingPlan)
+	if _, err := s.repo.Transition(trainingPlanId, model.TrainingPlanPublished, nil, webhook); err != nil {
+		return err
 	}
-	
-	hours := 8
-	if trainingPlan.NumberOfHours != nil {
-		hours = *trainingPlan.NumberOfHours
+
+	s.auditService.Record(actor, model.AuditPublish, model.AuditEntityTrainingPlan, trainingPlanId,
+		map[string]interface{}{"status": previousStatus},
+		map[string]interface{}{"status": model.TrainingPlanPublished},
+	)
+
+	return nil
+}
+
+// CANCEL TRAINING PLAN: records are kept and marked cancelled
+func (s *TrainingPlanServiceImpl) Cancel(actor model.Actor, trainingPlanId int, req request.CancelTrainingPlanRequest) error {
+	if err := s.validate.Struct(req); err != nil {
+		return helper.ValidationError(
+			helper.FormatValidationError(err),
+		)
 	}
 
+	trainingPlan, err := s.repo.FindById(trainingPlanId)
+	if err != nil {
+		return err
+	}
 
-	eventID, err := helper.CreateTrainingPlanCalendarEvent(
-		context.Background(),
-		s.calendar,
-		trainingPlan.Name,
-		trainingPlan.Content,
-		trainingPlan.Date.In(s.location),
-		hours,
+	reason := strings.TrimSpace(req.Reason)
+	previousStatus := trainingPlan.Status
+	trainingPlan.Status = model.TrainingPlanCancelled
+	trainingPlan.CancellationReason = &reason
+	webhook := s.webhookService.TrainingPlanWebhook(model.WebhookTrainingPlanCancelled, trainingPlan)
+	cancelled, err := s.repo.Transition(trainingPlanId, model.TrainingPlanCancelled, &reason, webhook)
+	if err != nil {
+		return err
+	}
+
+	s.auditService.Record(actor, model.AuditCancel, model.AuditEntityTrainingPlan, trainingPlanId,
+		map[string]interface{}{"status": previousStatus},
+		map[string]interface{}{"status": model.TrainingPlanCancelled, "cancellationReason": reason},
 	)
+	s.auditCancelledRecords(actor, cancelled)
+
+	notified := make([]uint, 0, len(cancelled))
+	for i := range cancelled {
+		notified = append(notified, cancelled[i].UserID)
+		cancelled[i].Status = model.RecordStatusCancelled
+	}
+	s.notificationService.NotifyTrainingPlan(model.NotifyPlanCancelled, trainingPlan, notified, reason)
+	s.eventService.PublishRegistrations(cancelled)
+
+	enrollmentIds, err := s.enrollmentRepo.CancelOpenByTrainingPlan(uint(trainingPlanId), model.EnrollmentRequestEvent{
+		ActorID:   actor.UserID,
+		ActorRole: string(actor.Role),
+		Action:    model.EnrollmentActionCancel,
+		Reason:    "training plan cancelled: " + reason,
+	})
 	if err != nil {
-		log.Println("Calendar create failed 1st place:", err)
 		return err
 	}
+	for _, id := range enrollmentIds {
+		s.auditService.Record(actor, model.AuditCancel, model.AuditEntityEnrollment, id,
+			nil, map[string]interface{}{"status": model.EnrollmentCancelled},
+		)
+	}
 
-	trainingPlan.CalendarEventID = &eventID
-	if err := s.repo.Update(&trainingPlan); err != nil {
-		log.Println("Failed to save calendar_event_id:", err)
+	return nil
+}
+
+// COMPLETE TRAINING PLAN: attendance is locked afterwards
+func (s *TrainingPlanServiceImpl) Complete(actor model.Actor, trainingPlanId int) error {
+	trainingPlan, err := s.repo.FindById(trainingPlanId)
+	if err != nil {
+		return err
 	}
 
+	days := trainingPlan.NumberOfDays
+	if days < 1 {
+		days = 1
+	}
+	lastDay := trainingPlan.Date.AddDate(0, 0, days-1).Format("2006-01-02")
+	today := time.Now()
+	if s.location != nil {
+		today = today.In(s.location)
+	}
+	if today.Format("2006-01-02") < lastDay {
+		return helper.BadRequest("training plan cannot be completed before its last day")
+	}
+
+	previousStatus := trainingPlan.Status
+	trainingPlan.Status = model.TrainingPlanCompleted
+	webhook := s.webhookService.TrainingPlanWebhook(model.WebhookTrainingPlanCompleted, trainingPlan)
+	cancelled, err := s.repo.Transition(trainingPlanId, model.TrainingPlanCompleted, nil, webhook)
+	if err != nil {
+		return err
+	}
+
+	s.auditService.Record(actor, model.AuditComplete, model.AuditEntityTrainingPlan, trainingPlanId,
+		map[string]interface{}{"status": previousStatus},
+		map[string]interface{}{"status": model.TrainingPlanCompleted},
+	)
+	s.auditCancelledRecords(actor, cancelled)
+
 	return nil
 }
 
+// CLONE TRAINING PLAN: content, cost and category are copied to a new draft
+func (s *TrainingPlanServiceImpl) Clone(actor model.Actor, trainingPlanId int, req request.CloneTrainingPlanRequest) (response.TrainingPlanResponse, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return response.TrainingPlanResponse{}, helper.ValidationError(
+			helper.FormatValidationError(err),
+		)
+	}
+
+	source, err := s.repo.FindById(trainingPlanId)
+	if err != nil {
+		return response.TrainingPlanResponse{}, err
+	}
+
+	sessions, err := s.sessionRepo.FindByTrainingPlan(uint(source.ID))
+	if err != nil {
+		return response.TrainingPlanResponse{}, err
+	}
+
+	trainingPlan, copies := copyTrainingPlan(source, sessions, req.Date)
+	if req.Name != nil {
+		trainingPlan.Name = *req.Name
+	}
+
+	if err := s.repo.SaveWithSessions(&trainingPlan, copies); err != nil {
+		return response.TrainingPlanResponse{}, err
+	}
+
+	s.auditService.Record(actor, model.AuditCreate, model.AuditEntityTrainingPlan, trainingPlan.ID, nil, trainingPlan)
+
+	return s.FindById(actor, trainingPlan.ID)
+}
+
 // DELETE TRAINING PLAN
-func (s *TrainingPlanServiceImpl) Delete(trainingPlanId int) error {
+func (s *TrainingPlanServiceImpl) Delete(actor model.Actor, trainingPlanId int) error {
 	trainingPlan, err := s.repo.FindById(trainingPlanId)
 	if err != nil {
 		return err
 	}
 
-	// Delete calendar event if exists
-	if trainingPlan.CalendarEventID != nil {
-		if err := helper.DeleteTrainingPlanCalendarEvent(
-			context.Background(),
-			s.calendar,
-			*trainingPlan.CalendarEventID,
-		); err != nil {
-			log.Println("Calendar delete failed:", err)
-		}
+	// published plans keep their history and are cancelled instead
+	if trainingPlan.Status != model.TrainingPlanDraft {
+		return helper.BadRequest("only draft training plans can be deleted, cancel the training plan instead")
 	}
 
 	if err := s.repo.Delete(trainingPlanId); err != nil {
 		return err
 	}
 
+	s.auditService.Record(actor, model.AuditDelete, model.AuditEntityTrainingPlan, trainingPlanId, trainingPlan, nil)
+
 	return nil
 }
 
 // FIND BY ID (CACHE)
-func (s *TrainingPlanServiceImpl) FindById(trainingPlanId int) (response.TrainingPlanResponse, error) {
+func (s *TrainingPlanServiceImpl) FindById(actor model.Actor, trainingPlanId int) (response.TrainingPlanResponse, error) {
 	trainingPlan, err := s.repo.FindById(trainingPlanId)
 	if err != nil {
 		return response.TrainingPlanResponse{}, err
 	}
 
+	if trainingPlan.Status == model.TrainingPlanDraft && !s.canSeeDrafts(actor) {
+		return response.TrainingPlanResponse{}, helper.NotFound("training plan not found")
+	}
+
 	resp := mapper.ToTrainingPlanResponse(*trainingPlan)
 
-	return resp, nil
+	items := []response.TrainingPlanResponse{resp}
+	if err := s.attachSeats(items); err != nil {
+		return response.TrainingPlanResponse{}, err
+	}
+
+	return items[0], nil
 }
 
 // FIND PAGINATED (CACHE)
 func (s *TrainingPlanServiceImpl) FindPaginated(
+	actor model.Actor,
+	status string,
 	page int,
 	pageSize int,
 ) (response.PaginatedResponse[response.TrainingPlanResponse], error) {
@@ -140,13 +282,37 @@ func (s *TrainingPlanServiceImpl) FindPaginated(
 		pageSize = 10
 	}
 
+	// drafts are only listed for callers who can edit plans
+	var statuses []model.TrainingPlanStatus
+	if !s.canSeeDrafts(actor) {
+		statuses = []model.TrainingPlanStatus{
+			model.TrainingPlanPublished,
+			model.TrainingPlanCancelled,
+			model.TrainingPlanCompleted,
+		}
+	}
+
+	if status != "" {
+		requested := model.TrainingPlanStatus(status)
+		if !requested.IsValid() {
+			return response.PaginatedResponse[response.TrainingPlanResponse]{}, helper.BadRequest("invalid training plan status")
+		}
+		if requested == model.TrainingPlanDraft && !s.canSeeDrafts(actor) {
+			return response.PaginatedResponse[response.TrainingPlanResponse]{}, helper.Forbidden("you are not allowed to view draft training plans")
+		}
+		statuses = []model.TrainingPlanStatus{requested}
+	}
+
 	offset := (page - 1) * pageSize
-	trainingPlans, total, err := s.repo.FindPaginated(offset, pageSize)
+	trainingPlans, total, err := s.repo.FindPaginated(statuses, offset, pageSize)
 	if err != nil {
 		return response.PaginatedResponse[response.TrainingPlanResponse]{}, err
 	}
 
 	items := mapper.ToTrainingPlanResponseList(trainingPlans)
+	if err := s.attachSeats(items); err != nil {
+		return response.PaginatedResponse[response.TrainingPlanResponse]{}, err
+	}
 
 	resp := response.PaginatedResponse[response.TrainingPlanResponse]{
 		Items: items,
@@ -161,8 +327,48 @@ func (s *TrainingPlanServiceImpl) FindPaginated(
 	return resp, nil
 }
 
+// FIND CATALOG: upcoming plans staff can request to join
+func (s *TrainingPlanServiceImpl) FindCatalog(
+	page int,
+	pageSize int,
+) (response.PaginatedResponse[response.TrainingPlanResponse], error) {
+
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	today := time.Now()
+	if s.location != nil {
+		today = today.In(s.location)
+	}
+
+	offset := (page - 1) * pageSize
+	trainingPlans, total, err := s.repo.FindUpcomingPaginated(today, offset, pageSize)
+	if err != nil {
+		return response.PaginatedResponse[response.TrainingPlanResponse]{}, err
+	}
+
+	items := mapper.ToTrainingPlanResponseList(trainingPlans)
+	if err := s.attachSeats(items); err != nil {
+		return response.PaginatedResponse[response.TrainingPlanResponse]{}, err
+	}
+
+	return response.PaginatedResponse[response.TrainingPlanResponse]{
+		Items: items,
+		Meta: response.PaginationMeta{
+			Page:       page,
+			Limit:      pageSize,
+			TotalItems: total,
+			TotalPages: int(math.Ceil(float64(total) / float64(pageSize))),
+		},
+	}, nil
+}
+
 // UPDATE TRAINING PLAN
-func (s *TrainingPlanServiceImpl) Update(trainingPlanId int, req request.UpdateTrainingPlanRequest) error {
+func (s *TrainingPlanServiceImpl) Update(actor model.Actor, trainingPlanId int, req request.UpdateTrainingPlanRequest) error {
 	if err := s.validate.Struct(req); err != nil {
 			return helper.ValidationError(
 		helper.FormatValidationError(err),
@@ -174,52 +380,151 @@ func (s *TrainingPlanServiceImpl) Update(trainingPlanId int, req request.UpdateT
 		return err
 	}
 
+	if trainingPlan.Status.IsFinal() {
+		return helper.BadRequest("a " + strings.ToLower(string(trainingPlan.Status)) + " training plan can no longer be edited")
+	}
+
+	before := helper.AuditSnapshot(trainingPlan)
+	previousCapacity := trainingPlan.NumberOfPerson
+	previousSchedule := trainingPlanSchedule(trainingPlan)
+
 	mapper.UpdateTrainingPlanFromRequest(trainingPlan, req)
 
 	if err := s.repo.Update(trainingPlan); err != nil {
 		return err
 	}
 
-	// ===== SAFETY CHECKS =====
-	if s.calendar == nil || s.location == nil {
-		log.Println("Calendar not initialized, skipping calendar update")
-		return nil
-	}
+	s.auditService.Record(actor, model.AuditUpdate, model.AuditEntityTrainingPlan, trainingPlanId, before, trainingPlan)
 
-	if trainingPlan.CalendarEventID == nil {
-		log.Println("No calendar_event_id, skipping calendar update")
-		return nil
+	// a larger (or removed) limit frees seats for the waitlist
+	if trainingPlan.NumberOfPerson != previousCapacity {
+		promoted, err := s.recordRepo.PromoteWaitlisted(uint(trainingPlanId))
+		if err != nil {
+			return err
+		}
+		for _, p := range promoted {
+			s.auditService.Record(actor, model.AuditUpdate, model.AuditEntityRecord, p.ID,
+				map[string]interface{}{"status": model.RecordStatusWaitlisted},
+				map[string]interface{}{"status": p.Status},
+			)
+		}
+		s.notificationService.NotifyRegistration(uint(trainingPlanId), promoted, true)
+		s.eventService.PublishRegistrations(promoted)
 	}
 
-	if trainingPlan.Date.IsZero() {
-		log.Println("Training plan date is zero, skipping calendar update")
-		return nil
+	// participants hear about changes to when and where the training is
+	if trainingPlan.Status == model.TrainingPlanPublished && trainingPlanSchedule(trainingPlan) != previousSchedule {
+		records, err := s.recordRepo.FindByTrainingPlan(uint(trainingPlanId))
+		if err != nil {
+			return err
+		}
+
+		participants := make([]uint, 0, len(records))
+		for _, record := range records {
+			if record.Status != model.RecordStatusCancelled {
+				participants = append(participants, record.UserID)
+			}
+		}
+		s.notificationService.NotifyTrainingPlan(model.NotifyPlanChanged, trainingPlan, participants, "")
 	}
-	// ========================
 
-	hours := 8
+	return nil
+}
+
+// trainingPlanSchedule summarises the fields participants are told about
+// when they change.
+func trainingPlanSchedule(trainingPlan *model.TrainingPlan) string {
+	location := ""
+	if trainingPlan.Location != nil {
+		location = *trainingPlan.Location
+	}
+	hours := 0
 	if trainingPlan.NumberOfHours != nil {
 		hours = *trainingPlan.NumberOfHours
 	}
+	return fmt.Sprintf("%s|%s|%d|%d|%s", trainingPlan.Name, trainingPlan.Date.Format("2006-01-02"),
+		trainingPlan.NumberOfDays, hours, location)
+}
+
+// copyTrainingPlan copies a plan into a new draft on date. Sessions keep
+// their offset from the plan's first day; lifecycle, calendar and series
+// fields are not copied.
+func copyTrainingPlan(source *model.TrainingPlan, sessions []model.TrainingSession, date time.Time) (model.TrainingPlan, []model.TrainingSession) {
+	sourceID := source.ID
+	trainingPlan := model.TrainingPlan{
+		Name:             source.Name,
+		SpeakerInstitute: source.SpeakerInstitute,
+		Type:             source.Type,
+		Category:         source.Category,
+		Date:             date,
+		Content:          source.Content,
+		NumberOfDays:     source.NumberOfDays,
+		NumberOfHours:    source.NumberOfHours,
+		Location:         source.Location,
+		TotalCost:        source.TotalCost,
+		BudgetCode:       source.BudgetCode,
+		NumberOfPerson:   source.NumberOfPerson,
+		CostPerPerson:    source.CostPerPerson,
+		Status:           model.TrainingPlanDraft,
+		ClonedFromID:     &sourceID,
+	}
+
+	offset := int(math.Round(date.Sub(source.Date).Hours() / 24))
+	copies := make([]model.TrainingSession, 0, len(sessions))
+	for _, session := range sessions {
+		copies = append(copies, model.TrainingSession{
+			Date:      session.Date.AddDate(0, 0, offset),
+			StartTime: session.StartTime,
+			EndTime:   session.EndTime,
+			Location:  session.Location,
+			Trainer:   session.Trainer,
+		})
+	}
+
+	return trainingPlan, copies
+}
+
+func (s *TrainingPlanServiceImpl) auditCancelledRecords(actor model.Actor, records []model.Record) {
+	for _, r := range records {
+		s.auditService.Record(actor, model.AuditUpdate, model.AuditEntityRecord, r.ID,
+			map[string]interface{}{"status": r.Status},
+			map[string]interface{}{"status": model.RecordStatusCancelled},
+		)
+	}
+}
+
+func (s *TrainingPlanServiceImpl) canSeeDrafts(actor model.Actor) bool {
+	return s.permissionService.HasPermission(string(actor.Role), model.PermTrainingPlansWrite)
+}
+
+// attachSeats fills the seat summary of each plan from its records.
+func (s *TrainingPlanServiceImpl) attachSeats(items []response.TrainingPlanResponse) error {
+	ids := make([]uint, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, uint(item.ID))
+	}
 
-	// description := "Training  Plan"
-	// if trainingPlan.Content != nil && *trainingPlan.Content != "" {
-	// 	description = *trainingPlan.Content
-	// }
-
-	if err := helper.UpdateTrainingPlanCalendarEvent(
-		context.Background(),
-		s.calendar,
-		*trainingPlan.CalendarEventID,
-		trainingPlan.Name,
-		trainingPlan.Content,
-		trainingPlan.Date.In(s.location),
-		hours,
-	); err != nil {
-		log.Println("Calendar update failed:", err)
+	counts, err := s.recordRepo.CountSeats(ids)
+	if err != nil {
 		return err
 	}
 
+	for i := range items {
+		count := counts[uint(items[i].ID)]
+		items[i].Seats = response.SeatSummary{
+			Registered: count.Registered,
+			Attended:   count.Attended,
+			Waitlisted: count.Waitlisted,
+			Cancelled:  count.Cancelled,
+		}
+		if items[i].NumberOfPerson > 0 {
+			remaining := int64(items[i].NumberOfPerson) - count.Registered
+			if remaining < 0 {
+				remaining = 0
+			}
+			items[i].Seats.Remaining = &remaining
+		}
+	}
+
 	return nil
 }
-
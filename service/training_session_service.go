@@ -0,0 +1,489 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"time"
+	"training-plan-api/data/request"
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+	"training-plan-api/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type TrainingSessionServiceImpl struct {
+	repo              repository.TrainingSessionRepository
+	trainingPlanRepo  repository.TrainingPlanRepository
+	recordRepo        repository.RecordRepository
+	userRepo          repository.UserRepository
+	permissionService PermissionService
+	auditService      AuditService
+	webhookService    WebhookService
+	validate          *validator.Validate
+	policy            helper.AttendancePolicy
+	location          *time.Location
+}
+
+func NewTrainingSessionServiceImpl(
+	repo repository.TrainingSessionRepository,
+	trainingPlanRepo repository.TrainingPlanRepository,
+	recordRepo repository.RecordRepository,
+	userRepo repository.UserRepository,
+	permissionService PermissionService,
+	auditService AuditService,
+	webhookService WebhookService,
+	validate *validator.Validate,
+	policy helper.AttendancePolicy,
+	location *time.Location,
+) TrainingSessionService {
+	return &TrainingSessionServiceImpl{
+		repo:              repo,
+		trainingPlanRepo:  trainingPlanRepo,
+		recordRepo:        recordRepo,
+		userRepo:          userRepo,
+		permissionService: permissionService,
+		auditService:      auditService,
+		webhookService:    webhookService,
+		validate:          validate,
+		policy:            policy,
+		location:          location,
+	}
+}
+
+// Create implements TrainingSessionService.
+func (s *TrainingSessionServiceImpl) Create(
+	actor model.Actor,
+	trainingPlanId int,
+	req request.CreateTrainingSessionRequest,
+) (response.TrainingSessionResponse, error) {
+
+	if err := s.validate.Struct(req); err != nil {
+		return response.TrainingSessionResponse{}, helper.ValidationError(helper.FormatValidationError(err))
+	}
+
+	plan, err := s.trainingPlanRepo.FindById(trainingPlanId)
+	if err != nil {
+		return response.TrainingSessionResponse{}, err
+	}
+	if plan.Status.IsFinal() {
+		return response.TrainingSessionResponse{}, helper.BadRequest("sessions of a cancelled or completed training plan cannot be changed")
+	}
+
+	session := &model.TrainingSession{
+		TrainingPlanID: uint(plan.ID),
+		Date:           req.Date,
+		StartTime:      req.StartTime,
+		EndTime:        req.EndTime,
+		Location:       req.Location,
+		Trainer:        req.Trainer,
+	}
+	if session.Hours() <= 0 {
+		return response.TrainingSessionResponse{}, helper.BadRequest("endTime must be after startTime")
+	}
+
+	if err := s.repo.Save(session); err != nil {
+		return response.TrainingSessionResponse{}, err
+	}
+
+	s.auditService.Record(actor, model.AuditCreate, model.AuditEntitySession, session.ID, nil, session)
+
+	if err := s.syncTrainingPlan(actor, plan); err != nil {
+		return response.TrainingSessionResponse{}, err
+	}
+
+	return toTrainingSessionResponse(*session), nil
+}
+
+// Update implements TrainingSessionService.
+func (s *TrainingSessionServiceImpl) Update(
+	actor model.Actor,
+	sessionId uint,
+	req request.UpdateTrainingSessionRequest,
+) (response.TrainingSessionResponse, error) {
+
+	if err := s.validate.Struct(req); err != nil {
+		return response.TrainingSessionResponse{}, helper.ValidationError(helper.FormatValidationError(err))
+	}
+
+	session, err := s.repo.FindById(sessionId)
+	if err != nil {
+		return response.TrainingSessionResponse{}, err
+	}
+	plan := session.TrainingPlan
+	if plan == nil || plan.Status.IsFinal() {
+		return response.TrainingSessionResponse{}, helper.BadRequest("sessions of a cancelled or completed training plan cannot be changed")
+	}
+
+	session.TrainingPlan = nil
+	before := helper.AuditSnapshot(session)
+
+	if req.Date != nil {
+		session.Date = *req.Date
+	}
+	if req.StartTime != nil {
+		session.StartTime = *req.StartTime
+	}
+	if req.EndTime != nil {
+		session.EndTime = *req.EndTime
+	}
+	if req.Location != nil {
+		session.Location = req.Location
+	}
+	if req.Trainer != nil {
+		session.Trainer = req.Trainer
+	}
+	if session.Hours() <= 0 {
+		return response.TrainingSessionResponse{}, helper.BadRequest("endTime must be after startTime")
+	}
+
+	if err := s.repo.Update(session); err != nil {
+		return response.TrainingSessionResponse{}, err
+	}
+
+	s.auditService.Record(actor, model.AuditUpdate, model.AuditEntitySession, session.ID, before, session)
+
+	if err := s.syncTrainingPlan(actor, plan); err != nil {
+		return response.TrainingSessionResponse{}, err
+	}
+
+	return toTrainingSessionResponse(*session), nil
+}
+
+// Delete implements TrainingSessionService.
+func (s *TrainingSessionServiceImpl) Delete(actor model.Actor, sessionId uint) error {
+	session, err := s.repo.FindById(sessionId)
+	if err != nil {
+		return err
+	}
+	plan := session.TrainingPlan
+	if plan == nil || plan.Status.IsFinal() {
+		return helper.BadRequest("sessions of a cancelled or completed training plan cannot be changed")
+	}
+
+	if err := s.repo.Delete(sessionId); err != nil {
+		return err
+	}
+
+	session.TrainingPlan = nil
+	s.auditService.Record(actor, model.AuditDelete, model.AuditEntitySession, sessionId, session, nil)
+
+	return s.syncTrainingPlan(actor, plan)
+}
+
+// FindByTrainingPlan implements TrainingSessionService.
+func (s *TrainingSessionServiceImpl) FindByTrainingPlan(actor model.Actor, trainingPlanId int) ([]response.TrainingSessionResponse, error) {
+	plan, err := s.trainingPlanRepo.FindById(trainingPlanId)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Status == model.TrainingPlanDraft &&
+		!s.permissionService.HasPermission(string(actor.Role), model.PermTrainingPlansWrite) {
+		return nil, helper.NotFound("training plan not found")
+	}
+
+	sessions, err := s.repo.FindByTrainingPlan(uint(plan.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]response.TrainingSessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		items = append(items, toTrainingSessionResponse(session))
+	}
+	return items, nil
+}
+
+// FindAttendance implements TrainingSessionService. Callers limited to
+// their department only see records of that department.
+func (s *TrainingSessionServiceImpl) FindAttendance(actor model.Actor, sessionId uint) ([]response.SessionAttendanceResponse, error) {
+	session, err := s.repo.FindById(sessionId)
+	if err != nil {
+		return nil, err
+	}
+
+	departmentID, err := s.departmentScope(actor, model.PermRecordsRead, model.PermRecordsReadDepartment)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := s.recordRepo.FindByTrainingPlan(session.TrainingPlanID)
+	if err != nil {
+		return nil, err
+	}
+
+	attendance, err := s.repo.FindAttendanceBySession(sessionId)
+	if err != nil {
+		return nil, err
+	}
+	marks := make(map[uint]model.AttendanceStatus, len(attendance))
+	for _, a := range attendance {
+		marks[a.RecordID] = a.Status
+	}
+
+	items := make([]response.SessionAttendanceResponse, 0, len(records))
+	for _, r := range records {
+		if !holdsSeat(r.Status) {
+			continue
+		}
+		if departmentID != nil && (r.User == nil || r.User.DepartmentID != *departmentID) {
+			continue
+		}
+
+		item := response.SessionAttendanceResponse{
+			RecordID:      r.ID,
+			UserID:        r.UserID,
+			RecordStatus:  string(r.Status),
+			CreditedHours: r.CreditedHours,
+		}
+		if r.User != nil {
+			item.EmployeeID = r.User.EmployeeID
+			item.EmployeeName = r.User.Name
+			if r.User.Department != nil {
+				item.Department = r.User.Department.Name
+			}
+		}
+		if status, ok := marks[r.ID]; ok {
+			value := string(status)
+			item.Status = &value
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// MarkAttendance implements TrainingSessionService. The records' overall
+// status and credited hours are recomputed afterwards.
+func (s *TrainingSessionServiceImpl) MarkAttendance(
+	actor model.Actor,
+	sessionId uint,
+	req request.MarkAttendanceRequest,
+) error {
+
+	if err := s.validate.Struct(req); err != nil {
+		return helper.ValidationError(helper.FormatValidationError(err))
+	}
+
+	session, err := s.repo.FindById(sessionId)
+	if err != nil {
+		return err
+	}
+
+	plan := session.TrainingPlan
+	if plan == nil || plan.Status != model.TrainingPlanPublished {
+		return helper.BadRequest("attendance can only be marked on a published training plan")
+	}
+
+	today := time.Now()
+	if s.location != nil {
+		today = today.In(s.location)
+	}
+	if session.Date.Format("2006-01-02") > today.Format("2006-01-02") {
+		return helper.BadRequest("attendance cannot be marked before the session takes place")
+	}
+
+	departmentID, err := s.departmentScope(actor, model.PermRecordsWrite, model.PermRecordsWriteDepartment)
+	if err != nil {
+		return err
+	}
+
+	records, err := s.recordRepo.FindByTrainingPlan(session.TrainingPlanID)
+	if err != nil {
+		return err
+	}
+	byID := make(map[uint]model.Record, len(records))
+	for _, r := range records {
+		byID[r.ID] = r
+	}
+
+	attendance := make([]model.SessionAttendance, 0, len(req.Entries))
+	for _, entry := range req.Entries {
+		record, ok := byID[entry.RecordID]
+		if !ok {
+			return helper.BadRequest(fmt.Sprintf("record %d is not on this training plan", entry.RecordID))
+		}
+		if !holdsSeat(record.Status) {
+			return helper.BadRequest(fmt.Sprintf("record %d does not hold a seat", entry.RecordID))
+		}
+		if departmentID != nil && (record.User == nil || record.User.DepartmentID != *departmentID) {
+			return helper.Forbidden(fmt.Sprintf("record %d is not in your department", entry.RecordID))
+		}
+
+		attendance = append(attendance, model.SessionAttendance{
+			TrainingSessionID: sessionId,
+			RecordID:          entry.RecordID,
+			Status:            entry.Status,
+			MarkedByID:        &actor.UserID,
+		})
+	}
+
+	if err := s.repo.SaveAttendance(attendance); err != nil {
+		return err
+	}
+
+	s.auditService.Record(actor, model.AuditUpdate, model.AuditEntitySession, sessionId,
+		nil, map[string]interface{}{"attendance": req.Entries},
+	)
+
+	return s.recomputeRecords(actor, uint(plan.ID))
+}
+
+// syncTrainingPlan keeps the plan's date, days and hours in line with its
+// sessions and recomputes the records, since the total hours changed.
+func (s *TrainingSessionServiceImpl) syncTrainingPlan(actor model.Actor, plan *model.TrainingPlan) error {
+	sessions, err := s.repo.FindByTrainingPlan(uint(plan.ID))
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	before := map[string]interface{}{
+		"date":          plan.Date,
+		"numberOfDays":  plan.NumberOfDays,
+		"numberOfHours": plan.NumberOfHours,
+	}
+
+	days := make(map[string]bool)
+	var total float64
+	for _, session := range sessions {
+		days[session.Date.Format("2006-01-02")] = true
+		total += session.Hours()
+	}
+	hours := int(math.Ceil(total))
+
+	plan.Date = sessions[0].Date
+	plan.NumberOfDays = len(days)
+	plan.NumberOfHours = &hours
+
+	if err := s.trainingPlanRepo.Update(plan); err != nil {
+		return err
+	}
+
+	s.auditService.Record(actor, model.AuditUpdate, model.AuditEntityTrainingPlan, plan.ID, before,
+		map[string]interface{}{
+			"date":          plan.Date,
+			"numberOfDays":  plan.NumberOfDays,
+			"numberOfHours": plan.NumberOfHours,
+		},
+	)
+
+	return s.recomputeRecords(actor, uint(plan.ID))
+}
+
+// recomputeRecords derives each seat-holding record's status and credited
+// hours from its session attendance.
+func (s *TrainingSessionServiceImpl) recomputeRecords(actor model.Actor, trainingPlanId uint) error {
+	sessions, err := s.repo.FindByTrainingPlan(trainingPlanId)
+	if err != nil {
+		return err
+	}
+
+	hours := make(map[uint]float64, len(sessions))
+	var total float64
+	for _, session := range sessions {
+		hours[session.ID] = session.Hours()
+		total += session.Hours()
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	attendance, err := s.repo.FindAttendanceByTrainingPlan(trainingPlanId)
+	if err != nil {
+		return err
+	}
+
+	attended := make(map[uint]float64)
+	marked := make(map[uint]float64)
+	for _, a := range attendance {
+		marked[a.RecordID] += hours[a.TrainingSessionID]
+		if a.Status == model.AttendancePresent {
+			attended[a.RecordID] += hours[a.TrainingSessionID]
+		}
+	}
+
+	records, err := s.recordRepo.FindByTrainingPlan(trainingPlanId)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		if !holdsSeat(r.Status) {
+			continue
+		}
+
+		status := model.RecordStatusRegister
+		switch {
+		case s.policy.Meets(attended[r.ID], total):
+			status = model.RecordStatusAttended
+		case s.policy.Unreachable(attended[r.ID], total-marked[r.ID], total):
+			status = model.RecordStatusAbsent
+		}
+		credited := math.Round(attended[r.ID]*100) / 100
+
+		if status == r.Status && credited == r.CreditedHours {
+			continue
+		}
+
+		var webhooks []model.WebhookMessage
+		if status == model.RecordStatusAttended && r.Status != model.RecordStatusAttended {
+			attended := r
+			attended.Status = status
+			attended.CreditedHours = credited
+			webhooks = append(webhooks, s.webhookService.RecordWebhook(model.WebhookRecordAttended, &attended))
+		}
+		if err := s.recordRepo.UpdateAttendanceSummary(r.ID, status, credited, webhooks...); err != nil {
+			return err
+		}
+
+		s.auditService.Record(actor, model.AuditUpdate, model.AuditEntityRecord, r.ID,
+			map[string]interface{}{"status": r.Status, "creditedHours": r.CreditedHours},
+			map[string]interface{}{"status": status, "creditedHours": credited},
+		)
+	}
+
+	return nil
+}
+
+// departmentScope returns nil when the caller holds the all-records
+// permission, or the caller's department when limited to it.
+func (s *TrainingSessionServiceImpl) departmentScope(actor model.Actor, all, department model.Permission) (*int, error) {
+	role := string(actor.Role)
+
+	if s.permissionService.HasPermission(role, all) {
+		return nil, nil
+	}
+	if !s.permissionService.HasPermission(role, department) {
+		return nil, helper.Forbidden("you are not allowed to access attendance")
+	}
+
+	caller, err := s.userRepo.FindById(actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &caller.DepartmentID, nil
+}
+
+// holdsSeat reports whether a record takes part in the training, i.e. is
+// neither waitlisted nor cancelled.
+func holdsSeat(status model.RecordStatus) bool {
+	return status != model.RecordStatusWaitlisted && status != model.RecordStatusCancelled
+}
+
+func toTrainingSessionResponse(session model.TrainingSession) response.TrainingSessionResponse {
+	return response.TrainingSessionResponse{
+		ID:             session.ID,
+		TrainingPlanID: session.TrainingPlanID,
+		Date:           session.Date,
+		StartTime:      session.StartTime,
+		EndTime:        session.EndTime,
+		Hours:          session.Hours(),
+		Location:       session.Location,
+		Trainer:        session.Trainer,
+	}
+}
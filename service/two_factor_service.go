@@ -0,0 +1,355 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"training-plan-api/data/request"
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+	"training-plan-api/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	recoveryCodeCount = 10
+	// twoFactorChallengeMaxFailures is how many wrong codes a login
+	// challenge takes before the user has to sign in again
+	twoFactorChallengeMaxFailures = 5
+)
+
+type TwoFactorServiceImpl struct {
+	userRepo         repository.UserRepository
+	recoveryCodeRepo repository.RecoveryCodeRepository
+	challengeRepo    repository.TwoFactorChallengeRepository
+	tokenService     AuthTokenService
+	auditService     AuditService
+	issuer           string
+	requiredRoles    map[model.Role]bool
+	lockoutPolicy    helper.LockoutPolicy
+	validate         *validator.Validate
+}
+
+// NewTwoFactorServiceImpl takes requiredRoles as a comma separated list of
+// role names for which two-factor authentication is mandatory.
+func NewTwoFactorServiceImpl(
+	userRepo repository.UserRepository,
+	recoveryCodeRepo repository.RecoveryCodeRepository,
+	challengeRepo repository.TwoFactorChallengeRepository,
+	tokenService AuthTokenService,
+	auditService AuditService,
+	issuer string,
+	requiredRoles string,
+	lockoutPolicy helper.LockoutPolicy,
+	validate *validator.Validate,
+) TwoFactorService {
+	if issuer == "" {
+		issuer = "Training Plan"
+	}
+
+	roles := map[model.Role]bool{}
+	for _, role := range strings.Split(requiredRoles, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles[model.Role(role)] = true
+		}
+	}
+
+	return &TwoFactorServiceImpl{
+		userRepo:         userRepo,
+		recoveryCodeRepo: recoveryCodeRepo,
+		challengeRepo:    challengeRepo,
+		tokenService:     tokenService,
+		auditService:     auditService,
+		issuer:           issuer,
+		requiredRoles:    roles,
+		lockoutPolicy:    lockoutPolicy,
+		validate:         validate,
+	}
+}
+
+func (s *TwoFactorServiceImpl) IsRequired(role model.Role) bool {
+	return s.requiredRoles[role]
+}
+
+func (s *TwoFactorServiceImpl) Status(userID uint) (response.TwoFactorStatusResponse, error) {
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		return response.TwoFactorStatusResponse{}, err
+	}
+
+	remaining, err := s.recoveryCodeRepo.CountUnused(userID)
+	if err != nil {
+		return response.TwoFactorStatusResponse{}, helper.InternalServerError("Failed to load recovery codes")
+	}
+
+	return response.TwoFactorStatusResponse{
+		Enabled:                user.TOTPEnabled,
+		Required:               s.IsRequired(user.Role),
+		RemainingRecoveryCodes: remaining,
+	}, nil
+}
+
+// Setup generates a new secret. It is stored but not enforced until
+// Activate confirms the user can produce a valid code.
+func (s *TwoFactorServiceImpl) Setup(userID uint) (response.TwoFactorSetupResponse, error) {
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		return response.TwoFactorSetupResponse{}, err
+	}
+
+	if user.TOTPEnabled {
+		return response.TwoFactorSetupResponse{}, helper.BadRequest("Two-factor authentication is already enabled")
+	}
+
+	secret, err := helper.GenerateTOTPSecret()
+	if err != nil {
+		return response.TwoFactorSetupResponse{}, helper.InternalServerError("Failed to generate secret")
+	}
+
+	if err := s.userRepo.UpdateTwoFactor(userID, secret, false); err != nil {
+		return response.TwoFactorSetupResponse{}, err
+	}
+
+	return response.TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: helper.TOTPProvisioningURI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// Activate enables two-factor authentication and returns fresh recovery
+// codes. The plain codes are only ever returned here.
+func (s *TwoFactorServiceImpl) Activate(userID uint, req request.TwoFactorCodeRequest) (*model.User, []string, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, nil, helper.ValidationError(helper.FormatValidationError(err))
+	}
+
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, nil, helper.BadRequest("Two-factor authentication is already enabled")
+	}
+	if user.TOTPSecret == "" {
+		return nil, nil, helper.BadRequest("Two-factor setup has not been started")
+	}
+
+	counter, ok := helper.ValidateTOTP(user.TOTPSecret, req.Code, time.Now())
+	if !ok {
+		return nil, nil, helper.Unauthorized("Invalid verification code")
+	}
+
+	if err := s.userRepo.UpdateTwoFactor(userID, user.TOTPSecret, true); err != nil {
+		return nil, nil, err
+	}
+	if err := s.userRepo.AdvanceTOTPCounter(userID, counter); err != nil {
+		return nil, nil, err
+	}
+
+	codes, err := s.replaceRecoveryCodes(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user.TOTPEnabled = true
+	return user, codes, nil
+}
+
+// IssueChallenge returns a challenge token for the second login step of
+// user. Verification challenges are tracked so they can be redeemed once.
+func (s *TwoFactorServiceImpl) IssueChallenge(user *model.User, purpose string) (string, error) {
+	id, err := helper.GenerateRandomToken(24)
+	if err != nil {
+		return "", helper.InternalServerError("Failed to generate challenge token")
+	}
+
+	if purpose == helper.ChallengeVerifyTwoFactor {
+		challenge := &model.TwoFactorChallenge{
+			ID:        id,
+			UserID:    user.ID,
+			ExpiresAt: time.Now().Add(helper.ChallengeTokenExpiry),
+		}
+		if err := s.challengeRepo.Save(challenge); err != nil {
+			return "", helper.InternalServerError("Failed to generate challenge token")
+		}
+	}
+
+	token, err := helper.GenerateChallengeToken(user.ID, string(user.Role), purpose, id)
+	if err != nil {
+		return "", helper.InternalServerError("Failed to generate challenge token")
+	}
+	return token, nil
+}
+
+// Verify checks a TOTP or recovery code for the second login step. Wrong
+// codes count toward the account lockout and the challenge stops working
+// after twoFactorChallengeMaxFailures of them or once it succeeded.
+func (s *TwoFactorServiceImpl) Verify(challengeToken string, code string) (*model.User, error) {
+	claims, err := helper.VerifyChallengeToken(challengeToken, helper.ChallengeVerifyTwoFactor)
+	if err != nil {
+		return nil, helper.Unauthorized("Invalid or expired challenge")
+	}
+
+	challengeID := helper.ExtractChallengeID(claims)
+	challenge, err := s.challengeRepo.FindUsable(challengeID, twoFactorChallengeMaxFailures)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindById(challenge.UserID)
+	if err != nil {
+		return nil, helper.Unauthorized("User not found")
+	}
+
+	if user.Status != model.UserStatusActive {
+		return nil, helper.Unauthorized("Account is deactivated")
+	}
+
+	if !user.TOTPEnabled {
+		return nil, helper.BadRequest("Two-factor authentication is not enabled")
+	}
+
+	if user.IsLocked() {
+		return nil, lockedError(*user.LockedUntil)
+	}
+
+	if err := s.checkCode(user, code); err != nil {
+		if failErr := s.challengeRepo.RegisterFailure(challengeID); failErr != nil {
+			return nil, helper.InternalServerError("Failed to record verification attempt")
+		}
+		lockedUntil, failErr := s.userRepo.RegisterLoginFailure(user.ID, s.lockoutPolicy)
+		if failErr != nil {
+			return nil, helper.InternalServerError("Failed to record verification attempt")
+		}
+		if lockedUntil != nil {
+			return nil, lockedError(*lockedUntil)
+		}
+		return nil, err
+	}
+
+	if err := s.challengeRepo.Consume(challengeID, twoFactorChallengeMaxFailures); err != nil {
+		return nil, err
+	}
+
+	if user.Error > 0 || user.LockedUntil != nil {
+		if err := s.userRepo.ResetLoginFailures(user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+func (s *TwoFactorServiceImpl) Disable(userID uint, req request.TwoFactorCodeRequest) error {
+	if err := s.validate.Struct(req); err != nil {
+		return helper.ValidationError(helper.FormatValidationError(err))
+	}
+
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		return err
+	}
+
+	if s.IsRequired(user.Role) {
+		return helper.Forbidden("Two-factor authentication is required for your role")
+	}
+	if !user.TOTPEnabled {
+		return helper.BadRequest("Two-factor authentication is not enabled")
+	}
+
+	if err := s.checkCode(user, req.Code); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdateTwoFactor(userID, "", false); err != nil {
+		return err
+	}
+
+	return s.recoveryCodeRepo.DeleteByUser(userID)
+}
+
+func (s *TwoFactorServiceImpl) RegenerateRecoveryCodes(userID uint, req request.TwoFactorCodeRequest) ([]string, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, helper.ValidationError(helper.FormatValidationError(err))
+	}
+
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.TOTPEnabled {
+		return nil, helper.BadRequest("Two-factor authentication is not enabled")
+	}
+
+	if err := s.checkCode(user, req.Code); err != nil {
+		return nil, err
+	}
+
+	return s.replaceRecoveryCodes(userID)
+}
+
+// AdminReset removes two-factor authentication for a user who lost their
+// device. Their sessions are revoked so the next login re-enrolls if the
+// role requires it.
+func (s *TwoFactorServiceImpl) AdminReset(actor model.Actor, userID uint) error {
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdateTwoFactor(userID, "", false); err != nil {
+		return err
+	}
+	if err := s.recoveryCodeRepo.DeleteByUser(userID); err != nil {
+		return helper.InternalServerError("Failed to delete recovery codes")
+	}
+
+	if err := s.tokenService.RevokeAllForUser(userID); err != nil {
+		return err
+	}
+
+	s.auditService.Record(actor, model.AuditResetTwoFactor, model.AuditEntityUser, userID,
+		map[string]interface{}{"totpEnabled": user.TOTPEnabled},
+		map[string]interface{}{"totpEnabled": false},
+	)
+	return nil
+}
+
+func (s *TwoFactorServiceImpl) checkCode(user *model.User, code string) error {
+	if counter, ok := helper.ValidateTOTP(user.TOTPSecret, code, time.Now()); ok {
+		return s.userRepo.AdvanceTOTPCounter(user.ID, counter)
+	}
+
+	hash := helper.HashToken(helper.NormalizeRecoveryCode(code))
+	return s.recoveryCodeRepo.Use(user.ID, hash)
+}
+
+func (s *TwoFactorServiceImpl) replaceRecoveryCodes(userID uint) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := helper.GenerateRecoveryCode()
+		if err != nil {
+			return nil, helper.InternalServerError("Failed to generate recovery codes")
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, helper.HashToken(code))
+	}
+
+	if err := s.recoveryCodeRepo.ReplaceForUser(userID, hashes); err != nil {
+		return nil, helper.InternalServerError("Failed to store recovery codes")
+	}
+
+	return codes, nil
+}
+
+func lockedError(lockedUntil time.Time) error {
+	retryAfter := int(math.Ceil(time.Until(lockedUntil).Seconds()))
+	return helper.Locked(fmt.Sprintf("Account is temporarily locked. Try again in %d seconds", retryAfter))
+}
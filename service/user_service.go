@@ -12,30 +12,39 @@ import (
 )
 
 type UserServiceImpl struct {
-	userRepo repository.UserRepository
-	deptRepo repository.DepartmentRepository
-	validate *validator.Validate
+	userRepo     repository.UserRepository
+	deptRepo     repository.DepartmentRepository
+	roleRepo     repository.RoleRepository
+	tokenService AuthTokenService
+	auditService AuditService
+	validate     *validator.Validate
 }
 
 func NewUserServiceImpl(
 	userRepo repository.UserRepository,
 	deptRepo repository.DepartmentRepository,
+	roleRepo repository.RoleRepository,
+	tokenService AuthTokenService,
+	auditService AuditService,
 	validate *validator.Validate,
 ) UserService {
 	return &UserServiceImpl{
-		userRepo: userRepo,
-		deptRepo: deptRepo,
-		validate: validate,
+		userRepo:     userRepo,
+		deptRepo:     deptRepo,
+		roleRepo:     roleRepo,
+		tokenService: tokenService,
+		auditService: auditService,
+		validate:     validate,
 	}
 }
 
-func (s *UserServiceImpl) AdminCreate(req request.CreateUserRequest, creatorID uint) error {
+func (s *UserServiceImpl) AdminCreate(actor model.Actor, req request.CreateUserRequest) error {
 	if err := s.validate.Struct(req); err != nil {
 		return helper.ValidationError(helper.FormatValidationError(err))
 	}
 
-	if !req.Role.IsValid() {
-		return helper.BadRequest("Invalid system role specified")
+	if !s.roleRepo.ExistsByName(string(req.Role)) {
+		return helper.BadRequest("Invalid role specified")
 	}
 
 	if _, err := s.deptRepo.FindById(req.DepartmentID); err != nil {
@@ -60,20 +69,26 @@ func (s *UserServiceImpl) AdminCreate(req request.CreateUserRequest, creatorID u
 		Status:       req.Status,
 		Password:     helper.GeneratePassword(req.Password),
 		CreatedBy:    model.CreatedByAdmin,
-		CreatedByID:  &creatorID,
+		CreatedByID:  &actor.UserID,
 		IsProfileComplete: true,
+		MustChangePassword: true,
 	}
 
-	return s.userRepo.Save(user)
+	if err := s.userRepo.Save(user); err != nil {
+		return err
+	}
+
+	s.auditService.Record(actor, model.AuditCreate, model.AuditEntityUser, user.ID, nil, user)
+	return nil
 }
 
-func (s *UserServiceImpl) AdminUpdate(userID uint, req request.UpdateUserRequest) error {
+func (s *UserServiceImpl) AdminUpdate(actor model.Actor, userID uint, req request.UpdateUserRequest) error {
 	if err := s.validate.Struct(req); err != nil {
 		return helper.ValidationError(helper.FormatValidationError(err))
 	}
 
-	if !req.Role.IsValid() {
-		return helper.BadRequest("Invalid system role specified")
+	if !s.roleRepo.ExistsByName(string(req.Role)) {
+		return helper.BadRequest("Invalid role specified")
 	}
 
 	existingUser, err := s.userRepo.FindById(userID)
@@ -85,6 +100,9 @@ func (s *UserServiceImpl) AdminUpdate(userID uint, req request.UpdateUserRequest
 		return helper.BadRequest("Invalid department selected")
 	}
 
+	before := helper.AuditSnapshot(existingUser)
+	roleChanged := existingUser.Role != req.Role
+
 	existingUser.Name = req.Name
 	existingUser.EmployeeID = req.EmployeeID
 	existingUser.Email = req.Email
@@ -94,11 +112,52 @@ func (s *UserServiceImpl) AdminUpdate(userID uint, req request.UpdateUserRequest
 	existingUser.Position = req.Position
 	existingUser.Status = req.Status
 
-	return s.userRepo.Update(existingUser)
+	if err := s.userRepo.Update(existingUser); err != nil {
+		return err
+	}
+
+	s.auditService.Record(actor, model.AuditUpdate, model.AuditEntityUser, userID, before, existingUser)
+
+	// suspended or deactivated users lose their sessions immediately, and a
+	// role change must not leave tokens carrying the old role in circulation
+	if existingUser.Status != model.UserStatusActive || roleChanged {
+		return s.tokenService.RevokeAllForUser(userID)
+	}
+
+	return nil
+}
+
+func (s *UserServiceImpl) AdminDelete(actor model.Actor, userID uint) error {
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.tokenService.RevokeAllForUser(userID); err != nil {
+		return err
+	}
+	if err := s.userRepo.Delete(userID); err != nil {
+		return err
+	}
+
+	s.auditService.Record(actor, model.AuditDelete, model.AuditEntityUser, userID, user, nil)
+	return nil
 }
 
-func (s *UserServiceImpl) AdminDelete(userID uint) error {
-	return s.userRepo.Delete(userID)
+func (s *UserServiceImpl) AdminUnlock(actor model.Actor, userID uint) error {
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.ResetLoginFailures(userID); err != nil {
+		return err
+	}
+
+	s.auditService.Record(actor, model.AuditUnlock, model.AuditEntityUser, userID,
+		map[string]interface{}{"error": user.Error, "lockedUntil": user.LockedUntil},
+		map[string]interface{}{"error": 0, "lockedUntil": nil},
+	)
+	return nil
 }
 
 func (s *UserServiceImpl) AdminFindAll(page, pageSize int) (response.PaginatedResponse[response.UserListResponse], error) {
@@ -161,7 +220,7 @@ func (s *UserServiceImpl) AdminFindAllForTable(params request.UserTableQueryPara
 	}, nil
 }
 
-func (s *UserServiceImpl) ManagerCreate(req request.ManagerCreateUserRequest, managerID uint, managerDepartmentID int) error {
+func (s *UserServiceImpl) ManagerCreate(actor model.Actor, req request.ManagerCreateUserRequest, managerDepartmentID int) error {
 	if err := s.validate.Struct(req); err != nil {
 		return helper.ValidationError(helper.FormatValidationError(err))
 	}
@@ -184,11 +243,17 @@ func (s *UserServiceImpl) ManagerCreate(req request.ManagerCreateUserRequest, ma
 		Status:       req.Status,
 		Password:     helper.GeneratePassword(req.Password),
 		CreatedBy:    model.CreatedByManager,
-		CreatedByID:  &managerID,
+		CreatedByID:  &actor.UserID,
 		IsProfileComplete: true,
+		MustChangePassword: true,
 	}
 
-	return s.userRepo.Save(user)
+	if err := s.userRepo.Save(user); err != nil {
+		return err
+	}
+
+	s.auditService.Record(actor, model.AuditCreate, model.AuditEntityUser, user.ID, nil, user)
+	return nil
 }
 
 func (s *UserServiceImpl) ManagerFindByDepartment(departmentID, page, pageSize int) (response.PaginatedResponse[response.UserListResponse], error) {
@@ -216,11 +281,25 @@ func (s *UserServiceImpl) ManagerFindByDepartment(departmentID, page, pageSize i
 	}, nil
 }
 
-func (s *UserServiceImpl) CompleteProfile(userID uint, req request.CompleteProfileRequest) error {
+func (s *UserServiceImpl) CompleteProfile(actor model.Actor, req request.CompleteProfileRequest) error {
 	if err := s.validate.Struct(req); err != nil {
 		return helper.ValidationError(helper.FormatValidationError(err))
 	}
 
+	userID := actor.UserID
+
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		return err
+	}
+	before := map[string]interface{}{
+		"employee_id":         user.EmployeeID,
+		"department_id":       user.DepartmentID,
+		"is_profile_complete": user.IsProfileComplete,
+		"phone":               user.Phone,
+		"position":            user.Position,
+	}
+
 	if _, err := s.deptRepo.FindById(req.DepartmentID); err != nil {
 		return helper.BadRequest("Invalid department selected")
 	}
@@ -251,5 +330,16 @@ func (s *UserServiceImpl) CompleteProfile(userID uint, req request.CompleteProfi
 		updates["position"] = req.Position
 	}
 
-	return s.userRepo.UpdateProfile(userID, updates)
+	if err := s.userRepo.UpdateProfile(userID, updates); err != nil {
+		return err
+	}
+
+	for key := range before {
+		if _, ok := updates[key]; !ok {
+			delete(before, key)
+		}
+	}
+
+	s.auditService.Record(actor, model.AuditUpdate, model.AuditEntityUser, userID, before, updates)
+	return nil
 }
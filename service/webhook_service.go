@@ -0,0 +1,442 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+	"training-plan-api/data/request"
+	"training-plan-api/data/response"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+	"training-plan-api/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	webhookBatchSize = 20
+	webhookLease     = 2 * time.Minute
+)
+
+type WebhookServiceImpl struct {
+	repo             repository.WebhookRepository
+	userRepo         repository.UserRepository
+	trainingPlanRepo repository.TrainingPlanRepository
+	sender           *helper.WebhookSender
+	auditService     AuditService
+	retry            helper.RetryPolicy
+	validate         *validator.Validate
+}
+
+func NewWebhookServiceImpl(
+	repo repository.WebhookRepository,
+	userRepo repository.UserRepository,
+	trainingPlanRepo repository.TrainingPlanRepository,
+	sender *helper.WebhookSender,
+	auditService AuditService,
+	retry helper.RetryPolicy,
+	validate *validator.Validate,
+) WebhookService {
+	return &WebhookServiceImpl{
+		repo:             repo,
+		userRepo:         userRepo,
+		trainingPlanRepo: trainingPlanRepo,
+		sender:           sender,
+		auditService:     auditService,
+		retry:            retry,
+		validate:         validate,
+	}
+}
+
+// Create implements WebhookService. The secret is returned only here and
+// when it is changed.
+func (s *WebhookServiceImpl) Create(actor model.Actor, req request.WebhookSubscriptionRequest) (response.WebhookSubscriptionResponse, error) {
+	events, err := s.parseRequest(req)
+	if err != nil {
+		return response.WebhookSubscriptionResponse{}, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = helper.GenerateRandomToken(32); err != nil {
+			return response.WebhookSubscriptionResponse{}, err
+		}
+	}
+
+	subscription := &model.WebhookSubscription{
+		Name:        strings.TrimSpace(req.Name),
+		URL:         req.URL,
+		Secret:      secret,
+		Active:      req.Active == nil || *req.Active,
+		CreatedByID: actor.UserID,
+		Events:      events,
+	}
+	if err := s.repo.Save(subscription); err != nil {
+		return response.WebhookSubscriptionResponse{}, err
+	}
+
+	resp := toWebhookSubscriptionResponse(*subscription)
+	s.auditService.Record(actor, model.AuditCreate, model.AuditEntityWebhook, subscription.ID, nil, resp)
+
+	resp.Secret = secret
+	return resp, nil
+}
+
+// Update implements WebhookService.
+func (s *WebhookServiceImpl) Update(actor model.Actor, id uint, req request.WebhookSubscriptionRequest) (response.WebhookSubscriptionResponse, error) {
+	events, err := s.parseRequest(req)
+	if err != nil {
+		return response.WebhookSubscriptionResponse{}, err
+	}
+
+	subscription, err := s.repo.FindById(id)
+	if err != nil {
+		return response.WebhookSubscriptionResponse{}, err
+	}
+	before := toWebhookSubscriptionResponse(*subscription)
+
+	subscription.Name = strings.TrimSpace(req.Name)
+	subscription.URL = req.URL
+	if req.Secret != "" {
+		subscription.Secret = req.Secret
+	}
+	if req.Active != nil {
+		subscription.Active = *req.Active
+	}
+	subscription.Events = events
+
+	if err := s.repo.Update(subscription); err != nil {
+		return response.WebhookSubscriptionResponse{}, err
+	}
+
+	updated, err := s.repo.FindById(id)
+	if err != nil {
+		return response.WebhookSubscriptionResponse{}, err
+	}
+	resp := toWebhookSubscriptionResponse(*updated)
+
+	after := map[string]interface{}{"subscription": resp, "secretChanged": req.Secret != ""}
+	s.auditService.Record(actor, model.AuditUpdate, model.AuditEntityWebhook, id, before, after)
+	return resp, nil
+}
+
+// Delete implements WebhookService.
+func (s *WebhookServiceImpl) Delete(actor model.Actor, id uint) error {
+	subscription, err := s.repo.FindById(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+
+	s.auditService.Record(actor, model.AuditDelete, model.AuditEntityWebhook, id, toWebhookSubscriptionResponse(*subscription), nil)
+	return nil
+}
+
+// FindAll implements WebhookService.
+func (s *WebhookServiceImpl) FindAll() ([]response.WebhookSubscriptionResponse, error) {
+	subscriptions, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]response.WebhookSubscriptionResponse, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		items = append(items, toWebhookSubscriptionResponse(subscription))
+	}
+	return items, nil
+}
+
+// FindById implements WebhookService.
+func (s *WebhookServiceImpl) FindById(id uint) (response.WebhookSubscriptionResponse, error) {
+	subscription, err := s.repo.FindById(id)
+	if err != nil {
+		return response.WebhookSubscriptionResponse{}, err
+	}
+	return toWebhookSubscriptionResponse(*subscription), nil
+}
+
+// FindDeliveries implements WebhookService.
+func (s *WebhookServiceImpl) FindDeliveries(subscriptionId uint, status string, page, limit int) (response.PaginatedResponse[response.WebhookDeliveryResponse], error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+
+	if _, err := s.repo.FindById(subscriptionId); err != nil {
+		return response.PaginatedResponse[response.WebhookDeliveryResponse]{}, err
+	}
+
+	deliveries, total, err := s.repo.FindDeliveriesPaginated(subscriptionId, model.WebhookDeliveryStatus(status), (page-1)*limit, limit)
+	if err != nil {
+		return response.PaginatedResponse[response.WebhookDeliveryResponse]{}, err
+	}
+
+	items := make([]response.WebhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		items = append(items, toWebhookDeliveryResponse(delivery))
+	}
+
+	return response.PaginatedResponse[response.WebhookDeliveryResponse]{
+		Items: items,
+		Meta: response.PaginationMeta{
+			Page:       page,
+			Limit:      limit,
+			TotalItems: total,
+			TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+		},
+	}, nil
+}
+
+// Replay implements WebhookService. The payload is sent again as a new
+// delivery with the same event ID, so the log keeps the original attempt.
+func (s *WebhookServiceImpl) Replay(actor model.Actor, subscriptionId, deliveryId uint) (response.WebhookDeliveryResponse, error) {
+	original, err := s.repo.FindDeliveryById(deliveryId)
+	if err != nil {
+		return response.WebhookDeliveryResponse{}, err
+	}
+	if original.SubscriptionID != subscriptionId {
+		return response.WebhookDeliveryResponse{}, helper.NotFound("webhook delivery not found")
+	}
+
+	replays := []model.WebhookDelivery{{
+		SubscriptionID: original.SubscriptionID,
+		Event:          original.Event,
+		EventID:        original.EventID,
+		Payload:        original.Payload,
+		ReplayOfID:     &original.ID,
+		Status:         model.WebhookDeliveryPending,
+		NextAttemptAt:  time.Now(),
+	}}
+	if err := s.repo.Enqueue(replays); err != nil {
+		return response.WebhookDeliveryResponse{}, err
+	}
+
+	s.auditService.Record(actor, model.AuditCreate, model.AuditEntityWebhook, subscriptionId, nil,
+		map[string]interface{}{"replayOf": original.ID, "event": original.Event},
+	)
+	return toWebhookDeliveryResponse(replays[0]), nil
+}
+
+// RecordWebhook implements WebhookService. The employee and plan are looked
+// up now; the record fields are read when the message is queued.
+func (s *WebhookServiceImpl) RecordWebhook(event model.WebhookEvent, record *model.Record) model.WebhookMessage {
+	var employeeID, email string
+	s.fillUser(record.User, record.UserID, &employeeID, &email)
+	trainingPlanName := s.trainingPlanName(record.TrainingPlan, record.TrainingPlanID)
+
+	return model.WebhookMessage{Event: event, Data: func() interface{} {
+		return response.WebhookRecordData{
+			RecordID:         record.ID,
+			UserID:           record.UserID,
+			EmployeeID:       employeeID,
+			Email:            email,
+			TrainingPlanID:   record.TrainingPlanID,
+			TrainingPlanName: trainingPlanName,
+			Status:           string(record.Status),
+			CreditedHours:    record.CreditedHours,
+		}
+	}}
+}
+
+// CertificateWebhook implements WebhookService.
+func (s *WebhookServiceImpl) CertificateWebhook(event model.WebhookEvent, certificate *model.Certificate) model.WebhookMessage {
+	var employeeID, email string
+	s.fillUser(certificate.User, certificate.UserID, &employeeID, &email)
+	var trainingPlanName string
+	if certificate.TrainingID != nil {
+		trainingPlanName = s.trainingPlanName(certificate.Training, *certificate.TrainingID)
+	}
+
+	return model.WebhookMessage{Event: event, Data: func() interface{} {
+		data := response.WebhookCertificateData{
+			CertificateID:  certificate.ID,
+			UserID:         certificate.UserID,
+			EmployeeID:     employeeID,
+			Kind:           string(certificate.Kind),
+			TrainingPlanID: certificate.TrainingID,
+			Status:         string(certificate.Status),
+		}
+		if certificate.TrainingID != nil {
+			data.TrainingPlanName = trainingPlanName
+		} else {
+			data.Title = certificate.Title
+			data.Issuer = certificate.Issuer
+			data.Hours = certificate.Hours
+		}
+		return data
+	}}
+}
+
+// TrainingPlanWebhook implements WebhookService.
+func (s *WebhookServiceImpl) TrainingPlanWebhook(event model.WebhookEvent, trainingPlan *model.TrainingPlan) model.WebhookMessage {
+	return model.WebhookMessage{Event: event, Data: func() interface{} {
+		return response.WebhookTrainingPlanData{
+			TrainingPlanID:     trainingPlan.ID,
+			Name:               trainingPlan.Name,
+			Date:               trainingPlan.Date,
+			NumberOfDays:       trainingPlan.NumberOfDays,
+			Location:           trainingPlan.Location,
+			Status:             string(trainingPlan.Status),
+			CancellationReason: trainingPlan.CancellationReason,
+		}
+	}}
+}
+
+// Run sends due webhook deliveries every interval until ctx is cancelled.
+func (s *WebhookServiceImpl) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ProcessDue(ctx); err != nil {
+			log.Println("Webhook delivery failed:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessDue implements WebhookService. It returns how many deliveries were
+// attempted.
+func (s *WebhookServiceImpl) ProcessDue(ctx context.Context) (int, error) {
+	deliveries, err := s.repo.ClaimDue(time.Now(), webhookLease, webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+
+		attempts := delivery.Attempts + 1
+
+		// deliveries of a disabled subscription are dropped rather than kept
+		// waiting; they can be replayed once it is active again
+		if delivery.Subscription == nil || !delivery.Subscription.Active {
+			if err := s.repo.MarkFailed(delivery.ID, delivery.Attempts, helper.WebhookResult{}, "subscription is disabled"); err != nil {
+				return len(deliveries), err
+			}
+			continue
+		}
+
+		result, err := s.sender.Send(ctx, helper.WebhookRequest{
+			URL:        delivery.Subscription.URL,
+			Secret:     delivery.Subscription.Secret,
+			Event:      string(delivery.Event),
+			DeliveryID: delivery.ID,
+			Body:       []byte(delivery.Payload),
+		})
+
+		switch {
+		case err == nil:
+			err = s.repo.MarkSent(delivery.ID, attempts, result)
+		case s.retry.Exhausted(attempts):
+			log.Printf("Webhook delivery %d (%s to subscription %d) failed permanently: %v", delivery.ID, delivery.Event, delivery.SubscriptionID, err)
+			err = s.repo.MarkFailed(delivery.ID, attempts, result, err.Error())
+		default:
+			err = s.repo.MarkRetry(delivery.ID, attempts, time.Now().Add(s.retry.Delay(attempts)), result, err.Error())
+		}
+		if err != nil {
+			return len(deliveries), err
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// parseRequest validates req and returns its events.
+func (s *WebhookServiceImpl) parseRequest(req request.WebhookSubscriptionRequest) ([]model.WebhookSubscriptionEvent, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, helper.ValidationError(helper.FormatValidationError(err))
+	}
+
+	parsed, err := url.Parse(req.URL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return nil, helper.BadRequest("webhook URL must be an http or https URL")
+	}
+
+	seen := make(map[model.WebhookEvent]bool, len(req.Events))
+	events := make([]model.WebhookSubscriptionEvent, 0, len(req.Events))
+	for _, name := range req.Events {
+		event := model.WebhookEvent(name)
+		if !event.IsValid() {
+			return nil, helper.BadRequest("unknown webhook event " + name)
+		}
+		if seen[event] {
+			continue
+		}
+		seen[event] = true
+		events = append(events, model.WebhookSubscriptionEvent{Event: event})
+	}
+	return events, nil
+}
+
+func (s *WebhookServiceImpl) fillUser(user *model.User, userId uint, employeeID, email *string) {
+	if user == nil {
+		var err error
+		if user, err = s.userRepo.FindById(userId); err != nil {
+			return
+		}
+	}
+	*employeeID = user.EmployeeID
+	*email = user.Email
+}
+
+func (s *WebhookServiceImpl) trainingPlanName(trainingPlan *model.TrainingPlan, trainingPlanId uint) string {
+	if trainingPlan == nil {
+		var err error
+		if trainingPlan, err = s.trainingPlanRepo.FindById(int(trainingPlanId)); err != nil {
+			return ""
+		}
+	}
+	return trainingPlan.Name
+}
+
+func toWebhookSubscriptionResponse(subscription model.WebhookSubscription) response.WebhookSubscriptionResponse {
+	events := make([]string, 0, len(subscription.Events))
+	for _, event := range subscription.Events {
+		events = append(events, string(event.Event))
+	}
+	return response.WebhookSubscriptionResponse{
+		ID:        subscription.ID,
+		Name:      subscription.Name,
+		URL:       subscription.URL,
+		Active:    subscription.Active,
+		Events:    events,
+		CreatedAt: subscription.CreatedAt,
+		UpdatedAt: subscription.UpdatedAt,
+	}
+}
+
+func toWebhookDeliveryResponse(delivery model.WebhookDelivery) response.WebhookDeliveryResponse {
+	return response.WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		SubscriptionID: delivery.SubscriptionID,
+		Event:          string(delivery.Event),
+		EventID:        delivery.EventID,
+		ReplayOfID:     delivery.ReplayOfID,
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		ResponseStatus: delivery.ResponseStatus,
+		ResponseBody:   delivery.ResponseBody,
+		LastError:      delivery.LastError,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+		Payload:        json.RawMessage(delivery.Payload),
+	}
+}
@@ -0,0 +1,322 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"training-plan-api/helper"
+	"training-plan-api/model"
+	"training-plan-api/repository"
+)
+
+const testWebhookSecret = "subscription-secret"
+
+// memoryWebhookRepository keeps deliveries in memory and claims every
+// pending delivery that is due.
+type memoryWebhookRepository struct {
+	repository.WebhookRepository
+
+	subscription model.WebhookSubscription
+	deliveries   map[uint]*model.WebhookDelivery
+	nextID       uint
+}
+
+func newMemoryWebhookRepository(url string) *memoryWebhookRepository {
+	return &memoryWebhookRepository{
+		subscription: model.WebhookSubscription{ID: 1, URL: url, Secret: testWebhookSecret, Active: true},
+		deliveries:   make(map[uint]*model.WebhookDelivery),
+	}
+}
+
+func (r *memoryWebhookRepository) Enqueue(deliveries []model.WebhookDelivery) error {
+	for i := range deliveries {
+		r.nextID++
+		deliveries[i].ID = r.nextID
+		delivery := deliveries[i]
+		r.deliveries[delivery.ID] = &delivery
+	}
+	return nil
+}
+
+func (r *memoryWebhookRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]model.WebhookDelivery, error) {
+	var due []model.WebhookDelivery
+	for id := uint(1); id <= r.nextID; id++ {
+		delivery, ok := r.deliveries[id]
+		if !ok || delivery.Status != model.WebhookDeliveryPending || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		claimed := *delivery
+		subscription := r.subscription
+		claimed.Subscription = &subscription
+		due = append(due, claimed)
+	}
+	return due, nil
+}
+
+func (r *memoryWebhookRepository) MarkSent(id uint, attempts int, result helper.WebhookResult) error {
+	delivery := r.deliveries[id]
+	delivery.Status = model.WebhookDeliverySent
+	delivery.Attempts = attempts
+	delivery.ResponseStatus = &result.StatusCode
+	return nil
+}
+
+func (r *memoryWebhookRepository) MarkRetry(id uint, attempts int, nextAttemptAt time.Time, result helper.WebhookResult, lastError string) error {
+	delivery := r.deliveries[id]
+	delivery.Attempts = attempts
+	delivery.NextAttemptAt = nextAttemptAt
+	delivery.LastError = lastError
+	return nil
+}
+
+func (r *memoryWebhookRepository) MarkFailed(id uint, attempts int, result helper.WebhookResult, lastError string) error {
+	delivery := r.deliveries[id]
+	delivery.Status = model.WebhookDeliveryFailed
+	delivery.Attempts = attempts
+	delivery.LastError = lastError
+	return nil
+}
+
+func (r *memoryWebhookRepository) FindDeliveryById(id uint) (*model.WebhookDelivery, error) {
+	delivery, ok := r.deliveries[id]
+	if !ok {
+		return nil, helper.NotFound("webhook delivery not found")
+	}
+	copied := *delivery
+	return &copied, nil
+}
+
+// makeDue lets a delivery waiting for its backoff be claimed right away.
+func (r *memoryWebhookRepository) makeDue(id uint) {
+	r.deliveries[id].NextAttemptAt = time.Now().Add(-time.Second)
+}
+
+type noopAuditService struct {
+	AuditService
+}
+
+func (noopAuditService) Record(actor model.Actor, action model.AuditAction, entityType string, entityID interface{}, before, after interface{}) {
+}
+
+// webhookReceiver answers with the next status of statuses, repeating the
+// last one, and keeps every request it received.
+type webhookReceiver struct {
+	statuses []int
+
+	mu       sync.Mutex
+	requests []receivedWebhook
+}
+
+type receivedWebhook struct {
+	deliveryID string
+	body       string
+	verified   bool
+}
+
+func (rc *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	timestamp, _ := strconv.ParseInt(r.Header.Get(helper.WebhookTimestampHeader), 10, 64)
+
+	rc.mu.Lock()
+	rc.requests = append(rc.requests, receivedWebhook{
+		deliveryID: r.Header.Get(helper.WebhookDeliveryHeader),
+		body:       string(body),
+		verified:   helper.VerifyWebhook(testWebhookSecret, timestamp, body, r.Header.Get(helper.WebhookSignatureHeader)),
+	})
+	status := rc.statuses[len(rc.statuses)-1]
+	if len(rc.requests) <= len(rc.statuses) {
+		status = rc.statuses[len(rc.requests)-1]
+	}
+	rc.mu.Unlock()
+
+	w.WriteHeader(status)
+}
+
+func newTestWebhookService(t *testing.T, statuses ...int) (*WebhookServiceImpl, *memoryWebhookRepository, *webhookReceiver) {
+	receiver := &webhookReceiver{statuses: statuses}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+
+	repo := newMemoryWebhookRepository(server.URL)
+	svc := NewWebhookServiceImpl(
+		repo,
+		nil,
+		nil,
+		helper.NewWebhookSender(time.Second),
+		noopAuditService{},
+		helper.NewRetryPolicy(3, time.Minute, 10*time.Minute),
+		nil,
+	).(*WebhookServiceImpl)
+	return svc, repo, receiver
+}
+
+func enqueueTestDelivery(t *testing.T, repo *memoryWebhookRepository) *model.WebhookDelivery {
+	deliveries := []model.WebhookDelivery{{
+		SubscriptionID: repo.subscription.ID,
+		Event:          model.WebhookRecordAttended,
+		EventID:        "evt-1",
+		Payload:        `{"id":"evt-1","event":"record.attended","data":{"recordId":7}}`,
+		Status:         model.WebhookDeliveryPending,
+		NextAttemptAt:  time.Now(),
+	}}
+	if err := repo.Enqueue(deliveries); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	return repo.deliveries[deliveries[0].ID]
+}
+
+func TestProcessDueSendsSignedDelivery(t *testing.T) {
+	svc, repo, receiver := newTestWebhookService(t, http.StatusOK)
+	delivery := enqueueTestDelivery(t, repo)
+
+	attempted, err := svc.ProcessDue(context.Background())
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if attempted != 1 {
+		t.Fatalf("attempted = %d, want 1", attempted)
+	}
+
+	if len(receiver.requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(receiver.requests))
+	}
+	got := receiver.requests[0]
+	if !got.verified {
+		t.Error("signature does not verify")
+	}
+	if got.body != delivery.Payload {
+		t.Errorf("body = %s, want %s", got.body, delivery.Payload)
+	}
+	if got.deliveryID != strconv.FormatUint(uint64(delivery.ID), 10) {
+		t.Errorf("delivery header = %s, want %d", got.deliveryID, delivery.ID)
+	}
+
+	if delivery.Status != model.WebhookDeliverySent || delivery.Attempts != 1 {
+		t.Errorf("delivery = %s after %d attempts, want Sent after 1", delivery.Status, delivery.Attempts)
+	}
+}
+
+func TestProcessDueRetriesWithBackoffThenFails(t *testing.T) {
+	svc, repo, receiver := newTestWebhookService(t, http.StatusServiceUnavailable)
+	delivery := enqueueTestDelivery(t, repo)
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		before := time.Now()
+		if _, err := svc.ProcessDue(context.Background()); err != nil {
+			t.Fatalf("attempt %d: %v", attempt, err)
+		}
+
+		if delivery.Status != model.WebhookDeliveryPending {
+			t.Fatalf("attempt %d: status = %s, want Pending", attempt, delivery.Status)
+		}
+		if delivery.Attempts != attempt {
+			t.Errorf("attempt %d: attempts = %d", attempt, delivery.Attempts)
+		}
+		if !strings.Contains(delivery.LastError, "503") {
+			t.Errorf("attempt %d: last error = %q", attempt, delivery.LastError)
+		}
+
+		wait := delivery.NextAttemptAt.Sub(before)
+		want := svc.retry.Delay(attempt)
+		if wait < want || wait > want+5*time.Second {
+			t.Errorf("attempt %d: next attempt in %s, want %s", attempt, wait, want)
+		}
+
+		// not due again until the backoff has passed
+		if attempted, _ := svc.ProcessDue(context.Background()); attempted != 0 {
+			t.Errorf("attempt %d: retried %d deliveries before the backoff passed", attempt, attempted)
+		}
+		repo.makeDue(delivery.ID)
+	}
+
+	if _, err := svc.ProcessDue(context.Background()); err != nil {
+		t.Fatalf("last attempt: %v", err)
+	}
+	if delivery.Status != model.WebhookDeliveryFailed || delivery.Attempts != 3 {
+		t.Errorf("delivery = %s after %d attempts, want Failed after 3", delivery.Status, delivery.Attempts)
+	}
+	if len(receiver.requests) != 3 {
+		t.Errorf("receiver got %d requests, want 3", len(receiver.requests))
+	}
+
+	// a failed delivery is never claimed again
+	if attempted, _ := svc.ProcessDue(context.Background()); attempted != 0 {
+		t.Errorf("failed delivery was claimed again")
+	}
+}
+
+func TestProcessDueDropsDisabledSubscription(t *testing.T) {
+	svc, repo, receiver := newTestWebhookService(t, http.StatusOK)
+	repo.subscription.Active = false
+	delivery := enqueueTestDelivery(t, repo)
+
+	if _, err := svc.ProcessDue(context.Background()); err != nil {
+		t.Fatalf("process: %v", err)
+	}
+
+	if delivery.Status != model.WebhookDeliveryFailed || delivery.Attempts != 0 {
+		t.Errorf("delivery = %s after %d attempts, want Failed after 0", delivery.Status, delivery.Attempts)
+	}
+	if len(receiver.requests) != 0 {
+		t.Errorf("receiver got %d requests, want 0", len(receiver.requests))
+	}
+}
+
+func TestReplaySendsFailedDeliveryAgain(t *testing.T) {
+	svc, repo, receiver := newTestWebhookService(t, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK)
+	original := enqueueTestDelivery(t, repo)
+
+	for original.Status == model.WebhookDeliveryPending {
+		if _, err := svc.ProcessDue(context.Background()); err != nil {
+			t.Fatalf("process: %v", err)
+		}
+		repo.makeDue(original.ID)
+	}
+	if original.Status != model.WebhookDeliveryFailed {
+		t.Fatalf("original = %s, want Failed", original.Status)
+	}
+
+	if _, err := svc.Replay(model.SystemActor, repo.subscription.ID+1, original.ID); err == nil {
+		t.Error("replay through another subscription succeeded")
+	}
+
+	replay, err := svc.Replay(model.SystemActor, repo.subscription.ID, original.ID)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if replay.ReplayOfID == nil || *replay.ReplayOfID != original.ID {
+		t.Errorf("replay of = %v, want %d", replay.ReplayOfID, original.ID)
+	}
+	if replay.EventID != original.EventID {
+		t.Errorf("replay event id = %s, want %s", replay.EventID, original.EventID)
+	}
+
+	if _, err := svc.ProcessDue(context.Background()); err != nil {
+		t.Fatalf("process replay: %v", err)
+	}
+
+	sent := repo.deliveries[replay.ID]
+	if sent.Status != model.WebhookDeliverySent {
+		t.Errorf("replay = %s, want Sent", sent.Status)
+	}
+	if original.Status != model.WebhookDeliveryFailed {
+		t.Errorf("original = %s, want it kept Failed", original.Status)
+	}
+
+	last := receiver.requests[len(receiver.requests)-1]
+	if !last.verified {
+		t.Error("replay signature does not verify")
+	}
+	if last.body != original.Payload {
+		t.Errorf("replay body = %s, want %s", last.body, original.Payload)
+	}
+	if last.deliveryID != strconv.FormatUint(uint64(replay.ID), 10) {
+		t.Errorf("replay delivery header = %s, want %d", last.deliveryID, replay.ID)
+	}
+}